@@ -0,0 +1,153 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/service/apikey"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var apiKeyFlags = struct {
+	name      string
+	account   string
+	ecosystem int64
+	scopes    string
+	rateLimit float64
+	expire    time.Duration
+}{}
+
+// apiKeyCmd represents the apikey command
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage API keys of the node",
+}
+
+var apiKeyCreateCmd = &cobra.Command{
+	Use:    "create",
+	Short:  "Create API key for the account",
+	PreRun: loadConfigWKey,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyID := converter.StringToAddress(apiKeyFlags.account)
+		if keyID == 0 {
+			log.WithFields(log.Fields{"account": apiKeyFlags.account}).Fatal("invalid account")
+			return
+		}
+		scopes, err := apikey.ParseScopes(apiKeyFlags.scopes, apiKeyFlags.ecosystem)
+		if err != nil {
+			log.WithError(err).Fatal("parsing scopes")
+			return
+		}
+		if err := sqldb.GormInit(conf.Config.DB); err != nil {
+			log.WithError(err).Fatal("init db")
+			return
+		}
+		secret, hash, err := apikey.Generate()
+		if err != nil {
+			log.WithError(err).Fatal("generating api key")
+			return
+		}
+		now := time.Now()
+		key := &sqldb.APIKey{
+			Name:      apiKeyFlags.name,
+			KeyID:     keyID,
+			Ecosystem: apiKeyFlags.ecosystem,
+			Scopes:    strings.Join(scopes, ","),
+			Hash:      hash,
+			RateLimit: apiKeyFlags.rateLimit,
+			CreatedAt: now.Unix(),
+		}
+		if apiKeyFlags.expire > 0 {
+			key.ExpiresAt = now.Add(apiKeyFlags.expire).Unix()
+		}
+		if err := key.Create(); err != nil {
+			log.WithError(err).Fatal("creating api key")
+			return
+		}
+		fmt.Printf("id: %d\nkey: %s\n", key.ID, secret)
+		fmt.Println("The key is shown only once, use it in the header: Authorization: " + apikey.HeaderPrefix + "<key>")
+	},
+}
+
+var apiKeyListCmd = &cobra.Command{
+	Use:    "list",
+	Short:  "List API keys of the node",
+	PreRun: loadConfigWKey,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := sqldb.GormInit(conf.Config.DB); err != nil {
+			log.WithError(err).Fatal("init db")
+			return
+		}
+		keys, err := sqldb.GetAPIKeys()
+		if err != nil {
+			log.WithError(err).Fatal("getting api keys")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tACCOUNT\tECOSYSTEM\tSCOPES\tRATE\tEXPIRES\tSTATUS")
+		for _, k := range keys {
+			expires, status := "never", "active"
+			if k.ExpiresAt > 0 {
+				expires = time.Unix(k.ExpiresAt, 0).Format(time.RFC3339)
+			}
+			if k.RevokedAt > 0 {
+				status = "revoked"
+			} else if !k.IsActive() {
+				status = "expired"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%v\t%s\t%s\n", k.ID, k.Name, converter.AddressToString(k.KeyID),
+				k.Ecosystem, k.Scopes, k.RateLimit, expires, status)
+		}
+		w.Flush()
+	},
+}
+
+var apiKeyRevokeCmd = &cobra.Command{
+	Use:    "revoke <id>",
+	Short:  "Revoke API key",
+	Args:   cobra.ExactArgs(1),
+	PreRun: loadConfigWKey,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := converter.StrToInt64(args[0])
+		if err := sqldb.GormInit(conf.Config.DB); err != nil {
+			log.WithError(err).Fatal("init db")
+			return
+		}
+		ok, err := sqldb.RevokeAPIKey(id)
+		if err != nil {
+			log.WithError(err).Fatal("revoking api key")
+			return
+		}
+		if !ok {
+			log.WithFields(log.Fields{"id": id}).Fatal("active api key has not been found")
+			return
+		}
+		log.WithFields(log.Fields{"id": id}).Info("api key has been revoked")
+	},
+}
+
+func init() {
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyFlags.name, "name", "", "Name of the key")
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyFlags.account, "account", "", "Account address or key id")
+	apiKeyCreateCmd.Flags().Int64Var(&apiKeyFlags.ecosystem, "ecosystem", 1, "Ecosystem ID")
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyFlags.scopes, "scopes", apikey.ScopeRead,
+		"Comma separated scopes: read, send_tx, contract:<name>")
+	apiKeyCreateCmd.Flags().Float64Var(&apiKeyFlags.rateLimit, "rate-limit", apikey.DefaultRateLimit, "Requests per second")
+	apiKeyCreateCmd.Flags().DurationVar(&apiKeyFlags.expire, "expire", 0, "Lifetime of the key, 0 means no expiration")
+	apiKeyCreateCmd.MarkFlagRequired("account")
+
+	apiKeyCmd.AddCommand(apiKeyCreateCmd, apiKeyListCmd, apiKeyRevokeCmd)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/IBAX-io/go-ibax/packages/common/crypto"
 	"github.com/IBAX-io/go-ibax/packages/conf"
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/service/ratelimit"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -66,6 +67,7 @@ func init() {
 	cmdFlags.StringVar(&conf.Config.DirPathConf.DataDir, "dataDir", "", "Data directory (default cwd/data)")
 	cmdFlags.StringVar(&conf.Config.DirPathConf.TempDir, "tempDir", "", "Temporary directory (default temporary directory of OS)")
 	cmdFlags.StringVar(&conf.Config.DirPathConf.FirstBlockPath, "firstBlock", "", "First block path (default dataDir/1block)")
+	cmdFlags.StringVar(&conf.Config.DirPathConf.SnapshotDir, "snapshotDir", "", "Snapshots directory (default dataDir/snapshots)")
 
 	// tls
 	cmdFlags.BoolVar(&conf.Config.TLSConf.Enabled, "tlsEnable", false, "Enable https")
@@ -75,6 +77,18 @@ func init() {
 	//Bootstrap
 	cmdFlags.StringSliceVar(&conf.Config.BootNodes.NodesAddr, "bootNodes", []string{}, "List of addresses for downloading blockchain")
 
+	// Peers
+	cmdFlags.BoolVar(&conf.Config.Peers.Public, "peersPublic", false, "Serve blocks and peers to the nodes with unknown keys")
+	cmdFlags.BoolVar(&conf.Config.Peers.Legacy, "peersLegacy", false, "Serve and dial the old nodes without the handshake")
+	cmdFlags.StringVar(&conf.Config.Peers.ExternalAddr, "peersExternalAddr", "", "TCP address announced to the peers")
+	cmdFlags.IntVar(&conf.Config.Peers.MaxPeers, "peersMax", 1000, "Max number of the peers in the address book")
+
+	// Snapshots
+	cmdFlags.Int64Var(&conf.Config.Snapshot.Interval, "snapshotInterval", 0, "Create the snapshot of the state every N blocks, 0 is disabled")
+	cmdFlags.IntVar(&conf.Config.Snapshot.Keep, "snapshotKeep", 2, "Number of the kept snapshots")
+	cmdFlags.BoolVar(&conf.Config.Snapshot.Sync, "snapshotSync", false, "Restore the state from the snapshot of the peers on the first start")
+	cmdFlags.IntVar(&conf.Config.Snapshot.Confirmations, "snapshotConfirmations", 2, "Number of the honor nodes confirming the snapshot")
+
 	//LocalConf
 	cmdFlags.Int64Var(&conf.Config.LocalConf.MaxPageGenerationTime, "mpgt", 3000, "Max page generation time in ms")
 	cmdFlags.Int64Var(&conf.Config.LocalConf.HTTPServerMaxBodySize, "mbs", 1<<20, "Max server body size in byte")
@@ -92,6 +106,19 @@ func init() {
 	// JSON-RPC Server
 	cmdFlags.BoolVar(&conf.Config.JsonRPC.Enabled, "jsonRPCEnabled", false, "Node Json-RPC Enabled")
 	cmdFlags.StringVar(&conf.Config.JsonRPC.Namespace, "jsonRPCNamespace", "ibax,net", "Node Json-RPC Namespace")
+	cmdFlags.Int64Var(&conf.Config.JsonRPC.CallFuel, "jsonRPCCallFuel", 0, "Max fuel of Json-RPC read-only contract call, 0 is max_fuel of platform")
+	cmdFlags.StringSliceVar(&conf.Config.JsonRPC.AllowedOrigins, "jsonRPCAllowedOrigins", []string{}, "Origins allowed to open Json-RPC websocket connections, * is any origin")
+	cmdFlags.IntVar(&conf.Config.JsonRPC.MaxSubscriptions, "jsonRPCMaxSubscriptions", 32, "Max subscriptions of Json-RPC websocket connection")
+
+	// Rate limit of REST and Json-RPC
+	cmdFlags.BoolVar(&conf.Config.RateLimit.Enabled, "rateLimitEnabled", false, "Limit requests of the clients")
+	cmdFlags.Float64Var(&conf.Config.RateLimit.Default.Rate, "rateLimit", ratelimit.DefaultRate, "Requests per second of the client")
+	cmdFlags.IntVar(&conf.Config.RateLimit.Default.Burst, "rateLimitBurst", 0, "Max requests of the client at once, 0 is the rate")
+	cmdFlags.StringSliceVar(&conf.Config.RateLimit.IPLookups, "rateLimitIPLookups", []string{"RemoteAddr"}, "Sources of the client IP (RemoteAddr | X-Forwarded-For | X-Real-IP)")
+
+	// GraphQL
+	cmdFlags.Int64Var(&conf.Config.GraphQL.MaxCost, "graphqlMaxCost", 1000, "Max query cost of GraphQL request")
+	cmdFlags.IntVar(&conf.Config.GraphQL.MaxDepth, "graphqlMaxDepth", 3, "Max depth of related tables in GraphQL request")
 
 	// DB
 	cmdFlags.StringVar(&conf.Config.DB.Host, "dbHost", "127.0.0.1", "DB host")
@@ -0,0 +1,114 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/smart"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var lintContractFlags = struct {
+	ecosystem int64
+	db        bool
+	json      bool
+}{}
+
+type lintContractResult struct {
+	File   string              `json:"file"`
+	Issues []*script.LintIssue `json:"issues"`
+}
+
+// lintContractCmd represents the lint-contract command
+var lintContractCmd = &cobra.Command{
+	Use:   "lint-contract <file>...",
+	Short: "Check the source of contracts by the static analysis",
+	Long: `Check the source of contracts by the static analysis. Use - to read the source from stdin.
+The contracts and the ecosystem parameters are resolved in the database of the node if --db is set,
+otherwise only the built-in functions are known. The command exits with code 1 if there are issues.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		smart.InitVM()
+		opts := &script.LintOptions{
+			VM:    script.GetVM(),
+			Owner: &script.OwnerInfo{StateID: uint32(lintContractFlags.ecosystem)},
+		}
+		if lintContractFlags.db {
+			loadConfig(cmd, args)
+			if err := sqldb.GormInit(conf.Config.DB); err != nil {
+				log.WithError(err).Fatal("init db")
+				return
+			}
+			if err := syspar.SysUpdate(nil); err != nil {
+				log.WithError(err).Error("can't read platform parameters")
+			}
+			if err := smart.LoadContracts(); err != nil {
+				log.WithError(err).Fatal("loading contracts")
+				return
+			}
+			opts = smart.LintOptions(script.GetVM(), lintContractFlags.ecosystem, nil)
+		} else {
+			script.LoadSysFuncs(script.GetVM(), 1)
+		}
+
+		var (
+			results = make([]*lintContractResult, 0, len(args))
+			found   bool
+		)
+		for _, name := range args {
+			var (
+				data []byte
+				err  error
+			)
+			if name == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(name)
+			}
+			if err != nil {
+				log.WithError(err).Fatal("reading contract source")
+				return
+			}
+			issues := script.Lint(string(data), opts)
+			found = found || len(issues) > 0
+			results = append(results, &lintContractResult{File: name, Issues: issues})
+		}
+
+		if lintContractFlags.json {
+			out, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				log.WithError(err).Fatal("marshalling issues")
+				return
+			}
+			fmt.Println(string(out))
+		} else {
+			for _, result := range results {
+				for _, issue := range result.Issues {
+					fmt.Printf("%s:%s\n", result.File, issue)
+				}
+			}
+		}
+		if found {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintContractCmd.Flags().Int64Var(&lintContractFlags.ecosystem, "ecosystem", 1, "Ecosystem ID of the contracts")
+	lintContractCmd.Flags().BoolVar(&lintContractFlags.db, "db", false, "Resolve contracts and ecosystem parameters in the database")
+	lintContractCmd.Flags().BoolVar(&lintContractFlags.json, "json", false, "Print issues in JSON format")
+}
@@ -31,13 +31,17 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(
+		apiKeyCmd,
 		generateFirstBlockCmd,
 		generateKeysCmd,
 		initDatabaseCmd,
+		lintContractCmd,
+		testContractCmd,
 		rollbackCmd,
 		startCmd,
 		configCmd,
 		stopNetworkCmd,
+		snapshotCmd,
 		versionCmd,
 	)
 
@@ -0,0 +1,82 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/snapshot"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var snapshotFile string
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Create or restore the snapshot of the state",
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:    "create",
+	Short:  "Create the snapshot of the state at the last block",
+	PreRun: loadConfigWKey,
+	Run: func(cmd *cobra.Command, args []string) {
+		f := utils.LockOrDie(conf.Config.DirPathConf.LockFilePath)
+		defer f.Unlock()
+
+		if err := sqldb.GormInit(conf.Config.DB); err != nil {
+			log.WithError(err).Fatal("init db")
+			return
+		}
+		m, err := snapshot.Create(context.Background(), conf.Config.GetSnapshotDir())
+		if err != nil {
+			log.WithError(err).Fatal("creating snapshot")
+			return
+		}
+		log.WithFields(log.Fields{"block_id": m.BlockID, "path": snapshot.Path(conf.Config.GetSnapshotDir(), m.BlockID)}).Info("snapshot is created")
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:    "restore",
+	Short:  "Replace the state with the snapshot",
+	PreRun: loadConfigWKey,
+	Run: func(cmd *cobra.Command, args []string) {
+		f := utils.LockOrDie(conf.Config.DirPathConf.LockFilePath)
+		defer f.Unlock()
+
+		if err := sqldb.GormInit(conf.Config.DB); err != nil {
+			log.WithError(err).Fatal("init db")
+			return
+		}
+		m, err := snapshot.ReadManifest(snapshotFile)
+		if err != nil {
+			log.WithError(err).Fatal("reading snapshot manifest")
+			return
+		}
+		if err = snapshot.Restore(context.Background(), m, snapshotFile, nil); err != nil {
+			log.WithError(err).Fatal("restoring snapshot")
+			return
+		}
+		if err = sqldb.UpdateSchema(); err != nil {
+			log.WithError(err).Fatal("updating schema")
+			return
+		}
+		log.WithFields(log.Fields{"block_id": m.BlockID}).Info("snapshot is restored")
+	},
+}
+
+func init() {
+	snapshotRestoreCmd.Flags().StringVar(&snapshotFile, "file", "", "path to the snapshot file")
+	snapshotRestoreCmd.MarkFlagRequired("file")
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotRestoreCmd)
+}
@@ -0,0 +1,117 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/IBAX-io/go-ibax/packages/contracttest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var testContractFlags = struct {
+	run       string
+	fixtures  string
+	ecosystem int64
+	keyID     int64
+	blockTime int64
+	json      bool
+}{}
+
+// testContractCmd represents the test-contract command
+var testContractCmd = &cobra.Command{
+	Use:   "test-contract <file>...",
+	Short: "Run the tests of contracts without the node",
+	Long: `Run the tests of contracts without the node. The files are loaded into a new VM where
+the database functions work with the in-memory tables, then every function named test_* is run
+as a test. A test fails if it returns an error, the assertions are Assert, AssertEqual,
+AssertNotEqual and Fail. The command exits with code 1 if any test fails.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var match func(string) bool
+		if len(testContractFlags.run) > 0 {
+			re, err := regexp.Compile(testContractFlags.run)
+			if err != nil {
+				log.WithError(err).Fatal("compiling run pattern")
+				return
+			}
+			match = re.MatchString
+		}
+		h, err := contracttest.New()
+		if err != nil {
+			log.WithError(err).Fatal("creating VM")
+			return
+		}
+		h.EcosystemID = testContractFlags.ecosystem
+		h.KeyID = testContractFlags.keyID
+		if testContractFlags.blockTime != 0 {
+			h.BlockTime = testContractFlags.blockTime
+		}
+		if len(testContractFlags.fixtures) > 0 {
+			if err = h.Store.LoadFile(testContractFlags.fixtures, h.EcosystemID); err != nil {
+				log.WithError(err).Fatal("loading fixtures")
+				return
+			}
+		}
+		for _, name := range args {
+			if err = h.LoadFile(name); err != nil {
+				log.WithError(err).Fatal("loading contracts")
+				return
+			}
+		}
+
+		// the failed tests are printed, so the errors of VM are not logged
+		log.SetLevel(log.FatalLevel)
+		results := h.RunAll(match)
+		failed := 0
+		for _, res := range results {
+			if !res.Passed {
+				failed++
+			}
+		}
+		if testContractFlags.json {
+			out, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				log.WithError(err).Fatal("marshalling results")
+				return
+			}
+			fmt.Println(string(out))
+		} else {
+			for _, res := range results {
+				status := "PASS"
+				if !res.Passed {
+					status = "FAIL"
+				}
+				fmt.Printf("--- %s: %s (gas %d, %v)\n", status, res.Name, res.Gas, res.Duration)
+				if !res.Passed {
+					fmt.Printf("    %s\n", res.Error)
+				}
+			}
+			if failed > 0 {
+				fmt.Printf("FAIL %d of %d tests\n", failed, len(results))
+			} else {
+				fmt.Printf("ok %d tests\n", len(results))
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	testContractCmd.Flags().StringVar(&testContractFlags.run, "run", "", "Run only the tests matching the regular expression")
+	testContractCmd.Flags().StringVar(&testContractFlags.fixtures, "fixtures", "", "JSON file with the initial rows of the tables")
+	testContractCmd.Flags().Int64Var(&testContractFlags.ecosystem, "ecosystem", 1, "Ecosystem ID of the contracts and $ecosystem_id")
+	testContractCmd.Flags().Int64Var(&testContractFlags.keyID, "key-id", 1, "Initial $key_id of the tests")
+	testContractCmd.Flags().Int64Var(&testContractFlags.blockTime, "block-time", 0, "Initial block time of the tests as Unix time, the current time by default")
+	testContractCmd.Flags().BoolVar(&testContractFlags.json, "json", false, "Print results in JSON format")
+}
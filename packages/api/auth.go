@@ -10,8 +10,10 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/service/apikey"
 	"github.com/IBAX-io/go-ibax/packages/types"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -62,6 +64,20 @@ func parseJWTToken(header string) (*jwt.Token, error) {
 	})
 }
 
+// apiKeyToken returns the token with the claims of the api key, so the key is handled as the session of its account
+func apiKeyToken(key *apikey.Key) *jwt.Token {
+	claims := &JWTClaims{
+		EcosystemID: converter.Int64ToStr(key.Ecosystem),
+		KeyID:       converter.Int64ToStr(key.KeyID),
+		AccountID:   converter.AddressToString(key.KeyID),
+		RoleID:      "0",
+	}
+	if key.ExpiresAt > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Unix(key.ExpiresAt, 0))
+	}
+	return &jwt.Token{Claims: claims, Valid: true}
+}
+
 func getClientFromToken(token *jwt.Token, ecosysNameService types.EcosystemGetter) (*Client, error) {
 	claims, ok := token.Claims.(*JWTClaims)
 	if !ok {
@@ -108,7 +124,9 @@ func getAuthStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result.IsActive = true
-	result.ExpiresAt = claims.ExpiresAt.Unix()
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Unix()
+	}
 }
 
 func InitJwtSecret(secret []byte) {
@@ -9,6 +9,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/IBAX-io/go-ibax/packages/service/apikey"
 	"github.com/golang-jwt/jwt/v4"
 	log "github.com/sirupsen/logrus"
 )
@@ -19,6 +20,8 @@ const (
 	contextKeyLogger contextKey = iota
 	contextKeyToken
 	contextKeyClient
+	contextKeyAPIKey
+	contextKeyGraphQL
 )
 
 func setContext(r *http.Request, key, value any) *http.Request {
@@ -61,3 +64,14 @@ func getClient(r *http.Request) *Client {
 	}
 	return nil
 }
+
+func setAPIKey(r *http.Request, key *apikey.Key) *http.Request {
+	return setContext(r, contextKeyAPIKey, key)
+}
+
+func getAPIKey(r *http.Request) *apikey.Key {
+	if v := getContext(r, contextKeyAPIKey); v != nil {
+		return v.(*apikey.Key)
+	}
+	return nil
+}
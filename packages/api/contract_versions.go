@@ -0,0 +1,136 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+type contractVersionResult struct {
+	Version    int64  `json:"version"`
+	Name       string `json:"name"`
+	Value      string `json:"value,omitempty"`
+	Conditions string `json:"conditions"`
+	WalletID   string `json:"walletid"`
+	TokenID    string `json:"tokenid"`
+	Hash       string `json:"hash"`
+	Timestamp  int64  `json:"timestamp"`
+	Restored   int64  `json:"restored"`
+	ActiveFrom int64  `json:"active_from"` // the block from which the version is active
+	ActiveTo   int64  `json:"active_to"`   // the block of the next version, it is 0 for the current version
+}
+
+type contractVersionsResult struct {
+	List []contractVersionResult `json:"list"`
+}
+
+// getContractVersions returns the history of the versions of the contract without the sources.
+// The contracts created before the history have the current record with the source as the only version
+func getContractVersions(w http.ResponseWriter, r *http.Request) ([]sqldb.ContractVersion, bool) {
+	params := mux.Vars(r)
+	logger := getLogger(r)
+
+	contract := getContract(r, params["name"])
+	if contract == nil {
+		logger.WithFields(log.Fields{"type": consts.ContractError, "contract_name": params["name"]}).Debug("contract name")
+		errorResponse(w, errContract.Errorf(params["name"]))
+		return nil, false
+	}
+	id := getContractInfo(contract).Owner.TableID
+	versions, err := sqldb.GetContractVersions(id)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "contract_id": id}).Error("get contract versions")
+		errorResponse(w, errQuery)
+		return nil, false
+	}
+	if len(versions) == 0 {
+		con := &sqldb.Contract{}
+		found, err := con.Get(id)
+		if err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "contract_id": id}).Error("get contract")
+			errorResponse(w, errQuery)
+			return nil, false
+		}
+		if !found {
+			errorResponse(w, errContract.Errorf(params["name"]))
+			return nil, false
+		}
+		versions = append(versions, sqldb.ContractVersion{ContractID: id, Version: 1, EcosystemID: con.EcosystemID,
+			Name: con.Name, Value: con.Value, Conditions: con.Conditions, WalletID: con.WalletID, TokenID: con.TokenID})
+	}
+	return versions, true
+}
+
+func newContractVersionResult(versions []sqldb.ContractVersion, i int) contractVersionResult {
+	ver := versions[i]
+	result := contractVersionResult{
+		Version:    ver.Version,
+		Name:       ver.Name,
+		Value:      ver.Value,
+		Conditions: ver.Conditions,
+		WalletID:   converter.Int64ToStr(ver.WalletID),
+		TokenID:    converter.Int64ToStr(ver.TokenID),
+		Hash:       hex.EncodeToString(ver.TxHash),
+		Timestamp:  ver.Timestamp,
+		Restored:   ver.Restored,
+		ActiveFrom: ver.BlockID,
+	}
+	if i+1 < len(versions) {
+		result.ActiveTo = versions[i+1].BlockID
+	}
+	return result
+}
+
+func getContractVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	versions, ok := getContractVersions(w, r)
+	if !ok {
+		return
+	}
+	result := &contractVersionsResult{List: make([]contractVersionResult, 0, len(versions))}
+	for i := range versions {
+		item := newContractVersionResult(versions, i)
+		item.Value = ``
+		result.List = append(result.List, item)
+	}
+	jsonResponse(w, result)
+}
+
+func getContractVersionHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	logger := getLogger(r)
+
+	versions, ok := getContractVersions(w, r)
+	if !ok {
+		return
+	}
+	version := converter.StrToInt64(params["version"])
+	for i, ver := range versions {
+		if ver.Version != version {
+			continue
+		}
+		result := newContractVersionResult(versions, i)
+		if len(result.Value) == 0 {
+			full := &sqldb.ContractVersion{}
+			if _, err := full.Get(nil, ver.ContractID, ver.Version); err != nil {
+				logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "contract_id": ver.ContractID}).Error("get contract version")
+				errorResponse(w, errQuery)
+				return
+			}
+			result.Value = full.Value
+		}
+		jsonResponse(w, result)
+		return
+	}
+	errorResponse(w, errNotFoundRecord)
+}
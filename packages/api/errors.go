@@ -56,6 +56,9 @@ var (
 	errCheckRole         = errType{"E_CHECKROLE", "Access denied", http.StatusForbidden}
 	errNewUser           = errType{"E_NEWUSER", "The block packing in progress, please wait", http.StatusUnauthorized}
 	errEcoNotOpen        = errType{"E_ECONOTOPEN", "The ecosystem (%d) is not open and cannot be registered address", http.StatusUnauthorized}
+	errAPIKeyLimit       = errType{"E_APIKEYLIMIT", "Rate limit of the api key has been reached", http.StatusTooManyRequests}
+	errAPIKeyScope       = errType{"E_APIKEYSCOPE", "%s", http.StatusForbidden}
+	errRateLimit         = errType{"E_RATELIMIT", "Rate limit has been reached, retry after %d seconds", http.StatusTooManyRequests}
 )
 
 type errType struct {
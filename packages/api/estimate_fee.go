@@ -0,0 +1,74 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/smart"
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+type estimateFeeForm struct {
+	Params   string `schema:"params"`
+	Expedite string `schema:"expedite"`
+	MaxSum   string `schema:"max_sum"`
+
+	params map[string]any
+}
+
+func (f *estimateFeeForm) Validate(r *http.Request) error {
+	if len(f.Params) > 0 {
+		if err := json.Unmarshal([]byte(f.Params), &f.params); err != nil {
+			return errUndefineval.Errorf("params")
+		}
+	}
+	if len(f.Expedite) > 0 {
+		if _, err := decimal.NewFromString(f.Expedite); err != nil {
+			return errUndefineval.Errorf("expedite")
+		}
+	}
+	if len(f.MaxSum) > 0 && converter.StrToInt64(f.MaxSum) <= 0 {
+		return errUndefineval.Errorf("max_sum")
+	}
+	return nil
+}
+
+func estimateFeeHandler(w http.ResponseWriter, r *http.Request) {
+	form := &estimateFeeForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	params := mux.Vars(r)
+	logger := getLogger(r)
+	client := getClient(r)
+
+	if getContract(r, params["name"]) == nil {
+		logger.WithFields(log.Fields{"type": consts.ContractError, "contract_name": params["name"]}).Debug("contract name")
+		errorResponse(w, errContract.Errorf(params["name"]))
+		return
+	}
+	result, err := smart.EstimateFee(&smart.ContractCall{
+		Contract:    params["name"],
+		EcosystemID: client.EcosystemID,
+		KeyID:       client.KeyID,
+		Params:      form.params,
+		Expedite:    form.Expedite,
+		MaxSum:      form.MaxSum,
+	})
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.ContractError, "contract_name": params["name"], "error": err}).Error("estimating fee")
+		errorResponse(w, err)
+		return
+	}
+	jsonResponse(w, result)
+}
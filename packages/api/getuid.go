@@ -40,7 +40,9 @@ func getUIDHandler(w http.ResponseWriter, r *http.Request) {
 	if token != nil {
 		if claims, ok := token.Claims.(*JWTClaims); ok && len(claims.KeyID) > 0 {
 			result.EcosystemID = claims.EcosystemID
-			result.Expire = claims.ExpiresAt.Sub(time.Now()).String()
+			if claims.ExpiresAt != nil {
+				result.Expire = claims.ExpiresAt.Sub(time.Now()).String()
+			}
 			result.KeyID = claims.KeyID
 			result.Address = converter.AddressToString(converter.StrToInt64(claims.KeyID))
 			jsonResponse(w, result)
@@ -0,0 +1,737 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	qb "github.com/IBAX-io/go-ibax/packages/storage/sqldb/queryBuilder"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb/querycost"
+	"github.com/IBAX-io/go-ibax/packages/types"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	graphqlDefaultMaxCost  = 1000
+	graphqlDefaultMaxDepth = 3
+
+	graphqlContentType = "application/json"
+
+	graphqlAnd = "_and"
+	graphqlOr  = "_or"
+)
+
+var (
+	errGraphQLCost  = errType{"E_QUERYCOST", "Query cost exceeds the limit %d", http.StatusBadRequest}
+	errGraphQLDepth = errType{"E_QUERYDEPTH", "Depth of the related tables exceeds the limit %d", http.StatusBadRequest}
+
+	errEmptyQuery = errors.New("Query is empty")
+	reGraphQLName = regexp.MustCompile(`^[_a-zA-Z][_0-9a-zA-Z]*$`)
+
+	// graphqlOperators are the operators of the column filters and the operators of the where map
+	graphqlOperators = map[string]string{
+		"eq":    "$eq",
+		"neq":   "$neq",
+		"gt":    "$gt",
+		"gte":   "$gte",
+		"lt":    "$lt",
+		"lte":   "$lte",
+		"in":    "$in",
+		"nin":   "$nin",
+		"like":  "$like",
+		"begin": "$begin",
+		"end":   "$end",
+	}
+)
+
+type graphqlForm struct {
+	Query         string         `schema:"query" json:"query"`
+	OperationName string         `schema:"operationName" json:"operationName"`
+	RawVariables  string         `schema:"variables" json:"-"`
+	Variables     map[string]any `schema:"-" json:"variables"`
+}
+
+func (f *graphqlForm) Validate(r *http.Request) error {
+	if len(f.RawVariables) > 0 {
+		if err := json.Unmarshal([]byte(f.RawVariables), &f.Variables); err != nil {
+			return err
+		}
+	}
+	if len(strings.TrimSpace(f.Query)) == 0 {
+		return errEmptyQuery
+	}
+	return nil
+}
+
+func parseGraphQLForm(r *http.Request, form *graphqlForm) error {
+	if !strings.HasPrefix(r.Header.Get(contentType), graphqlContentType) {
+		return parseForm(r, form)
+	}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		return err
+	}
+	return form.Validate(r)
+}
+
+// graphqlError is the API error with its code in the extensions of GraphQL error
+type graphqlError struct {
+	errType
+}
+
+func (e graphqlError) Error() string {
+	return e.Message
+}
+
+func (e graphqlError) Extensions() map[string]any {
+	return map[string]any{"code": e.Err}
+}
+
+// graphqlTable is the table of the ecosystem in the GraphQL schema
+type graphqlTable struct {
+	name      string
+	columns   map[string]string // column name and the type returned by GetColumnType
+	relations map[string]graphqlRelation
+
+	object *graphql.Object
+	filter *graphql.InputObject
+	order  *graphql.InputObject
+}
+
+// graphqlRelation is the field of the related table. The relation is found by the number column
+// which is named by the related table, e.g. key_id of members refers to id of keys
+type graphqlRelation struct {
+	table  string
+	column string // the column which refers to id
+	many   bool   // the column of the related table refers to the row
+}
+
+func (t *graphqlTable) addRelation(name string, rel graphqlRelation) {
+	if _, ok := t.columns[name]; ok {
+		return
+	}
+	if _, ok := t.relations[name]; ok || !reGraphQLName.MatchString(name) {
+		return
+	}
+	t.relations[name] = rel
+}
+
+type graphqlSchema struct {
+	hash   string
+	schema graphql.Schema
+}
+
+var graphqlSchemas = struct {
+	sync.Mutex
+	list map[int64]*graphqlSchema
+}{list: make(map[int64]*graphqlSchema)}
+
+var graphqlInt64 = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "Int64",
+	Description:  "The 64-bit integer value",
+	Serialize:    coerceGraphQLInt64,
+	ParseValue:   coerceGraphQLInt64,
+	ParseLiteral: parseGraphQLInt64,
+})
+
+func coerceGraphQLInt64(value any) any {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	}
+	return nil
+}
+
+func parseGraphQLInt64(value ast.Value) any {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		return coerceGraphQLInt64(v.Value)
+	case *ast.StringValue:
+		return coerceGraphQLInt64(v.Value)
+	}
+	return nil
+}
+
+func newGraphQLFilter(name string, typ graphql.Input, like bool) *graphql.InputObject {
+	fields := graphql.InputObjectConfigFieldMap{}
+	for op := range graphqlOperators {
+		switch op {
+		case "in", "nin":
+			fields[op] = &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(typ))}
+		case "like", "begin", "end":
+			if like {
+				fields[op] = &graphql.InputObjectFieldConfig{Type: typ}
+			}
+		default:
+			fields[op] = &graphql.InputObjectFieldConfig{Type: typ}
+		}
+	}
+	return graphql.NewInputObject(graphql.InputObjectConfig{Name: name, Fields: fields})
+}
+
+var (
+	graphqlStringFilter  = newGraphQLFilter("StringFilter", graphql.String, true)
+	graphqlInt64Filter   = newGraphQLFilter("Int64Filter", graphqlInt64, false)
+	graphqlFloatFilter   = newGraphQLFilter("FloatFilter", graphql.Float, false)
+	graphqlBooleanFilter = newGraphQLFilter("BooleanFilter", graphql.Boolean, false)
+)
+
+// graphqlColumnType returns the GraphQL types of the value and the filter of the column
+func graphqlColumnType(itype string) (graphql.Output, *graphql.InputObject) {
+	switch itype {
+	case "number":
+		return graphqlInt64, graphqlInt64Filter
+	case "double":
+		return graphql.Float, graphqlFloatFilter
+	case "boolean":
+		return graphql.Boolean, graphqlBooleanFilter
+	}
+	return graphql.String, graphqlStringFilter
+}
+
+func graphqlColumnValue(itype, value string) any {
+	switch itype {
+	case "number":
+		return coerceGraphQLInt64(value)
+	case "double":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+		return nil
+	case "boolean":
+		return value == "true" || value == "t"
+	}
+	return value
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getGraphQLSchema returns the schema of the ecosystem. The schema is built again
+// if the tables or their columns have been changed
+func getGraphQLSchema(ecosystem int64) (graphql.Schema, error) {
+	rows, err := sqldb.GetDB(nil).Table("1_tables").Select("name, columns").
+		Where("ecosystem = ?", ecosystem).Order("name").Rows()
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+	list, err := sqldb.GetResult(rows)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+	h := sha256.New()
+	for _, item := range list {
+		fmt.Fprintf(h, "%s\x00%s\x00", item["name"], item["columns"])
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	graphqlSchemas.Lock()
+	defer graphqlSchemas.Unlock()
+	if s, ok := graphqlSchemas.list[ecosystem]; ok && s.hash == hash {
+		return s.schema, nil
+	}
+	tables, err := getGraphQLTables(ecosystem, list)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+	schema, err := buildGraphQLSchema(tables)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+	graphqlSchemas.list[ecosystem] = &graphqlSchema{hash: hash, schema: schema}
+	return schema, nil
+}
+
+func getGraphQLTables(ecosystem int64, list []map[string]string) (map[string]*graphqlTable, error) {
+	dbTx := sqldb.NewDbTransaction(nil)
+	tables := make(map[string]*graphqlTable)
+	for _, item := range list {
+		name := item["name"]
+		if !reGraphQLName.MatchString(name) || strings.HasPrefix(name, "__") {
+			continue
+		}
+		var columns map[string]any
+		if err := json.Unmarshal([]byte(item["columns"]), &columns); err != nil {
+			return nil, err
+		}
+		t := &graphqlTable{
+			name:      name,
+			columns:   make(map[string]string),
+			relations: make(map[string]graphqlRelation),
+		}
+		for _, col := range append([]string{"id"}, sortedKeys(columns)...) {
+			if !reGraphQLName.MatchString(col) || col == graphqlAnd || col == graphqlOr {
+				continue
+			}
+			itype, err := dbTx.GetColumnType(qb.GetTableName(ecosystem, name), col)
+			if err != nil {
+				return nil, err
+			}
+			if len(itype) > 0 {
+				t.columns[col] = itype
+			}
+		}
+		tables[name] = t
+	}
+	findGraphQLRelations(tables)
+	return tables, nil
+}
+
+// findGraphQLRelations adds the relations of the columns like <table>_id or <name>_id
+// which refer to the tables <table> or <name>s
+func findGraphQLRelations(tables map[string]*graphqlTable) {
+	for _, name := range sortedKeys(tables) {
+		t := tables[name]
+		for _, col := range sortedKeys(t.columns) {
+			if t.columns[col] != "number" || !strings.HasSuffix(col, "_id") {
+				continue
+			}
+			base := strings.TrimSuffix(col, "_id")
+			for _, target := range []string{base, base + "s"} {
+				rt, ok := tables[target]
+				if !ok {
+					continue
+				}
+				t.addRelation(base, graphqlRelation{table: target, column: col})
+				rt.addRelation(t.name+"_by_"+base, graphqlRelation{table: t.name, column: col, many: true})
+				break
+			}
+		}
+	}
+}
+
+func buildGraphQLSchema(tables map[string]*graphqlTable) (graphql.Schema, error) {
+	for _, t := range tables {
+		t.build(tables)
+	}
+	fields := graphql.Fields{}
+	for _, name := range sortedKeys(tables) {
+		t := tables[name]
+		fields[name] = &graphql.Field{
+			Type:    graphql.NewList(t.object),
+			Args:    t.listArgs(),
+			Resolve: t.resolveList(nil),
+		}
+		if _, ok := tables[name+"_count"]; !ok {
+			fields[name+"_count"] = &graphql.Field{
+				Type:    graphqlInt64,
+				Args:    graphql.FieldConfigArgument{"where": {Type: t.filter}},
+				Resolve: t.resolveCount,
+			}
+		}
+	}
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: fields}),
+	})
+}
+
+func (t *graphqlTable) build(tables map[string]*graphqlTable) {
+	t.object = graphql.NewObject(graphql.ObjectConfig{
+		Name: t.name,
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			fields := graphql.Fields{}
+			for col, itype := range t.columns {
+				out, _ := graphqlColumnType(itype)
+				fields[col] = &graphql.Field{Type: out, Resolve: resolveGraphQLColumn(col, itype)}
+			}
+			for name, rel := range t.relations {
+				rel := rel
+				rt := tables[rel.table]
+				if rel.many {
+					fields[name] = &graphql.Field{
+						Type:    graphql.NewList(rt.object),
+						Args:    rt.listArgs(),
+						Resolve: rt.resolveList(&rel),
+					}
+				} else {
+					fields[name] = &graphql.Field{Type: rt.object, Resolve: rt.resolveRow(rel.column)}
+				}
+			}
+			return fields
+		}),
+	})
+	t.filter = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: t.name + "_filter",
+		Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+			fields := graphql.InputObjectConfigFieldMap{
+				graphqlAnd: {Type: graphql.NewList(graphql.NewNonNull(t.filter))},
+				graphqlOr:  {Type: graphql.NewList(graphql.NewNonNull(t.filter))},
+			}
+			for col, itype := range t.columns {
+				_, filter := graphqlColumnType(itype)
+				fields[col] = &graphql.InputObjectFieldConfig{Type: filter}
+			}
+			return fields
+		}),
+	})
+	values := graphql.EnumValueConfigMap{}
+	for col := range t.columns {
+		values[col] = &graphql.EnumValueConfig{Value: col}
+	}
+	t.order = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: t.name + "_order",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"column": {Type: graphql.NewNonNull(graphql.NewEnum(graphql.EnumConfig{
+				Name:   t.name + "_column",
+				Values: values,
+			}))},
+			"desc": {Type: graphql.Boolean},
+		},
+	})
+}
+
+func (t *graphqlTable) listArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"where":  {Type: t.filter},
+		"order":  {Type: graphql.NewList(graphql.NewNonNull(t.order))},
+		"offset": {Type: graphql.Int, DefaultValue: 0},
+		"limit":  {Type: graphql.Int, DefaultValue: defaultPaginatorLimit},
+	}
+}
+
+func resolveGraphQLColumn(col, itype string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		row, _ := p.Source.(map[string]string)
+		value, ok := row[col]
+		if !ok || (len(value) == 0 && itype != "varchar" && itype != "text") {
+			return nil, nil
+		}
+		return graphqlColumnValue(itype, value), nil
+	}
+}
+
+// graphqlSelect is the query of the rows of the table
+type graphqlSelect struct {
+	table   *graphqlTable
+	columns map[string]bool
+	conds   []any
+	order   []any
+	offset  int
+	limit   int
+}
+
+func (t *graphqlTable) newSelect(p graphql.ResolveParams) *graphqlSelect {
+	s := &graphqlSelect{table: t, columns: t.selectedColumns(p)}
+	if filter, ok := p.Args["where"].(map[string]any); ok {
+		s.conds = graphqlConditions(filter, s.columns)
+	}
+	if order, ok := p.Args["order"].([]any); ok {
+		for _, item := range order {
+			param, _ := item.(map[string]any)
+			col, _ := param["column"].(string)
+			if len(col) == 0 {
+				continue
+			}
+			dir := 1
+			if desc, _ := param["desc"].(bool); desc {
+				dir = -1
+			}
+			s.columns[col] = true
+			s.order = append(s.order, map[string]any{col: dir})
+		}
+	}
+	s.offset, _ = p.Args["offset"].(int)
+	s.limit, _ = p.Args["limit"].(int)
+	if s.offset < 0 {
+		s.offset = 0
+	}
+	if s.limit <= 0 {
+		s.limit = defaultPaginatorLimit
+	}
+	if s.limit > maxPaginatorLimit {
+		s.limit = maxPaginatorLimit
+	}
+	return s
+}
+
+// selectedColumns returns the columns which are required by the selection set of the field
+func (t *graphqlTable) selectedColumns(p graphql.ResolveParams) map[string]bool {
+	cols := map[string]bool{"id": true}
+	var walk func(set *ast.SelectionSet)
+	walk = func(set *ast.SelectionSet) {
+		if set == nil {
+			return
+		}
+		for _, sel := range set.Selections {
+			switch s := sel.(type) {
+			case *ast.Field:
+				name := s.Name.Value
+				if _, ok := t.columns[name]; ok {
+					cols[name] = true
+				} else if rel, ok := t.relations[name]; ok && !rel.many {
+					cols[rel.column] = true
+				}
+			case *ast.InlineFragment:
+				walk(s.SelectionSet)
+			case *ast.FragmentSpread:
+				if f, ok := p.Info.Fragments[s.Name.Value].(*ast.FragmentDefinition); ok {
+					walk(f.SelectionSet)
+				}
+			}
+		}
+	}
+	for _, field := range p.Info.FieldASTs {
+		walk(field.SelectionSet)
+	}
+	return cols
+}
+
+func graphqlWhereMap(key string, value any) *types.Map {
+	m := types.NewMap()
+	m.Set(key, value)
+	return m
+}
+
+// graphqlConditions converts the filter to the list of the conditions of the query builder.
+// The used columns are added to cols for checking of the read permissions
+func graphqlConditions(filter map[string]any, cols map[string]bool) []any {
+	var conds []any
+	for _, key := range sortedKeys(filter) {
+		switch key {
+		case graphqlAnd, graphqlOr:
+			list, _ := filter[key].([]any)
+			var sub []any
+			for _, item := range list {
+				m, _ := item.(map[string]any)
+				if c := graphqlConditions(m, cols); len(c) > 0 {
+					sub = append(sub, graphqlWhereMap("$and", c))
+				}
+			}
+			if len(sub) > 0 {
+				conds = append(conds, graphqlWhereMap("$"+key[1:], sub))
+			}
+		default:
+			ops, _ := filter[key].(map[string]any)
+			for _, op := range sortedKeys(ops) {
+				if ops[op] == nil {
+					continue
+				}
+				cols[key] = true
+				conds = append(conds, graphqlWhereMap(key, graphqlWhereMap(graphqlOperators[op], ops[op])))
+			}
+		}
+	}
+	return conds
+}
+
+// graphqlQuery is the state of GraphQL request which limits the cost and the depth of the queries
+type graphqlQuery struct {
+	client   *Client
+	logger   *log.Entry
+	maxCost  int64
+	maxDepth int
+
+	mu   sync.Mutex
+	cost int64
+}
+
+func newGraphQLQuery(client *Client, logger *log.Entry) *graphqlQuery {
+	gq := &graphqlQuery{
+		client:   client,
+		logger:   logger,
+		maxCost:  conf.Config.GraphQL.MaxCost,
+		maxDepth: conf.Config.GraphQL.MaxDepth,
+	}
+	if gq.maxCost <= 0 {
+		gq.maxCost = graphqlDefaultMaxCost
+	}
+	if gq.maxDepth <= 0 {
+		gq.maxDepth = graphqlDefaultMaxDepth
+	}
+	return gq
+}
+
+func getGraphQLQuery(p graphql.ResolveParams) *graphqlQuery {
+	return p.Context.Value(contextKeyGraphQL).(*graphqlQuery)
+}
+
+// checkDepth returns the error if the number of the nested table fields exceeds the limit
+func (gq *graphqlQuery) checkDepth(p graphql.ResolveParams) error {
+	var depth int
+	for path := p.Info.Path; path != nil; path = path.Prev {
+		if _, ok := path.Key.(string); ok {
+			depth++
+		}
+	}
+	// the fields of the columns are not counted, so the root table has depth 1
+	if depth > gq.maxDepth {
+		return graphqlError{errGraphQLDepth.Errorf(gq.maxDepth)}
+	}
+	return nil
+}
+
+// addCost adds querycost of the select query to the cost of the request
+func (gq *graphqlQuery) addCost(table string) error {
+	cost, err := querycost.GetQueryCoster(querycost.FormulaQueryCosterType).
+		QueryCost(nil, fmt.Sprintf(`select * from "%s"`, table))
+	if err != nil {
+		return err
+	}
+	gq.mu.Lock()
+	defer gq.mu.Unlock()
+	gq.cost += cost
+	if gq.cost > gq.maxCost {
+		return graphqlError{errGraphQLCost.Errorf(gq.maxCost)}
+	}
+	return nil
+}
+
+// prepare checks the read permissions of the columns and the limits of the request
+// and returns the query of the table
+func (gq *graphqlQuery) prepare(p graphql.ResolveParams, s *graphqlSelect) (*gorm.DB, string, error) {
+	if err := gq.checkDepth(p); err != nil {
+		return nil, "", err
+	}
+	table, cols, err := checkAccess(s.table.name, strings.Join(sortedKeys(s.columns), ","), gq.client)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = gq.addCost(table); err != nil {
+		return nil, "", err
+	}
+	q := sqldb.GetTableQuery(s.table.name, gq.client.EcosystemID).Select(cols)
+	if len(s.conds) > 0 {
+		where, err := qb.GetWhere(graphqlWhereMap("$and", s.conds))
+		if err != nil {
+			return nil, "", err
+		}
+		if len(where) > 0 {
+			q = q.Where(where)
+		}
+	}
+	return q, table, nil
+}
+
+func (gq *graphqlQuery) rows(p graphql.ResolveParams, s *graphqlSelect) ([]map[string]string, error) {
+	q, table, err := gq.prepare(p, s)
+	if err != nil {
+		return nil, err
+	}
+	order, err := qb.GetOrder(table, s.order, true)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.Order(order).Offset(s.offset).Limit(s.limit).Rows()
+	if err != nil {
+		gq.logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("Getting rows from table")
+		return nil, graphqlError{errQuery}
+	}
+	return sqldb.GetResult(rows)
+}
+
+func (t *graphqlTable) resolveList(rel *graphqlRelation) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		s := t.newSelect(p)
+		if rel != nil {
+			row, _ := p.Source.(map[string]string)
+			s.columns[rel.column] = true
+			s.conds = append(s.conds, graphqlWhereMap(rel.column, graphqlWhereMap("$eq", row["id"])))
+		}
+		return getGraphQLQuery(p).rows(p, s)
+	}
+}
+
+func (t *graphqlTable) resolveRow(column string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		row, _ := p.Source.(map[string]string)
+		id := row[column]
+		if len(id) == 0 || id == "0" {
+			return nil, nil
+		}
+		s := &graphqlSelect{
+			table:   t,
+			columns: t.selectedColumns(p),
+			conds:   []any{graphqlWhereMap("id", graphqlWhereMap("$eq", id))},
+			limit:   1,
+		}
+		list, err := getGraphQLQuery(p).rows(p, s)
+		if err != nil || len(list) == 0 {
+			return nil, err
+		}
+		return list[0], nil
+	}
+}
+
+func (t *graphqlTable) resolveCount(p graphql.ResolveParams) (any, error) {
+	gq := getGraphQLQuery(p)
+	s := t.newSelect(p)
+	q, table, err := gq.prepare(p, s)
+	if err != nil {
+		return nil, err
+	}
+	var count int64
+	if err = q.Count(&count).Error; err != nil {
+		gq.logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("Getting table records count")
+		return nil, graphqlError{errTableNotFound.Errorf(table)}
+	}
+	return count, nil
+}
+
+func graphqlHandler(w http.ResponseWriter, r *http.Request) {
+	form := &graphqlForm{}
+	if err := parseGraphQLForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	client := getClient(r)
+	logger := getLogger(r)
+
+	schema, err := getGraphQLSchema(client.EcosystemID)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "ecosystem": client.EcosystemID}).Error("building graphql schema")
+		errorResponse(w, err)
+		return
+	}
+
+	gq := newGraphQLQuery(client, logger)
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  form.Query,
+		VariableValues: form.Variables,
+		OperationName:  form.OperationName,
+		Context:        context.WithValue(r.Context(), contextKeyGraphQL, gq),
+	})
+	result.Extensions = map[string]any{"cost": gq.cost}
+	jsonResponse(w, result)
+}
@@ -0,0 +1,83 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"sort"
+	"testing"
+
+	qb "github.com/IBAX-io/go-ibax/packages/storage/sqldb/queryBuilder"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraphQLTables() map[string]*graphqlTable {
+	tables := map[string]*graphqlTable{
+		"keys": {
+			name:      "keys",
+			columns:   map[string]string{"id": "number", "amount": "money", "deleted": "number"},
+			relations: make(map[string]graphqlRelation),
+		},
+		"members": {
+			name:      "members",
+			columns:   map[string]string{"id": "number", "key_id": "number", "member_name": "varchar"},
+			relations: make(map[string]graphqlRelation),
+		},
+	}
+	findGraphQLRelations(tables)
+	return tables
+}
+
+func TestGraphQLRelations(t *testing.T) {
+	tables := testGraphQLTables()
+	assert.Equal(t, map[string]graphqlRelation{
+		"key": {table: "keys", column: "key_id"},
+	}, tables["members"].relations)
+	assert.Equal(t, map[string]graphqlRelation{
+		"members_by_key": {table: "members", column: "key_id", many: true},
+	}, tables["keys"].relations)
+}
+
+func TestGraphQLSchema(t *testing.T) {
+	schema, err := buildGraphQLSchema(testGraphQLTables())
+	require.NoError(t, err)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ __type(name: "members") { fields { name } } }`,
+	})
+	require.Empty(t, result.Errors)
+	var fields []string
+	for _, f := range result.Data.(map[string]any)["__type"].(map[string]any)["fields"].([]any) {
+		fields = append(fields, f.(map[string]any)["name"].(string))
+	}
+	sort.Strings(fields)
+	assert.Equal(t, []string{"id", "key", "key_id", "member_name"}, fields)
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { keys { id } }`,
+	})
+	assert.NotEmpty(t, result.Errors)
+}
+
+func TestGraphQLConditions(t *testing.T) {
+	cols := make(map[string]bool)
+	conds := graphqlConditions(map[string]any{
+		"amount": map[string]any{"gt": "10", "lte": "100"},
+		graphqlOr: []any{
+			map[string]any{"id": map[string]any{"eq": int64(1)}},
+			map[string]any{"id": map[string]any{"in": []any{int64(2), int64(3)}}},
+		},
+	}, cols)
+	assert.Equal(t, map[string]bool{"amount": true, "id": true}, cols)
+
+	where, err := qb.GetWhere(graphqlWhereMap("$and", conds))
+	require.NoError(t, err)
+	assert.Equal(t, `(((("id" = '1')) or (("id" in ('2', '3')))) and ("amount" > '10') and ("amount" <= '100'))`, where)
+}
@@ -0,0 +1,46 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/smart"
+)
+
+type lintContractForm struct {
+	Value string `schema:"value"`
+}
+
+func (f *lintContractForm) Validate(r *http.Request) error {
+	if len(f.Value) == 0 || int64(len(f.Value)) > syspar.GetMaxTxSize() {
+		return errUndefineval.Errorf("value")
+	}
+	return nil
+}
+
+type lintContractResult struct {
+	Issues []*script.LintIssue `json:"issues"`
+}
+
+// lintContractHandler returns the issues of the static analysis of the contract source. The analysis
+// does not change the state, so it is not the part of the contracts creating or editing the contracts
+func lintContractHandler(w http.ResponseWriter, r *http.Request) {
+	form := &lintContractForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	client := getClient(r)
+
+	issues := script.Lint(form.Value, smart.LintOptions(script.GetVM(), client.EcosystemID, nil))
+	if issues == nil {
+		issues = make([]*script.LintIssue, 0)
+	}
+	jsonResponse(w, &lintContractResult{Issues: issues})
+}
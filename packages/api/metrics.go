@@ -52,8 +52,12 @@ type memMetric struct {
 }
 
 type banMetric struct {
-	NodePosition int  `json:"node_position"`
-	Status       bool `json:"status"`
+	NodePosition int    `json:"node_position"`
+	Status       bool   `json:"status"`
+	Connected    bool   `json:"connected"`
+	Latency      int64  `json:"latency"`
+	Errors       int64  `json:"errors"`
+	LastError    string `json:"last_error,omitempty"`
 }
 
 func blocksCountHandler(w http.ResponseWriter, r *http.Request) {
@@ -169,10 +173,17 @@ func banStatHandler(w http.ResponseWriter, _ *http.Request) {
 
 	b := node.GetNodesBanService()
 	for i, n := range nodes {
-		list = append(list, banMetric{
+		item := banMetric{
 			NodePosition: i,
 			Status:       b.IsBanned(n),
-		})
+		}
+		if stats, ok := b.GetPeerStats(n.TCPAddress); ok {
+			item.Connected = stats.Connected
+			item.Latency = stats.Latency.Milliseconds()
+			item.Errors = stats.Errors
+			item.LastError = stats.LastError
+		}
+		list = append(list, item)
 	}
 
 	jsonResponse(w, list)
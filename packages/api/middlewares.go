@@ -9,10 +9,15 @@ import (
 	"fmt"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/service/apikey"
+	"github.com/IBAX-io/go-ibax/packages/service/metrics"
 	"github.com/IBAX-io/go-ibax/packages/service/node"
+	"github.com/IBAX-io/go-ibax/packages/service/ratelimit"
 	"github.com/IBAX-io/go-ibax/packages/statsd"
 	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
 
@@ -93,6 +98,23 @@ func tokenMiddleware(next http.Handler) http.Handler {
 	const authHeader = "AUTHORIZATION"
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret, ok := apikey.FromHeader(r.Header.Get(authHeader)); ok {
+			key, err := apikey.Authenticate(secret)
+			if err != nil {
+				logger := getLogger(r)
+				logger.WithFields(log.Fields{"type": consts.AccessDenied, "error": err}).Warning("checking api key")
+				errorResponse(w, errUnauthorized)
+				return
+			}
+			if !key.Allow() {
+				errorResponse(w, errAPIKeyLimit)
+				return
+			}
+			r = setAPIKey(r, key)
+			r = setToken(r, apiKeyToken(key))
+			next.ServeHTTP(w, r)
+			return
+		}
 		//token, err := RefreshToken(r.Header.Get(authHeader))
 		token, err := parseJWTToken(r.Header.Get(authHeader))
 		if err != nil {
@@ -126,6 +148,36 @@ func (m Mode) clientMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// rateLimitMiddleware limits the requests of the client by the rule of the route
+func rateLimitMiddleware(lmt *ratelimit.Limiter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if lmt == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var apiKeyID, keyID int64
+			if key := getAPIKey(r); key != nil {
+				apiKeyID = key.ID
+			}
+			if client := getClient(r); client != nil {
+				keyID = client.KeyID
+			}
+			var name string
+			if route := mux.CurrentRoute(r); route != nil {
+				name, _ = route.GetPathTemplate()
+				name = strings.TrimPrefix(name, "/api/v2")
+			}
+			if retry, ok := lmt.Take(name, lmt.Client(r, apiKeyID, keyID)); !ok {
+				seconds := int64(retry / time.Second)
+				w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+				errorResponse(w, errRateLimit.Errorf(seconds))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func statsdMiddleware(next http.Handler) http.Handler {
 	const v = 1.0
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -142,3 +194,17 @@ func statsdMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// metricsMiddleware records the latency of the route for Prometheus
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		next.ServeHTTP(w, r)
+
+		var name string
+		if route := mux.CurrentRoute(r); route != nil {
+			name, _ = route.GetPathTemplate()
+		}
+		metrics.ObserveAPIRequest("api", r.Method, name, time.Since(startTime))
+	})
+}
@@ -0,0 +1,43 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/service/node"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type nodeStatusResult struct {
+	Version string          `json:"version"`
+	Status  string          `json:"status"`
+	BlockID int64           `json:"block_id"`
+	Sync    node.SyncStatus `json:"sync"`
+}
+
+// getNodeStatusHandler returns the state of the node and the progress of the collecting of the blocks.
+// It is available while the node is updating the blockchain
+func getNodeStatusHandler(w http.ResponseWriter, r *http.Request) {
+	logger := getLogger(r)
+
+	infoBlock := &sqldb.InfoBlock{}
+	if _, err := infoBlock.Get(); err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		errorResponse(w, err)
+		return
+	}
+
+	jsonResponse(w, &nodeStatusResult{
+		Version: consts.Version(),
+		Status:  node.NodePauseType().String(),
+		BlockID: infoBlock.BlockID,
+		Sync:    node.GetSyncStatus(),
+	})
+}
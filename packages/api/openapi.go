@@ -0,0 +1,273 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/common/jsonschema"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/gorilla/mux"
+)
+
+const openAPIVersion = "3.0.3"
+
+// routeForms are the forms which are parsed by handlers of routes, the routes without forms have nil.
+// Every route of the router must be here, TestOpenAPIRouteForms checks it
+var routeForms = map[string]formValidator{
+	"/appcontent/{appID}":                 &appParamsForm{},
+	"/appparam/{appID}/{name}":            &ecosystemForm{},
+	"/appparams/{appID}":                  &appParamsForm{},
+	"/auth/status":                        nil,
+	"/avatar/{ecosystem}/{account}":       nil,
+	"/balance/{wallet}":                   &ecosystemForm{},
+	"/block/{id}":                         nil,
+	"/blocks":                             &blocksTxInfoForm{},
+	"/config/{option}":                    nil,
+	"/content":                            &jsonContentForm{},
+	"/content/hash/{name}":                nil,
+	"/content/menu/{name}":                nil,
+	"/content/page/{name}":                nil,
+	"/content/source/{name}":              nil,
+	"/contract/{name}":                    nil,
+	"/contract/{name}/versions":           nil,
+	"/contract/{name}/versions/{version}": nil,
+	"/contracts":                          &paginatorForm{},
+	"/data/{id}/data/{hash}":              nil,
+	"/data/{table}/{id}/{column}/{hash}":  nil,
+	"/detailed_blocks":                    &blocksTxInfoForm{},
+	"/ecosystemname":                      nil,
+	"/ecosystemparam/{name}":              &ecosystemForm{},
+	"/ecosystemparams":                    &appParamsForm{},
+	"/estimateFee/{name}":                 &estimateFeeForm{},
+	"/getuid":                             nil,
+	"/graphql":                            &graphqlForm{},
+	"/history/{name}/{id}":                nil,
+	"/interface/menu/{name}":              nil,
+	"/interface/page/{name}":              nil,
+	"/interface/snippet/{name}":           nil,
+	"/keyinfo/{wallet}":                   nil,
+	"/lintContract":                       &lintContractForm{},
+	"/list/{name}":                        &listForm{},
+	"/listWhere/{name}":                   &listWhereForm{},
+	"/login":                              &loginForm{},
+	"/maxblockid":                         nil,
+	"/member/{ecosystem}/{account}":       nil,
+	"/metrics":                            nil,
+	"/metrics/ban":                        nil,
+	"/metrics/blockper/{node}/{mode}":     nil,
+	"/metrics/blocks":                     nil,
+	"/metrics/ecosystems":                 nil,
+	"/metrics/honornodes":                 nil,
+	"/metrics/keys":                       nil,
+	"/metrics/mem":                        nil,
+	"/metrics/transactions":               nil,
+	"/network":                            nil,
+	"/node/{name}":                        nil,
+	"/nodelistWhere/{name}":               &listWhereForm{},
+	"/nodestatus":                         nil,
+	"/openapi.json":                       nil,
+	"/page/validators_count/{name}":       nil,
+	"/row/{name}/{column}/{id}":           &rowForm{},
+	"/row/{name}/{id}":                    &rowForm{},
+	"/sections":                           &sectionsForm{},
+	"/sendTx":                             nil,
+	"/sumWhere/{name}":                    &SumWhereForm{},
+	"/systemparams":                       &paramsForm{},
+	"/table/{name}":                       nil,
+	"/tables":                             &paginatorForm{},
+	"/tx_record/{hashes}":                 nil,
+	"/txinfo/{hash}":                      &txInfoForm{},
+	"/txinfomultiple":                     &txInfoForm{},
+	"/txstatus":                           nil,
+	"/version":                            nil,
+}
+
+var (
+	reRouteVar      = regexp.MustCompile(`{([^:}]+)(:[^}]+)?}`)
+	authRequireName = funcName(authRequire)
+)
+
+const (
+	openAPIBearerKey = "bearer"
+	openAPIKeyKey    = "apiKey"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string             `json:"name"`
+	In       string             `json:"in"`
+	Required bool               `json:"required,omitempty"`
+	Schema   *jsonschema.Schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+func openAPIHandler(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := newOpenAPIDocument(router)
+		if err != nil {
+			errorResponse(w, err)
+			return
+		}
+		jsonResponse(w, doc)
+	}
+}
+
+// newOpenAPIDocument describes the routes registered in router with the forms of their handlers
+func newOpenAPIDocument(router *mux.Router) (*openAPIDocument, error) {
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:   "IBAX REST API",
+			Version: consts.Version(),
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				openAPIBearerKey: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				openAPIKeyKey:    {Type: "apiKey", In: "header", Name: "Authorization"},
+			},
+		},
+	}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// the subrouters have no methods
+			return nil
+		}
+		path := reRouteVar.ReplaceAllString(tpl, `{$1}`)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		for _, method := range methods {
+			doc.Paths[path][strings.ToLower(method)] = newOpenAPIOperation(route, method, path, ancestors)
+		}
+		return nil
+	})
+	return doc, err
+}
+
+func newOpenAPIOperation(route *mux.Route, method, path string, ancestors []*mux.Route) *openAPIOperation {
+	op := &openAPIOperation{
+		OperationID: strings.ToLower(method) + operationName(path),
+		Parameters:  make([]openAPIParameter, 0),
+		Responses: map[string]openAPIResponse{
+			"200": {Description: "OK"},
+			"400": {Description: "Bad request"},
+		},
+	}
+	for _, match := range reRouteVar.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &jsonschema.Schema{Type: "string"},
+		})
+	}
+	if h := route.GetHandler(); h != nil && strings.HasPrefix(funcName(h), authRequireName+".") {
+		op.Security = []map[string][]string{{openAPIBearerKey: {}}, {openAPIKeyKey: {}}}
+		op.Responses["401"] = openAPIResponse{Description: "Unauthorized"}
+	}
+
+	prefix := ""
+	if len(ancestors) > 0 {
+		prefix, _ = ancestors[len(ancestors)-1].GetPathTemplate()
+	}
+	form := routeForms[strings.TrimPrefix(path, prefix)]
+	if form == nil {
+		return op
+	}
+	schema := jsonschema.Reflect(reflect.TypeOf(form), "schema")
+	if method == http.MethodGet {
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: name, In: "query", Schema: schema.Properties[name]})
+		}
+		return op
+	}
+	op.RequestBody = &openAPIRequestBody{Content: map[string]openAPIMediaType{
+		"application/x-www-form-urlencoded": {Schema: schema},
+		multipartFormData:                   {Schema: schema},
+	}}
+	return op
+}
+
+func funcName(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// operationName makes the name from the path, /row/{name}/{id} is RowByNameById
+func operationName(path string) string {
+	var sb strings.Builder
+	for _, item := range strings.Split(path, "/") {
+		if len(item) == 0 {
+			continue
+		}
+		if strings.HasPrefix(item, "{") {
+			sb.WriteString("By")
+			item = strings.Trim(item, "{}")
+		}
+		for _, word := range strings.FieldsFunc(item, func(r rune) bool { return r == '_' || r == '.' || r == '-' }) {
+			sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,78 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api/v2").Subrouter()
+	api.HandleFunc("/version", getVersionHandler).Methods("GET")
+	api.HandleFunc("/row/{name}/{id:[0-9]+}", authRequire(getRowHandler)).Methods("GET")
+	api.HandleFunc("/estimateFee/{name}", authRequire(estimateFeeHandler)).Methods("POST")
+
+	doc, err := newOpenAPIDocument(r)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(doc.Paths))
+
+	op := doc.Paths["/api/v2/version"]["get"]
+	require.NotNil(t, op)
+	assert.Equal(t, "getApiV2Version", op.OperationID)
+	assert.Empty(t, op.Security)
+
+	op = doc.Paths["/api/v2/row/{name}/{id}"]["get"]
+	require.NotNil(t, op)
+	assert.Equal(t, 2, len(op.Security))
+	names := make([]string, 0)
+	for _, p := range op.Parameters {
+		names = append(names, p.In+":"+p.Name)
+	}
+	assert.Equal(t, []string{"path:name", "path:id", "query:columns"}, names)
+
+	op = doc.Paths["/api/v2/estimateFee/{name}"]["post"]
+	require.NotNil(t, op)
+	require.NotNil(t, op.RequestBody)
+	schema := op.RequestBody.Content["application/x-www-form-urlencoded"].Schema
+	assert.Equal(t, "string", schema.Properties["max_sum"].Type)
+	assert.Equal(t, "string", schema.Properties["params"].Type)
+}
+
+func TestOpenAPIRouteForms(t *testing.T) {
+	m := Mode{}
+	r := NewRouter(m)
+	m.SetBlockchainRoutes(r)
+
+	paths := make(map[string]bool)
+	err := r.GetAPI().Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		if _, err = route.GetMethods(); err != nil {
+			return nil
+		}
+		prefix := ""
+		if len(ancestors) > 0 {
+			prefix, _ = ancestors[len(ancestors)-1].GetPathTemplate()
+		}
+		path := strings.TrimPrefix(reRouteVar.ReplaceAllString(tpl, `{$1}`), prefix)
+		paths[path] = true
+		_, ok := routeForms[path]
+		assert.True(t, ok, "route %s has no entry in routeForms", path)
+		return nil
+	})
+	require.NoError(t, err)
+	for path := range routeForms {
+		assert.True(t, paths[path], "routeForms has unknown route %s", path)
+	}
+}
@@ -8,6 +8,10 @@ package api
 import (
 	"net/http"
 
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/service/metrics"
+	"github.com/IBAX-io/go-ibax/packages/service/ratelimit"
+
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
@@ -36,9 +40,11 @@ func (r Router) NewVersion(preffix string) *mux.Router {
 // Route sets routing pathes
 func (m Mode) SetCommonRoutes(r Router) {
 	NoneMiddlewareRoutes(r.NewVersion("/api/v2"), m)
+	r.GetAPIVersion("/api/v2").HandleFunc("/openapi.json", openAPIHandler(r.GetAPI())).Methods("GET")
 
 	api := r.NewVersion("/api/v2")
-	api.Use(nodeStateMiddleware, tokenMiddleware, m.clientMiddleware)
+	api.Use(nodeStateMiddleware, tokenMiddleware, m.clientMiddleware,
+		rateLimitMiddleware(ratelimit.New("api", conf.Config.RateLimit, conf.Config.RateLimit.Routes)))
 
 	SetOtherCommonRoutes(api, m)
 	api.HandleFunc("/data/{id}/data/{hash}", getBinaryHandler).Methods("GET")
@@ -47,10 +53,13 @@ func (m Mode) SetCommonRoutes(r Router) {
 	api.HandleFunc("/auth/status", getAuthStatus).Methods("GET")
 
 	api.HandleFunc("/contract/{name}", authRequire(getContractInfoHandler)).Methods("GET")
+	api.HandleFunc("/contract/{name}/versions", authRequire(getContractVersionsHandler)).Methods("GET")
+	api.HandleFunc("/contract/{name}/versions/{version}", authRequire(getContractVersionHandler)).Methods("GET")
 	api.HandleFunc("/contracts", authRequire(getContractsHandler)).Methods("GET")
 	api.HandleFunc("/getuid", getUIDHandler).Methods("GET")
 	api.HandleFunc("/keyinfo/{wallet}", m.getKeyInfoHandler).Methods("GET")
 	api.HandleFunc("/list/{name}", authRequire(getListHandler)).Methods("GET")
+	api.HandleFunc("/graphql", authRequire(graphqlHandler)).Methods("POST")
 	api.HandleFunc("/network", getNetworkHandler).Methods("GET")
 	api.HandleFunc("/sections", authRequire(getSectionsHandler)).Methods("GET")
 	api.HandleFunc("/row/{name}/{id}", authRequire(getRowHandler)).Methods("GET")
@@ -72,6 +81,8 @@ func (m Mode) SetCommonRoutes(r Router) {
 	api.HandleFunc("/sendTx", authRequire(m.sendTxHandler)).Methods("POST")
 	api.HandleFunc("/node/{name}", nodeContractHandler).Methods("POST")
 	api.HandleFunc("/txstatus", authRequire(getTxStatusHandler)).Methods("POST")
+	api.HandleFunc("/estimateFee/{name}", authRequire(estimateFeeHandler)).Methods("POST")
+	api.HandleFunc("/lintContract", authRequire(lintContractHandler)).Methods("POST")
 	api.HandleFunc("/metrics/blocks", blocksCountHandler).Methods("GET")
 	api.HandleFunc("/metrics/transactions", txCountHandler).Methods("GET")
 	api.HandleFunc("/metrics/ecosystems", ecosysCountHandler).Methods("GET")
@@ -104,6 +115,7 @@ func (m Mode) SetBlockchainRoutes(r Router) {
 
 func NoneMiddlewareRoutes(api *mux.Router, m Mode) {
 	api.HandleFunc("/version", getVersionHandler).Methods("GET")
+	api.HandleFunc("/nodestatus", getNodeStatusHandler).Methods("GET")
 }
 
 func SetOtherCommonRoutes(api *mux.Router, m Mode) {
@@ -123,7 +135,8 @@ func (m Mode) SetSubNodeRoutes(r Router) {}
 func NewRouter(m Mode) Router {
 	r := mux.NewRouter()
 	r.StrictSlash(true)
-	r.Use(loggerMiddleware, recoverMiddleware, statsdMiddleware)
+	r.Use(loggerMiddleware, recoverMiddleware, statsdMiddleware, metricsMiddleware)
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
 
 	api := Router{
 		main:        r,
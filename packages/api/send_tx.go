@@ -97,6 +97,12 @@ func txHandlerBatches(r *http.Request, m Mode, mtx map[string][]byte) ([]string,
 		transaction.BadTxForBan(client.KeyID)
 		return nil, errLimitTxSize.Errorf(len(txData))
 	}
+	if key := getAPIKey(r); key != nil {
+		if err := key.CheckTxs(txData); err != nil {
+			logger.WithFields(log.Fields{"type": consts.AccessDenied, "error": err, "api_key": key.ID}).Warning("checking api key scopes")
+			return nil, errAPIKeyScope.Errorf(err)
+		}
+	}
 
 	hash, err := m.ClientTxProcessor.ProcessClientTxBatches(txData, client.KeyID, logger)
 	if err != nil {
@@ -68,6 +68,9 @@ func getTxInfo(r *http.Request, txHash string, getInfo bool) (*txinfoResult, err
 		}
 		status.Data.Status = ltx.Status
 		status.Data.Ecosystem = ltx.EcosystemID
+		status.Data.PeakMemory = ltx.PeakMemory
+		status.Data.PeakDepth = ltx.PeakDepth
+		status.Data.Instructions = ltx.Instructions
 	}
 
 	return &status, nil
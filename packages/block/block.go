@@ -6,6 +6,12 @@
 package block
 
 import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/service/event"
 	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
 	"github.com/IBAX-io/go-ibax/packages/transaction"
 	"github.com/IBAX-io/go-ibax/packages/types"
@@ -30,7 +36,9 @@ type Block struct {
 	OutputsMap        map[sqldb.KeyUTXO][]sqldb.SpentInfo
 	ClassifyTxsMap    map[int][]*transaction.Transaction
 	PrevSysPar        map[string]string
-	EcoParams         []sqldb.EcoParam // combustion percent,digits for each ecosystem
+	EcoParams         []sqldb.EcoParam        // combustion percent,digits for each ecosystem
+	txFuel            int64                   // fuel used by the played transactions
+	vmStats           map[string]script.Stats // VM statistics of the played contracts by the hash of the transaction
 }
 
 // GetLogger is returns logger
@@ -74,5 +82,74 @@ func InsertBlockWOForksNew(data []byte, classifyTxsMap map[int][]*transaction.Tr
 	}
 
 	log.WithFields(log.Fields{"block_id": block.Header.BlockId}).Debug("block was inserted successfully")
+	block.publishEvents()
 	return nil
 }
+
+// publishEvents notifies the subscribers about the inserted block and its transactions
+func (b *Block) publishEvents() {
+	event.Publish(event.TopicNewHeads, &event.BlockHeader{
+		BlockID:       b.Header.BlockId,
+		Hash:          hex.EncodeToString(b.Header.BlockHash),
+		RollbacksHash: hex.EncodeToString(b.Header.RollbacksHash),
+		Time:          b.Header.Timestamp,
+		EcosystemID:   b.Header.EcosystemId,
+		KeyID:         b.Header.KeyId,
+		NodePosition:  b.Header.NodePosition,
+		ConsensusMode: b.Header.ConsensusMode,
+		TxCount:       len(b.TxFullData),
+	})
+	if b.AfterTxs == nil {
+		return
+	}
+	for _, tx := range b.AfterTxs.Txs {
+		if s := tx.UpdTxStatus; s != nil {
+			event.Publish(event.TopicTxStatus, &event.TxStatus{
+				Hash:    hex.EncodeToString(s.Hash),
+				BlockID: b.Header.BlockId,
+				Code:    int32(s.Code),
+				Result:  s.Result,
+				Error:   s.Error,
+			})
+		}
+		if l := tx.Lts; l != nil {
+			event.Publish(event.TopicLogs, &event.ContractLog{
+				BlockID:      b.Header.BlockId,
+				TxHash:       hex.EncodeToString(l.Hash),
+				EcosystemID:  l.EcosystemId,
+				ContractName: l.ContractName,
+				Address:      l.Address,
+				Status:       int64(l.InvokeStatus),
+				Timestamp:    l.Timestamp,
+			})
+		}
+	}
+	b.publishContractEvents()
+}
+
+// publishContractEvents sends the committed events emitted by the contracts of the block
+func (b *Block) publishContractEvents() {
+	if !event.HasSubscribers(event.TopicLogs) {
+		return
+	}
+	events, err := sqldb.GetContractEvents(&sqldb.ContractEventFilter{
+		FromBlock: b.Header.BlockId,
+		ToBlock:   b.Header.BlockId,
+	})
+	if err != nil {
+		b.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting contract events")
+		return
+	}
+	for _, ev := range events {
+		event.Publish(event.TopicLogs, &event.ContractEvent{
+			BlockID:      ev.BlockID,
+			TxHash:       hex.EncodeToString(ev.TxHash),
+			LogIndex:     ev.LogIndex,
+			EcosystemID:  ev.EcosystemID,
+			ContractName: ev.ContractName,
+			Name:         ev.Name,
+			Data:         json.RawMessage(ev.Data),
+			Timestamp:    ev.Timestamp,
+		})
+	}
+}
@@ -275,6 +275,11 @@ func (b *Block) GenAfterTxs() *AfterTxs {
 		lt.EcosystemID = tx.Lts.EcosystemId
 		lt.ContractName = tx.Lts.ContractName
 		lt.Status = int64(tx.Lts.InvokeStatus)
+		if stats, ok := b.vmStats[string(tx.Lts.Hash)]; ok {
+			lt.PeakMemory = stats.PeakMemory
+			lt.PeakDepth = stats.PeakDepth
+			lt.Instructions = stats.Instructions
+		}
 		playTx.Lts[i] = lt
 
 		u := new(pbgo.TxResult)
@@ -11,12 +11,15 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/IBAX-io/go-ibax/packages/common/random"
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
 	"github.com/IBAX-io/go-ibax/packages/consts"
 	"github.com/IBAX-io/go-ibax/packages/notificator"
 	"github.com/IBAX-io/go-ibax/packages/pbgo"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/service/metrics"
 	"github.com/IBAX-io/go-ibax/packages/service/node"
 	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
 	"github.com/IBAX-io/go-ibax/packages/transaction"
@@ -28,6 +31,7 @@ import (
 // PlaySafe is inserting block safely
 func (b *Block) PlaySafe() error {
 	logger := b.GetLogger()
+	startTime := time.Now()
 	dbTx, err := sqldb.StartTransaction()
 	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("starting db transaction")
@@ -53,6 +57,11 @@ func (b *Block) PlaySafe() error {
 	if err != nil {
 		return err
 	}
+	mode := metrics.BlockPlayed
+	if b.GenBlock {
+		mode = metrics.BlockGenerated
+	}
+	metrics.ObserveBlock(mode, time.Since(startTime), len(b.TxFullData), b.txFuel)
 	for _, q := range b.Notifications {
 		q.Send()
 	}
@@ -305,6 +314,11 @@ func (b *Block) serialExecuteTxs(dbTx *sqldb.DbTransaction, txBadChan chan badTx
 		if t.IsSmartContract() {
 			eco = t.SmartContract().TxSmart.EcosystemID
 			code = t.TxResult.Code
+			b.txFuel += t.SmartContract().TxFuel
+			if b.vmStats == nil {
+				b.vmStats = make(map[string]script.Stats)
+			}
+			b.vmStats[string(t.Hash())] = t.SmartContract().Stats
 			if t.SmartContract().TxContract != nil {
 				contract = t.SmartContract().TxContract.Name
 			}
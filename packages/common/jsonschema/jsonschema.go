@@ -0,0 +1,110 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Schema is the subset of JSON Schema which is used to describe parameters and results of API
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	decimalType    = reflect.TypeOf(decimal.Decimal{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// Reflect returns the schema of the type. The names of struct fields are taken from tag,
+// usually it is json or schema for forms of REST API
+func Reflect(t reflect.Type, tag string) *Schema {
+	return reflectType(t, tag, make(map[reflect.Type]bool))
+}
+
+func reflectType(t reflect.Type, tag string, visited map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case rawMessageType:
+		return &Schema{}
+	case decimalType:
+		return &Schema{Type: "string", Format: "decimal"}
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: reflectType(t.Elem(), tag, visited)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: reflectType(t.Elem(), tag, visited)}
+	case reflect.Struct:
+		s := &Schema{Type: "object"}
+		// recursive types are described only at the first level
+		if visited[t] {
+			return s
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		s.Properties = make(map[string]*Schema)
+		reflectFields(s, t, tag, visited)
+		return s
+	}
+	// interfaces and other types can have any value
+	return &Schema{}
+}
+
+func reflectFields(s *Schema, t reflect.Type, tag string, visited map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		// the fields of embedded structs are promoted to the parent
+		if field.Anonymous && len(name) == 0 && ft.Kind() == reflect.Struct {
+			reflectFields(s, ft, tag, visited)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			// untagged interfaces are the dependencies of forms rather than their values
+			if ft.Kind() == reflect.Interface || ft.Kind() == reflect.Func || ft.Kind() == reflect.Chan {
+				continue
+			}
+			name = field.Name
+		}
+		s.Properties[name] = reflectType(field.Type, tag, visited)
+	}
+}
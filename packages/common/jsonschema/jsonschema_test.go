@@ -0,0 +1,51 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type paginator struct {
+	Limit int `json:"limit" schema:"limit"`
+}
+
+type node struct {
+	Name     string  `json:"name"`
+	Children []*node `json:"children"`
+}
+
+type form struct {
+	paginator
+	Name    string          `json:"name" schema:"name"`
+	Amount  decimal.Decimal `json:"amount"`
+	Hash    []byte          `json:"hash"`
+	Params  map[string]any  `json:"params"`
+	Data    json.RawMessage `json:"data"`
+	Tree    *node           `json:"tree"`
+	Skipped string          `json:"-"`
+	hidden  string
+}
+
+func TestReflect(t *testing.T) {
+	s := Reflect(reflect.TypeOf(&form{}), "json")
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, 7, len(s.Properties))
+	assert.Equal(t, "integer", s.Properties["limit"].Type)
+	assert.Equal(t, "decimal", s.Properties["amount"].Format)
+	assert.Equal(t, "byte", s.Properties["hash"].Format)
+	assert.Equal(t, "object", s.Properties["params"].Type)
+	assert.Equal(t, &Schema{}, s.Properties["data"])
+	assert.Equal(t, &Schema{Type: "object"}, s.Properties["tree"].Properties["children"].Items)
+
+	s = Reflect(reflect.TypeOf(form{}), "schema")
+	assert.Equal(t, "string", s.Properties["name"].Type)
+	assert.Equal(t, "string", s.Properties["Amount"].Type)
+}
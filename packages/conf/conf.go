@@ -32,6 +32,14 @@ func (c *GlobalConfig) GetPidPath() string {
 	return c.DirPathConf.PidFilePath
 }
 
+// GetSnapshotDir returns path to the directory of the snapshots
+func (c *GlobalConfig) GetSnapshotDir() string {
+	if len(c.DirPathConf.SnapshotDir) == 0 {
+		return filepath.Join(c.DirPathConf.DataDir, consts.DefaultSnapshotDirName)
+	}
+	return c.DirPathConf.SnapshotDir
+}
+
 // LoadConfig from configFile
 // the function has side effect updating global var Config
 func LoadConfig(path string) error {
@@ -135,6 +143,10 @@ func FillRuntimePaths() error {
 		Config.DirPathConf.FirstBlockPath = filepath.Join(Config.DirPathConf.DataDir, consts.FirstBlockFilename)
 	}
 
+	if Config.DirPathConf.SnapshotDir == "" {
+		Config.DirPathConf.SnapshotDir = filepath.Join(Config.DirPathConf.DataDir, consts.DefaultSnapshotDirName)
+	}
+
 	if Config.DirPathConf.PidFilePath == "" {
 		Config.DirPathConf.PidFilePath = filepath.Join(Config.DirPathConf.DataDir, consts.DefaultPidFilename)
 	}
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"sync"
@@ -80,8 +81,27 @@ const (
 	// PrivateBlockchain is value defining blockchain mode
 	PrivateBlockchain = `private_blockchain`
 
+	// MaxCallDepth is the maximum depth of the calls of functions and contracts in VM
+	MaxCallDepth = `max_call_depth`
+	// MaxVMMemory is the maximum memory of VM for the transaction in bytes
+	MaxVMMemory = `max_vm_memory`
+	// MaxStringSize is the maximum size of the string value in VM in bytes
+	MaxStringSize = `max_string_size`
+	// MaxArraySize is the maximum count of the items of the array value in VM
+	MaxArraySize = `max_array_size`
+	// VMVersion is the version of the rules of VM, it can be only increased
+	VMVersion = `vm_version`
+
 	// CostDefault is the default maximum cost of F
 	CostDefault = int64(20000000)
+	// CallDepthDefault is the default maximum depth of the calls in VM
+	CallDepthDefault = int64(1000)
+	// VMMemoryDefault is the default maximum memory of VM
+	VMMemoryDefault = int64(128 << 20)
+	// StringSizeDefault is the default maximum size of the string in VM
+	StringSizeDefault = int64(32 << 20)
+	// ArraySizeDefault is the default maximum size of the array in VM
+	ArraySizeDefault = int64(1000000)
 
 	PriceExec       = "price_exec_"
 	AccessExec      = "access_exec_"
@@ -269,6 +289,46 @@ func GetNodePositionByPublicKey(publicKey []byte) (int64, error) {
 	return 0, fmt.Errorf("incorrect public key")
 }
 
+// IsKnownNodeKey returns true if the public key belongs to the honor node or to the candidate node
+func IsKnownNodeKey(publicKey []byte) bool {
+	publicKey = crypto.CutPub(publicKey)
+	mutex.RLock()
+	_, ok := nodes[hex.EncodeToString(publicKey)]
+	for i := 0; !ok && i < len(nodesByPosition); i++ {
+		ok = bytes.Equal(nodesByPosition[i].PublicKey, publicKey)
+	}
+	mutex.RUnlock()
+	if ok || !IsCandidateNodeMode() {
+		return ok
+	}
+	candidateNode := &sqldb.CandidateNode{}
+	if err := candidateNode.GetCandidateNodeByPublicKey("04" + hex.EncodeToString(publicKey)); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting candidate node by public key")
+		return false
+	}
+	return candidateNode.ID > 0
+}
+
+// GetNodeKeyByHost returns the public key of the honor node or the candidate node by its tcp address.
+// It returns nil if the host is not a node
+func GetNodeKeyByHost(host string) []byte {
+	if n, err := GetNodeByHost(host); err == nil {
+		return n.PublicKey
+	}
+	if !IsCandidateNodeMode() {
+		return nil
+	}
+	candidateNode := &sqldb.CandidateNode{}
+	if err := candidateNode.GetCandidateNodeByAddress(host); err != nil || candidateNode.ID == 0 {
+		return nil
+	}
+	publicKey, err := hex.DecodeString(candidateNode.NodePubKey)
+	if err != nil {
+		return nil
+	}
+	return crypto.CutPub(publicKey)
+}
+
 // GetCountOfActiveNodes is count of nodes with stopped = false
 func GetCountOfActiveNodes() int64 {
 	return int64(len(nodesByPosition))
@@ -337,6 +397,18 @@ func GetNodeByHost(host string) (HonorNode, error) {
 	return HonorNode{}, fmt.Errorf("incorrect host")
 }
 
+// IsNodeIP returns true if the ip is the address of the honor node
+func IsNodeIP(ip string) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, n := range nodes {
+		if host, _, err := net.SplitHostPort(n.TCPAddress); err == nil && host == ip {
+			return true
+		}
+	}
+	return false
+}
+
 // GetNodeHostByPosition is retrieving node host by position
 func GetNodeHostByPosition(position int64) (string, error) {
 	mutex.RLock()
@@ -636,6 +708,39 @@ func GetMaxCost() int64 {
 	return cost
 }
 
+// getLimit returns the value of the platform parameter or the default value if it is not set
+func getLimit(name string, def int64) int64 {
+	if val := converter.StrToInt64(SysString(name)); val > 0 {
+		return val
+	}
+	return def
+}
+
+// GetMaxCallDepth returns the maximum depth of the calls in VM
+func GetMaxCallDepth() int64 {
+	return getLimit(MaxCallDepth, CallDepthDefault)
+}
+
+// GetMaxVMMemory returns the maximum memory of VM for the transaction
+func GetMaxVMMemory() int64 {
+	return getLimit(MaxVMMemory, VMMemoryDefault)
+}
+
+// GetMaxStringSize returns the maximum size of the string in VM
+func GetMaxStringSize() int64 {
+	return getLimit(MaxStringSize, StringSizeDefault)
+}
+
+// GetMaxArraySize returns the maximum count of the items of the array in VM
+func GetMaxArraySize() int64 {
+	return getLimit(MaxArraySize, ArraySizeDefault)
+}
+
+// GetVMVersion returns the version of the rules of VM
+func GetVMVersion() int64 {
+	return converter.StrToInt64(SysString(VMVersion))
+}
+
 func GetAccessExec(s string) string {
 	return SysString(AccessExec + s)
 }
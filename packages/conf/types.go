@@ -88,12 +88,29 @@ type (
 		TempDir        string // temporary dir
 		KeysDir        string // place for private keys files: NodePrivateKey, PrivateKey
 		FirstBlockPath string
+		SnapshotDir    string // place for the snapshots of the state
 	}
 
 	BootstrapNodeConfig struct {
 		NodesAddr []string
 	}
 
+	// PeersConfig is the settings of the discovery of the peers
+	PeersConfig struct {
+		Public       bool   // serve the sync requests of the nodes with unknown keys
+		Legacy       bool   // serve and dial the old nodes without the handshake while the network is upgraded
+		ExternalAddr string // tcp address announced to the peers, empty if the node is not reachable
+		MaxPeers     int    // max size of the address book
+	}
+
+	// SnapshotConfig is the settings of the snapshots of the state
+	SnapshotConfig struct {
+		Interval      int64 // blocks between the snapshots created by the node, 0 disables the creation
+		Keep          int   // number of the kept snapshots
+		Sync          bool  // restore the state from the snapshot of the peers on the first start
+		Confirmations int   // number of the distinct honor nodes which must serve the snapshot and confirm its block
+	}
+
 	CryptoSettings struct {
 		Cryptoer string
 		Hasher   string
@@ -109,6 +126,29 @@ type (
 	BlockSyncMethod struct {
 		Method string
 	}
+
+	// RateLimitRule is the limit of the requests of one client
+	RateLimitRule struct {
+		Name   string  // path template of the REST route without /api/v2, e.g. "/listWhere/{name}", or JSON-RPC method, e.g. "ibax.getList"
+		Rate   float64 // requests per second, 0 is the rate of the default rule
+		Burst  int     // max requests at once, 0 is the rate rounded up
+		Weight int     // the number of requests taken by one call, 0 is 1
+	}
+
+	// RateLimitConfig is the limits of the requests of the clients by API key, key ID or IP
+	RateLimitConfig struct {
+		Enabled   bool
+		IPLookups []string        // the sources of the client IP: RemoteAddr, X-Forwarded-For, X-Real-IP
+		Default   RateLimitRule   // the rule of the routes and methods without their own rate
+		Routes    []RateLimitRule // the rules of REST routes
+		Methods   []RateLimitRule // the rules of JSON-RPC methods
+	}
+
+	// GraphQLConfig is the limits of the queries of the GraphQL endpoint
+	GraphQLConfig struct {
+		MaxCost  int64 // max querycost of all tables selected by one request
+		MaxDepth int   // max depth of the traversal of the related tables
+	}
 	// GlobalConfig is storing all startup config as global struct
 	GlobalConfig struct {
 		KeyID        int64  `toml:"-"`
@@ -118,12 +158,19 @@ type (
 		LocalConf    LocalConfig
 		DirPathConf  DirectoryConfig
 		BootNodes    BootstrapNodeConfig
+		Peers        PeersConfig
+		Snapshot     SnapshotConfig
 		TLSConf      TLSConfig
 		TCPServer    HostPort
 		HTTP         HostPort
 		JsonRPC      struct {
 			Enabled   bool
 			Namespace string
+			CallFuel  int64 // max fuel of the read-only contract call
+			// AllowedOrigins are the origins of the browsers allowed to open websocket connections,
+			// "*" allows any origin. The same origin as the host is always allowed
+			AllowedOrigins   []string
+			MaxSubscriptions int // max subscriptions of one websocket connection
 		}
 		DB              DBConfig
 		Redis           RedisConfig
@@ -134,5 +181,7 @@ type (
 		BanKey          BanKeyConfig
 		CryptoSettings  CryptoSettings
 		BlockSyncMethod BlockSyncMethod
+		RateLimit       RateLimitConfig
+		GraphQL         GraphQLConfig
 	}
 )
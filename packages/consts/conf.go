@@ -15,6 +15,9 @@ const (
 	// DefaultWorkdirName name of working directory
 	DefaultWorkdirName = "data"
 
+	// DefaultSnapshotDirName is default name of the directory of the snapshots
+	DefaultSnapshotDirName = "snapshots"
+
 	// DefaultPidFilename is default filename of pid file
 	DefaultPidFilename = "go-ibax.pid"
 
@@ -38,4 +41,7 @@ const (
 
 	// KeyIDFilename generated KeyID
 	KeyIDFilename = "KeyID"
+
+	// AddrBookFilename name of the address book of the peers
+	AddrBookFilename = "peers.json"
 )
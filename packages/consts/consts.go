@@ -137,6 +137,7 @@ const (
 const (
 	SavePointMarkBlock = "block"
 	SavePointMarkTx    = "tx"
+	SavePointMarkTry   = "try"
 )
 
 func Version() string {
@@ -147,6 +148,10 @@ func SetSavePointMarkBlock(idTx string) string {
 	return fmt.Sprintf("\"%s-%s\";", SavePointMarkBlock, idTx)
 }
 
+func SetSavePointMarkTry(index int64) string {
+	return fmt.Sprintf("\"%s-%d\"", SavePointMarkTry, index)
+}
+
 const (
 	UTXO_Type_First_Block  = 1 //Initialize the first block
 	UTXO_Type_Self_UTXO    = 11
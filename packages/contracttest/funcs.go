@@ -0,0 +1,447 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package contracttest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/smart"
+	qb "github.com/IBAX-io/go-ibax/packages/storage/sqldb/queryBuilder"
+	"github.com/IBAX-io/go-ibax/packages/types"
+)
+
+// CostQuery is the cost of every query to the in-memory store. The node takes the cost
+// from the plan of the database, so the gas of the queries is approximate
+const CostQuery = 100
+
+var (
+	errAccessDenied = errors.New(`access denied`)
+	errEmptyCond    = errors.New(`the condition is empty`)
+
+	funcCallsDB = map[string]struct{}{
+		"DBInsert":    {},
+		"DBUpdate":    {},
+		"DBUpdateExt": {},
+		"DBSelect":    {},
+	}
+	writeFuncs = map[string]struct{}{
+		"DBInsert":    {},
+		"DBUpdate":    {},
+		"DBUpdateExt": {},
+	}
+)
+
+// Env is the environment of the running test. It is passed to the embedded functions
+// instead of smart.SmartContract and keeps the call stack of the contracts
+type Env struct {
+	KeyID       int64
+	EcosystemID int64
+	BlockTime   int64
+	Params      map[string]string
+	Store       *Store
+
+	vm     *script.VM
+	extend map[string]any
+	stack  []any
+}
+
+// getExtend returns the extended variables of the new call
+func (env *Env) getExtend(cost int64) map[string]any {
+	extend := map[string]any{
+		script.Extend_type:          int64(0),
+		script.Extend_txcost:        cost,
+		script.Extend_txhash:        []byte{},
+		script.Extend_sc:            env,
+		script.Extend_parent:        ``,
+		script.Extend_block:         int64(1),
+		script.Extend_block_key_id:  int64(0),
+		script.Extend_node_position: int64(0),
+
+		script.Extend_original_contract:   ``,
+		script.Extend_this_contract:       ``,
+		script.Extend_pre_block_data_hash: ``,
+		script.Extend_guest_key:           consts.GuestKey,
+		script.Extend_guest_account:       consts.GuestAddress,
+		script.Extend_black_hole_key:      converter.HoleAddrMap[converter.BlackHoleAddr].K,
+		script.Extend_black_hole_account:  converter.HoleAddrMap[converter.BlackHoleAddr].S,
+		script.Extend_white_hole_key:      converter.HoleAddrMap[converter.WhiteHoleAddr].K,
+		script.Extend_white_hole_account:  converter.HoleAddrMap[converter.WhiteHoleAddr].S,
+	}
+	env.setVars(extend)
+	return extend
+}
+
+// setVars updates the variables which can be changed by the test
+func (env *Env) setVars(extend map[string]any) {
+	extend[script.Extend_key_id] = env.KeyID
+	extend[script.Extend_account_id] = converter.AddressToString(env.KeyID)
+	extend[script.Extend_ecosystem_id] = env.EcosystemID
+	extend[script.Extend_time] = env.BlockTime
+	extend[script.Extend_block_time] = env.BlockTime
+}
+
+func (env *Env) isContract(name string) bool {
+	return smart.VMGetContract(env.vm, name, uint32(env.EcosystemID)) != nil
+}
+
+// AppendStack adds the contract to the call stack
+func (env *Env) AppendStack(fn string) error {
+	if !env.isContract(fn) {
+		return nil
+	}
+	for _, item := range env.stack {
+		if item == fn {
+			return fmt.Errorf(`there is loop in %s contract`, fn)
+		}
+	}
+	env.stack = append(env.stack, fn)
+	env.extend[script.Extend_stack] = env.stack
+	return nil
+}
+
+// PopStack removes the contract from the call stack
+func (env *Env) PopStack(fn string) {
+	if env.isContract(fn) && len(env.stack) > 0 {
+		env.stack = env.stack[:len(env.stack)-1]
+		if len(env.stack) > 0 {
+			env.extend[script.Extend_stack] = env.stack
+		} else {
+			delete(env.extend, script.Extend_stack)
+		}
+	}
+}
+
+// Savepoint returns the copy of the store which is restored if the try block fails
+func (env *Env) Savepoint() (any, error) {
+	return env.Store.Clone(), nil
+}
+
+// RollbackSavepoint restores the store
+func (env *Env) RollbackSavepoint(point any) error {
+	env.Store.tables = point.(*Store).tables
+	return nil
+}
+
+// ReleaseSavepoint drops the copy of the store
+func (env *Env) ReleaseSavepoint(point any) error {
+	return nil
+}
+
+// EmbedFuncs returns the functions of smart contracts which don't need the node and
+// the functions working with the in-memory store and the assertions
+func EmbedFuncs() map[string]any {
+	scType := reflect.TypeOf(&smart.SmartContract{})
+	f := make(map[string]any)
+	for name, fn := range smart.EmbedFuncs(script.VMType_Smart) {
+		ftype := reflect.TypeOf(fn)
+		var withSC bool
+		for i := 0; i < ftype.NumIn(); i++ {
+			if ftype.In(i) == scType {
+				withSC = true
+				break
+			}
+		}
+		if !withSC {
+			f[name] = fn
+		}
+	}
+	for name, fn := range map[string]any{
+		"DBInsert":           DBInsert,
+		"DBSelect":           DBSelect,
+		"DBUpdate":           DBUpdate,
+		"DBUpdateExt":        DBUpdateExt,
+		"DBCount":            DBCount,
+		"EcosysParam":        EcosysParam,
+		"BlockTime":          BlockTime,
+		"ContractAccess":     ContractAccess,
+		"ContractConditions": ContractConditions,
+		"Eval":               Eval,
+		"CheckCondition":     CheckCondition,
+		"ValidateCondition":  ValidateCondition,
+		"SetKeyId":           SetKeyId,
+		"SetEcosystem":       SetEcosystem,
+		"SetBlockTime":       SetBlockTime,
+		"Assert":             Assert,
+		"AssertEqual":        AssertEqual,
+		"AssertNotEqual":     AssertNotEqual,
+		"Fail":               Fail,
+	} {
+		f[name] = fn
+	}
+	return f
+}
+
+func (env *Env) table(tblname string) (*Table, error) {
+	name := qb.GetTableName(env.EcosystemID, tblname)
+	t := env.Store.Table(name)
+	if t == nil {
+		return nil, fmt.Errorf(`table %s does not exist`, name)
+	}
+	return t, nil
+}
+
+// DBInsert inserts a record into the specified table, the table is created if it doesn't exist
+func DBInsert(env *Env, tblname string, values *types.Map) (qcost int64, ret int64, err error) {
+	if values == nil || values.IsEmpty() {
+		return 0, 0, fmt.Errorf(`values are undefined`)
+	}
+	t := env.Store.CreateTable(qb.GetTableName(env.EcosystemID, tblname))
+	ret, err = t.Insert(values)
+	return CostQuery, ret, err
+}
+
+// DBSelect returns the rows of the table in the same way as smart.DBSelect
+func DBSelect(env *Env, tblname string, inColumns any, id int64, inOrder any,
+	offset, limit int64, inWhere *types.Map, query any, group string, all bool) (int64, []any, error) {
+	if query != nil && query != `` {
+		return 0, nil, fmt.Errorf(`select query is not supported by the test store`)
+	}
+	if len(group) > 0 {
+		return 0, nil, fmt.Errorf(`group is not supported by the test store`)
+	}
+	columns, err := qb.GetColumns(inColumns)
+	if err != nil {
+		return 0, nil, err
+	}
+	t, err := env.table(tblname)
+	if err != nil {
+		return 0, nil, err
+	}
+	if id != 0 {
+		inWhere = types.LoadMap(map[string]any{`id`: id})
+		limit = 1
+	}
+	if limit == 0 {
+		limit = 25
+	}
+	if limit < 0 || limit > consts.DBFindLimit {
+		limit = consts.DBFindLimit
+	}
+	rows, err := t.Select(inWhere)
+	if err != nil {
+		return 0, nil, err
+	}
+	sortRows(rows, getOrder(inOrder))
+	if !all {
+		if offset >= int64(len(rows)) {
+			rows = nil
+		} else {
+			rows = rows[offset:]
+			if int64(len(rows)) > limit {
+				rows = rows[:limit]
+			}
+		}
+	}
+	if len(columns) == 0 || (len(columns) == 1 && columns[0] == `*`) {
+		columns = t.Columns
+	}
+	result := make([]any, 0, len(rows))
+	for _, row := range rows {
+		item := types.NewMap()
+		for _, col := range columns {
+			col = strings.ToLower(strings.TrimSpace(col))
+			val, _ := columnValue(row, col)
+			item.Set(strings.ReplaceAll(col, `->`, `.`), val)
+		}
+		result = append(result, item)
+	}
+	return CostQuery, result, nil
+}
+
+// DBUpdateExt updates the records of the table matching where
+func DBUpdateExt(env *Env, tblname string, where *types.Map, values *types.Map) (qcost int64, err error) {
+	t, err := env.table(tblname)
+	if err != nil {
+		return 0, err
+	}
+	count, err := t.Update(where, values)
+	if err == nil && count == 0 {
+		err = fmt.Errorf(`record has not been found in %s`, t.Name)
+	}
+	return CostQuery, err
+}
+
+// DBUpdate updates the item with the specified id in the table
+func DBUpdate(env *Env, tblname string, id int64, values *types.Map) (qcost int64, err error) {
+	return DBUpdateExt(env, tblname, types.LoadMap(map[string]any{`id`: id}), values)
+}
+
+// DBCount returns the count of the rows matching where
+func DBCount(env *Env, tblname string, inWhere *types.Map) (int64, error) {
+	t, err := env.table(tblname)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := t.Select(inWhere)
+	return int64(len(rows)), err
+}
+
+// EcosysParam returns the value of the ecosystem parameter
+func EcosysParam(env *Env, name string) string {
+	return env.Params[name]
+}
+
+// BlockTime returns the time of the block which is set by the test
+func BlockTime(env *Env) string {
+	return smart.DateTime(env.BlockTime)
+}
+
+// ContractAccess checks whether the name of the executable contract matches one of the names
+func ContractAccess(env *Env, names ...any) bool {
+	for _, iname := range names {
+		name, ok := iname.(string)
+		if !ok || len(name) == 0 {
+			continue
+		}
+		if name[0] != '@' {
+			name = fmt.Sprintf(`@%d`, env.EcosystemID) + name
+		}
+		for i := len(env.stack) - 1; i >= 0; i-- {
+			contName := env.stack[i].(string)
+			if strings.HasPrefix(contName, `@`) {
+				if contName == name {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+// ContractConditions runs the conditions of the contracts
+func ContractConditions(env *Env, names ...any) (bool, error) {
+	for _, iname := range names {
+		name, _ := iname.(string)
+		if len(name) == 0 {
+			return false, fmt.Errorf(`empty contract name in ContractConditions`)
+		}
+		contract := smart.VMGetContract(env.vm, name, uint32(env.EcosystemID))
+		if contract == nil {
+			if contract = smart.VMGetContract(env.vm, name, 0); contract == nil {
+				return false, fmt.Errorf(`unknown contract %s`, name)
+			}
+		}
+		if contract.GetFunc(`conditions`) == nil {
+			return true, nil
+		}
+		if err := env.AppendStack(contract.Name); err != nil {
+			return false, err
+		}
+		err := script.RunContractByName(env.vm, contract.Name, []string{`conditions`},
+			env.getExtend(env.extend[script.Extend_txcost].(int64)), nil)
+		env.PopStack(contract.Name)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Eval evaluates the condition and returns the error if it is false
+func Eval(env *Env, condition string) error {
+	ret, err := CheckCondition(env, condition)
+	if err != nil {
+		return err
+	}
+	if !ret {
+		return errAccessDenied
+	}
+	return nil
+}
+
+// CheckCondition evaluates the condition
+func CheckCondition(env *Env, condition string) (bool, error) {
+	if len(condition) == 0 {
+		return false, errEmptyCond
+	}
+	return env.vm.EvalIf(condition, uint32(env.EcosystemID), env.getExtend(env.extend[script.Extend_txcost].(int64)))
+}
+
+// ValidateCondition checks the syntax of the condition
+func ValidateCondition(env *Env, condition string, state int64) error {
+	if len(condition) == 0 {
+		return errEmptyCond
+	}
+	return script.VMCompileEval(env.vm, condition, uint32(state))
+}
+
+// SetKeyId changes $key_id and $account_id of the test
+func SetKeyId(env *Env, id int64) {
+	env.KeyID = id
+	env.setVars(env.extend)
+}
+
+// SetEcosystem changes $ecosystem_id of the test, the tables without the prefix are
+// resolved in this ecosystem
+func SetEcosystem(env *Env, id int64) {
+	env.EcosystemID = id
+	env.setVars(env.extend)
+}
+
+// SetBlockTime changes $time and $block_time of the test
+func SetBlockTime(env *Env, unix int64) {
+	env.BlockTime = unix
+	env.setVars(env.extend)
+}
+
+func isEqual(left, right any) bool {
+	if reflect.DeepEqual(left, right) {
+		return true
+	}
+	l, err := toDBValue(left)
+	if err != nil {
+		return false
+	}
+	r, err := toDBValue(right)
+	if err != nil {
+		return false
+	}
+	if l == r {
+		return true
+	}
+	if _, err := strconv.ParseFloat(l, 64); err == nil {
+		return compareValues(l, r) == 0
+	}
+	return false
+}
+
+// Assert fails the test if the condition is false
+func Assert(cond bool) error {
+	if !cond {
+		return errors.New(`assertion failed`)
+	}
+	return nil
+}
+
+// AssertEqual fails the test if the values are not equal. The values are equal if they are
+// the same or they are the same in the database, for example 10 and "10"
+func AssertEqual(expected, actual any) error {
+	if !isEqual(expected, actual) {
+		return fmt.Errorf(`expected %v, got %v`, expected, actual)
+	}
+	return nil
+}
+
+// AssertNotEqual fails the test if the values are equal
+func AssertNotEqual(expected, actual any) error {
+	if isEqual(expected, actual) {
+		return fmt.Errorf(`expected not %v`, actual)
+	}
+	return nil
+}
+
+// Fail fails the test with the message
+func Fail(message string) error {
+	return errors.New(message)
+}
@@ -0,0 +1,143 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+// Package contracttest runs the tests of contracts without the node. The contracts are
+// loaded into a new VM where the database functions work with the in-memory store, and
+// the functions named test_* are executed as tests
+package contracttest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/script"
+)
+
+// TestPrefix is the prefix of the names of the test functions
+const TestPrefix = `test_`
+
+// Result is the result of the test
+type Result struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Gas      int64         `json:"gas"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Harness keeps the VM with the loaded contracts and the initial state of the tests.
+// Every test runs with the copy of Store, so the tests don't affect each other
+type Harness struct {
+	VM          *script.VM
+	Store       *Store
+	KeyID       int64
+	EcosystemID int64
+	BlockTime   int64
+	MaxCost     int64
+	Params      map[string]string
+}
+
+// New returns the harness with the new VM
+func New() (*Harness, error) {
+	vm := script.NewVM()
+	vm.SetFuncCallsDB(funcCallsDB)
+	vm.Extend(&script.ExtendData{
+		Objects: EmbedFuncs(),
+		AutoPars: map[string]string{
+			`*contracttest.Env`: `sc`,
+		},
+		WriteFuncs: writeFuncs,
+	})
+	if err := script.LoadSysFuncs(vm, 1); err != nil {
+		return nil, err
+	}
+	return &Harness{
+		VM:          vm,
+		Store:       NewStore(),
+		KeyID:       1,
+		EcosystemID: 1,
+		BlockTime:   time.Now().Unix(),
+		MaxCost:     syspar.GetMaxCost(),
+		Params:      make(map[string]string),
+	}, nil
+}
+
+// Load compiles the source into the ecosystem of the harness
+func (h *Harness) Load(src string) error {
+	return h.VM.Compile([]rune(src), &script.OwnerInfo{StateID: uint32(h.EcosystemID)})
+}
+
+// LoadFile compiles the source file
+func (h *Harness) LoadFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err = h.Load(string(data)); err != nil {
+		return fmt.Errorf(`%s: %w`, filename, err)
+	}
+	return nil
+}
+
+// Tests returns the names of the test functions in the order of loading
+func (h *Harness) Tests() []string {
+	names := make(map[*script.CodeBlock]string)
+	for name, obj := range h.VM.Objects {
+		if obj.Type == script.ObjectType_Func && strings.HasPrefix(name, TestPrefix) &&
+			len(obj.GetCodeBlock().GetFuncInfo().Params) == 0 {
+			names[obj.GetCodeBlock()] = name
+		}
+	}
+	var ret []string
+	for _, block := range h.VM.Children {
+		if name, ok := names[block]; ok {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// Run executes the test function with the copy of the store
+func (h *Harness) Run(name string) *Result {
+	ret := &Result{Name: name}
+	obj, ok := h.VM.Objects[name]
+	if !ok || obj.Type != script.ObjectType_Func {
+		ret.Error = fmt.Sprintf(`unknown test %s`, name)
+		return ret
+	}
+	env := &Env{
+		KeyID:       h.KeyID,
+		EcosystemID: h.EcosystemID,
+		BlockTime:   h.BlockTime,
+		Params:      h.Params,
+		Store:       h.Store.Clone(),
+		vm:          h.VM,
+	}
+	env.extend = env.getExtend(h.MaxCost)
+	start := time.Now()
+	_, err := script.VMRun(h.VM, obj.GetCodeBlock(), nil, env.extend, nil)
+	ret.Duration = time.Since(start)
+	ret.Gas = h.MaxCost - env.extend[script.Extend_txcost].(int64)
+	if err != nil {
+		ret.Error = err.Error()
+	} else {
+		ret.Passed = true
+	}
+	return ret
+}
+
+// RunAll executes the tests which names are accepted by match, all tests if match is nil
+func (h *Harness) RunAll(match func(string) bool) []*Result {
+	var ret []*Result
+	for _, name := range h.Tests() {
+		if match == nil || match(name) {
+			ret = append(ret, h.Run(name))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,110 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package contracttest
+
+import (
+	"strings"
+	"testing"
+)
+
+const testContracts = `contract Deposit {
+	data {
+		Amount int
+	}
+	conditions {
+		if $Amount <= 0 {
+			error "amount must be positive"
+		}
+	}
+	action {
+		var row map
+		row = DBRow("balances").Where({"key_id": $key_id})
+		if row {
+			var amount int
+			amount = Int(row["amount"]) + $Amount
+			DBUpdate("balances", Int(row["id"]), {"amount": amount})
+		} else {
+			DBInsert("balances", {"key_id": $key_id, "amount": $Amount})
+		}
+		$result = $Amount
+	}
+}
+
+func balance(key int) int {
+	return Int(DBFind("balances").Columns("amount").Where({"key_id": key}).One("amount"))
+}
+
+func test_deposit() {
+	Deposit("Amount", 10)
+	SetKeyId(2)
+	Deposit("Amount", 5)
+	Deposit("Amount", 7)
+	AssertEqual(10, balance(1))
+	AssertEqual(12, balance(2))
+	AssertEqual(2, DBCount("balances", {"amount": {"$gte": 10}}))
+}
+
+func test_rollback() {
+	try {
+		Deposit("Amount", 3)
+		Deposit("Amount", 0)
+	} catch err {
+		AssertEqual("amount must be positive", err["message"])
+	}
+	AssertEqual(0, DBCount("balances", {"key_id": 1}))
+	AssertEqual("2021-01-01 00:00:00", BlockTime())
+}
+
+func test_isolation() {
+	AssertEqual(1, DBCount("balances", {"key_id": 5}))
+	AssertEqual(0, DBCount("balances", {"key_id": 1}))
+	Assert($key_id == 1)
+}
+
+func test_fail() {
+	AssertEqual(1, 2)
+}
+`
+
+func TestHarness(t *testing.T) {
+	h, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.BlockTime = 1609459200
+	if err = h.Store.LoadJSON([]byte(`{"balances": [{"key_id": 5, "amount": 1}]}`), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = h.Load(testContracts); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name   string
+		passed bool
+		err    string
+	}{
+		{`test_deposit`, true, ``},
+		{`test_rollback`, true, ``},
+		{`test_isolation`, true, ``},
+		{`test_fail`, false, `expected 1, got 2`},
+	}
+	results := h.RunAll(nil)
+	if len(results) != len(want) {
+		t.Fatalf(`wrong count of tests %d`, len(results))
+	}
+	for i, item := range want {
+		res := results[i]
+		if res.Name != item.name || res.Passed != item.passed || !strings.HasPrefix(res.Error, item.err) {
+			t.Errorf(`wrong result %+v, want %+v`, res, item)
+		}
+		if res.Gas <= 0 {
+			t.Errorf(`%s: gas is not counted`, res.Name)
+		}
+	}
+	if rows := h.Store.Table(`1_balances`).Rows; len(rows) != 1 {
+		t.Errorf(`store is changed by the tests: %v`, rows)
+	}
+}
@@ -0,0 +1,451 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	qb "github.com/IBAX-io/go-ibax/packages/storage/sqldb/queryBuilder"
+	"github.com/IBAX-io/go-ibax/packages/types"
+	"github.com/shopspring/decimal"
+)
+
+// Store keeps the tables of the ecosystems in memory. The values are kept as strings
+// in the same way as they are returned by the database
+type Store struct {
+	tables map[string]*Table
+}
+
+// Table is the in-memory table
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    []map[string]string
+	lastID  int64
+}
+
+// NewStore returns the empty store
+func NewStore() *Store {
+	return &Store{tables: make(map[string]*Table)}
+}
+
+// Table returns the table with the full name like 1_keys, nil if it doesn't exist
+func (s *Store) Table(name string) *Table {
+	return s.tables[name]
+}
+
+// Tables returns the sorted names of the tables
+func (s *Store) Tables() []string {
+	ret := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// CreateTable creates the table if it doesn't exist
+func (s *Store) CreateTable(name string) *Table {
+	if t, ok := s.tables[name]; ok {
+		return t
+	}
+	t := &Table{Name: name, Columns: []string{`id`}}
+	s.tables[name] = t
+	return t
+}
+
+// Clone returns the deep copy of the store
+func (s *Store) Clone() *Store {
+	ret := NewStore()
+	for name, t := range s.tables {
+		ct := &Table{
+			Name:    t.Name,
+			Columns: append([]string{}, t.Columns...),
+			Rows:    make([]map[string]string, len(t.Rows)),
+			lastID:  t.lastID,
+		}
+		for i, row := range t.Rows {
+			ct.Rows[i] = make(map[string]string, len(row))
+			for k, v := range row {
+				ct.Rows[i][k] = v
+			}
+		}
+		ret.tables[name] = ct
+	}
+	return ret
+}
+
+// LoadJSON loads the rows from the JSON object where keys are the names of the tables
+// and values are the arrays of rows. The names without the ecosystem prefix are
+// resolved in the specified ecosystem
+func (s *Store) LoadJSON(data []byte, ecosystem int64) error {
+	var fixtures map[string][]map[string]any
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(fixtures))
+	for name := range fixtures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := s.CreateTable(qb.GetTableName(ecosystem, name))
+		for _, row := range fixtures[name] {
+			if _, err := t.Insert(types.LoadMap(row)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFile loads the rows from the JSON file, see LoadJSON
+func (s *Store) LoadFile(filename string, ecosystem int64) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return s.LoadJSON(data, ecosystem)
+}
+
+func (t *Table) addColumn(name string) {
+	for _, col := range t.Columns {
+		if col == name {
+			return
+		}
+	}
+	t.Columns = append(t.Columns, name)
+}
+
+// Insert appends the row to the table and returns its id. If the id is not specified
+// then the next id is used
+func (t *Table) Insert(values *types.Map) (int64, error) {
+	row := make(map[string]string)
+	for _, key := range values.Keys() {
+		v, _ := values.Get(key)
+		key = strings.ToLower(key)
+		val, err := toDBValue(v)
+		if err != nil {
+			return 0, err
+		}
+		row[key] = val
+	}
+	var id int64
+	if val, ok := row[`id`]; ok {
+		var err error
+		if id, err = strconv.ParseInt(val, 10, 64); err != nil {
+			return 0, fmt.Errorf(`wrong id %s`, val)
+		}
+		for _, item := range t.Rows {
+			if item[`id`] == val {
+				return 0, fmt.Errorf(`duplicate key id=%s in table %s`, val, t.Name)
+			}
+		}
+	} else {
+		id = t.lastID + 1
+		row[`id`] = strconv.FormatInt(id, 10)
+	}
+	if id > t.lastID {
+		t.lastID = id
+	}
+	for key := range row {
+		t.addColumn(key)
+	}
+	t.Rows = append(t.Rows, row)
+	return id, nil
+}
+
+// Select returns the rows matching where
+func (t *Table) Select(where *types.Map) ([]map[string]string, error) {
+	ret := make([]map[string]string, 0)
+	for _, row := range t.Rows {
+		ok, err := matchWhere(row, where)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ret = append(ret, row)
+		}
+	}
+	return ret, nil
+}
+
+// Update changes the rows matching where and returns their count
+func (t *Table) Update(where, values *types.Map) (int64, error) {
+	rows, err := t.Select(where)
+	if err != nil {
+		return 0, err
+	}
+	set := make(map[string]string)
+	for _, key := range values.Keys() {
+		v, _ := values.Get(key)
+		val, err := toDBValue(v)
+		if err != nil {
+			return 0, err
+		}
+		key = strings.ToLower(key)
+		set[key] = val
+		t.addColumn(key)
+	}
+	for _, row := range rows {
+		for key, val := range set {
+			row[key] = val
+		}
+	}
+	return int64(len(rows)), nil
+}
+
+// toDBValue converts the value of the contract to the string which is returned by the database
+func toDBValue(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return ``, nil
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case decimal.Decimal:
+		return val.String(), nil
+	case *types.Map, []any, map[string]any:
+		out, err := json.Marshal(val)
+		if err != nil {
+			return ``, err
+		}
+		return string(out), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// columnValue returns the value of the column, col->field gets the field of json column
+func columnValue(row map[string]string, col string) (string, bool) {
+	fields := strings.Split(col, `->`)
+	val, ok := row[fields[0]]
+	if !ok || len(fields) == 1 {
+		return val, ok
+	}
+	var data any
+	if err := json.Unmarshal([]byte(val), &data); err != nil {
+		return ``, false
+	}
+	for _, field := range fields[1:] {
+		m, ok := data.(map[string]any)
+		if !ok {
+			return ``, false
+		}
+		if data, ok = m[field]; !ok {
+			return ``, false
+		}
+	}
+	ret, err := toDBValue(data)
+	return ret, err == nil
+}
+
+// compareValues compares the values as numbers if both of them are numbers
+func compareValues(left, right string) int {
+	if l, err := decimal.NewFromString(left); err == nil {
+		if r, err := decimal.NewFromString(right); err == nil {
+			return l.Cmp(r)
+		}
+	}
+	return strings.Compare(left, right)
+}
+
+// matchWhere checks the row by the conditions in the same format as queryBuilder.GetWhere
+func matchWhere(row map[string]string, where *types.Map) (bool, error) {
+	if where == nil {
+		return true, nil
+	}
+	for _, key := range where.Keys() {
+		v, _ := where.Get(key)
+		key = converter.Sanitize(strings.ToLower(key), `->$`)
+		var (
+			ok  bool
+			err error
+		)
+		switch {
+		case key == `$and` || key == `$or`:
+			ok, err = matchOperator(row, ``, key, v)
+		case strings.HasPrefix(key, `$`):
+			return false, fmt.Errorf(`operator %s must be applied to the column`, key)
+		default:
+			val, _ := columnValue(row, key)
+			ok, err = matchValue(row, val, v)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchValue checks the value of the column by the condition
+func matchValue(row map[string]string, val string, cond any) (bool, error) {
+	switch c := cond.(type) {
+	case *types.Map:
+		for _, op := range c.Keys() {
+			arg, _ := c.Get(op)
+			ok, err := matchOperator(row, val, strings.ToLower(op), arg)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case []any:
+		for _, item := range c {
+			ok, err := matchValue(row, val, item)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	arg, err := toDBValue(cond)
+	if err != nil {
+		return false, err
+	}
+	if arg == `$isnull` {
+		return len(val) == 0, nil
+	}
+	return compareValues(val, arg) == 0, nil
+}
+
+func matchOperator(row map[string]string, val, op string, arg any) (bool, error) {
+	logic := func(all bool) (bool, error) {
+		list, _ := arg.([]any)
+		for _, item := range list {
+			where, ok := item.(*types.Map)
+			if !ok {
+				continue
+			}
+			ok, err := matchWhere(row, where)
+			if err != nil {
+				return false, err
+			}
+			if ok != all {
+				return ok, nil
+			}
+		}
+		return all, nil
+	}
+	in := func() (bool, error) {
+		switch list := arg.(type) {
+		case []any:
+			for _, item := range list {
+				s, err := toDBValue(item)
+				if err != nil {
+					return false, err
+				}
+				if compareValues(val, s) == 0 {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			return val == list, nil
+		}
+		return false, fmt.Errorf(`wrong value of %s`, op)
+	}
+	switch op {
+	case `$and`:
+		return logic(true)
+	case `$or`:
+		return logic(false)
+	case `$in`:
+		return in()
+	case `$nin`:
+		ok, err := in()
+		return !ok, err
+	}
+	s, err := toDBValue(arg)
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case `$eq`:
+		return compareValues(val, s) == 0, nil
+	case `$neq`:
+		return compareValues(val, s) != 0, nil
+	case `$gt`:
+		return compareValues(val, s) > 0, nil
+	case `$gte`:
+		return compareValues(val, s) >= 0, nil
+	case `$lt`:
+		return compareValues(val, s) < 0, nil
+	case `$lte`:
+		return compareValues(val, s) <= 0, nil
+	case `$like`:
+		return strings.Contains(val, s), nil
+	case `$begin`:
+		return strings.HasPrefix(val, s), nil
+	case `$end`:
+		return strings.HasSuffix(val, s), nil
+	case `$ilike`:
+		return strings.Contains(strings.ToLower(val), strings.ToLower(s)), nil
+	case `$ibegin`:
+		return strings.HasPrefix(strings.ToLower(val), strings.ToLower(s)), nil
+	case `$iend`:
+		return strings.HasSuffix(strings.ToLower(val), strings.ToLower(s)), nil
+	}
+	return false, fmt.Errorf(`unknown operator %s`, op)
+}
+
+type orderColumn struct {
+	name string
+	desc bool
+}
+
+// getOrder parses the order in the same format as queryBuilder.GetOrder
+func getOrder(inOrder any) []orderColumn {
+	var ret []orderColumn
+	add := func(name string, value any) {
+		name = converter.Sanitize(strings.ToLower(name), ``)
+		if len(name) == 0 {
+			name = `id`
+		}
+		ret = append(ret, orderColumn{name: name, desc: fmt.Sprint(value) == `-1`})
+	}
+	addMap := func(m *types.Map) {
+		for _, key := range m.Keys() {
+			v, _ := m.Get(key)
+			add(key, v)
+		}
+	}
+	switch v := inOrder.(type) {
+	case string:
+		if len(v) > 0 {
+			add(v, nil)
+		}
+	case *types.Map:
+		addMap(v)
+	case []any:
+		for _, item := range v {
+			switch param := item.(type) {
+			case string:
+				add(param, nil)
+			case *types.Map:
+				addMap(param)
+			}
+		}
+	}
+	return append(ret, orderColumn{name: `id`})
+}
+
+func sortRows(rows []map[string]string, order []orderColumn) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, col := range order {
+			if c := compareValues(rows[i][col.name], rows[j][col.name]); c != 0 {
+				return (c < 0) != col.desc
+			}
+		}
+		return false
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
 	"github.com/IBAX-io/go-ibax/packages/consts"
 	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/network/addrbook"
 	"github.com/IBAX-io/go-ibax/packages/network/tcpclient"
 	"github.com/IBAX-io/go-ibax/packages/rollback"
 	"github.com/IBAX-io/go-ibax/packages/script"
@@ -73,11 +74,21 @@ func blocksCollection(ctx context.Context, d *daemon) (err error) {
 	}
 
 	DBLock()
+	node.StartSync(infoBlock.BlockID, maxBlockID)
 	defer func() {
+		node.StopSync()
 		node.NodeDoneUpdatingBlockchain()
 		DBUnlock()
 	}()
 
+	// the long chain is collected by headers and the bodies of the blocks are downloaded from several hosts
+	if maxBlockID-infoBlock.BlockID > headersSyncMinBlocks {
+		if err = SyncChain(ctx, d, host, maxBlockID); err != errHeadersFork {
+			return err
+		}
+		d.logger.WithFields(log.Fields{"host": host, "block_id": infoBlock.BlockID}).Warning("chain of the host is forked, collecting blocks one by one")
+	}
+
 	// update our chain till maxBlockID from the host
 	return UpdateChain(ctx, d, host, maxBlockID)
 }
@@ -96,55 +107,6 @@ func UpdateChain(ctx context.Context, d *daemon, host string, maxBlockID int64)
 		return ctx.Err()
 	}
 
-	playRawBlock := func(rb []byte) error {
-		var lastBlockID, lastBlockTime int64
-		var err error
-		var bl *block.Block
-		defer func(err2 *error) {
-			if err2 != nil {
-				banNodePause(host, lastBlockID, lastBlockTime, *err2)
-			}
-		}(&err)
-		bl, err = block.ProcessBlockByBinData(rb, true)
-		if err != nil {
-			d.logger.WithFields(log.Fields{"error": err, "type": consts.BlockError}).Error("processing block")
-			return err
-		}
-
-		curBlock := &sqldb.InfoBlock{}
-		if _, err = curBlock.Get(); err != nil {
-			d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("Getting info block")
-			return err
-		}
-
-		if curBlock.BlockID != bl.PrevHeader.BlockId {
-			d.logger.WithFields(log.Fields{"type": consts.BlockError}).Error("info block compare with previous block")
-			return fmt.Errorf("info block compare with previous block err curBlock: %d, PrevBlock: %d", curBlock.BlockID, bl.PrevHeader.BlockId)
-		}
-
-		lastBlockID = bl.Header.BlockId
-		lastBlockTime = bl.Header.Timestamp
-
-		if err = bl.Check(); err != nil {
-			var replaceCount int64 = 1
-			if err == block.ErrIncorrectRollbackHash {
-				replaceCount++
-			}
-			d.logger.WithFields(log.Fields{"error": err, "from_host": host,
-				"different": fmt.Errorf("not match previous block %d, prev_position %d, current_position %d",
-					bl.PrevHeader.BlockId,
-					bl.PrevHeader.NodePosition,
-					bl.Header.NodePosition),
-				"type": consts.BlockError, "replaceCount": replaceCount}).Error("checking block hash")
-			//if it is forked, replace the previous blocks to ones from the host
-			if errReplace := ReplaceBlocksFromHost(ctx, host, bl.PrevHeader.BlockId, replaceCount); errReplace != nil {
-				return errReplace
-			}
-			return err
-		}
-		return bl.PlaySafe()
-	}
-
 	var count int
 	st := time.Now()
 
@@ -164,15 +126,11 @@ func UpdateChain(ctx context.Context, d *daemon, host string, maxBlockID int64)
 			}
 
 			for rawBlock := range rawBlocksChan {
-				if err = playRawBlock(rawBlock); err != nil {
+				if err = playRawBlock(ctx, d, host, rawBlock); err != nil {
 					// d.logger.WithFields(log.Fields{"error": err, "type": consts.BlockError}).Error("playing raw block")
 					return err
 				}
-				if candidateNodes, err := sqldb.GetCandidateNode(syspar.SysInt(syspar.NumberNodes)); err == nil && len(candidateNodes) > 0 {
-					syspar.SetRunModel(consts.CandidateNodeMode)
-				} else {
-					syspar.SetRunModel(consts.HonorNodeMode)
-				}
+				updateRunModel()
 				count++
 			}
 
@@ -184,6 +142,69 @@ func UpdateChain(ctx context.Context, d *daemon, host string, maxBlockID int64)
 	return nil
 }
 
+// playRawBlock checks and plays the block received from the host
+func playRawBlock(ctx context.Context, d *daemon, host string, rb []byte) error {
+	var lastBlockID, lastBlockTime int64
+	var err error
+	var bl *block.Block
+	defer func(err2 *error) {
+		if err2 != nil {
+			banNodePause(host, lastBlockID, lastBlockTime, *err2)
+		}
+	}(&err)
+	bl, err = block.ProcessBlockByBinData(rb, true)
+	if err != nil {
+		d.logger.WithFields(log.Fields{"error": err, "type": consts.BlockError}).Error("processing block")
+		return err
+	}
+
+	curBlock := &sqldb.InfoBlock{}
+	if _, err = curBlock.Get(); err != nil {
+		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("Getting info block")
+		return err
+	}
+
+	if curBlock.BlockID != bl.PrevHeader.BlockId {
+		d.logger.WithFields(log.Fields{"type": consts.BlockError}).Error("info block compare with previous block")
+		return fmt.Errorf("info block compare with previous block err curBlock: %d, PrevBlock: %d", curBlock.BlockID, bl.PrevHeader.BlockId)
+	}
+
+	lastBlockID = bl.Header.BlockId
+	lastBlockTime = bl.Header.Timestamp
+
+	if err = bl.Check(); err != nil {
+		var replaceCount int64 = 1
+		if err == block.ErrIncorrectRollbackHash {
+			replaceCount++
+		}
+		d.logger.WithFields(log.Fields{"error": err, "from_host": host,
+			"different": fmt.Errorf("not match previous block %d, prev_position %d, current_position %d",
+				bl.PrevHeader.BlockId,
+				bl.PrevHeader.NodePosition,
+				bl.Header.NodePosition),
+			"type": consts.BlockError, "replaceCount": replaceCount}).Error("checking block hash")
+		//if it is forked, replace the previous blocks to ones from the host
+		if errReplace := ReplaceBlocksFromHost(ctx, host, bl.PrevHeader.BlockId, replaceCount); errReplace != nil {
+			return errReplace
+		}
+		return err
+	}
+	if errPlay := bl.PlaySafe(); errPlay != nil {
+		return errPlay
+	}
+	node.SetSyncBlock(bl.Header.BlockId)
+	return nil
+}
+
+// updateRunModel sets the consensus mode after the played block
+func updateRunModel() {
+	if candidateNodes, err := sqldb.GetCandidateNode(syspar.SysInt(syspar.NumberNodes)); err == nil && len(candidateNodes) > 0 {
+		syspar.SetRunModel(consts.CandidateNodeMode)
+	} else {
+		syspar.SetRunModel(consts.HonorNodeMode)
+	}
+}
+
 func banNodePause(host string, blockID, blockTime int64, err error) {
 	if err == nil || !utils.IsBanError(err) {
 		return
@@ -192,6 +213,7 @@ func banNodePause(host string, blockID, blockTime int64, err error) {
 	reason := err.Error()
 	//log.WithFields(log.Fields{"host": host, "block_id": blockID, "block_time": blockTime, "err": err}).Error("ban node")
 
+	addrbook.Get().Bad(host, addrbook.PenaltyBadBlock)
 	n, err := syspar.GetNodeByHost(host)
 	if err != nil {
 		log.WithError(err).Error("getting node by host")
@@ -207,6 +229,18 @@ func banNodePause(host string, blockID, blockTime int64, err error) {
 
 // GetHostWithMaxID returns host with maxBlockID
 func getHostWithMaxID(ctx context.Context, logger *log.Entry) (host string, maxBlockID int64, err error) {
+	hosts := getSyncHosts(logger)
+	host, maxBlockID, err = tcpclient.HostWithMaxBlock(ctx, hosts)
+	if len(hosts) == 0 || err == tcpclient.ErrNodesUnavailable {
+		hosts = conf.GetNodesAddr()
+		return tcpclient.HostWithMaxBlock(ctx, hosts)
+	}
+
+	return
+}
+
+// getSyncHosts returns the hosts which the blocks are collected from
+func getSyncHosts(logger *log.Entry) []string {
 	selectMode := SelectModel{}
 	hosts, err := selectMode.GetHostWithMaxID()
 
@@ -214,13 +248,11 @@ func getHostWithMaxID(ctx context.Context, logger *log.Entry) (host string, maxB
 		logger.WithError(err).Error("on filtering banned hosts")
 	}
 
-	host, maxBlockID, err = tcpclient.HostWithMaxBlock(ctx, hosts)
-	if len(hosts) == 0 || err == tcpclient.ErrNodesUnavailable {
-		hosts = conf.GetNodesAddr()
-		return tcpclient.HostWithMaxBlock(ctx, hosts)
+	// the full nodes also sync from the peers of the address book
+	if _, errPos := selectMode.GetThisNodePosition(); errPos != nil {
+		hosts = append(hosts, getSyncPeers(hosts)...)
 	}
-
-	return
+	return hosts
 }
 
 // ReplaceBlocksFromHost replaces blockchain received from the host.
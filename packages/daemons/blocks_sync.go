@@ -0,0 +1,353 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package daemons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/block"
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/network/addrbook"
+	"github.com/IBAX-io/go-ibax/packages/network/tcpclient"
+	"github.com/IBAX-io/go-ibax/packages/protocols"
+	"github.com/IBAX-io/go-ibax/packages/service/node"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/types"
+	"github.com/IBAX-io/go-ibax/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// headersSyncMinBlocks is the lag of the chain from which the blocks are collected by the headers
+	headersSyncMinBlocks = int64(2 * network.BlocksPerRequest)
+	// syncWorkers is the maximum number of the parallel downloads of the bodies
+	syncWorkers = 4
+	// syncWindow is the maximum number of the ranges of the bodies which are downloaded ahead of the played range
+	syncWindow = 2 * syncWorkers
+	// bodyAttempts is the number of the hosts which are asked for the bodies of the blocks
+	bodyAttempts = 3
+)
+
+var (
+	errHeadersFork  = errors.New("headers do not follow the last block")
+	errHeadersChain = errors.New("wrong chain of headers")
+	errHeaderTime   = errors.New("incorrect block time of header")
+	errBodyMismatch = errors.New("block body does not match header")
+	errNoBodies     = errors.New("hosts have no block bodies")
+)
+
+// SyncChain collects the blocks from our last block to maxBlockID. The chain of the headers is received
+// from the host and checked first, then the bodies of the blocks are downloaded from all hosts having them
+// in parallel and played in order. errHeadersFork is returned if the chain of the host does not follow our last block
+func SyncChain(ctx context.Context, d *daemon, host string, maxBlockID int64) error {
+	hosts := getSyncHosts(d.logger)
+	if !converter.InSliceString(host, hosts) {
+		hosts = append(hosts, host)
+	}
+	peers := tcpclient.HostsMaxBlock(ctx, hosts)
+	if peers[host] < maxBlockID {
+		peers[host] = maxBlockID
+	}
+	node.SetSyncPeers(len(peers))
+
+	var count int
+	st := time.Now()
+	defer func() {
+		d.logger.WithFields(log.Fields{"count": count, "peers": len(peers), "time": time.Since(st).String()}).Info("blocks downloaded")
+	}()
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		last, err := lastHeader()
+		if err != nil {
+			d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last block header")
+			return err
+		}
+		if last.BlockId >= maxBlockID {
+			return nil
+		}
+
+		limit := maxBlockID - last.BlockId
+		if limit > network.HeadersPerRequest {
+			limit = network.HeadersPerRequest
+		}
+		rawHeaders, err := tcpclient.GetBlocksHeaders(host, last.BlockId+1, limit)
+		if err != nil {
+			d.logger.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": host}).Error("getting block headers")
+			return err
+		}
+		headers, err := checkHeaders(last, rawHeaders)
+		if err == nil && len(headers) == 0 {
+			err = utils.WithBan(errHeadersChain)
+		}
+		if err != nil {
+			if err != errHeadersFork {
+				d.logger.WithFields(log.Fields{"type": consts.BlockError, "error": err, "host": host}).Error("checking block headers")
+				banNodePause(host, last.BlockId+1, 0, err)
+			}
+			return err
+		}
+		node.SetSyncHeaders(headers[len(headers)-1].Header.BlockId)
+
+		played, err := playBodies(ctx, d, headers, peers)
+		count += played
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lastHeader returns the header of our last block
+func lastHeader() (*types.BlockHeader, error) {
+	infoBlock := &sqldb.InfoBlock{}
+	if _, err := infoBlock.Get(); err != nil {
+		return nil, err
+	}
+	return block.GetBlockHeaderFromBlockChain(infoBlock.BlockID)
+}
+
+type headerSlot struct {
+	start    int64
+	position int64
+}
+
+// checkHeaders checks that the headers are the chain which follows the last block, the hashes and the signs
+// of the headers are correct and there is one block of the node in the time interval. The headers are checked
+// with the current honor nodes, so the chain is cut at the first header signed by the unknown node,
+// the rest of the headers are checked again after the playing of the blocks
+func checkHeaders(last *types.BlockHeader, rawHeaders [][]byte) ([]*types.BlockData, error) {
+	var (
+		btc     *protocols.BlockTimeCounter
+		slots   = make(map[headerSlot]bool)
+		prev    = last
+		headers = make([]*types.BlockData, 0, len(rawHeaders))
+	)
+	if syspar.IsHonorNodeMode() {
+		btc = protocols.NewBlockTimeCounter()
+		if start, _, err := btc.RangeByTime(time.Unix(last.Timestamp, 0)); err == nil {
+			slots[headerSlot{start.Unix(), last.NodePosition}] = true
+		}
+	}
+	for _, raw := range rawHeaders {
+		bd := &types.BlockData{}
+		if err := bd.UnmarshallBlock(raw); err != nil {
+			return nil, utils.WithBan(err)
+		}
+		h := bd.Header
+		if h == nil || bd.PrevHeader == nil || h.BlockId != prev.BlockId+1 || bd.PrevHeader.BlockId != prev.BlockId {
+			return nil, utils.WithBan(errHeadersChain)
+		}
+		if !bytes.Equal(bd.PrevHeader.BlockHash, prev.BlockHash) ||
+			(h.Version >= consts.BvRollbackHash && !bytes.Equal(bd.PrevHeader.RollbacksHash, prev.RollbacksHash)) {
+			if len(headers) == 0 {
+				return nil, errHeadersFork
+			}
+			return nil, utils.WithBan(errHeadersChain)
+		}
+		if !bytes.Equal(h.BlockHash, h.GenHash(bd.PrevHeader, bd.MerkleRoot)) {
+			return nil, utils.WithBan(errHeadersChain)
+		}
+		if h.Timestamp > time.Now().Unix() {
+			return nil, utils.WithBan(errHeaderTime)
+		}
+		if btc != nil && h.ConsensusMode != consts.CandidateNodeMode {
+			start, _, err := btc.RangeByTime(time.Unix(h.Timestamp, 0))
+			if err != nil {
+				return nil, utils.WithBan(errHeaderTime)
+			}
+			slot := headerSlot{start.Unix(), h.NodePosition}
+			if slots[slot] {
+				return nil, utils.WithBan(errHeaderTime)
+			}
+			slots[slot] = true
+		}
+		if err := checkHeaderSign(bd); err != nil {
+			if len(headers) == 0 {
+				return nil, utils.WithBan(err)
+			}
+			break
+		}
+		headers = append(headers, bd)
+		prev = h
+	}
+	return headers, nil
+}
+
+// checkHeaderSign checks the sign of the header by the key of the node which has generated the block
+func checkHeaderSign(bd *types.BlockData) error {
+	if conf.Config.IsSubNode() {
+		return nil
+	}
+	nodePub, err := syspar.GetNodePublicKeyByPosition(bd.Header.NodePosition)
+	if err != nil {
+		return err
+	}
+	if len(nodePub) == 0 {
+		return errors.New("empty nodePublicKey")
+	}
+	_, err = utils.CheckSign([][]byte{nodePub}, []byte(bd.ForSign()), bd.Header.Sign, true)
+	return err
+}
+
+type bodiesResult struct {
+	host   string
+	bodies [][]byte
+	err    error
+}
+
+// playBodies downloads the bodies of the blocks of the headers from the peers in parallel and plays
+// them in order. Only syncWindow ranges are downloaded ahead, the next range is queued after a range
+// has been played. It returns the number of the played blocks
+func playBodies(ctx context.Context, d *daemon, headers []*types.BlockData, peers map[string]int64) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	hosts := make([]string, 0, len(peers))
+	for h := range peers {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	var ranges [][]*types.BlockData
+	for i := 0; i < len(headers); i += network.BlocksPerRequest {
+		end := i + network.BlocksPerRequest
+		if end > len(headers) {
+			end = len(headers)
+		}
+		ranges = append(ranges, headers[i:end])
+	}
+	results := make([]chan bodiesResult, len(ranges))
+	for i := range ranges {
+		results[i] = make(chan bodiesResult, 1)
+	}
+	jobs := make(chan int, syncWindow)
+	defer close(jobs)
+	for i := 0; i < len(ranges) && i < syncWindow; i++ {
+		jobs <- i
+	}
+
+	workers := syncWorkers
+	if workers > len(hosts) {
+		workers = len(hosts)
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				if ctx.Err() != nil {
+					results[i] <- bodiesResult{err: ctx.Err()}
+					continue
+				}
+				results[i] <- downloadBodies(ctx, d.logger, ranges[i], hosts, peers, i)
+			}
+		}()
+	}
+
+	var count int
+	for i := range ranges {
+		var res bodiesResult
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+			return count, ctx.Err()
+		}
+		if res.err != nil {
+			return count, res.err
+		}
+		for _, rawBlock := range res.bodies {
+			if err := playRawBlock(ctx, d, res.host, rawBlock); err != nil {
+				return count, err
+			}
+			updateRunModel()
+			count++
+		}
+		if next := i + syncWindow; next < len(ranges) {
+			jobs <- next
+		}
+	}
+	return count, nil
+}
+
+// downloadBodies downloads the bodies of the blocks of the headers, the hosts having the blocks are asked
+// in turn starting from the host with the index
+func downloadBodies(ctx context.Context, logger *log.Entry, headers []*types.BlockData, hosts []string,
+	peers map[string]int64, index int) bodiesResult {
+	var (
+		attempts int
+		err      = errNoBodies
+		lastID   = headers[len(headers)-1].Header.BlockId
+	)
+	for i := 0; i < len(hosts) && attempts < bodyAttempts; i++ {
+		host := hosts[(index+i)%len(hosts)]
+		if peers[host] < lastID {
+			continue
+		}
+		attempts++
+		bodies, errGet := getBodies(ctx, host, headers)
+		if errGet == nil {
+			return bodiesResult{host: host, bodies: bodies}
+		}
+		if ctx.Err() != nil {
+			return bodiesResult{err: ctx.Err()}
+		}
+		err = errGet
+		logger.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": host, "block_id": headers[0].Header.BlockId}).Warning("getting block bodies")
+		if utils.IsBanError(err) {
+			addrbook.Get().Bad(host, addrbook.PenaltyBadBlock)
+		} else {
+			addrbook.Get().Bad(host, addrbook.PenaltyFail)
+		}
+	}
+	return bodiesResult{err: err}
+}
+
+// getBodies returns the bodies of the blocks of the headers from the host
+func getBodies(ctx context.Context, host string, headers []*types.BlockData) ([][]byte, error) {
+	ctxDone, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rawBlocksChan, err := tcpclient.GetBlocksBodies(ctxDone, host, headers[0].Header.BlockId, false)
+	if err != nil {
+		return nil, err
+	}
+	bodies := make([][]byte, 0, len(headers))
+	for rawBlock := range rawBlocksChan {
+		if len(bodies) == len(headers) {
+			break
+		}
+		if err = checkBody(rawBlock, headers[len(bodies)]); err != nil {
+			return nil, err
+		}
+		// the data of the channel is reused after cancel
+		bodies = append(bodies, append([]byte(nil), rawBlock...))
+	}
+	if len(bodies) < len(headers) {
+		return nil, tcpclient.ErrorEmptyBlockBody
+	}
+	return bodies, nil
+}
+
+// checkBody checks that the body of the block has the checked header
+func checkBody(data []byte, header *types.BlockData) error {
+	bd := &types.BlockData{}
+	if err := bd.UnmarshallBlock(data); err != nil {
+		return utils.WithBan(err)
+	}
+	if bd.Header == nil || bd.Header.BlockId != header.Header.BlockId ||
+		!bytes.Equal(bd.Header.BlockHash, header.Header.BlockHash) || !bytes.Equal(bd.MerkleRoot, header.MerkleRoot) {
+		return utils.WithBan(errBodyMismatch)
+	}
+	return nil
+}
@@ -51,6 +51,8 @@ var daemonsList = map[string]func(context.Context, *daemon) error{
 	"Confirmations":       Confirmations,
 	"Scheduler":           Scheduler,
 	"CandidateNodeVoting": CandidateNodeVoting,
+	"PeerExchange":        PeerExchange,
+	"Snapshots":           Snapshots,
 	//"ExternalNetwork":   ExternalNetwork,
 }
 
@@ -24,11 +24,24 @@ func InitialLoad(logger *log.Entry) error {
 	}
 
 	if toLoad {
-		logger.Debug("start first block loading")
+		if initialSnapshot() {
+			if err = snapshotLoad(logger); err == nil {
+				return sqldb.UpdateSchema()
+			}
+			logger.WithError(err).Warning("cant load snapshot, loading blockchain from the first block")
+			// the first block may be loaded by snapshotLoad before the snapshot
+			if toLoad, err = needLoad(logger); err != nil {
+				return err
+			}
+		}
 
-		if err := firstLoad(logger); err != nil {
-			logger.WithError(err).Error("cant load first block form file or host")
-			return err
+		if toLoad {
+			logger.Debug("start first block loading")
+
+			if err := firstLoad(logger); err != nil {
+				logger.WithError(err).Error("cant load first block form file or host")
+				return err
+			}
 		}
 
 		if err := sqldb.UpdateSchema(); err != nil {
@@ -77,6 +90,17 @@ func firstLoad(logger *log.Entry) error {
 	return loadFirstBlock(logger)
 }
 
+// snapshotLoad loads the first block, its honor nodes check the chain of the snapshot, then restores the snapshot
+func snapshotLoad(logger *log.Entry) error {
+	DBLock()
+	defer DBUnlock()
+
+	if err := loadFirstBlock(logger); err != nil {
+		return err
+	}
+	return loadSnapshot(context.Background(), logger)
+}
+
 func needLoad(logger *log.Entry) (bool, error) {
 	infoBlock := &sqldb.InfoBlock{}
 	_, err := infoBlock.Get()
@@ -0,0 +1,82 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package daemons
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/network/addrbook"
+	"github.com/IBAX-io/go-ibax/packages/network/tcpclient"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// peerExchangeInterval is the interval of the requests of the peers
+	peerExchangeInterval = time.Minute
+	// peerExchangeCount is the number of the peers asked for their peers at once
+	peerExchangeCount = 5
+	// syncPeersCount is the number of the best peers of the address book checked for the max block
+	syncPeersCount = 10
+)
+
+// PeerExchange adds the boot nodes and the honor nodes to the address book, requests the known
+// peers from the random peers and saves the address book
+func PeerExchange(ctx context.Context, d *daemon) error {
+	if !atomic.CompareAndSwapUint32(&d.atomic, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreUint32(&d.atomic, 0)
+	d.sleepTime = peerExchangeInterval
+
+	book := addrbook.Get()
+	for _, host := range conf.GetNodesAddr() {
+		book.Add(host, ``, addrbook.SourceBoot)
+	}
+	for _, host := range syspar.GetRemoteHosts() {
+		book.Add(host, ``, addrbook.SourceHonor)
+	}
+
+	for _, entry := range book.Select(peerExchangeCount) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		peers, err := tcpclient.GetPeers(entry.Addr, conf.Config.Peers.ExternalAddr)
+		if err != nil {
+			d.logger.WithFields(log.Fields{"error": err, "host": entry.Addr}).Debug("getting peers")
+			continue
+		}
+		var added int
+		for _, p := range peers {
+			if book.Add(p.Addr, p.PublicKey, addrbook.SourcePeer) {
+				added++
+			}
+		}
+		d.logger.WithFields(log.Fields{"host": entry.Addr, "peers": len(peers), "added": added}).Debug("got peers")
+	}
+	return book.Save()
+}
+
+// getSyncPeers returns the best peers of the address book which are not in the list of hosts
+func getSyncPeers(hosts []string) []string {
+	used := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		if addr, err := addrbook.NormalizeAddr(h); err == nil {
+			used[addr] = true
+		}
+	}
+	var ret []string
+	for _, addr := range addrbook.Get().Best(syncPeersCount) {
+		if !used[addr] {
+			ret = append(ret, addr)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,307 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package daemons
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/network/tcpclient"
+	"github.com/IBAX-io/go-ibax/packages/snapshot"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errNoSnapshot         = errors.New("peers have no snapshots")
+	errSnapshotNotConfirm = errors.New("snapshot block is not confirmed by peers")
+	errSnapshotChain      = errors.New("snapshot block is not in the chain of the trusted honor nodes")
+)
+
+// Snapshots creates the snapshot of the state every Snapshot.Interval blocks and removes the old snapshots
+func Snapshots(ctx context.Context, d *daemon) error {
+	d.sleepTime = time.Minute
+	if conf.Config.Snapshot.Interval <= 0 {
+		return nil
+	}
+	if !atomic.CompareAndSwapUint32(&d.atomic, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreUint32(&d.atomic, 0)
+
+	infoBlock := &sqldb.InfoBlock{}
+	if _, err := infoBlock.Get(); err != nil {
+		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		return err
+	}
+	var lastID int64
+	dir := conf.Config.GetSnapshotDir()
+	if latest, err := snapshot.Latest(dir); err == nil {
+		lastID = latest.BlockID
+	}
+	if infoBlock.BlockID-lastID < conf.Config.Snapshot.Interval {
+		return nil
+	}
+	if _, err := snapshot.Create(ctx, dir); err != nil {
+		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating snapshot")
+		return err
+	}
+	keep := conf.Config.Snapshot.Keep
+	if keep < 1 {
+		keep = 1
+	}
+	return snapshot.Prune(dir, keep)
+}
+
+// snapshotOffer is the snapshot which is served by the hosts
+type snapshotOffer struct {
+	manifest *snapshot.Manifest
+	hosts    []string
+}
+
+// loadSnapshot restores the state from the latest snapshot of the honor nodes. The snapshot and its block must be
+// confirmed by Snapshot.Confirmations distinct honor nodes and the block must be in the chain of the headers which
+// are signed by the honor nodes of the first block, then the blocks after it are collected by BlocksCollection.
+// The first block must be loaded
+func loadSnapshot(ctx context.Context, logger *log.Entry) error {
+	need := conf.Config.Snapshot.Confirmations
+	if need < 1 {
+		need = 1
+	}
+	offer := bestSnapshot(honorHosts(), need, logger)
+	if offer == nil {
+		return errNoSnapshot
+	}
+	m := offer.manifest
+	logger = logger.WithFields(log.Fields{"block_id": m.BlockID, "size": m.Size})
+	logger.Info("restoring state from snapshot")
+
+	blockData, err := confirmSnapshotBlock(ctx, offer, need, logger)
+	if err != nil {
+		return err
+	}
+	if err = checkSnapshotChain(ctx, offer, logger); err != nil {
+		return err
+	}
+	path, err := downloadSnapshot(ctx, offer, logger)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	return snapshot.Restore(ctx, m, path, blockData)
+}
+
+// checkSnapshotChain checks that the block of the snapshot is in the chain of the headers from our last block.
+// The headers are checked by checkHeaders with the honor nodes of our state, so the chain is rejected if it has
+// a block of the node which is not the honor node of the first block. The headers are requested from the hosts
+// of the offer in turn, the next host is tried if the host fails
+func checkSnapshotChain(ctx context.Context, offer *snapshotOffer, logger *log.Entry) error {
+	m := offer.manifest
+	last, err := lastHeader()
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last block header")
+		return err
+	}
+	for i := 0; last.BlockId < m.BlockID; {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if i == len(offer.hosts) {
+			return errSnapshotChain
+		}
+		host := offer.hosts[i]
+		limit := m.BlockID - last.BlockId
+		if limit > network.HeadersPerRequest {
+			limit = network.HeadersPerRequest
+		}
+		rawHeaders, err := tcpclient.GetBlocksHeaders(host, last.BlockId+1, limit)
+		if err != nil {
+			logger.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": host}).Warning("getting block headers")
+			i++
+			continue
+		}
+		headers, err := checkHeaders(last, rawHeaders)
+		if err == nil && len(headers) < len(rawHeaders) {
+			err = errSnapshotChain
+		}
+		if err != nil || len(headers) == 0 {
+			logger.WithFields(log.Fields{"type": consts.BlockError, "error": err, "host": host}).Warning("checking block headers")
+			i++
+			continue
+		}
+		last = headers[len(headers)-1].Header
+	}
+	if last.BlockId != m.BlockID || hex.EncodeToString(last.BlockHash) != m.BlockHash {
+		logger.WithFields(log.Fields{"type": consts.BlockError, "block_id": last.BlockId}).Error("checking snapshot block hash")
+		return errSnapshotChain
+	}
+	return nil
+}
+
+// honorHosts returns the distinct addresses of the honor nodes of the config and of the platform parameters
+func honorHosts() []string {
+	var (
+		hosts []string
+		added = make(map[string]bool)
+	)
+	addrs := conf.GetNodesAddr()
+	for _, n := range syspar.GetNodes() {
+		addrs = append(addrs, n.TCPAddress)
+	}
+	for _, addr := range addrs {
+		host, err := tcpclient.NormalizeHostAddress(addr, consts.DefaultTcpPort)
+		if err != nil || added[host] {
+			continue
+		}
+		added[host] = true
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// bestSnapshot returns the newest snapshot which is served by at least need hosts. The snapshots are the same
+// if they have the same block, state and file
+func bestSnapshot(hosts []string, need int, logger *log.Entry) *snapshotOffer {
+	var (
+		best   *snapshotOffer
+		offers = make(map[string]*snapshotOffer)
+	)
+	for _, host := range hosts {
+		data, err := tcpclient.GetSnapshotInfo(host)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		m, err := snapshot.ParseManifest(data)
+		if err != nil {
+			logger.WithFields(log.Fields{"type": consts.InvalidObject, "error": err, "host": host}).Warning("parsing snapshot manifest")
+			continue
+		}
+		key := m.BlockHash + m.StateHash + m.FileHash
+		offer, ok := offers[key]
+		if !ok {
+			offer = &snapshotOffer{manifest: m}
+			offers[key] = offer
+		}
+		offer.hosts = append(offer.hosts, host)
+	}
+	for _, offer := range offers {
+		if len(offer.hosts) < need {
+			continue
+		}
+		if best == nil || offer.manifest.BlockID > best.manifest.BlockID ||
+			(offer.manifest.BlockID == best.manifest.BlockID && len(offer.hosts) > len(best.hosts)) {
+			best = offer
+		}
+	}
+	return best
+}
+
+// confirmSnapshotBlock requests the block of the snapshot from the hosts of the offer and returns it if at least
+// need hosts have the same block. Only the confirming hosts are left in the offer
+func confirmSnapshotBlock(ctx context.Context, offer *snapshotOffer, need int, logger *log.Entry) ([]byte, error) {
+	var (
+		m         = offer.manifest
+		blockData []byte
+		confirmed []string
+	)
+	for _, host := range offer.hosts {
+		data, err := getBlock(ctx, host, m.BlockID)
+		if err != nil {
+			continue
+		}
+		if _, err = m.CheckBlock(data); err != nil || (blockData != nil && !bytes.Equal(data, blockData)) {
+			logger.WithFields(log.Fields{"type": consts.BlockError, "error": err, "host": host}).Warning("host has another snapshot block")
+			continue
+		}
+		blockData = data
+		confirmed = append(confirmed, host)
+	}
+	if len(confirmed) < need {
+		logger.WithFields(log.Fields{"confirmed": len(confirmed), "need": need}).Error("confirming snapshot block")
+		return nil, errSnapshotNotConfirm
+	}
+	offer.hosts = confirmed
+	return blockData, nil
+}
+
+// getBlock returns the binary block from the host
+func getBlock(ctx context.Context, host string, blockID int64) ([]byte, error) {
+	ctxDone, cancel := context.WithCancel(ctx)
+	defer cancel()
+	rawBlocksChan, err := tcpclient.GetBlocksBodies(ctxDone, host, blockID, false)
+	if err != nil {
+		return nil, err
+	}
+	rawBlock, ok := <-rawBlocksChan
+	if !ok {
+		return nil, tcpclient.ErrorEmptyBlockBody
+	}
+	// the data of the channel is reused after cancel
+	return append([]byte(nil), rawBlock...), nil
+}
+
+// downloadSnapshot downloads the snapshot file from the hosts of the offer to the temporary directory
+func downloadSnapshot(ctx context.Context, offer *snapshotOffer, logger *log.Entry) (string, error) {
+	m := offer.manifest
+	path := snapshot.Path(conf.Config.DirPathConf.TempDir, m.BlockID)
+	if err := os.MkdirAll(filepath.Dir(path), 0775); err != nil {
+		return ``, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": path}).Error("creating snapshot file")
+		return ``, err
+	}
+	defer f.Close()
+
+	var offset int64
+	for i := 0; offset < m.Size && err == nil; {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		host := offer.hosts[i%len(offer.hosts)]
+		data, errChunk := tcpclient.GetSnapshotChunk(host, m.BlockID, offset)
+		if errChunk == nil && len(data) == 0 {
+			errChunk = snapshot.ErrNotFound
+		}
+		if errChunk != nil {
+			logger.WithFields(log.Fields{"error": errChunk, "host": host, "offset": offset}).Warning("downloading snapshot")
+			// the next host is tried, the download fails if all hosts fail at the same offset
+			if i++; i == len(offer.hosts) {
+				err = errChunk
+			}
+			continue
+		}
+		_, err = f.Write(data)
+		offset += int64(len(data))
+		i = 0
+	}
+	if err == nil && offset != m.Size {
+		err = snapshot.ErrFileHash
+	}
+	if err != nil {
+		os.Remove(path)
+		return ``, err
+	}
+	return path, nil
+}
+
+// initialSnapshot returns true if the state should be restored from the snapshot of the honor nodes
+func initialSnapshot() bool {
+	return conf.Config.Snapshot.Sync && len(honorHosts()) > 0
+}
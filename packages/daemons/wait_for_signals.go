@@ -14,6 +14,8 @@ import (
 
 	"github.com/IBAX-io/go-ibax/packages/conf"
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network/addrbook"
+	"github.com/IBAX-io/go-ibax/packages/network/tcpclient"
 	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
 	"github.com/IBAX-io/go-ibax/packages/utils"
 
@@ -70,6 +72,8 @@ func WaitForSignals() {
 
 					log.Debug("Daemons killed")
 				}
+				tcpclient.ClosePeers()
+				addrbook.Get().Save()
 
 				if sqldb.DBConn != nil {
 					err := sqldb.GormClose()
@@ -62,6 +62,9 @@ var (
 		t.Column("address", "bigint", {"default": "0"})
 		t.Column("ecosystem_id", "bigint", {"default": "0"})
 		t.Column("status", "bigint", {"default": "0"})
+		t.Column("peak_memory", "bigint", {"default": "0"})
+		t.Column("peak_depth", "bigint", {"default": "0"})
+		t.Column("instructions", "bigint", {"default": "0"})
 	{{footer "primary(hash)"}}
 
 	{{head "queue_blocks"}}
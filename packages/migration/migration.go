@@ -32,6 +32,11 @@ var updateMigrations = []*migration{
 	{"0.0.3", updates.MigrationUpdatePriceExec, false},
 	{"0.0.4", updates.MigrationUpdateAccessExec, false},
 	{"0.0.5", updates.MigrationUpdatePriceCreateExec, false},
+	{"0.0.6", updates.MigrationUpdateContractEvents, false},
+	{"0.0.7", updates.MigrationUpdateAPIKeys, false},
+	{"0.0.8", updates.MigrationUpdateVMLimits, false},
+	{"0.0.9", updates.MigrationUpdateContractVersions, false},
+	{"0.0.10", updates.MigrationUpdateLintContract, false},
 }
 
 type migration struct {
@@ -0,0 +1,24 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package updates
+
+var MigrationUpdateAPIKeys = `
+CREATE SEQUENCE IF NOT EXISTS "api_keys_id_seq" START WITH 1;
+CREATE TABLE IF NOT EXISTS "api_keys" (
+	"id" bigint NOT NULL DEFAULT nextval('api_keys_id_seq'),
+	"name" varchar(255) NOT NULL DEFAULT '',
+	"key_id" bigint NOT NULL DEFAULT '0',
+	"ecosystem" bigint NOT NULL DEFAULT '1',
+	"scopes" text NOT NULL DEFAULT '',
+	"hash" bytea NOT NULL DEFAULT '',
+	"rate_limit" double precision NOT NULL DEFAULT '0',
+	"created_at" bigint NOT NULL DEFAULT '0',
+	"expires_at" bigint NOT NULL DEFAULT '0',
+	"revoked_at" bigint NOT NULL DEFAULT '0',
+	CONSTRAINT "api_keys_pkey" PRIMARY KEY (id)
+);
+ALTER SEQUENCE "api_keys_id_seq" owned by "api_keys".id;
+CREATE UNIQUE INDEX IF NOT EXISTS "api_keys_hash" ON "api_keys" (hash);
+`
@@ -0,0 +1,62 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package updates
+
+// MigrationUpdateContractVersions creates RollbackContract with the reserved id 0,
+// so it has the same id on every node and the ids of other contracts don't change
+var MigrationUpdateContractVersions = `
+CREATE TABLE IF NOT EXISTS "contract_versions" (
+	"contract_id" bigint NOT NULL DEFAULT '0',
+	"version" bigint NOT NULL DEFAULT '0',
+	"ecosystem" bigint NOT NULL DEFAULT '0',
+	"name" varchar(255) NOT NULL DEFAULT '',
+	"value" text NOT NULL DEFAULT '',
+	"conditions" text NOT NULL DEFAULT '',
+	"wallet_id" bigint NOT NULL DEFAULT '0',
+	"token_id" bigint NOT NULL DEFAULT '0',
+	"block_id" bigint NOT NULL DEFAULT '0',
+	"tx_hash" bytea NOT NULL DEFAULT '',
+	"timestamp" bigint NOT NULL DEFAULT '0',
+	"restored" bigint NOT NULL DEFAULT '0',
+	CONSTRAINT "contract_versions_pkey" PRIMARY KEY (contract_id, version)
+);
+CREATE INDEX IF NOT EXISTS "contract_versions_block_id" ON "contract_versions" (block_id);
+
+INSERT INTO "1_platform_parameters" (id, name, value, conditions) VALUES
+	(next_id('1_platform_parameters'), 'access_exec_rollback_contract', 'ContractAccess("@1RollbackContract")', 'ContractAccess("@1UpdatePlatformParam")'),
+	(next_id('1_platform_parameters'), 'price_exec_rollback_contract', '50', 'ContractAccess("@1UpdatePlatformParam")');
+
+CREATE OR REPLACE FUNCTION next_id(table_name TEXT, OUT result INT) AS
+$$
+BEGIN
+	EXECUTE FORMAT('SELECT COUNT(*) + 1 FROM "%s" WHERE id > 0', table_name)
+	INTO result;
+	RETURN;
+END
+$$
+LANGUAGE plpgsql;
+
+INSERT INTO "1_contracts" (id, name, value, token_id, conditions, app_id, ecosystem) VALUES
+	(0, 'RollbackContract', 'contract RollbackContract {
+    data {
+        Id int
+        Version int
+    }
+
+    conditions {
+        RowConditions("contracts", $Id, false)
+        $cur = DBFind("contracts").Columns("id,value,wallet_id,token_id").WhereId($Id).Row()
+        if !$cur {
+            error Sprintf("Contract %d does not exist", $Id)
+        }
+        $recipient = Int($cur["wallet_id"])
+    }
+
+    action {
+        RollbackContract($Id, $Version, $recipient, $cur["token_id"])
+    }
+}
+', '1', 'ContractConditions("MainCondition")', '1', '1');
+`
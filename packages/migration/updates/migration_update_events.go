@@ -0,0 +1,27 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package updates
+
+var MigrationUpdateContractEvents = `
+CREATE TABLE IF NOT EXISTS "contract_events" (
+	"tx_hash" bytea NOT NULL DEFAULT '',
+	"log_index" bigint NOT NULL DEFAULT '0',
+	"block_id" bigint NOT NULL DEFAULT '0',
+	"ecosystem" bigint NOT NULL DEFAULT '0',
+	"contract_name" varchar(255) NOT NULL DEFAULT '',
+	"name" varchar(255) NOT NULL DEFAULT '',
+	"data" jsonb NOT NULL DEFAULT '{}',
+	"timestamp" bigint NOT NULL DEFAULT '0',
+	CONSTRAINT "contract_events_pkey" PRIMARY KEY (tx_hash, log_index)
+);
+CREATE INDEX IF NOT EXISTS "contract_events_block_id" ON "contract_events" (block_id);
+CREATE INDEX IF NOT EXISTS "contract_events_contract_name" ON "contract_events" (contract_name, block_id);
+CREATE INDEX IF NOT EXISTS "contract_events_name" ON "contract_events" (name, block_id);
+CREATE INDEX IF NOT EXISTS "contract_events_ecosystem" ON "contract_events" (ecosystem, block_id);
+CREATE INDEX IF NOT EXISTS "contract_events_data" ON "contract_events" USING gin (data jsonb_path_ops);
+
+INSERT INTO "1_platform_parameters" (id, name, value, conditions) VALUES
+	(next_id('1_platform_parameters'), 'price_exec_emit_event', '100', 'ContractAccess("@1UpdatePlatformParam")');
+`
@@ -0,0 +1,10 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package updates
+
+var MigrationUpdateLintContract = `
+INSERT INTO "1_platform_parameters" (id, name, value, conditions) VALUES
+	(next_id('1_platform_parameters'), 'price_exec_lint_contract', '100', 'ContractAccess("@1UpdatePlatformParam")');
+`
@@ -0,0 +1,18 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package updates
+
+var MigrationUpdateVMLimits = `
+ALTER TABLE "log_transactions" ADD COLUMN IF NOT EXISTS "peak_memory" bigint NOT NULL DEFAULT '0';
+ALTER TABLE "log_transactions" ADD COLUMN IF NOT EXISTS "peak_depth" bigint NOT NULL DEFAULT '0';
+ALTER TABLE "log_transactions" ADD COLUMN IF NOT EXISTS "instructions" bigint NOT NULL DEFAULT '0';
+
+INSERT INTO "1_platform_parameters" (id, name, value, conditions) VALUES
+	(next_id('1_platform_parameters'), 'max_call_depth', '1000', 'ContractAccess("@1UpdatePlatformParam")'),
+	(next_id('1_platform_parameters'), 'max_vm_memory', '134217728', 'ContractAccess("@1UpdatePlatformParam")'),
+	(next_id('1_platform_parameters'), 'max_string_size', '33554432', 'ContractAccess("@1UpdatePlatformParam")'),
+	(next_id('1_platform_parameters'), 'max_array_size', '1000000', 'ContractAccess("@1UpdatePlatformParam")'),
+	(next_id('1_platform_parameters'), 'vm_version', '0', 'ContractAccess("@1UpdatePlatformParam")');
+`
@@ -6,12 +6,15 @@ package modes
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/IBAX-io/go-ibax/packages/block"
 	"github.com/IBAX-io/go-ibax/packages/clbmanager"
 	"github.com/IBAX-io/go-ibax/packages/conf"
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
 	"github.com/IBAX-io/go-ibax/packages/daemons"
+	"github.com/IBAX-io/go-ibax/packages/network/addrbook"
 	"github.com/IBAX-io/go-ibax/packages/network/tcpserver"
 	"github.com/IBAX-io/go-ibax/packages/service/node"
 	"github.com/IBAX-io/go-ibax/packages/smart"
@@ -46,6 +49,7 @@ type BCDaemonFactory struct {
 
 // Load loads blockchain daemons
 func (l BCDaemonFactory) Load(ctx context.Context) error {
+	initAddrBook(l.logger)
 	if err := daemons.InitialLoad(l.logger); err != nil {
 		return err
 	}
@@ -105,6 +109,8 @@ func (BCDaemonFactory) GetDaemonsList() []string {
 		"Confirmations",
 		"Scheduler",
 		"CandidateNodeVoting",
+		"PeerExchange",
+		"Snapshots",
 		//"ExternalNetwork",
 	}
 }
@@ -116,6 +122,7 @@ type SNDaemonFactory struct {
 
 // Load loads subnode daemons
 func (l SNDaemonFactory) Load(ctx context.Context) error {
+	initAddrBook(l.logger)
 	daemons.InitialLoad(l.logger)
 
 	if err := syspar.SysUpdate(nil); err != nil {
@@ -163,6 +170,19 @@ func (l SNDaemonFactory) Load(ctx context.Context) error {
 func (SNDaemonFactory) GetDaemonsList() []string {
 	return []string{
 		"Scheduler",
+		"PeerExchange",
+	}
+}
+
+// initAddrBook loads the address book of the peers
+func initAddrBook(logger *log.Entry) {
+	self := conf.Config.Peers.ExternalAddr
+	if len(self) == 0 {
+		self = conf.Config.TCPServer.Str()
+	}
+	path := filepath.Join(conf.Config.DirPathConf.DataDir, consts.AddrBookFilename)
+	if err := addrbook.Init(path, conf.Config.Peers.MaxPeers, self); err != nil {
+		logger.WithError(err).Error("can't load address book")
 	}
 }
 
@@ -0,0 +1,335 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package addrbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The sources of the addresses
+const (
+	SourceBoot    = "boot"
+	SourceHonor   = "honor"
+	SourcePeer    = "peer"
+	SourceInbound = "inbound"
+)
+
+const (
+	// DefaultMaxPeers is the default size of the address book
+	DefaultMaxPeers = 1000
+
+	scoreMax  = 100
+	scoreMin  = -100
+	scoreGood = 1
+	// PenaltyFail is the penalty of the failed connection
+	PenaltyFail = 2
+	// PenaltyBadBlock is the penalty of the invalid block received from the peer
+	PenaltyBadBlock = 20
+
+	// maxFailures is the number of the failures in a row after which the peer is removed if it
+	// has not been reachable for staleTime
+	maxFailures = 10
+	staleTime   = 7 * 24 * time.Hour
+)
+
+var errWrongAddr = errors.New("wrong peer address")
+
+// Entry is the peer in the address book
+type Entry struct {
+	Addr      string `json:"addr"`
+	PublicKey string `json:"public_key,omitempty"`
+	Source    string `json:"source"`
+	Score     int    `json:"score"`
+	Added     int64  `json:"added"`
+	LastSeen  int64  `json:"last_seen"`
+	LastTry   int64  `json:"last_try"`
+	Failures  int    `json:"failures"`
+}
+
+// protected returns true if the peer is never evicted from the address book
+func (e *Entry) protected() bool {
+	return e.Source == SourceBoot || e.Source == SourceHonor
+}
+
+// Book is the persistent address book of the peers with the scoring of their availability
+type Book struct {
+	mu      sync.Mutex
+	path    string
+	max     int
+	self    string
+	entries map[string]*Entry
+}
+
+var book = New(``, DefaultMaxPeers)
+
+// Init sets the file and the size of the address book and loads the saved peers. self is the
+// address of this node, it is never added to the book
+func Init(path string, max int, self string) error {
+	b := New(path, max)
+	if len(self) > 0 {
+		b.self, _ = NormalizeAddr(self)
+	}
+	if err := b.Load(); err != nil {
+		return err
+	}
+	book = b
+	return nil
+}
+
+// Get returns the address book of the node
+func Get() *Book {
+	return book
+}
+
+// New returns the empty address book
+func New(path string, max int) *Book {
+	if max <= 0 {
+		max = DefaultMaxPeers
+	}
+	return &Book{path: path, max: max, entries: make(map[string]*Entry)}
+}
+
+// NormalizeAddr returns the address with the port. The default port is used if the port is missed
+func NormalizeAddr(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if len(addr) == 0 {
+		return ``, errWrongAddr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, fmt.Sprint(consts.DefaultTcpPort)
+	}
+	if len(host) == 0 || len(port) == 0 {
+		return ``, errWrongAddr
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Load reads the saved peers from the file
+func (b *Book) Load() error {
+	if len(b.path) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": b.path}).Error("reading address book")
+		return err
+	}
+	var list []*Entry
+	if err = json.Unmarshal(data, &list); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err, "path": b.path}).Error("unmarshalling address book")
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, e := range list {
+		if addr, err := NormalizeAddr(e.Addr); err == nil && addr != b.self {
+			e.Addr = addr
+			b.entries[addr] = e
+		}
+	}
+	for len(b.entries) > b.max && b.evict() {
+	}
+	return nil
+}
+
+// Save writes the peers to the file
+func (b *Book) Save() error {
+	if len(b.path) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.List(), ``, `  `)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling address book")
+		return err
+	}
+	tmp := b.path + `.tmp`
+	if err = os.MkdirAll(filepath.Dir(b.path), 0775); err == nil {
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, b.path)
+		}
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": b.path}).Error("writing address book")
+	}
+	return err
+}
+
+// Add adds the peer to the address book. It returns false if the address is wrong or it is
+// the address of this node. The source of the known peer is upgraded to the boot or honor
+func (b *Book) Add(addr, publicKey, source string) bool {
+	addr, err := NormalizeAddr(addr)
+	if err != nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if addr == b.self {
+		return false
+	}
+	if e, ok := b.entries[addr]; ok {
+		if len(e.PublicKey) == 0 {
+			e.PublicKey = publicKey
+		}
+		if (source == SourceBoot || source == SourceHonor) && !e.protected() {
+			e.Source = source
+		}
+		return true
+	}
+	if len(b.entries) >= b.max && !b.evict() {
+		return false
+	}
+	b.entries[addr] = &Entry{Addr: addr, PublicKey: publicKey, Source: source, Added: time.Now().Unix()}
+	return true
+}
+
+// evict removes the peer with the lowest score. The peers which have not been seen are removed first
+func (b *Book) evict() bool {
+	var worst *Entry
+	for _, e := range b.entries {
+		if e.protected() {
+			continue
+		}
+		if worst == nil || e.Score < worst.Score || (e.Score == worst.Score && e.LastSeen < worst.LastSeen) {
+			worst = e
+		}
+	}
+	if worst == nil {
+		return false
+	}
+	delete(b.entries, worst.Addr)
+	return true
+}
+
+// Good registers the successful connection to the peer
+func (b *Book) Good(addr, publicKey string) {
+	addr, err := NormalizeAddr(addr)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[addr]
+	if !ok {
+		return
+	}
+	now := time.Now().Unix()
+	e.LastSeen, e.LastTry, e.Failures = now, now, 0
+	if len(publicKey) > 0 {
+		e.PublicKey = publicKey
+	}
+	if e.Score += scoreGood; e.Score > scoreMax {
+		e.Score = scoreMax
+	}
+}
+
+// Bad registers the failure of the peer. The peer failing for a long time is removed
+func (b *Book) Bad(addr string, penalty int) {
+	addr, err := NormalizeAddr(addr)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[addr]
+	if !ok {
+		return
+	}
+	e.LastTry = time.Now().Unix()
+	e.Failures++
+	if e.Score -= penalty; e.Score < scoreMin {
+		e.Score = scoreMin
+	}
+	lastSeen := e.LastSeen
+	if lastSeen == 0 {
+		lastSeen = e.Added
+	}
+	if !e.protected() && e.Failures >= maxFailures && time.Since(time.Unix(lastSeen, 0)) > staleTime {
+		delete(b.entries, addr)
+	}
+}
+
+// List returns all peers ordered by the score
+func (b *Book) List() []Entry {
+	b.mu.Lock()
+	list := make([]Entry, 0, len(b.entries))
+	for _, e := range b.entries {
+		list = append(list, *e)
+	}
+	b.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score > list[j].Score
+		}
+		if list[i].LastSeen != list[j].LastSeen {
+			return list[i].LastSeen > list[j].LastSeen
+		}
+		return list[i].Addr < list[j].Addr
+	})
+	return list
+}
+
+// Best returns the addresses of n peers with the best score which are not failing now
+func (b *Book) Best(n int) []string {
+	list := b.List()
+	ret := make([]string, 0, n)
+	for i := 0; i < len(list) && len(ret) < n; i++ {
+		if list[i].Score >= 0 || list[i].Failures == 0 {
+			ret = append(ret, list[i].Addr)
+		}
+	}
+	return ret
+}
+
+// Select returns n random peers for the exchange of the addresses. The peers with
+// the better score are selected more often
+func (b *Book) Select(n int) []Entry {
+	list := b.List()
+	if len(list) <= n {
+		return list
+	}
+	// take the random peers from the best half and from all peers
+	half := list[:(len(list)+1)/2]
+	rand.Shuffle(len(half), func(i, j int) { half[i], half[j] = half[j], half[i] })
+	ret := make([]Entry, 0, n)
+	used := make(map[string]bool)
+	for i := 0; i < len(half) && len(ret) < (n+1)/2; i++ {
+		ret = append(ret, half[i])
+		used[half[i].Addr] = true
+	}
+	for _, i := range rand.Perm(len(list)) {
+		if len(ret) >= n {
+			break
+		}
+		if !used[list[i].Addr] {
+			ret = append(ret, list[i])
+		}
+	}
+	return ret
+}
+
+// Len returns the number of the peers
+func (b *Book) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
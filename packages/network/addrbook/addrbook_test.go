@@ -0,0 +1,69 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package addrbook
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAddr(t *testing.T) {
+	addr, err := NormalizeAddr(" 10.0.0.1 ")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:7078", addr)
+	addr, err = NormalizeAddr("10.0.0.1:8000")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:8000", addr)
+	_, err = NormalizeAddr(":8000")
+	require.Error(t, err)
+}
+
+func TestBookScore(t *testing.T) {
+	b := New(``, 3)
+	b.self = "10.0.0.9:7078"
+	require.False(t, b.Add("10.0.0.9", ``, SourcePeer))
+	require.True(t, b.Add("10.0.0.1", ``, SourceBoot))
+	require.True(t, b.Add("10.0.0.2", ``, SourcePeer))
+	require.True(t, b.Add("10.0.0.3", ``, SourcePeer))
+
+	b.Good("10.0.0.2", "key")
+	b.Bad("10.0.0.3", PenaltyBadBlock)
+	require.Equal(t, []string{"10.0.0.2:7078", "10.0.0.1:7078"}, b.Best(3))
+
+	// the peer with the lowest score is evicted, the boot node is protected
+	require.True(t, b.Add("10.0.0.4", ``, SourcePeer))
+	require.Equal(t, 3, b.Len())
+	for _, e := range b.List() {
+		require.NotEqual(t, "10.0.0.3:7078", e.Addr)
+	}
+	require.Len(t, b.Select(2), 2)
+
+	// the stale peer is removed after the failures
+	e := b.entries["10.0.0.4:7078"]
+	e.Added = time.Now().Add(-2 * staleTime).Unix()
+	for i := 0; i < maxFailures; i++ {
+		b.Bad("10.0.0.4", PenaltyFail)
+	}
+	require.Equal(t, 2, b.Len())
+	b.Bad("10.0.0.1", 1000)
+	require.Equal(t, scoreMin, b.entries["10.0.0.1:7078"].Score)
+}
+
+func TestBookSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	require.NoError(t, Init(path, 10, "10.0.0.9"))
+	b := Get()
+	b.Add("10.0.0.1", ``, SourceHonor)
+	b.Add("10.0.0.2:8000", ``, SourceInbound)
+	b.Good("10.0.0.2:8000", "key")
+	require.NoError(t, b.Save())
+
+	loaded := New(path, 10)
+	require.NoError(t, loaded.Load())
+	require.Equal(t, b.List(), loaded.List())
+}
@@ -0,0 +1,613 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Types of the frames of the multiplexed connection
+const (
+	frameOpen byte = iota + 1
+	frameData
+	frameWindow
+	frameClose
+	frameReset
+	framePing
+	framePong
+)
+
+const (
+	// muxHeaderSize is the size of the frame header: stream id, type and size of the data
+	muxHeaderSize = 9
+	// muxMaxData is the maximum size of the data in the frame
+	muxMaxData = 32 << 10
+	// muxWindow is the size of the receive window of the stream. The sender waits for the window
+	// update when the receiver does not read the data
+	muxWindow = 256 << 10
+	// MuxMaxStreams is the maximum number of the concurrent requests in one connection
+	MuxMaxStreams = 64
+	// MuxKeepAlive is the interval of the keepalive pings
+	MuxKeepAlive = 15 * time.Second
+	// muxControlQueue is the maximum number of the control frames waiting for sending. The connection
+	// is closed if the peer sends the requests faster than it reads the responses
+	muxControlQueue = 2 * MuxMaxStreams
+)
+
+var (
+	ErrSessionClosed = errors.New("connection is closed")
+	ErrStreamClosed  = errors.New("request stream is closed")
+	ErrStreamReset   = errors.New("request stream is reset by peer")
+	ErrKeepAlive     = errors.New("keepalive timeout")
+	ErrMuxProtocol   = errors.New("wrong multiplexed frame")
+	ErrControlQueue  = errors.New("too many control frames")
+	ErrTimeout       = timeoutError{}
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// MuxSession multiplexes the requests over one long-lived connection. Every request is sent by the
+// separate stream with its own id, so the handlers of the requests work with the stream as with
+// the connection
+type MuxSession struct {
+	conn      net.Conn
+	client    bool
+	keepAlive time.Duration
+	onPing    func(time.Duration)
+
+	mu         sync.Mutex
+	streams    map[uint32]*MuxStream
+	nextID     uint32
+	pingID     uint32
+	pings      map[uint32]time.Time
+	pongID     uint32
+	pongQueued bool
+
+	control  chan controlFrame
+	wmu      sync.Mutex
+	accept   chan *MuxStream
+	slots    chan struct{}
+	lastRecv atomic.Int64
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// NewMuxSession starts the multiplexing on the connection. The client opens the streams and
+// the server accepts them. onPing receives the round-trip time of the keepalive pings
+func NewMuxSession(conn net.Conn, client bool, onPing func(time.Duration)) *MuxSession {
+	return newMuxSession(conn, client, onPing, MuxKeepAlive)
+}
+
+func newMuxSession(conn net.Conn, client bool, onPing func(time.Duration), keepAlive time.Duration) *MuxSession {
+	s := &MuxSession{
+		conn:      conn,
+		client:    client,
+		keepAlive: keepAlive,
+		onPing:    onPing,
+		streams:   make(map[uint32]*MuxStream),
+		nextID:    2,
+		pings:     make(map[uint32]time.Time),
+		control:   make(chan controlFrame, muxControlQueue),
+		accept:    make(chan *MuxStream, MuxMaxStreams),
+		slots:     make(chan struct{}, MuxMaxStreams),
+		closed:    make(chan struct{}),
+	}
+	if client {
+		s.nextID = 1
+	}
+	s.lastRecv.Store(time.Now().UnixNano())
+	go s.recvLoop()
+	go s.controlLoop()
+	go s.keepaliveLoop()
+	return s
+}
+
+// Open opens the stream of the new request. It waits while there are too many requests in progress
+func (s *MuxSession) Open() (*MuxStream, error) {
+	timer := time.NewTimer(consts.TCPConnTimeout)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+	case <-s.closed:
+		return nil, s.Err()
+	case <-timer.C:
+		return nil, ErrTimeout
+	}
+	s.mu.Lock()
+	st := newMuxStream(s, s.nextID, true)
+	s.streams[st.id] = st
+	s.nextID += 2
+	s.mu.Unlock()
+	if err := s.writeFrame(st.id, frameOpen, nil); err != nil {
+		s.remove(st)
+		return nil, err
+	}
+	return st, nil
+}
+
+// Accept waits for the stream of the incoming request
+func (s *MuxSession) Accept() (*MuxStream, error) {
+	select {
+	case st := <-s.accept:
+		return st, nil
+	case <-s.closed:
+		return nil, s.Err()
+	}
+}
+
+// Ping sends the keepalive ping. The round-trip time is passed to onPing
+func (s *MuxSession) Ping() error {
+	s.mu.Lock()
+	s.pingID++
+	id := s.pingID
+	s.pings[id] = time.Now()
+	s.mu.Unlock()
+	return s.writeFrame(id, framePing, nil)
+}
+
+// Close closes the connection and all its streams
+func (s *MuxSession) Close() error {
+	s.closeWithError(ErrSessionClosed)
+	return nil
+}
+
+// IsClosed returns true if the connection is closed
+func (s *MuxSession) IsClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Err returns the reason of closing the connection
+func (s *MuxSession) Err() error {
+	select {
+	case <-s.closed:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// NumStreams returns the number of the requests in progress
+func (s *MuxSession) NumStreams() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+func (s *MuxSession) closeWithError(err error) {
+	s.closeOnce.Do(func() {
+		s.err = err
+		close(s.closed)
+		s.conn.Close()
+	})
+}
+
+func (s *MuxSession) writeFrame(id uint32, typ byte, data []byte) error {
+	frame := make([]byte, muxHeaderSize, muxHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(frame, id)
+	frame[4] = typ
+	binary.LittleEndian.PutUint32(frame[5:], uint32(len(data)))
+	frame = append(frame, data...)
+
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	if s.IsClosed() {
+		return s.Err()
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(consts.WriteTimeout * time.Second))
+	if _, err := s.conn.Write(frame); err != nil {
+		s.closeWithError(err)
+		return err
+	}
+	return nil
+}
+
+// controlFrame is the frame sent by the session in response to the frames of the peer
+type controlFrame struct {
+	id  uint32
+	typ byte
+}
+
+// queueControl queues the control frame. The receiving loop never waits for the writing,
+// so the connection is closed if the queue is full
+func (s *MuxSession) queueControl(id uint32, typ byte) {
+	select {
+	case s.control <- controlFrame{id: id, typ: typ}:
+	default:
+		log.WithFields(log.Fields{"type": consts.ProtocolError, "host": s.conn.RemoteAddr().String()}).Warning("control frames queue is full")
+		s.closeWithError(ErrControlQueue)
+	}
+}
+
+// queuePong queues the pong to the latest ping. The pings received while the pong is waiting
+// are answered by one pong
+func (s *MuxSession) queuePong(id uint32) {
+	s.mu.Lock()
+	s.pongID = id
+	queued := s.pongQueued
+	s.pongQueued = true
+	s.mu.Unlock()
+	if !queued {
+		s.queueControl(0, framePong)
+	}
+}
+
+// controlLoop writes the queued control frames
+func (s *MuxSession) controlLoop() {
+	for {
+		select {
+		case <-s.closed:
+			return
+		case f := <-s.control:
+			if f.typ == framePong {
+				s.mu.Lock()
+				f.id = s.pongID
+				s.pongQueued = false
+				s.mu.Unlock()
+			}
+			if err := s.writeFrame(f.id, f.typ, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *MuxSession) remove(st *MuxStream) {
+	s.mu.Lock()
+	_, ok := s.streams[st.id]
+	delete(s.streams, st.id)
+	s.mu.Unlock()
+	if ok && st.local {
+		<-s.slots
+	}
+}
+
+func (s *MuxSession) recvLoop() {
+	r := bufio.NewReader(s.conn)
+	header := make([]byte, muxHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			s.closeWithError(err)
+			return
+		}
+		s.lastRecv.Store(time.Now().UnixNano())
+		id := binary.LittleEndian.Uint32(header)
+		typ := header[4]
+		size := binary.LittleEndian.Uint32(header[5:])
+		if size > muxMaxData {
+			log.WithFields(log.Fields{"type": consts.ProtocolError, "size": size}).Error("too large multiplexed frame")
+			s.closeWithError(ErrMuxProtocol)
+			return
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			s.closeWithError(err)
+			return
+		}
+
+		switch typ {
+		case framePing:
+			s.queuePong(id)
+			continue
+		case framePong:
+			// the pong answers the ping with this id and all previous pings
+			s.mu.Lock()
+			sent, ok := s.pings[id]
+			for pid := range s.pings {
+				if pid <= id {
+					delete(s.pings, pid)
+				}
+			}
+			s.mu.Unlock()
+			if ok && s.onPing != nil {
+				s.onPing(time.Since(sent))
+			}
+			continue
+		case frameOpen:
+			s.mu.Lock()
+			_, exists := s.streams[id]
+			full := len(s.streams) >= MuxMaxStreams
+			var st *MuxStream
+			if !exists && !full && (id%2 == 1) != s.client {
+				st = newMuxStream(s, id, false)
+				s.streams[id] = st
+			}
+			s.mu.Unlock()
+			if st != nil {
+				select {
+				case s.accept <- st:
+					continue
+				default:
+					s.remove(st)
+				}
+			}
+			s.queueControl(id, frameReset)
+			continue
+		}
+
+		s.mu.Lock()
+		st := s.streams[id]
+		s.mu.Unlock()
+		if st == nil {
+			continue
+		}
+		if err := st.receive(typ, data); err != nil {
+			log.WithFields(log.Fields{"type": consts.ProtocolError, "error": err, "frame": typ}).Error("receiving multiplexed frame")
+			s.remove(st)
+			s.queueControl(id, frameReset)
+		}
+	}
+}
+
+func (s *MuxSession) keepaliveLoop() {
+	ticker := time.NewTicker(s.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, s.lastRecv.Load())) > 3*s.keepAlive {
+				s.closeWithError(ErrKeepAlive)
+				return
+			}
+			s.Ping()
+		}
+	}
+}
+
+// MuxStream is the stream of one request in the multiplexed connection
+type MuxStream struct {
+	session *MuxSession
+	id      uint32
+	local   bool
+
+	mu            sync.Mutex
+	buf           bytes.Buffer
+	unacked       uint32
+	sendWindow    uint32
+	closed        bool
+	remoteClosed  bool
+	reset         bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+	readNotify    chan struct{}
+	writeNotify   chan struct{}
+}
+
+func newMuxStream(s *MuxSession, id uint32, local bool) *MuxStream {
+	return &MuxStream{
+		session:     s,
+		id:          id,
+		local:       local,
+		sendWindow:  muxWindow,
+		readNotify:  make(chan struct{}, 1),
+		writeNotify: make(chan struct{}, 1),
+	}
+}
+
+// ID returns the id of the request
+func (st *MuxStream) ID() uint32 {
+	return st.id
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (st *MuxStream) receive(typ byte, data []byte) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	switch typ {
+	case frameData:
+		if st.buf.Len()+len(data) > muxWindow {
+			st.reset = true
+			notify(st.readNotify)
+			notify(st.writeNotify)
+			return ErrMuxProtocol
+		}
+		st.buf.Write(data)
+		notify(st.readNotify)
+	case frameWindow:
+		if len(data) != 4 {
+			return ErrMuxProtocol
+		}
+		st.sendWindow += binary.LittleEndian.Uint32(data)
+		notify(st.writeNotify)
+	case frameClose:
+		st.remoteClosed = true
+		notify(st.readNotify)
+		notify(st.writeNotify)
+	case frameReset:
+		st.reset = true
+		notify(st.readNotify)
+		notify(st.writeNotify)
+		go st.session.remove(st)
+	default:
+		return ErrMuxProtocol
+	}
+	return nil
+}
+
+// wait waits for the notification, the deadline or closing the connection
+func (st *MuxStream) wait(ch chan struct{}, deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return ErrTimeout
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-ch:
+	case <-st.session.closed:
+	case <-timeout:
+		return ErrTimeout
+	}
+	return nil
+}
+
+// Read reads the data of the stream. It returns io.EOF after the peer has closed the stream
+func (st *MuxStream) Read(b []byte) (int, error) {
+	for {
+		st.mu.Lock()
+		if st.buf.Len() > 0 {
+			n, _ := st.buf.Read(b)
+			st.unacked += uint32(n)
+			var credit uint32
+			if st.unacked >= muxWindow/2 && !st.remoteClosed {
+				credit, st.unacked = st.unacked, 0
+			}
+			st.mu.Unlock()
+			if credit > 0 {
+				data := make([]byte, 4)
+				binary.LittleEndian.PutUint32(data, credit)
+				st.session.writeFrame(st.id, frameWindow, data)
+			}
+			return n, nil
+		}
+		var err error
+		switch {
+		case st.closed:
+			err = ErrStreamClosed
+		case st.reset:
+			err = ErrStreamReset
+		case st.remoteClosed:
+			err = io.EOF
+		case st.session.IsClosed():
+			err = st.session.Err()
+		}
+		deadline := st.readDeadline
+		st.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+		if err = st.wait(st.readNotify, deadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write writes the data to the stream. It waits while the peer has not read the previous data
+func (st *MuxStream) Write(b []byte) (int, error) {
+	var written int
+	for len(b) > 0 {
+		st.mu.Lock()
+		var err error
+		switch {
+		case st.closed, st.remoteClosed:
+			err = ErrStreamClosed
+		case st.reset:
+			err = ErrStreamReset
+		case st.session.IsClosed():
+			err = st.session.Err()
+		}
+		if err != nil {
+			st.mu.Unlock()
+			return written, err
+		}
+		if st.sendWindow == 0 {
+			deadline := st.writeDeadline
+			st.mu.Unlock()
+			if err = st.wait(st.writeNotify, deadline); err != nil {
+				return written, err
+			}
+			continue
+		}
+		size := uint32(len(b))
+		if size > muxMaxData {
+			size = muxMaxData
+		}
+		if size > st.sendWindow {
+			size = st.sendWindow
+		}
+		st.sendWindow -= size
+		st.mu.Unlock()
+
+		if err = st.session.writeFrame(st.id, frameData, b[:size]); err != nil {
+			return written, err
+		}
+		written += int(size)
+		b = b[size:]
+	}
+	return written, nil
+}
+
+// Close closes the stream of the request. The connection stays open for the next requests
+func (st *MuxStream) Close() error {
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return nil
+	}
+	st.closed = true
+	reset := st.reset
+	notify(st.readNotify)
+	notify(st.writeNotify)
+	st.mu.Unlock()
+
+	st.session.remove(st)
+	if reset || st.session.IsClosed() {
+		return nil
+	}
+	return st.session.writeFrame(st.id, frameClose, nil)
+}
+
+func (st *MuxStream) LocalAddr() net.Addr {
+	return st.session.conn.LocalAddr()
+}
+
+func (st *MuxStream) RemoteAddr() net.Addr {
+	return st.session.conn.RemoteAddr()
+}
+
+func (st *MuxStream) SetDeadline(t time.Time) error {
+	st.SetReadDeadline(t)
+	return st.SetWriteDeadline(t)
+}
+
+func (st *MuxStream) SetReadDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline = t
+	st.mu.Unlock()
+	notify(st.readNotify)
+	return nil
+}
+
+func (st *MuxStream) SetWriteDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.writeDeadline = t
+	st.mu.Unlock()
+	notify(st.writeNotify)
+	return nil
+}
@@ -0,0 +1,179 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testMuxSessions(t *testing.T, keepAlive time.Duration) (*MuxSession, *MuxSession) {
+	c, s := net.Pipe()
+	client := newMuxSession(c, true, nil, keepAlive)
+	server := newMuxSession(s, false, nil, keepAlive)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+// echoServer responds with the request data
+func echoServer(server *MuxSession) {
+	for {
+		stream, err := server.Accept()
+		if err != nil {
+			return
+		}
+		go func(stream *MuxStream) {
+			defer stream.Close()
+			data, err := ReadSlice(stream)
+			if err != nil {
+				return
+			}
+			writeSlice(stream, data)
+		}(stream)
+	}
+}
+
+func TestMuxConcurrentRequests(t *testing.T) {
+	client, server := testMuxSessions(t, MuxKeepAlive)
+	go echoServer(server)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stream, err := client.Open()
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer stream.Close()
+			// the size is larger than the window, so the sender waits for the window updates
+			req := bytes.Repeat([]byte(fmt.Sprint(i)), 300000+i)
+			if err = writeSlice(stream, req); err != nil {
+				errs <- err
+				return
+			}
+			resp, err := ReadSlice(stream)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !bytes.Equal(req, resp) {
+				errs <- fmt.Errorf("wrong response of request %d", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		return client.NumStreams() == 0 && server.NumStreams() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMuxStreamClose(t *testing.T) {
+	client, server := testMuxSessions(t, MuxKeepAlive)
+	go func() {
+		stream, err := server.Accept()
+		if err != nil {
+			return
+		}
+		stream.Write([]byte("response"))
+		stream.Close()
+	}()
+
+	stream, err := client.Open()
+	require.NoError(t, err)
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	require.Equal(t, "response", string(data))
+	_, err = stream.Write([]byte("request"))
+	require.Equal(t, ErrStreamClosed, err)
+	require.NoError(t, stream.Close())
+
+	stream, err = client.Open()
+	require.NoError(t, err)
+	stream.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err = stream.Read(make([]byte, 1))
+	require.Equal(t, ErrTimeout, err)
+	require.False(t, client.IsClosed())
+}
+
+func TestMuxKeepAlive(t *testing.T) {
+	c, s := net.Pipe()
+	latency := make(chan time.Duration, 1)
+	client := newMuxSession(c, true, func(d time.Duration) {
+		select {
+		case latency <- d:
+		default:
+		}
+	}, 20*time.Millisecond)
+	defer client.Close()
+	server := newMuxSession(s, false, nil, time.Hour)
+	defer server.Close()
+
+	select {
+	case <-latency:
+	case <-time.After(time.Second):
+		t.Fatal("no keepalive pong")
+	}
+	time.Sleep(100 * time.Millisecond)
+	require.False(t, client.IsClosed())
+
+	// the peer reads the frames but does not respond
+	c, s = net.Pipe()
+	go io.Copy(io.Discard, s)
+	stalled := newMuxSession(c, true, nil, 20*time.Millisecond)
+	require.Eventually(t, stalled.IsClosed, time.Second, 10*time.Millisecond)
+	require.Equal(t, ErrKeepAlive, stalled.Err())
+	_, err := stalled.Open()
+	require.Error(t, err)
+}
+
+func TestMuxControlQueue(t *testing.T) {
+	c, s := net.Pipe()
+	server := newMuxSession(s, false, nil, time.Hour)
+	defer server.Close()
+	defer c.Close()
+
+	// the peer sends the frames but does not read the responses
+	frame := func(id uint32, typ byte) []byte {
+		header := make([]byte, muxHeaderSize)
+		binary.LittleEndian.PutUint32(header, id)
+		header[4] = typ
+		return header
+	}
+	for i := uint32(1); i <= 1000; i++ {
+		_, err := c.Write(frame(i, framePing))
+		require.NoError(t, err)
+	}
+	// the pongs are coalesced
+	require.False(t, server.IsClosed())
+	require.LessOrEqual(t, len(server.control), 1)
+
+	// the rejected streams are reset until the queue is full
+	for i := uint32(2); i <= 2*(muxControlQueue+1); i += 2 {
+		if _, err := c.Write(frame(i, frameOpen)); err != nil {
+			break
+		}
+	}
+	require.Eventually(t, server.IsClosed, time.Second, 10*time.Millisecond)
+	require.Equal(t, ErrControlQueue, server.Err())
+}
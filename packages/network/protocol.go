@@ -6,6 +6,7 @@ package network
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -29,6 +30,10 @@ const (
 	RequestTypeMaxBlock
 	RequestTypeVoting
 	RequestSyncMatchineState
+	RequestTypeGetPeers
+	RequestTypeSnapshotInfo
+	RequestTypeSnapshotChunk
+	RequestTypeGetHeaders
 
 	// BlocksPerRequest contains count of blocks per request
 	BlocksPerRequest int = 10
@@ -470,3 +475,199 @@ func (resp *BroadcastNodeConnInfoResponse) Read(r io.Reader) error {
 func (resp *BroadcastNodeConnInfoResponse) Write(w io.Writer) error {
 	return writeSlice(w, resp.Data)
 }
+
+// MaxPeersInResponse is the maximum number of the peers sent in GetPeersResponse
+const MaxPeersInResponse = 100
+
+// GetPeersRequest is the request of the known peers. Addr is the tcp address announced by
+// the requester, it is empty if the requester is not reachable
+type GetPeersRequest struct {
+	Addr string
+}
+
+func (req *GetPeersRequest) Read(r io.Reader) error {
+	slice, err := ReadSliceWithMaxSize(r, 255)
+	if err != nil {
+		log.WithError(err).Error("on reading GetPeersRequest")
+		return err
+	}
+
+	req.Addr = string(slice)
+	return nil
+}
+
+func (req *GetPeersRequest) Write(w io.Writer) error {
+	return writeSlice(w, []byte(req.Addr))
+}
+
+// PeerAddr is the address and the node public key of the peer
+type PeerAddr struct {
+	Addr      string `json:"addr"`
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+// GetPeersResponse contains the known peers
+type GetPeersResponse struct {
+	Peers []PeerAddr
+}
+
+func (resp *GetPeersResponse) Read(r io.Reader) error {
+	slice, err := ReadSliceWithMaxSize(r, MaxPeersInResponse*512)
+	if err != nil {
+		log.WithError(err).Error("on reading GetPeersResponse")
+		return err
+	}
+
+	if err = json.Unmarshal(slice, &resp.Peers); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("on unmarshalling peers")
+		return err
+	}
+	if len(resp.Peers) > MaxPeersInResponse {
+		resp.Peers = resp.Peers[:MaxPeersInResponse]
+	}
+	return nil
+}
+
+func (resp *GetPeersResponse) Write(w io.Writer) error {
+	data, err := json.Marshal(resp.Peers)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("on marshalling peers")
+		return err
+	}
+	return writeSlice(w, data)
+}
+
+const (
+	// SnapshotChunkSize is the maximum size of the part of the snapshot file in SnapshotChunkResponse
+	SnapshotChunkSize = 1 << 20
+	// maxSnapshotManifest is the maximum size of the manifest of the snapshot
+	maxSnapshotManifest = 4 << 20
+)
+
+// SnapshotInfoResponse contains the manifest of the latest snapshot of the node, it is empty if the
+// node has no snapshots
+type SnapshotInfoResponse struct {
+	Manifest []byte
+}
+
+func (resp *SnapshotInfoResponse) Read(r io.Reader) error {
+	slice, err := ReadSliceWithMaxSize(r, maxSnapshotManifest)
+	if err != nil {
+		log.WithError(err).Error("on reading SnapshotInfoResponse")
+		return err
+	}
+
+	resp.Manifest = slice
+	return nil
+}
+
+func (resp *SnapshotInfoResponse) Write(w io.Writer) error {
+	return writeSlice(w, resp.Manifest)
+}
+
+// SnapshotChunkRequest is the request of the part of the snapshot file at the block
+type SnapshotChunkRequest struct {
+	BlockID int64
+	Offset  int64
+}
+
+func (req *SnapshotChunkRequest) Read(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &req.BlockID); err != nil {
+		return err
+	}
+	return binary.Read(r, binary.LittleEndian, &req.Offset)
+}
+
+func (req *SnapshotChunkRequest) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, req.BlockID); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, req.Offset)
+}
+
+// SnapshotChunkResponse contains the part of the snapshot file, it is empty at the end of the file
+type SnapshotChunkResponse struct {
+	Data []byte
+}
+
+func (resp *SnapshotChunkResponse) Read(r io.Reader) error {
+	slice, err := ReadSliceWithMaxSize(r, SnapshotChunkSize)
+	if err != nil {
+		log.WithError(err).Error("on reading SnapshotChunkResponse")
+		return err
+	}
+
+	resp.Data = slice
+	return nil
+}
+
+func (resp *SnapshotChunkResponse) Write(w io.Writer) error {
+	return writeSlice(w, resp.Data)
+}
+
+const (
+	// HeadersPerRequest is the maximum number of the headers in GetHeadersResponse
+	HeadersPerRequest = 500
+	// maxHeaderSize is the maximum size of the header of the block
+	maxHeaderSize = 64 << 10
+)
+
+// GetHeadersRequest is the request of Count headers of the blocks starting from BlockID
+type GetHeadersRequest struct {
+	BlockID int64
+	Count   int64
+}
+
+func (req *GetHeadersRequest) Read(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &req.BlockID); err != nil {
+		return err
+	}
+	return binary.Read(r, binary.LittleEndian, &req.Count)
+}
+
+func (req *GetHeadersRequest) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, req.BlockID); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, req.Count)
+}
+
+// GetHeadersResponse contains the headers of the blocks in ascending order. The header is the binary block
+// without transactions
+type GetHeadersResponse struct {
+	Headers [][]byte
+}
+
+func (resp *GetHeadersResponse) Read(r io.Reader) error {
+	count, err := ReadInt(r)
+	if err != nil {
+		return err
+	}
+	if count < 0 || count > HeadersPerRequest {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "count": count}).Error("on reading GetHeadersResponse")
+		return ErrMaxSize
+	}
+
+	resp.Headers = make([][]byte, 0, count)
+	for i := int64(0); i < count; i++ {
+		slice, err := ReadSliceWithMaxSize(r, maxHeaderSize)
+		if err != nil {
+			log.WithError(err).Error("on reading GetHeadersResponse")
+			return err
+		}
+		resp.Headers = append(resp.Headers, slice)
+	}
+	return nil
+}
+
+func (resp *GetHeadersResponse) Write(w io.Writer) error {
+	if err := WriteInt(int64(len(resp.Headers)), w); err != nil {
+		return err
+	}
+	for _, header := range resp.Headers {
+		if err := writeSlice(w, header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -76,3 +76,32 @@ func TestBodyResponse(t *testing.T) {
 	fmt.Println(rt, result)
 
 }
+
+func TestSnapshotChunkRequest(t *testing.T) {
+	req := SnapshotChunkRequest{BlockID: 100, Offset: 3 << 20}
+	b := &bytes.Buffer{}
+
+	result := SnapshotChunkRequest{}
+	require.NoError(t, req.Write(b))
+	require.NoError(t, result.Read(b))
+	require.Equal(t, req, result)
+}
+
+func TestGetHeaders(t *testing.T) {
+	req := GetHeadersRequest{BlockID: 11, Count: HeadersPerRequest}
+	b := &bytes.Buffer{}
+
+	reqResult := GetHeadersRequest{}
+	require.NoError(t, req.Write(b))
+	require.NoError(t, reqResult.Read(b))
+	require.Equal(t, req, reqResult)
+
+	resp := GetHeadersResponse{Headers: [][]byte{[]byte("header1"), []byte("header2")}}
+	respResult := GetHeadersResponse{}
+	require.NoError(t, resp.Write(b))
+	require.NoError(t, respResult.Read(b))
+	require.Equal(t, resp, respResult)
+
+	require.NoError(t, WriteInt(HeadersPerRequest+1, b))
+	require.Equal(t, ErrMaxSize, respResult.Read(b))
+}
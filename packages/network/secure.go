@@ -0,0 +1,385 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package network
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+
+	"github.com/IBAX-io/go-ibax/packages/common/crypto"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// handshakeVersion is the latest version of the handshake protocol
+	handshakeVersion byte = 1
+	// minHandshakeVersion is the oldest supported version of the handshake protocol
+	minHandshakeVersion byte = 1
+	// maxHandshakeSlice is the maximum size of the key or the signature in the handshake
+	maxHandshakeSlice = 256
+	// maxFrameSize is the maximum size of the plain data in the encrypted frame
+	maxFrameSize = 64 << 10
+)
+
+// handshakeMagic starts the handshake. It is not a valid request type, so the server distinguishes
+// the handshake from the request of the old node which sends the request type first
+var handshakeMagic = []byte{0xfe, 0xfe}
+
+var (
+	ErrHandshakeVersion = errors.New("unsupported handshake version")
+	ErrLegacyPeer       = errors.New("peer does not support the handshake")
+	ErrUnknownNodeKey   = errors.New("unknown node public key")
+	ErrHandshakeSign    = errors.New("incorrect handshake signature")
+	ErrFrameSize        = errors.New("encrypted frame is too large")
+)
+
+// Peer is the remote side of the incoming connection
+type Peer interface {
+	RemoteAddr() net.Addr
+	RemotePublicKey() []byte
+	Trusted() bool
+}
+
+// LegacyPeer is the old node connected without the handshake. It has no key, so it is trusted
+// by the address only
+type LegacyPeer struct {
+	addr    net.Addr
+	trusted bool
+}
+
+// NewLegacyPeer returns the peer of the old node
+func NewLegacyPeer(addr net.Addr, trusted bool) *LegacyPeer {
+	return &LegacyPeer{addr: addr, trusted: trusted}
+}
+
+// RemoteAddr returns the address of the old node
+func (lp *LegacyPeer) RemoteAddr() net.Addr {
+	return lp.addr
+}
+
+// RemotePublicKey returns nil, the old node does not send its key
+func (lp *LegacyPeer) RemotePublicKey() []byte {
+	return nil
+}
+
+// Trusted returns true if the old node has the address of the honor node
+func (lp *LegacyPeer) Trusted() bool {
+	return lp.trusted
+}
+
+// SecureConn is the connection between the nodes authenticated by the node keys. All data is encrypted
+// with the session keys derived from the ephemeral keys of the handshake
+type SecureConn struct {
+	net.Conn
+	remoteKey []byte
+	trusted   bool
+	version   byte
+
+	rmu     sync.Mutex
+	rcipher cipher.AEAD
+	rnonce  uint64
+	rbuf    []byte
+
+	wmu     sync.Mutex
+	wcipher cipher.AEAD
+	wnonce  uint64
+}
+
+// RemotePublicKey returns the node public key of the remote side
+func (sc *SecureConn) RemotePublicKey() []byte {
+	return sc.remoteKey
+}
+
+// Trusted returns true if the remote side is the honor or candidate node
+func (sc *SecureConn) Trusted() bool {
+	return sc.trusted
+}
+
+// Version returns the negotiated version of the handshake protocol
+func (sc *SecureConn) Version() byte {
+	return sc.version
+}
+
+// prefixConn returns the peeked bytes before reading the connection
+type prefixConn struct {
+	net.Conn
+	prefix []byte
+}
+
+func (pc *prefixConn) Read(b []byte) (int, error) {
+	if len(pc.prefix) > 0 {
+		n := copy(b, pc.prefix)
+		pc.prefix = pc.prefix[n:]
+		return n, nil
+	}
+	return pc.Conn.Read(b)
+}
+
+// PeekHandshake reads the first bytes of the incoming connection and reports whether the peer starts
+// the handshake. The returned connection reads these bytes again, so the request of the old node
+// can be served as is
+func PeekHandshake(conn net.Conn) (net.Conn, bool, error) {
+	prefix := make([]byte, len(handshakeMagic))
+	if _, err := io.ReadFull(conn, prefix); err != nil {
+		return nil, false, err
+	}
+	return &prefixConn{Conn: conn, prefix: prefix}, bytes.Equal(prefix, handshakeMagic), nil
+}
+
+func nonce(size int, counter uint64) []byte {
+	out := make([]byte, size)
+	binary.LittleEndian.PutUint64(out, counter)
+	return out
+}
+
+// Read reads and decrypts the frames from the connection
+func (sc *SecureConn) Read(b []byte) (int, error) {
+	sc.rmu.Lock()
+	defer sc.rmu.Unlock()
+
+	if len(sc.rbuf) == 0 {
+		var size uint32
+		if err := binary.Read(sc.Conn, binary.LittleEndian, &size); err != nil {
+			return 0, err
+		}
+		if size > maxFrameSize+uint32(sc.rcipher.Overhead()) {
+			return 0, ErrFrameSize
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(sc.Conn, frame); err != nil {
+			return 0, err
+		}
+		data, err := sc.rcipher.Open(frame[:0], nonce(sc.rcipher.NonceSize(), sc.rnonce), frame, nil)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("decrypting frame")
+			return 0, err
+		}
+		sc.rnonce++
+		sc.rbuf = data
+	}
+	n := copy(b, sc.rbuf)
+	sc.rbuf = sc.rbuf[n:]
+	return n, nil
+}
+
+// Write encrypts the data and writes it to the connection by the frames
+func (sc *SecureConn) Write(b []byte) (int, error) {
+	sc.wmu.Lock()
+	defer sc.wmu.Unlock()
+
+	var written int
+	for len(b) > 0 {
+		size := len(b)
+		if size > maxFrameSize {
+			size = maxFrameSize
+		}
+		frame := make([]byte, 4, 4+size+sc.wcipher.Overhead())
+		frame = sc.wcipher.Seal(frame, nonce(sc.wcipher.NonceSize(), sc.wnonce), b[:size], nil)
+		binary.LittleEndian.PutUint32(frame, uint32(len(frame)-4))
+		if _, err := sc.Conn.Write(frame); err != nil {
+			return written, err
+		}
+		sc.wnonce++
+		written += size
+		b = b[size:]
+	}
+	return written, nil
+}
+
+// SecureClient performs the handshake on the outgoing connection with the key of this node.
+// If expectedKey is not empty the peer must have this key, otherwise any authenticated key is accepted
+func SecureClient(conn net.Conn, expectedKey []byte) (*SecureConn, error) {
+	accept := func(key []byte) bool {
+		return len(expectedKey) == 0 || bytes.Equal(crypto.CutPub(key), crypto.CutPub(expectedKey))
+	}
+	sc, err := handshake(conn, true, syspar.GetNodePrivKey(), syspar.GetNodePubKey(), accept)
+	if err != nil {
+		return nil, err
+	}
+	sc.trusted = syspar.IsKnownNodeKey(sc.remoteKey)
+	return sc, nil
+}
+
+// SecureServer performs the handshake on the incoming connection with the key of this node.
+// The connections of the nodes with unknown keys are rejected if the node is not public
+func SecureServer(conn net.Conn, public bool) (*SecureConn, error) {
+	var trusted bool
+	accept := func(key []byte) bool {
+		trusted = syspar.IsKnownNodeKey(key)
+		return trusted || public
+	}
+	sc, err := handshake(conn, false, syspar.GetNodePrivKey(), syspar.GetNodePubKey(), accept)
+	if err != nil {
+		return nil, err
+	}
+	sc.trusted = trusted
+	return sc, nil
+}
+
+// handshakeData returns the data signed by the side of the handshake
+func handshakeData(initiator bool, clientEph, serverEph []byte) []byte {
+	role := []byte(`ibax-server`)
+	if initiator {
+		role = []byte(`ibax-client`)
+	}
+	return bytes.Join([][]byte{role, clientEph, serverEph}, nil)
+}
+
+func writeHandshakeKey(w io.Writer, privKey, pubKey []byte, initiator bool, clientEph, serverEph []byte) error {
+	sign, err := crypto.Sign(privKey, handshakeData(initiator, clientEph, serverEph))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("signing handshake")
+		return err
+	}
+	if err = writeSlice(w, pubKey); err != nil {
+		return err
+	}
+	return writeSlice(w, sign)
+}
+
+func readHandshakeKey(r io.Reader, accept func([]byte) bool, initiator bool, clientEph, serverEph []byte) ([]byte, error) {
+	pubKey, err := ReadSliceWithMaxSize(r, maxHandshakeSlice)
+	if err != nil {
+		return nil, err
+	}
+	sign, err := ReadSliceWithMaxSize(r, maxHandshakeSlice)
+	if err != nil {
+		return nil, err
+	}
+	if !accept(pubKey) {
+		log.WithFields(log.Fields{"type": consts.AccessDenied, "key": crypto.PubToHex(pubKey)}).Warning("unknown node key")
+		return nil, ErrUnknownNodeKey
+	}
+	ok, err := crypto.Verify(pubKey, handshakeData(initiator, clientEph, serverEph), sign)
+	if err != nil || !ok {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err, "key": crypto.PubToHex(pubKey)}).Warning("checking handshake signature")
+		return nil, ErrHandshakeSign
+	}
+	return pubKey, nil
+}
+
+// handshake authenticates both sides by the signatures of the node keys over the ephemeral keys and
+// derives the session keys from the ephemeral keys. The client sends the magic, its latest version and
+// its ephemeral key, the server responds with the negotiated version, its ephemeral key and the signed
+// node key, then the client sends its signed node key
+func handshake(conn net.Conn, initiator bool, privKey, pubKey []byte, accept func([]byte) bool) (*SecureConn, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		clientEph, serverEph, remoteKey []byte
+		version                         byte
+		remote                          = make([]byte, 1+len(eph.PublicKey().Bytes()))
+	)
+	if initiator {
+		clientEph = eph.PublicKey().Bytes()
+		hello := append(append(append([]byte{}, handshakeMagic...), handshakeVersion), clientEph...)
+		if _, err = conn.Write(hello); err != nil {
+			if isConnReset(err) {
+				return nil, ErrLegacyPeer
+			}
+			return nil, err
+		}
+		if _, err = io.ReadFull(conn, remote); err != nil {
+			if err == io.EOF || isConnReset(err) {
+				// the old node closes the connection after the unknown request type
+				return nil, ErrLegacyPeer
+			}
+			return nil, err
+		}
+		version = remote[0]
+		if version < minHandshakeVersion || version > handshakeVersion {
+			return nil, ErrHandshakeVersion
+		}
+		serverEph = remote[1:]
+		if remoteKey, err = readHandshakeKey(conn, accept, false, clientEph, serverEph); err != nil {
+			return nil, err
+		}
+		if err = writeHandshakeKey(conn, privKey, pubKey, true, clientEph, serverEph); err != nil {
+			return nil, err
+		}
+	} else {
+		magic := make([]byte, len(handshakeMagic))
+		if _, err = io.ReadFull(conn, magic); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(magic, handshakeMagic) {
+			return nil, ErrLegacyPeer
+		}
+		if _, err = io.ReadFull(conn, remote); err != nil {
+			return nil, err
+		}
+		// the server answers with the latest version supported by both sides
+		version = remote[0]
+		if version > handshakeVersion {
+			version = handshakeVersion
+		}
+		if version < minHandshakeVersion {
+			return nil, ErrHandshakeVersion
+		}
+		clientEph = remote[1:]
+		serverEph = eph.PublicKey().Bytes()
+		if _, err = conn.Write(append([]byte{version}, serverEph...)); err != nil {
+			return nil, err
+		}
+		if err = writeHandshakeKey(conn, privKey, pubKey, false, clientEph, serverEph); err != nil {
+			return nil, err
+		}
+		if remoteKey, err = readHandshakeKey(conn, accept, true, clientEph, serverEph); err != nil {
+			return nil, err
+		}
+	}
+
+	peerEph, err := ecdh.X25519().NewPublicKey(remote[1:])
+	if err != nil {
+		return nil, err
+	}
+	secret, err := eph.ECDH(peerEph)
+	if err != nil {
+		return nil, err
+	}
+	toServer, err := sessionCipher(secret, `client`, clientEph, serverEph)
+	if err != nil {
+		return nil, err
+	}
+	toClient, err := sessionCipher(secret, `server`, clientEph, serverEph)
+	if err != nil {
+		return nil, err
+	}
+	sc := &SecureConn{Conn: conn, remoteKey: remoteKey, version: version, rcipher: toServer, wcipher: toClient}
+	if initiator {
+		sc.rcipher, sc.wcipher = toClient, toServer
+	}
+	return sc, nil
+}
+
+func isConnReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// sessionCipher returns the cipher of the data sent by the side
+func sessionCipher(secret []byte, side string, clientEph, serverEph []byte) (cipher.AEAD, error) {
+	key := sha256.Sum256(bytes.Join([][]byte{secret, clientEph, serverEph, []byte(side)}, nil))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("creating session cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,185 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package network
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/IBAX-io/go-ibax/packages/common/crypto"
+
+	"github.com/stretchr/testify/require"
+)
+
+type handshakeResult struct {
+	conn *SecureConn
+	err  error
+}
+
+func testHandshake(t *testing.T, clientKnown, serverKnown func([]byte) bool) (*SecureConn, *SecureConn, error, error) {
+	crypto.InitAsymAlgo(crypto.AsymAlgo_ECC_Secp256k1.String())
+	clientPriv, clientPub, err := crypto.GenKeyPair()
+	require.NoError(t, err)
+	serverPriv, serverPub, err := crypto.GenKeyPair()
+	require.NoError(t, err)
+	if clientKnown == nil {
+		clientKnown = func(key []byte) bool { return bytes.Equal(key, serverPub) }
+	}
+	if serverKnown == nil {
+		serverKnown = func(key []byte) bool { return bytes.Equal(key, clientPub) }
+	}
+
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	ch := make(chan handshakeResult, 1)
+	go func() {
+		conn, err := handshake(server, false, serverPriv, serverPub, serverKnown)
+		if err != nil {
+			server.Close()
+		}
+		ch <- handshakeResult{conn, err}
+	}()
+	conn, err := handshake(client, true, clientPriv, clientPub, clientKnown)
+	if err != nil {
+		client.Close()
+	}
+	res := <-ch
+	if conn != nil {
+		require.Equal(t, serverPub, conn.RemotePublicKey())
+	}
+	if res.conn != nil {
+		require.Equal(t, clientPub, res.conn.RemotePublicKey())
+	}
+	return conn, res.conn, err, res.err
+}
+
+func TestSecureConn(t *testing.T) {
+	client, server, errClient, errServer := testHandshake(t, nil, nil)
+	require.NoError(t, errClient)
+	require.NoError(t, errServer)
+
+	big := []byte(strings.Repeat("block body ", 20000))
+	go func() {
+		req := &GetBodiesRequest{BlockID: 10, ReverseOrder: true}
+		req.Write(client)
+		(&GetBodyResponse{Data: big}).Write(client)
+	}()
+	req := &GetBodiesRequest{}
+	require.NoError(t, req.Read(server))
+	require.Equal(t, GetBodiesRequest{BlockID: 10, ReverseOrder: true}, *req)
+	resp := &GetBodyResponse{}
+	require.NoError(t, resp.Read(server))
+	require.Equal(t, big, resp.Data)
+
+	go (&MaxBlockResponse{BlockID: 77}).Write(server)
+	max := &MaxBlockResponse{}
+	require.NoError(t, max.Read(client))
+	require.Equal(t, int64(77), max.BlockID)
+}
+
+func TestSecureConnUnknownKey(t *testing.T) {
+	unknown := func([]byte) bool { return false }
+
+	client, _, errClient, errServer := testHandshake(t, nil, unknown)
+	require.NoError(t, errClient)
+	require.Equal(t, ErrUnknownNodeKey, errServer)
+	_, err := client.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	_, _, errClient, errServer = testHandshake(t, unknown, nil)
+	require.Equal(t, ErrUnknownNodeKey, errClient)
+	require.Error(t, errServer)
+}
+
+func TestSecureConnTampered(t *testing.T) {
+	client, server, errClient, errServer := testHandshake(t, nil, nil)
+	require.NoError(t, errClient)
+	require.NoError(t, errServer)
+
+	go func() {
+		var frame bytes.Buffer
+		plain := &SecureConn{Conn: client, wcipher: client.wcipher}
+		plain.Conn = &writerConn{Conn: client, w: &frame}
+		plain.Write([]byte("request"))
+		data := frame.Bytes()
+		data[len(data)-1] ^= 1
+		client.Conn.Write(data)
+	}()
+	_, err := io.ReadFull(server, make([]byte, 7))
+	require.Error(t, err)
+}
+
+type writerConn struct {
+	net.Conn
+	w io.Writer
+}
+
+func (wc *writerConn) Write(b []byte) (int, error) {
+	return wc.w.Write(b)
+}
+
+func TestSecureConnLegacy(t *testing.T) {
+	crypto.InitAsymAlgo(crypto.AsymAlgo_ECC_Secp256k1.String())
+	priv, pub, err := crypto.GenKeyPair()
+	require.NoError(t, err)
+	accept := func([]byte) bool { return true }
+
+	// the old node sends the request type without the handshake
+	client, server := net.Pipe()
+	go (&RequestType{Type: RequestTypeMaxBlock}).Write(client)
+	conn, ok, err := PeekHandshake(server)
+	require.NoError(t, err)
+	require.False(t, ok)
+	rt := &RequestType{}
+	require.NoError(t, rt.Read(conn))
+	require.Equal(t, RequestTypeMaxBlock, rt.Type)
+	client.Close()
+	server.Close()
+
+	// the old node closes the connection after the unknown request type
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			(&RequestType{}).Read(conn)
+			conn.Close()
+		}
+	}()
+	client, err = net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	_, err = handshake(client, true, priv, pub, accept)
+	require.Equal(t, ErrLegacyPeer, err)
+	client.Close()
+
+	// the new node is detected by the magic and negotiates the version
+	client, server = net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	ch := make(chan handshakeResult, 1)
+	go func() {
+		conn, ok, err := PeekHandshake(server)
+		if err == nil && !ok {
+			err = ErrLegacyPeer
+		}
+		var sc *SecureConn
+		if err == nil {
+			sc, err = handshake(conn, false, priv, pub, accept)
+		}
+		ch <- handshakeResult{sc, err}
+	}()
+	sc, err := handshake(client, true, priv, pub, accept)
+	require.NoError(t, err)
+	res := <-ch
+	require.NoError(t, res.err)
+	require.Equal(t, handshakeVersion, sc.Version())
+	require.Equal(t, handshakeVersion, res.conn.Version())
+}
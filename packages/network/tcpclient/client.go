@@ -11,7 +11,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/IBAX-io/go-ibax/packages/common/crypto"
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/network/addrbook"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -33,6 +38,7 @@ func NormalizeHostAddress(address string, defaultPort int64) (string, error) {
 	return address, nil
 }
 
+// newConnection opens the stream of the request in the persistent connection to the host
 func newConnection(addr string) (net.Conn, error) {
 	if len(addr) == 0 {
 		return nil, wrongAddressError
@@ -44,9 +50,8 @@ func newConnection(addr string) (net.Conn, error) {
 		return nil, err
 	}
 
-	conn, err := net.DialTimeout("tcp", host, consts.TCPConnTimeout)
+	conn, err := peers.open(host)
 	if err != nil {
-		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": host}).Debug("dialing tcp")
 		return nil, err
 	}
 
@@ -54,3 +59,40 @@ func newConnection(addr string) (net.Conn, error) {
 	conn.SetWriteDeadline(time.Now().Add(consts.WriteTimeout * time.Second))
 	return conn, nil
 }
+
+// dial connects to the host and performs the handshake
+func dial(host string) (*network.SecureConn, error) {
+	conn, err := net.DialTimeout("tcp", host, consts.TCPConnTimeout)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": host}).Debug("dialing tcp")
+		addrbook.Get().Bad(host, addrbook.PenaltyFail)
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(consts.ReadTimeout * time.Second))
+	// the honor and candidate nodes must have their own keys, other peers are only authenticated
+	secure, err := network.SecureClient(conn, syspar.GetNodeKeyByHost(host))
+	if err == network.ErrLegacyPeer && conf.Config.Peers.Legacy {
+		conn.Close()
+		return nil, err
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": host}).Error("on node handshake")
+		addrbook.Get().Bad(host, addrbook.PenaltyFail)
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	addrbook.Get().Good(host, crypto.PubToHex(secure.RemotePublicKey()))
+	return secure, nil
+}
+
+// dialLegacy connects to the old node without the handshake. Such node serves one request per connection
+func dialLegacy(host string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", host, consts.TCPConnTimeout)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": host}).Debug("dialing tcp")
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,40 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpclient
+
+import (
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GetPeers requests the known peers from the host. addr is the announced address of this node
+func GetPeers(host, addr string) ([]network.PeerAddr, error) {
+	conn, err := newConnection(host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rt := &network.RequestType{Type: network.RequestTypeGetPeers}
+	if err = rt.Write(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending request type")
+		return nil, err
+	}
+
+	req := &network.GetPeersRequest{Addr: addr}
+	if err = req.Write(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending get peers request")
+		return nil, err
+	}
+
+	resp := &network.GetPeersResponse{}
+	if err = resp.Read(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("receiving peers")
+		return nil, err
+	}
+	return resp.Peers, nil
+}
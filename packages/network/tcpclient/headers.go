@@ -0,0 +1,19 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpclient
+
+import (
+	"github.com/IBAX-io/go-ibax/packages/network"
+)
+
+// GetBlocksHeaders requests count headers of the blocks starting from blockID from the host
+func GetBlocksHeaders(host string, blockID, count int64) ([][]byte, error) {
+	resp := &network.GetHeadersResponse{}
+	req := &network.GetHeadersRequest{BlockID: blockID, Count: count}
+	if err := sendRequest(host, network.RequestTypeGetHeaders, req, resp); err != nil {
+		return nil, err
+	}
+	return resp.Headers, nil
+}
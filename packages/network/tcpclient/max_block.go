@@ -24,6 +24,35 @@ func HostWithMaxBlock(ctx context.Context, hosts []string) (bestHost string, max
 	return hostWithMaxBlock(ctx, hosts)
 }
 
+// HostsMaxBlock returns the max block of each available host
+func HostsMaxBlock(ctx context.Context, hosts []string) map[string]int64 {
+	var (
+		wg     sync.WaitGroup
+		mutex  sync.Mutex
+		blocks = make(map[string]int64, len(hosts))
+	)
+	for _, h := range hosts {
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			blockID, err := getMaxBlock(host)
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			blocks[host] = blockID
+			mutex.Unlock()
+		}(h)
+	}
+	wg.Wait()
+
+	return blocks
+}
+
 func GetMaxBlockID(host string) (blockID int64, err error) {
 	return getMaxBlock(host)
 }
@@ -0,0 +1,312 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpclient
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// reconnectMin is the delay before the first reconnect to the failed peer
+	reconnectMin = time.Second
+	// reconnectMax is the maximum delay between reconnects
+	reconnectMax = time.Minute
+	// legacyRecheck is the delay before the handshake with the old peer is tried again
+	legacyRecheck = 10 * time.Minute
+)
+
+var ErrPeerBackoff = errors.New("peer is unavailable, waiting for reconnect")
+
+// PeerStats are the statistics of the connection to the peer
+type PeerStats struct {
+	Host      string        `json:"host"`
+	Connected bool          `json:"connected"`
+	Latency   time.Duration `json:"latency"`
+	Requests  int64         `json:"requests"`
+	Errors    int64         `json:"errors"`
+	Connects  int64         `json:"connects"`
+	LastError string        `json:"last_error,omitempty"`
+	Legacy    bool          `json:"legacy,omitempty"`
+	RetryAt   time.Time     `json:"retry_at,omitempty"`
+}
+
+type peer struct {
+	host     string
+	manager  *peerManager
+	mu       sync.Mutex
+	session  *network.MuxSession
+	failures int
+	legacy   time.Time
+	stats    PeerStats
+}
+
+// peerManager keeps the long-lived connections to the nodes. The requests to the node
+// are multiplexed over one connection. The old node without the handshake gets one plain
+// connection per request in the legacy mode
+type peerManager struct {
+	mu         sync.Mutex
+	peers      map[string]*peer
+	reporter   func(PeerStats)
+	dial       func(host string) (net.Conn, error)
+	dialLegacy func(host string) (net.Conn, error)
+}
+
+var peers = func() *peerManager {
+	pm := newPeerManager(func(host string) (net.Conn, error) {
+		return dial(host)
+	})
+	pm.dialLegacy = dialLegacy
+	return pm
+}()
+
+func newPeerManager(dial func(host string) (net.Conn, error)) *peerManager {
+	return &peerManager{peers: make(map[string]*peer), dial: dial}
+}
+
+// SetStatsReporter sets the function receiving the statistics of the peer after every change
+func SetStatsReporter(reporter func(PeerStats)) {
+	peers.mu.Lock()
+	peers.reporter = reporter
+	peers.mu.Unlock()
+}
+
+// GetPeersStats returns the statistics of all peers
+func GetPeersStats() []PeerStats {
+	return peers.stats()
+}
+
+// ClosePeers closes all connections to the peers
+func ClosePeers() {
+	peers.close()
+}
+
+func (pm *peerManager) get(host string) *peer {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	p, ok := pm.peers[host]
+	if !ok {
+		p = &peer{host: host, manager: pm, stats: PeerStats{Host: host}}
+		pm.peers[host] = p
+	}
+	return p
+}
+
+func (pm *peerManager) report(stats PeerStats) {
+	pm.mu.Lock()
+	reporter := pm.reporter
+	pm.mu.Unlock()
+	if reporter != nil {
+		reporter(stats)
+	}
+}
+
+func (pm *peerManager) stats() []PeerStats {
+	pm.mu.Lock()
+	list := make([]*peer, 0, len(pm.peers))
+	for _, p := range pm.peers {
+		list = append(list, p)
+	}
+	pm.mu.Unlock()
+
+	result := make([]PeerStats, 0, len(list))
+	for _, p := range list {
+		p.mu.Lock()
+		result = append(result, p.stats)
+		p.mu.Unlock()
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Host < result[j].Host })
+	return result
+}
+
+func (pm *peerManager) close() {
+	pm.mu.Lock()
+	list := pm.peers
+	pm.peers = make(map[string]*peer)
+	pm.mu.Unlock()
+	for _, p := range list {
+		p.mu.Lock()
+		if p.session != nil {
+			p.session.Close()
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (pm *peerManager) legacyEnabled() bool {
+	return pm.dialLegacy != nil && conf.Config.Peers.Legacy
+}
+
+// open opens the stream of the request to the host. It reconnects if the connection is lost
+func (pm *peerManager) open(host string) (net.Conn, error) {
+	p := pm.get(host)
+	if p.isLegacy() && pm.legacyEnabled() {
+		return p.openLegacy()
+	}
+	session, err := p.connect()
+	if errors.Is(err, network.ErrLegacyPeer) && pm.legacyEnabled() {
+		return p.openLegacy()
+	}
+	if err != nil {
+		return nil, err
+	}
+	stream, err := session.Open()
+	if err != nil {
+		p.fail(err)
+		return nil, err
+	}
+	p.update(func(stats *PeerStats) {
+		stats.Requests++
+	})
+	return &peerConn{MuxStream: stream, peer: p}, nil
+}
+
+func backoff(failures int) time.Duration {
+	delay := reconnectMax
+	if failures < 7 {
+		delay = reconnectMin << (failures - 1)
+	}
+	if delay > reconnectMax {
+		delay = reconnectMax
+	}
+	return delay
+}
+
+// connect returns the connection to the peer. The failed peer is reconnected with the exponential backoff
+func (p *peer) connect() (*network.MuxSession, error) {
+	p.mu.Lock()
+	if p.session != nil && !p.session.IsClosed() {
+		defer p.mu.Unlock()
+		return p.session, nil
+	}
+	if p.session != nil {
+		p.session = nil
+		p.stats.Connected = false
+	}
+	if time.Now().Before(p.stats.RetryAt) {
+		p.mu.Unlock()
+		return nil, ErrPeerBackoff
+	}
+	p.mu.Unlock()
+
+	conn, err := p.manager.dial(p.host)
+
+	p.mu.Lock()
+	if errors.Is(err, network.ErrLegacyPeer) && p.manager.legacyEnabled() {
+		p.legacy = time.Now().Add(legacyRecheck)
+		p.stats.Legacy = true
+		stats := p.stats
+		p.mu.Unlock()
+		p.manager.report(stats)
+		log.WithFields(log.Fields{"type": consts.NetworkError, "host": p.host}).Warning("peer does not support the handshake")
+		return nil, err
+	}
+	if err != nil {
+		p.failures++
+		p.stats.Errors++
+		p.stats.LastError = err.Error()
+		p.stats.RetryAt = time.Now().Add(backoff(p.failures))
+		stats := p.stats
+		p.mu.Unlock()
+		p.manager.report(stats)
+		return nil, err
+	}
+	if p.session != nil && !p.session.IsClosed() {
+		// the concurrent request has already connected
+		defer p.mu.Unlock()
+		conn.Close()
+		return p.session, nil
+	}
+	p.failures = 0
+	p.session = network.NewMuxSession(conn, true, p.latency)
+	p.stats.Connected = true
+	p.stats.Legacy = false
+	p.stats.Connects++
+	p.stats.RetryAt = time.Time{}
+	stats := p.stats
+	p.mu.Unlock()
+	p.manager.report(stats)
+	log.WithFields(log.Fields{"type": consts.NetworkError, "host": p.host}).Debug("connected to peer")
+	return p.session, nil
+}
+
+func (p *peer) isLegacy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Now().Before(p.legacy)
+}
+
+// openLegacy opens the plain connection of one request to the old node
+func (p *peer) openLegacy() (net.Conn, error) {
+	conn, err := p.manager.dialLegacy(p.host)
+	if err != nil {
+		p.fail(err)
+		return nil, err
+	}
+	p.update(func(stats *PeerStats) {
+		stats.Requests++
+	})
+	return conn, nil
+}
+
+func (p *peer) update(f func(stats *PeerStats)) {
+	p.mu.Lock()
+	f(&p.stats)
+	stats := p.stats
+	p.mu.Unlock()
+	p.manager.report(stats)
+}
+
+func (p *peer) latency(d time.Duration) {
+	p.update(func(stats *PeerStats) {
+		stats.Latency = d
+	})
+}
+
+func (p *peer) fail(err error) {
+	p.update(func(stats *PeerStats) {
+		stats.Errors++
+		stats.LastError = err.Error()
+		if p.session != nil && p.session.IsClosed() {
+			stats.Connected = false
+		}
+	})
+}
+
+// peerConn is the stream of the request which counts the errors of the peer
+type peerConn struct {
+	*network.MuxStream
+	peer   *peer
+	failed atomic.Bool
+}
+
+func (pc *peerConn) check(err error) {
+	if err != nil && err != io.EOF && pc.failed.CompareAndSwap(false, true) {
+		pc.peer.fail(err)
+	}
+}
+
+func (pc *peerConn) Read(b []byte) (int, error) {
+	n, err := pc.MuxStream.Read(b)
+	pc.check(err)
+	return n, err
+}
+
+func (pc *peerConn) Write(b []byte) (int, error) {
+	n, err := pc.MuxStream.Write(b)
+	pc.check(err)
+	return n, err
+}
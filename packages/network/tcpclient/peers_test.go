@@ -0,0 +1,149 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpclient
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, time.Second, backoff(1))
+	require.Equal(t, 8*time.Second, backoff(4))
+	require.Equal(t, time.Minute, backoff(7))
+	require.Equal(t, time.Minute, backoff(100))
+}
+
+func TestPeerManager(t *testing.T) {
+	var (
+		dials   int
+		fail    bool
+		servers []*network.MuxSession
+	)
+	pm := newPeerManager(func(host string) (net.Conn, error) {
+		dials++
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		c, s := net.Pipe()
+		server := network.NewMuxSession(s, false, nil)
+		servers = append(servers, server)
+		go func() {
+			for {
+				stream, err := server.Accept()
+				if err != nil {
+					return
+				}
+				(&network.MaxBlockResponse{BlockID: 10}).Write(stream)
+				stream.Close()
+			}
+		}()
+		return c, nil
+	})
+	var reported []PeerStats
+	pm.reporter = func(stats PeerStats) {
+		reported = append(reported, stats)
+	}
+	defer pm.close()
+
+	request := func() error {
+		conn, err := pm.open("127.0.0.1:7078")
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		resp := &network.MaxBlockResponse{}
+		if err = resp.Read(conn); err != nil {
+			return err
+		}
+		require.Equal(t, int64(10), resp.BlockID)
+		return nil
+	}
+
+	// the requests share one connection
+	require.NoError(t, request())
+	require.NoError(t, request())
+	require.Equal(t, 1, dials)
+
+	// the connection is lost and the next dial fails
+	servers[0].Close()
+	require.Eventually(t, func() bool {
+		return pm.get("127.0.0.1:7078").session.IsClosed()
+	}, time.Second, 10*time.Millisecond)
+	fail = true
+	require.Error(t, request())
+	require.Equal(t, 2, dials)
+	// the peer is waiting for reconnect
+	require.Equal(t, ErrPeerBackoff, request())
+	require.Equal(t, 2, dials)
+
+	stats := pm.stats()
+	require.Len(t, stats, 1)
+	require.False(t, stats[0].Connected)
+	require.Equal(t, int64(2), stats[0].Requests)
+	require.Equal(t, int64(1), stats[0].Errors)
+	require.False(t, stats[0].RetryAt.IsZero())
+	require.Equal(t, stats[0], reported[len(reported)-1])
+
+	// reconnect after the backoff
+	fail = false
+	p := pm.get("127.0.0.1:7078")
+	p.stats.RetryAt = time.Now()
+	require.NoError(t, request())
+	require.Equal(t, 3, dials)
+	stats = pm.stats()
+	require.True(t, stats[0].Connected)
+	require.Equal(t, int64(2), stats[0].Connects)
+}
+
+func TestPeerManagerLegacy(t *testing.T) {
+	conf.Config.Peers.Legacy = true
+	defer func() { conf.Config.Peers.Legacy = false }()
+
+	var dials, legacyDials int
+	pm := newPeerManager(func(host string) (net.Conn, error) {
+		dials++
+		return nil, network.ErrLegacyPeer
+	})
+	pm.dialLegacy = func(host string) (net.Conn, error) {
+		legacyDials++
+		c, s := net.Pipe()
+		go func() {
+			(&network.MaxBlockResponse{BlockID: 5}).Write(s)
+			s.Close()
+		}()
+		return c, nil
+	}
+	defer pm.close()
+
+	// the old peer gets the plain connection per request without the handshake
+	for i := 0; i < 2; i++ {
+		conn, err := pm.open("127.0.0.1:7078")
+		require.NoError(t, err)
+		resp := &network.MaxBlockResponse{}
+		require.NoError(t, resp.Read(conn))
+		require.Equal(t, int64(5), resp.BlockID)
+		conn.Close()
+	}
+	require.Equal(t, 1, dials)
+	require.Equal(t, 2, legacyDials)
+	stats := pm.stats()
+	require.True(t, stats[0].Legacy)
+	require.Equal(t, int64(2), stats[0].Requests)
+	require.Equal(t, int64(0), stats[0].Errors)
+
+	// the old peer is an error if the legacy mode is off
+	conf.Config.Peers.Legacy = false
+	_, err := pm.open("127.0.0.1:7078")
+	require.Error(t, err)
+	require.Equal(t, 2, legacyDials)
+}
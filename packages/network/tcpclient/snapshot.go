@@ -0,0 +1,57 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpclient
+
+import (
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GetSnapshotInfo requests the manifest of the latest snapshot of the host
+func GetSnapshotInfo(host string) ([]byte, error) {
+	resp := &network.SnapshotInfoResponse{}
+	if err := sendRequest(host, network.RequestTypeSnapshotInfo, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp.Manifest, nil
+}
+
+// GetSnapshotChunk requests the part of the snapshot file at the block from the host
+func GetSnapshotChunk(host string, blockID, offset int64) ([]byte, error) {
+	resp := &network.SnapshotChunkResponse{}
+	req := &network.SnapshotChunkRequest{BlockID: blockID, Offset: offset}
+	if err := sendRequest(host, network.RequestTypeSnapshotChunk, req, resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// sendRequest sends the request of the type to the host and reads the response
+func sendRequest(host string, reqType network.ReqTypesFlag, req, resp network.SelfReaderWriter) error {
+	conn, err := newConnection(host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rt := &network.RequestType{Type: reqType}
+	if err = rt.Write(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending request type")
+		return err
+	}
+	if req != nil {
+		if err = req.Write(conn); err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending request")
+			return err
+		}
+	}
+	if err = resp.Read(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("receiving response")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpserver
+
+import (
+	"net"
+
+	"github.com/IBAX-io/go-ibax/packages/common/crypto"
+	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/network/addrbook"
+)
+
+// GetPeers returns the best peers of the address book. The announced address of the requester is
+// added to the address book if it has the same host as the connection
+func GetPeers(req *network.GetPeersRequest, peer network.Peer) (*network.GetPeersResponse, error) {
+	book := addrbook.Get()
+	var self string
+	if addr, err := addrbook.NormalizeAddr(req.Addr); err == nil {
+		host, _, _ := net.SplitHostPort(addr)
+		remote, _, _ := net.SplitHostPort(peer.RemoteAddr().String())
+		if host == remote {
+			self = addr
+			book.Add(addr, crypto.PubToHex(peer.RemotePublicKey()), addrbook.SourceInbound)
+		}
+	}
+
+	resp := &network.GetPeersResponse{Peers: make([]network.PeerAddr, 0)}
+	for _, e := range book.List() {
+		if len(resp.Peers) >= network.MaxPeersInResponse {
+			break
+		}
+		if e.Addr == self || (e.LastSeen == 0 && e.Source == addrbook.SourceInbound) || e.Score < 0 {
+			continue
+		}
+		resp.Peers = append(resp.Peers, network.PeerAddr{Addr: e.Addr, PublicKey: e.PublicKey})
+	}
+	return resp, nil
+}
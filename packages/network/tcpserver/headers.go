@@ -0,0 +1,42 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpserver
+
+import (
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Headers returns the headers of the blocks starting from the requested block
+func Headers(req *network.GetHeadersRequest) (*network.GetHeadersResponse, error) {
+	if req.BlockID < 1 {
+		return nil, network.ErrNotAccepted
+	}
+	count := req.Count
+	if count <= 0 || count > network.HeadersPerRequest {
+		count = network.HeadersPerRequest
+	}
+
+	blocks, err := (&sqldb.BlockChain{}).GetBlocksFrom(req.BlockID-1, "ASC", int(count))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": req.BlockID}).Error("getting blocks")
+		return nil, err
+	}
+
+	resp := &network.GetHeadersResponse{Headers: make([][]byte, 0, len(blocks))}
+	for _, b := range blocks {
+		header, err := types.MarshallHeader(b.Data)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.MarshallingError, "error": err, "block_id": b.ID}).Error("marshalling block header")
+			return nil, err
+		}
+		resp.Headers = append(resp.Headers, header)
+	}
+	return resp, nil
+}
@@ -0,0 +1,46 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package tcpserver
+
+import (
+	"encoding/json"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/snapshot"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SnapshotInfo returns the manifest of the latest snapshot of the node
+func SnapshotInfo() (*network.SnapshotInfoResponse, error) {
+	resp := &network.SnapshotInfoResponse{}
+	m, err := snapshot.Latest(conf.Config.GetSnapshotDir())
+	if err != nil {
+		if err != snapshot.ErrNotFound {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("getting latest snapshot")
+		}
+		return resp, nil
+	}
+	if resp.Manifest, err = json.Marshal(m); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling snapshot manifest")
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SnapshotChunk returns the part of the snapshot file
+func SnapshotChunk(req *network.SnapshotChunkRequest) (*network.SnapshotChunkResponse, error) {
+	if req.Offset < 0 {
+		return nil, network.ErrNotAccepted
+	}
+	data, err := snapshot.ReadChunk(conf.Config.GetSnapshotDir(), req.BlockID, req.Offset, network.SnapshotChunkSize)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "block_id": req.BlockID}).Warning("reading snapshot chunk")
+		return nil, err
+	}
+	return &network.SnapshotChunkResponse{Data: data}, nil
+}
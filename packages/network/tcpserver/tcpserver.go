@@ -9,15 +9,29 @@ import (
 	"strings"
 	"time"
 
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
 	"github.com/IBAX-io/go-ibax/packages/consts"
 	"github.com/IBAX-io/go-ibax/packages/network"
+	"github.com/IBAX-io/go-ibax/packages/service/metrics"
 	"github.com/IBAX-io/go-ibax/packages/service/node"
 
 	log "github.com/sirupsen/logrus"
 )
 
-// HandleTCPRequest proceed TCP requests
-func HandleTCPRequest(rw net.Conn) {
+// publicRequests are the requests served to the nodes with unknown keys
+var publicRequests = map[network.ReqTypesFlag]bool{
+	network.RequestTypeNotHonorNode:    true,
+	network.RequestTypeBlockCollection: true,
+	network.RequestTypeMaxBlock:        true,
+	network.RequestTypeGetPeers:        true,
+	network.RequestTypeSnapshotInfo:    true,
+	network.RequestTypeSnapshotChunk:   true,
+	network.RequestTypeGetHeaders:      true,
+}
+
+// HandleTCPRequest proceed TCP requests of the peer
+func HandleTCPRequest(rw net.Conn, peer network.Peer) {
 	dType := &network.RequestType{}
 	err := dType.Read(rw)
 	if err != nil {
@@ -25,7 +39,13 @@ func HandleTCPRequest(rw net.Conn) {
 		return
 	}
 
+	if !peer.Trusted() && !publicRequests[dType.Type] {
+		log.WithFields(log.Fields{"type": consts.AccessDenied, "request_type": dType.Type, "host": rw.RemoteAddr().String()}).Warning("request of the unknown node")
+		return
+	}
+
 	log.WithFields(log.Fields{"request_type": dType.Type}).Debug("tcpserver got request type")
+	metrics.TCPRequest(uint16(dType.Type))
 	var response network.SelfReaderWriter
 
 	switch dType.Type {
@@ -80,6 +100,27 @@ func HandleTCPRequest(rw net.Conn) {
 			}
 			return
 		}
+
+	case network.RequestTypeGetPeers:
+		req := &network.GetPeersRequest{}
+		if err = req.Read(rw); err == nil {
+			response, err = GetPeers(req, peer)
+		}
+
+	case network.RequestTypeSnapshotInfo:
+		response, err = SnapshotInfo()
+
+	case network.RequestTypeSnapshotChunk:
+		req := &network.SnapshotChunkRequest{}
+		if err = req.Read(rw); err == nil {
+			response, err = SnapshotChunk(req)
+		}
+
+	case network.RequestTypeGetHeaders:
+		req := &network.GetHeadersRequest{}
+		if err = req.Read(rw); err == nil {
+			response, err = Headers(req)
+		}
 	}
 
 	if err != nil || response == nil {
@@ -93,6 +134,50 @@ func HandleTCPRequest(rw net.Conn) {
 	}
 }
 
+// handleConnection authenticates the node and serves its multiplexed requests until the connection is closed.
+// The old node without the handshake is served by one request per connection if the legacy mode is on
+func handleConnection(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(consts.ReadTimeout * time.Second))
+	peeked, ok, err := network.PeekHandshake(conn)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": conn.RemoteAddr().String()}).Debug("reading node handshake")
+		return
+	}
+	if !ok {
+		if !conf.Config.Peers.Legacy {
+			log.WithFields(log.Fields{"type": consts.ConnectionError, "error": network.ErrLegacyPeer, "host": conn.RemoteAddr().String()}).Warning("on node handshake")
+			return
+		}
+		host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+		conn.SetWriteDeadline(time.Now().Add(consts.WriteTimeout * time.Second))
+		defer metrics.TCPConnOpened()()
+		HandleTCPRequest(peeked, network.NewLegacyPeer(conn.RemoteAddr(), syspar.IsNodeIP(host)))
+		return
+	}
+	secure, err := network.SecureServer(peeked, conf.Config.Peers.Public)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": conn.RemoteAddr().String()}).Warning("on node handshake")
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	session := network.NewMuxSession(secure, false, nil)
+	defer session.Close()
+	for {
+		stream, err := session.Accept()
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": conn.RemoteAddr().String()}).Debug("node connection closed")
+			return
+		}
+		go func(stream *network.MuxStream) {
+			defer metrics.TCPConnOpened()()
+			HandleTCPRequest(stream, secure)
+			stream.Close()
+		}(stream)
+	}
+}
+
 // TcpListener is listening tcp address
 func TcpListener(laddr string) error {
 
@@ -114,10 +199,7 @@ func TcpListener(laddr string) error {
 				log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": laddr}).Error("Error accepting")
 				time.Sleep(time.Second)
 			} else {
-				go func(conn net.Conn) {
-					HandleTCPRequest(conn)
-					conn.Close()
-				}(conn)
+				go handleConnection(conn)
 			}
 		}
 	}()
@@ -41,6 +41,22 @@ func (q *Queue) AddRoles(ecosystem int64, list ...int64) {
 	})
 }
 
+// queueMark is the length of the lists of the queue
+type queueMark struct {
+	accounts int
+	roles    int
+}
+
+func (q *Queue) Mark() any {
+	return queueMark{accounts: len(q.Accounts), roles: len(q.Roles)}
+}
+
+func (q *Queue) Truncate(mark any) {
+	m := mark.(queueMark)
+	q.Accounts = q.Accounts[:m.accounts]
+	q.Roles = q.Roles[:m.roles]
+}
+
 func (q *Queue) Send() {
 	for _, a := range q.Accounts {
 		UpdateNotifications(a.Ecosystem, a.List)
@@ -44,7 +44,7 @@ func RollbackBlock(data []byte) error {
 		return err
 	}
 
-	err = rollbackBlock(dbTx, bl)
+	err = rollbackBlock(dbTx, bl, true)
 	if err != nil {
 		dbTx.Rollback()
 		return err
@@ -88,7 +88,9 @@ func RollbackBlock(data []byte) error {
 	return dbTx.Commit()
 }
 
-func rollbackBlock(dbTx *sqldb.DbTransaction, block *block.Block) error {
+// rollbackBlock rolls back the changes of the block. If sysUpdate is false then the cache of
+// the system parameters is not reloaded
+func rollbackBlock(dbTx *sqldb.DbTransaction, block *block.Block, sysUpdate bool) error {
 	// rollback transactions in reverse order
 	logger := block.GetLogger()
 	var transferSelfHashes = make([]string, 0)
@@ -133,6 +135,9 @@ func rollbackBlock(dbTx *sqldb.DbTransaction, block *block.Block) error {
 				}
 			}
 		}
+		if !sysUpdate {
+			continue
+		}
 		err = t.Inner.TxRollback()
 		if err != nil {
 			return err
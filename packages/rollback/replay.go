@@ -0,0 +1,221 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package rollback
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/IBAX-io/go-ibax/packages/block"
+	"github.com/IBAX-io/go-ibax/packages/common/random"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/notificator"
+	"github.com/IBAX-io/go-ibax/packages/pbgo"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/smart"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxReplayBlocks is the maximum count of the blocks which are rolled back to replay the transaction.
+// The caller must hold the lock of the block processing till the end of the replay, so the blocks are not
+// changed while they are rolled back, and only the recent transactions can be replayed
+const MaxReplayBlocks = 10
+
+var (
+	ErrReplayTxNotFound  = errors.New("transaction not found")
+	ErrReplayNotContract = errors.New("transaction is not a contract")
+	ErrReplayTooFar      = fmt.Errorf("transaction is more than %d blocks behind", MaxReplayBlocks)
+	// ErrReplayVM is returned when the rolled back blocks changed the contracts of VM,
+	// they cannot be restored without changing the running VM
+	ErrReplayVM = errors.New("rolled back blocks changed the contracts")
+	// ErrReplaySysPar is returned when the rolled back blocks changed the platform parameters,
+	// the transaction is played with the current parameters which must be the same as in its block
+	ErrReplaySysPar = errors.New("rolled back blocks changed the platform parameters")
+)
+
+// replayVMTypes are the types of the system rollbacks which change VM
+var replayVMTypes = map[string]bool{
+	"NewContract":        true,
+	"EditContract":       true,
+	"NewEcosystem":       true,
+	"ActivateContract":   true,
+	"DeactivateContract": true,
+}
+
+// ReplayResult is the result of the replayed transaction
+type ReplayResult struct {
+	BlockID  int64          `json:"block_id"`
+	Contract string         `json:"contract"`
+	Result   *pbgo.TxResult `json:"result"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// ReplayTransaction executes the transaction again against the state before its block with
+// the attached debugger. The blocks down to the block of the transaction are rolled back in
+// the database transaction which is always discarded, then the previous transactions of
+// the block are played and the transaction is played under the debugger. The replay is refused
+// if the rolled back blocks changed the contracts or the platform parameters, so the current VM
+// and syspar are the same as at the block of the transaction. The block processing must be locked by the caller
+func ReplayTransaction(hash []byte, debug *script.Debugger) (*ReplayResult, error) {
+	lt := &sqldb.LogTransaction{}
+	found, err := lt.GetByHash(nil, hash)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting log transaction by hash")
+		return nil, err
+	}
+	if !found {
+		return nil, ErrReplayTxNotFound
+	}
+
+	dbTx, err := sqldb.StartTransaction()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("starting transaction")
+		return nil, err
+	}
+	defer dbTx.Rollback()
+
+	// the blocks are read in the transaction, so they are the same blocks which are rolled back
+	blocks, err := (&sqldb.BlockChain{}).GetBlocks(dbTx, lt.Block-1, MaxReplayBlocks+1)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting blocks")
+		return nil, err
+	}
+	if len(blocks) == 0 || blocks[len(blocks)-1].ID != lt.Block {
+		return nil, ErrReplayTooFar
+	}
+
+	var bl *block.Block
+	for _, item := range blocks {
+		if err = checkReplayRollback(dbTx, item.ID); err != nil {
+			return nil, err
+		}
+		if bl, err = block.UnmarshallBlock(bytes.NewBuffer(item.Data), true); err != nil {
+			return nil, err
+		}
+		if err = rollbackBlock(dbTx, bl, false); err != nil {
+			return nil, err
+		}
+	}
+	return replayBlockTx(dbTx, bl, hash, debug)
+}
+
+const platformParamsTable = "1_platform_parameters"
+
+// checkReplayRollback returns ErrReplayVM or ErrReplaySysPar if the block has changed VM or the platform parameters
+func checkReplayRollback(dbTx *sqldb.DbTransaction, blockID int64) error {
+	rollbackTxs, err := (&sqldb.RollbackTx{}).GetBlockRollbackTransactions(dbTx, blockID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block rollback transactions")
+		return err
+	}
+	for _, rtx := range rollbackTxs {
+		if rtx.NameTable == platformParamsTable {
+			return fmt.Errorf("%w: block %d", ErrReplaySysPar, blockID)
+		}
+		if rtx.NameTable != smart.SysName {
+			continue
+		}
+		var sysData smart.SysRollData
+		if err = json.Unmarshal([]byte(rtx.Data), &sysData); err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling rollback.Data from json")
+			return err
+		}
+		if replayVMTypes[sysData.Type] {
+			return fmt.Errorf("%w: block %d", ErrReplayVM, blockID)
+		}
+	}
+	return nil
+}
+
+// replayBlockTx plays the transactions of the block till the transaction with the hash
+func replayBlockTx(dbTx *sqldb.DbTransaction, bl *block.Block, hash []byte, debug *script.Debugger) (*ReplayResult, error) {
+	var (
+		keyIds       []int64
+		ecosystemIds []int64
+		target       = -1
+	)
+	for i, t := range bl.Transactions {
+		keyIds = append(keyIds, t.KeyID())
+		if t.IsSmartContract() {
+			ecosystemIds = append(ecosystemIds, t.SmartContract().TxSmart.EcosystemID)
+		}
+		if bytes.Equal(t.Hash(), hash) {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return nil, ErrReplayTxNotFound
+	}
+	if !bl.Transactions[target].IsSmartContract() {
+		return nil, ErrReplayNotContract
+	}
+	outputs, err := sqldb.GetTxOutputs(dbTx, keyIds)
+	if err != nil {
+		return nil, err
+	}
+	outputsMap := make(map[sqldb.KeyUTXO][]sqldb.SpentInfo)
+	sqldb.PutAllOutputsMap(outputs, outputsMap)
+	ecoParams, err := sqldb.GetEcoParam(dbTx, ecosystemIds)
+	if err != nil {
+		return nil, err
+	}
+	prevSysPar := syspar.GetSysParCache()
+	limits := transaction.NewLimits(transaction.GetLetParsing())
+	rand := random.NewRand(bl.Header.Timestamp)
+
+	for _, t := range bl.Transactions[:target+1] {
+		mark := consts.SetSavePointMarkBlock(hex.EncodeToString(t.Hash()))
+		if err = dbTx.Savepoint(mark); err != nil {
+			return nil, err
+		}
+		if err = t.WithOption(notificator.NewQueue(), false, bl.Header, bl.PrevHeader, dbTx, rand.BytesSeed(t.Hash()),
+			limits, mark, outputsMap, prevSysPar, ecoParams); err != nil {
+			return nil, err
+		}
+		if bytes.Equal(t.Hash(), hash) {
+			break
+		}
+		errPlay := t.Play()
+		if t.IsSmartContract() {
+			flushVM(t.SmartContract().SmartContract)
+		}
+		if errPlay != nil {
+			if err = dbTx.RollbackSavepoint(mark); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sqldb.UpdateTxInputs(t.Hash(), t.TxInputsMap, outputsMap)
+		sqldb.InsertTxOutputs(t.Hash(), t.TxOutputsMap, outputsMap)
+	}
+
+	t := bl.Transactions[target]
+	sc := t.SmartContract()
+	sc.Debug = debug
+	ret := &ReplayResult{BlockID: bl.Header.BlockId}
+	if err = t.Play(); err != nil {
+		ret.Error = err.Error()
+	}
+	flushVM(sc.SmartContract)
+	if sc.TxContract != nil {
+		ret.Contract = sc.TxContract.Name
+	}
+	ret.Result = t.OutCtx.TxResult
+	return ret, nil
+}
+
+// flushVM reverts the changes of VM made by the replayed transaction
+func flushVM(sc *smart.SmartContract) {
+	for i := len(sc.FlushRollback) - 1; i >= 0; i-- {
+		sc.FlushRollback[i].FlushVM()
+	}
+}
@@ -30,7 +30,7 @@ func ToBlockID(blockID int64, dbTx *sqldb.DbTransaction, logger *log.Entry) erro
 	// roll back our blocks
 	for {
 		block := &sqldb.BlockChain{}
-		blocks, err := block.GetBlocks(blockID, syspar.GetMaxTxCount())
+		blocks, err := block.GetBlocks(nil, blockID, syspar.GetMaxTxCount())
 		if err != nil {
 			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting blocks")
 			return err
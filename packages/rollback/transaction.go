@@ -88,6 +88,10 @@ func rollbackTransaction(txHash []byte, dbTx *sqldb.DbTransaction, logger *log.E
 				err = smart.SysRollbackDeleteColumn(dbTx, sysData)
 			case "DeleteTable":
 				err = smart.SysRollbackDeleteTable(dbTx, sysData)
+			case "EmitEvent":
+				err = smart.SysRollbackEvent(dbTx, txHash, sysData)
+			case "ContractVersion":
+				err = smart.SysRollbackContractVersion(dbTx, sysData)
 			}
 			if err != nil {
 				return err
@@ -30,6 +30,10 @@ const (
 	cmdMapInit                 // map initialization
 	cmdArrayInit               // array initialization
 	cmdError                   // error command
+	cmdForIn                   // for ... in
+	cmdTry                     // try
+	cmdCatch                   // catch
+	cmdStructInit              // struct initialization
 )
 
 // the commands for operations in expressions are listed below
@@ -51,6 +55,13 @@ const (
 	cmdNotLess
 	cmdGreat
 	cmdNotGreat
+	cmdMod
+	cmdPow
+	cmdBitAnd
+	cmdBitOr
+	cmdBitXor
+	cmdShiftL
+	cmdShiftR
 
 	cmdSys          = 0xff
 	cmdUnary uint16 = 50
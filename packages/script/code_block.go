@@ -30,9 +30,13 @@ type CodeBlock struct {
 	// Types that are valid to be assigned to Info:
 	//	*FuncInfo
 	//	*ContractInfo
+	//	*LibraryInfo
+	//	*ForInfo
+	//	*CatchInfo
 	Info     isCodeBlockInfo
 	Parent   *CodeBlock
 	Vars     []reflect.Type
+	Structs  map[int]*StructInfo // The struct types of the variables by their indexes
 	Code     ByteCodes
 	Children CodeBlocks
 }
@@ -43,6 +47,20 @@ type isCodeBlockInfo interface {
 
 func (*FuncInfo) isCodeBlockInfo()     {}
 func (*ContractInfo) isCodeBlockInfo() {}
+func (*LibraryInfo) isCodeBlockInfo()  {}
+func (*ForInfo) isCodeBlockInfo()      {}
+func (*CatchInfo) isCodeBlockInfo()    {}
+
+// ForInfo contains the names of the key and value variables of the for ... in loop
+type ForInfo struct {
+	Key   string
+	Value string
+}
+
+// CatchInfo contains the name of the error variable of the catch block
+type CatchInfo struct {
+	Name string
+}
 
 func (m *CodeBlock) GetInfo() isCodeBlockInfo {
 	if m != nil {
@@ -65,6 +83,27 @@ func (m *CodeBlock) GetContractInfo() *ContractInfo {
 	return nil
 }
 
+func (m *CodeBlock) GetLibraryInfo() *LibraryInfo {
+	if x, ok := m.GetInfo().(*LibraryInfo); ok {
+		return x
+	}
+	return nil
+}
+
+func (m *CodeBlock) GetForInfo() *ForInfo {
+	if x, ok := m.GetInfo().(*ForInfo); ok {
+		return x
+	}
+	return nil
+}
+
+func (m *CodeBlock) GetCatchInfo() *CatchInfo {
+	if x, ok := m.GetInfo().(*CatchInfo); ok {
+		return x
+	}
+	return nil
+}
+
 // ByteCode stores a command and an additional parameter.
 type ByteCode struct {
 	Cmd   uint16
@@ -130,6 +169,7 @@ type ObjInfo struct {
 	//	*ExtFuncInfo
 	//	*ObjInfo_Variable
 	//	*ObjInfo_ExtendVariable
+	//	*StructInfo
 	Value isObjInfoValue
 }
 
@@ -149,6 +189,7 @@ func (*CodeBlock) isObjInfoValue()              {}
 func (*ExtFuncInfo) isObjInfoValue()            {}
 func (*ObjInfo_Variable) isObjInfoValue()       {}
 func (*ObjInfo_ExtendVariable) isObjInfoValue() {}
+func (*StructInfo) isObjInfoValue()             {}
 
 func (m *ObjInfo) GetValue() isObjInfoValue {
 	if m != nil {
@@ -185,6 +226,13 @@ func (m *ObjInfo) GetExtendVariable() *ObjInfo_ExtendVariable {
 	return nil
 }
 
+func (m *ObjInfo) GetStructInfo() *StructInfo {
+	if x, ok := m.GetValue().(*StructInfo); ok {
+		return x
+	}
+	return nil
+}
+
 func NewCodeBlock() *CodeBlock {
 	b := &CodeBlock{
 		Objects: make(map[string]*ObjInfo),
@@ -243,7 +291,7 @@ func (block *CodeBlock) getObjByName(name string) (ret *ObjInfo) {
 		if i == len(names)-1 {
 			return
 		}
-		if ret.Type != ObjectType_Contract && ret.Type != ObjectType_Func {
+		if ret.Type != ObjectType_Contract && ret.Type != ObjectType_Func && ret.Type != ObjectType_Library {
 			return nil
 		}
 		block = ret.GetCodeBlock()
@@ -264,6 +312,15 @@ func (cb *CodeBlock) contractBaseCost() int64 {
 	return cost
 }
 
+// isLibraryFunc returns true if the object is the function of the library
+func (ret *ObjInfo) isLibraryFunc() bool {
+	if ret.Type != ObjectType_Func {
+		return false
+	}
+	parent := ret.GetCodeBlock().Parent
+	return parent != nil && parent.Type == ObjectType_Library
+}
+
 func (block *CodeBlock) isParentContract() bool {
 	if block.Parent != nil && block.Parent.Type == ObjectType_Contract {
 		return true
@@ -6,6 +6,9 @@ package script
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/IBAX-io/go-ibax/packages/consts"
@@ -37,6 +40,7 @@ const (
 	mapMap
 	mapExtend
 	mapArray
+	mapStruct
 
 	mustKey
 	mustColon
@@ -65,6 +69,8 @@ const (
 	errVarType               // must be type
 	errAssign                // must be '='
 	errStrNum                // must be number or string
+	errMustIn                // must be 'in'
+	errMustFunc              // must be 'func'
 )
 
 var (
@@ -80,8 +86,15 @@ var (
 		isLessEq:   {Cmd: cmdNotGreat, Priority: 22},
 		isPlus:     {Cmd: cmdAdd, Priority: 25},
 		isMinus:    {Cmd: cmdSub, Priority: 25},
+		isPipe:     {Cmd: cmdBitOr, Priority: 25},
+		isCaret:    {Cmd: cmdBitXor, Priority: 25},
 		isAsterisk: {Cmd: cmdMul, Priority: 30},
 		isSolidus:  {Cmd: cmdDiv, Priority: 30},
+		isPercent:  {Cmd: cmdMod, Priority: 30},
+		isAmp:      {Cmd: cmdBitAnd, Priority: 30},
+		isShiftL:   {Cmd: cmdShiftL, Priority: 30},
+		isShiftR:   {Cmd: cmdShiftR, Priority: 30},
+		isPower:    {Cmd: cmdPow, Priority: 35},
 		isSign:     {Cmd: cmdSign, Priority: cmdUnary},
 		isNot:      {Cmd: cmdNot, Priority: cmdUnary},
 		isLPar:     {Cmd: cmdSys, Priority: 0xff},
@@ -109,6 +122,13 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*CodeBlock, error) {
 	if len(lexemes) == 0 {
 		return root, nil
 	}
+	lexemes, err = vm.compileImports(lexemes, owner)
+	if err != nil {
+		return nil, err
+	}
+	if lexemes, err = vm.compileStructs(lexemes, root); err != nil {
+		return nil, err
+	}
 	curState := stateRoot
 	stack := make([]stateTypes, 0, 64)
 	blockstack := make(CodeBlocks, 1, 64)
@@ -197,18 +217,315 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*CodeBlock, error) {
 	if len(stack) > 0 {
 		return nil, fError(&blockstack, errMustRCurly, lexemes[len(lexemes)-1])
 	}
-	for _, item := range root.Objects {
-		if item.Type == ObjectType_Contract {
+	if err := checkTryCatch(root); err != nil {
+		return nil, err
+	}
+	for name, item := range root.Objects {
+		switch item.Type {
+		case ObjectType_Contract:
 			if cond, ok := item.GetCodeBlock().Objects[`conditions`]; ok {
 				if cond.Type == ObjectType_Func && cond.GetCodeBlock().GetFuncInfo().CanWrite {
 					return nil, errCondWrite
 				}
 			}
+		case ObjectType_Library:
+			if err := vm.checkLibrary(name, item); err != nil {
+				return nil, err
+			}
 		}
 	}
 	return root, nil
 }
 
+// compileImports resolves the libraries which are imported with 'import Name' at the top level
+// and removes the imports from the lexemes. The calls Name.func(...) are replaced with the full names
+// of the library functions, so they are checked at the compile time. The imports are not pinned to
+// the version of the library, the new version of the library replaces the functions for all callers
+func (vm *VM) compileImports(lexemes Lexemes, owner *OwnerInfo) (Lexemes, error) {
+	var level int
+	libs := make(map[string]*LibraryInfo)
+	ret := make(Lexemes, 0, len(lexemes))
+	for i := 0; i < len(lexemes); i++ {
+		lexeme := lexemes[i]
+		switch lexeme.Type {
+		case isLCurly:
+			level++
+		case isRCurly:
+			level--
+		case lexKeyword | (keyImport << 8):
+			if level > 0 {
+				return nil, fmt.Errorf(`import can be declared only at the top level [Ln:%d Col:%d]`,
+					lexeme.Line, lexeme.Column)
+			}
+			if i+1 == len(lexemes) || lexemes[i+1].Type != lexIdent {
+				return nil, fError(nil, errMustName, lexeme)
+			}
+			i++
+			name := lexemes[i].Value.(string)
+			obj := vm.getObjByName(StateName(owner.StateID, name))
+			if obj == nil || obj.Type != ObjectType_Library {
+				return nil, fmt.Errorf(eUnknownLibrary, name, lexeme.Line, lexeme.Column)
+			}
+			if strings.HasPrefix(name, `@`) {
+				name = strings.TrimLeft(name[1:], `0123456789`)
+			}
+			if _, ok := libs[name]; ok {
+				return nil, fmt.Errorf(eLibraryImport, name, lexeme.Line, lexeme.Column)
+			}
+			libs[name] = obj.GetCodeBlock().GetLibraryInfo()
+			continue
+		}
+		ret = append(ret, lexeme)
+	}
+	if len(libs) == 0 {
+		return lexemes, nil
+	}
+	lexemes = ret
+	ret = make(Lexemes, 0, len(lexemes))
+	for i := 0; i < len(lexemes); i++ {
+		lexeme := lexemes[i]
+		if lexeme.Type == lexIdent && (i == 0 || lexemes[i-1].Type != isDot) && i+2 < len(lexemes) &&
+			lexemes[i+1].Type == isDot && lexemes[i+2].Type == lexIdent {
+			if lib, ok := libs[lexeme.Value.(string)]; ok {
+				name := lib.Name + `.` + lexemes[i+2].Value.(string)
+				if obj := vm.getObjByName(name); obj == nil || obj.Type != ObjectType_Func {
+					return nil, fmt.Errorf(eUnknownLibFunc, lexemes[i+2].Value, lexeme.Value,
+						lexeme.Line, lexeme.Column)
+				}
+				ident := *lexeme
+				ident.Value = name
+				ret = append(ret, &ident)
+				i += 2
+				continue
+			}
+		}
+		ret = append(ret, lexeme)
+	}
+	return ret, nil
+}
+
+// checkLibrary checks that the new version of the library doesn't remove or change the functions
+// of the previous version, so the contracts which have imported the library remain correct
+func (vm *VM) checkLibrary(name string, item *ObjInfo) error {
+	cur, ok := vm.Objects[name]
+	if !ok {
+		return nil
+	}
+	if cur.Type != ObjectType_Library {
+		return fmt.Errorf(eLibraryName, name)
+	}
+	funcs := item.GetCodeBlock().Objects
+	prevFuncs := cur.GetCodeBlock().Objects
+	names := make([]string, 0, len(prevFuncs))
+	for fname, obj := range prevFuncs {
+		if obj.Type == ObjectType_Func {
+			names = append(names, fname)
+		}
+	}
+	// the functions are checked in order to get the same error every time
+	sort.Strings(names)
+	for _, fname := range names {
+		obj := prevFuncs[fname]
+		prev := obj.GetCodeBlock().GetFuncInfo()
+		if fobj, ok := funcs[fname]; ok && fobj.Type == ObjectType_Func {
+			finfo := fobj.GetCodeBlock().GetFuncInfo()
+			if finfo.Variadic == prev.Variadic && reflect.DeepEqual(finfo.Params, prev.Params) &&
+				reflect.DeepEqual(finfo.Results, prev.Results) {
+				continue
+			}
+		}
+		return fmt.Errorf(eLibraryChange, fname, name)
+	}
+	return nil
+}
+
+// checkPure checks that the function of the library calls only the functions of the libraries
+// and the embedded functions which neither depend on the contract nor modify the database
+func checkPure(block *CodeBlocks, obj *ObjInfo, name string) error {
+	if len(*block) < 2 || (*block)[1].Type != ObjectType_Library {
+		return nil
+	}
+	switch obj.Type {
+	case ObjectType_Func:
+		if obj.isLibraryFunc() {
+			return nil
+		}
+	case ObjectType_ExtFunc:
+		info := obj.GetExtFuncInfo()
+		pure := !info.CanWrite
+		for _, auto := range info.Auto {
+			if len(auto) > 0 {
+				pure = false
+			}
+		}
+		if pure {
+			return nil
+		}
+	}
+	return fmt.Errorf(eLibraryCall, name, (*block)[1].GetLibraryInfo().Name)
+}
+
+// compileStructs compiles the declarations of the structs and removes them from the lexemes.
+// The structs can be declared only at the top level. The names of the structs are replaced
+// with the type lexemes where they are used as the types
+func (vm *VM) compileStructs(lexemes Lexemes, root *CodeBlock) (Lexemes, error) {
+	var (
+		level int
+		decls []int
+	)
+	structs := make(map[string]*StructInfo)
+	for i, lexeme := range lexemes {
+		switch lexeme.Type {
+		case isLCurly:
+			level++
+		case isRCurly:
+			level--
+		case lexKeyword | (keyStruct << 8):
+			if level > 0 {
+				return nil, fmt.Errorf(`struct can be declared only at the top level [Ln:%d Col:%d]`,
+					lexeme.Line, lexeme.Column)
+			}
+			if i+1 == len(lexemes) || lexemes[i+1].Type != lexIdent {
+				return nil, fError(nil, errMustName, lexeme)
+			}
+			name := lexemes[i+1].Value.(string)
+			if !regexp.MustCompile(VarRegexp).MatchString(name) {
+				return nil, fmt.Errorf("identifier expected, got '%s'", name)
+			}
+			if _, ok := structs[name]; ok {
+				return nil, fmt.Errorf(`struct '%s' redeclared [Ln:%d Col:%d]`, name, lexeme.Line, lexeme.Column)
+			}
+			structs[name] = &StructInfo{Name: name}
+			decls = append(decls, i)
+		}
+	}
+	for i, lexeme := range lexemes {
+		if lexeme.Type != lexIdent || !isTypePosition(lexemes, i) {
+			continue
+		}
+		info, ok := structs[lexeme.Value.(string)]
+		if !ok {
+			obj := vm.getObjByName(lexeme.Value.(string))
+			if obj == nil || obj.Type != ObjectType_Struct {
+				continue
+			}
+			info = obj.GetStructInfo()
+		}
+		lexeme.Type = lexType
+		lexeme.Ext = DtStruct
+		lexeme.Value = reflect.TypeOf(&types.Map{})
+		lexeme.Struct = info
+	}
+	if len(decls) == 0 {
+		return lexemes, nil
+	}
+	if root.Objects == nil {
+		root.Objects = make(map[string]*ObjInfo)
+	}
+	ret := make(Lexemes, 0, len(lexemes))
+	start := 0
+	for _, i := range decls {
+		ret = append(ret, lexemes[start:i]...)
+		info := structs[lexemes[i+1].Value.(string)]
+		end, err := compileStruct(lexemes, i+2, info, structs)
+		if err != nil {
+			return nil, err
+		}
+		delete(structs, info.Name)
+		root.Objects[info.Name] = &ObjInfo{Type: ObjectType_Struct, Value: info}
+		start = end + 1
+	}
+	return append(ret, lexemes[start:]...), nil
+}
+
+// isTypePosition returns true if the identifier can be the name of the type. It is not
+// the name of the declared object, the field after the dot or the key of the map
+func isTypePosition(lexemes Lexemes, i int) bool {
+	if i > 0 {
+		prev := lexemes[i-1]
+		if prev.Type == isDot || (prev.Type&0xff == lexKeyword && prev.Type != lexKeyword|(keyReturn<<8)) {
+			return false
+		}
+	}
+	if i+1 < len(lexemes) {
+		switch lexemes[i+1].Type {
+		case isColon, lexIdent, lexType:
+			return false
+		}
+	}
+	return true
+}
+
+// compileStruct compiles the fields of the struct starting from '{' and returns the index of '}'.
+// The types of the fields can be only the structs which have been declared before
+func compileStruct(lexemes Lexemes, i int, info *StructInfo, pending map[string]*StructInfo) (int, error) {
+	for ; i < len(lexemes) && lexemes[i].Type == lexNewLine; i++ {
+	}
+	if i == len(lexemes) || lexemes[i].Type != isLCurly {
+		return 0, fError(nil, errMustLCurly, lexemes[i-1])
+	}
+	var names []string
+	for i++; i < len(lexemes); i++ {
+		lexeme := lexemes[i]
+		switch lexeme.Type {
+		case isComma:
+		case lexIdent:
+			name := lexeme.Value.(string)
+			if !regexp.MustCompile(VarRegexp).MatchString(name) {
+				return 0, fmt.Errorf("identifier expected, got '%s'", name)
+			}
+			if info.Field(name) != nil {
+				return 0, fmt.Errorf(`field '%s' redeclared in struct %s`, name, info.Name)
+			}
+			for _, item := range names {
+				if item == name {
+					return 0, fmt.Errorf(`field '%s' redeclared in struct %s`, name, info.Name)
+				}
+			}
+			names = append(names, name)
+		case lexType:
+			if len(names) == 0 {
+				return 0, fmt.Errorf(eStructFieldName, lexeme.Line, lexeme.Column)
+			}
+			if lexeme.Struct != nil && pending[lexeme.Struct.Name] == lexeme.Struct {
+				return 0, fmt.Errorf(`struct %s must be declared before struct %s [Ln:%d Col:%d]`,
+					lexeme.Struct.Name, info.Name, lexeme.Line, lexeme.Column)
+			}
+			for _, name := range names {
+				info.Fields = append(info.Fields, &FieldInfo{Name: name, Type: lexeme.Value.(reflect.Type),
+					Original: lexeme.Ext, Struct: lexeme.Struct})
+			}
+			names = names[:0]
+		case lexNewLine, isRCurly:
+			if len(names) > 0 {
+				prev := lexemes[i-1]
+				return 0, fmt.Errorf(eStructFieldType, prev.Line, prev.Column)
+			}
+			if lexeme.Type == isRCurly {
+				return i, nil
+			}
+		default:
+			return 0, fError(nil, errMustRCurly, lexeme)
+		}
+	}
+	return 0, fError(nil, errMustRCurly, lexemes[len(lexemes)-1])
+}
+
+// checkTryCatch checks that every try block is followed by catch
+func checkTryCatch(block *CodeBlock) error {
+	for i, cmd := range block.Code {
+		if cmd.Cmd == cmdTry && (i+1 == len(block.Code) || block.Code[i+1].Cmd != cmdCatch) {
+			return fmt.Errorf(`there is not catch after try [Ln:%d]`, cmd.Line)
+		}
+	}
+	for _, child := range block.Children {
+		if err := checkTryCatch(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // FlushBlock loads the compiled CodeBlock into the virtual machine
 func (vm *VM) FlushBlock(root *CodeBlock) {
 	shift := len(vm.Children)
@@ -220,6 +537,16 @@ func (vm *VM) FlushBlock(root *CodeBlock) {
 			case ObjectType_Func:
 				root.Objects[key].GetCodeBlock().GetFuncInfo().ID = cur.GetCodeBlock().GetFuncInfo().ID + flushMark
 				vm.Objects[key].Value = root.Objects[key].Value
+			case ObjectType_Library:
+				// the compiled calls refer to the functions of the previous version, so they are updated
+				item.GetCodeBlock().GetLibraryInfo().ID = cur.GetCodeBlock().GetLibraryInfo().ID + flushMark
+				funcs := item.GetCodeBlock().Objects
+				for fname, obj := range cur.GetCodeBlock().Objects {
+					if fobj, ok := funcs[fname]; ok && obj.Type == ObjectType_Func {
+						obj.Value = fobj.Value
+						funcs[fname] = obj
+					}
+				}
 			}
 		}
 		vm.Objects[key] = item
@@ -244,6 +571,15 @@ func (vm *VM) FlushBlock(root *CodeBlock) {
 			}
 			item.Parent = vm.CodeBlock
 			item.GetFuncInfo().ID += uint32(shift)
+		case ObjectType_Library:
+			if item.GetLibraryInfo().ID > flushMark {
+				item.GetLibraryInfo().ID -= flushMark
+				vm.Children[item.GetLibraryInfo().ID] = item
+				shift--
+				continue
+			}
+			item.Parent = vm.CodeBlock
+			item.GetLibraryInfo().ID += uint32(shift)
 		}
 		vm.Children = append(vm.Children, item)
 	}
@@ -322,6 +658,15 @@ func (vm *VM) getInitValue(lexemes *Lexemes, ind *int, block *CodeBlocks) (value
 		}
 	case lexNumber, lexString:
 		value = mapItem{Type: mapConst, Value: lexeme.Value}
+	case lexType:
+		if lexeme.Struct == nil {
+			err = errUnexpValue
+			break
+		}
+		var init *StructInit
+		if init, err = vm.getInitStruct(lexemes, &i, block); err == nil {
+			value = mapItem{Type: mapStruct, Value: init}
+		}
 	default:
 		err = errUnexpValue
 	}
@@ -329,6 +674,36 @@ func (vm *VM) getInitValue(lexemes *Lexemes, ind *int, block *CodeBlocks) (value
 	return
 }
 
+// getInitStruct compiles the initialization of the struct Name{Field: value, ...}
+func (vm *VM) getInitStruct(lexemes *Lexemes, ind *int, block *CodeBlocks) (*StructInit, error) {
+	i := *ind
+	lexeme := (*lexemes)[i]
+	if i+1 == len(*lexemes) || (*lexemes)[i+1].Type != isLCurly {
+		return nil, fmt.Errorf(eStructInit, lexeme.Struct.Name, lexeme.Line, lexeme.Column)
+	}
+	ret := &StructInit{Struct: lexeme.Struct, Fields: types.NewMap()}
+	i++
+	next := i + 1
+	for ; next < len(*lexemes) && (*lexemes)[next].Type == lexNewLine; next++ {
+	}
+	if next < len(*lexemes) && (*lexemes)[next].Type == isRCurly {
+		*ind = next
+		return ret, nil
+	}
+	fields, err := vm.getInitMap(lexemes, &i, block, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range fields.Keys() {
+		if ret.Struct.Field(key) == nil {
+			return nil, fmt.Errorf(eUnknownField, key, ret.Struct.Name)
+		}
+	}
+	ret.Fields = fields
+	*ind = i
+	return ret, nil
+}
+
 func (vm *VM) getInitMap(lexemes *Lexemes, ind *int, block *CodeBlocks, oneItem bool) (*types.Map, error) {
 	var next int
 	if !oneItem {
@@ -474,6 +849,7 @@ main:
 					return err
 				}
 				bytecode.push(newByteCode(cmdMapInit, lexeme.Line, pMap))
+				noMap = true
 				continue
 			}
 			if lexeme.Type == isLBrack {
@@ -482,6 +858,7 @@ main:
 					return err
 				}
 				bytecode.push(newByteCode(cmdArrayInit, lexeme.Line, pArray))
+				noMap = true
 				continue
 			}
 		}
@@ -565,6 +942,9 @@ main:
 								if i < len(*lexemes)-5 && (*lexemes)[i+3].Type == isLPar {
 									objInfo, _ := vm.findObj((*lexemes)[i+2].Value.(string), block)
 									if objInfo != nil && (objInfo.Type == ObjectType_Func || objInfo.Type == ObjectType_ExtFunc) {
+										if err := checkPure(block, objInfo, (*lexemes)[i+2].Value.(string)); err != nil {
+											return err
+										}
 										tail = newByteCode(uint16(cmdCall), lexeme.Line, objInfo)
 									}
 								}
@@ -601,6 +981,15 @@ main:
 							logger.WithFields(log.Fields{"error": errtext, "type": consts.ParseError}).Error(errtext)
 							return fmt.Errorf(errtext)
 						}
+					} else if prev.Value.(*ObjInfo).isLibraryFunc() {
+						finfo := prev.Value.(*ObjInfo).GetCodeBlock().GetFuncInfo()
+						wantlen := len(finfo.Params)
+						if finfo.Variadic {
+							wantlen--
+						}
+						if count != wantlen && (!finfo.Variadic || count < wantlen) {
+							return fmt.Errorf(eWrongParams, finfo.Name, wantlen)
+						}
 					}
 					if prev.Cmd == cmdCallVariadic {
 						bytecode.push(newByteCode(cmdPush, lexeme.Line, count))
@@ -667,7 +1056,9 @@ main:
 						break
 					} else {
 						prev := buffer[len(buffer)-1]
-						if prev.Value.(uint16) >= oper.Priority && oper.Priority != cmdUnary && prev.Cmd != cmdSys {
+						// ** is right associative
+						if prev.Value.(uint16) >= oper.Priority && oper.Priority != cmdUnary && prev.Cmd != cmdSys &&
+							(oper.Cmd != cmdPow || prev.Cmd != cmdPow) {
 							if prev.Value.(uint16) == cmdUnary { // Right to left
 								unar := len(buffer) - 1
 								for ; unar > 0 && buffer[unar-1].Value.(uint16) == cmdUnary; unar-- {
@@ -691,8 +1082,20 @@ main:
 		case lexNumber, lexString:
 			noMap = true
 			cmd = newByteCode(cmdPush, lexeme.Line, lexeme.Value)
+		case lexType:
+			if lexeme.Struct != nil {
+				noMap = true
+				init, err := vm.getInitStruct(lexemes, &i, block)
+				if err != nil {
+					return err
+				}
+				cmd = newByteCode(cmdStructInit, lexeme.Line, init)
+			}
 		case lexExtend:
 			noMap = true
+			if len(*block) > 1 && (*block)[1].Type == ObjectType_Library {
+				return fmt.Errorf(eLibraryExtend, lexeme.Value, (*block)[1].GetLibraryInfo().Name)
+			}
 			if i < len(*lexemes)-2 {
 				if (*lexemes)[i+1].Type == isLPar {
 					count := 0
@@ -731,6 +1134,9 @@ main:
 						logger.WithFields(log.Fields{"lex_value": lexeme.Value, "type": consts.ParseError}).Error("unknown function")
 						return fmt.Errorf(`unknown function %s`, lexeme.Value.(string))
 					}
+					if err := checkPure(block, objInfo, lexeme.Value.(string)); err != nil {
+						return err
+					}
 					if objInfo.Type == ObjectType_Contract {
 						if objInfo.Value != nil {
 							objContract = objInfo.GetCodeBlock()
@@ -785,7 +1191,14 @@ main:
 						logger.WithFields(log.Fields{"lex_value": lexeme.Value, "type": consts.ParseError}).Error("unknown variable")
 						return fmt.Errorf(`unknown variable %s`, lexeme.Value.(string))
 					}
-					buffer.push(newByteCode(cmdIndex, lexeme.Line, &IndexInfo{VarOffset: objInfo.GetVariable().Index, Owner: tobj}))
+					index := &IndexInfo{VarOffset: objInfo.GetVariable().Index, Owner: tobj}
+					if info := tobj.Structs[index.VarOffset]; info != nil && i < len(*lexemes)-3 &&
+						(*lexemes)[i+2].Type == lexString && (*lexemes)[i+3].Type == isRBrack {
+						if index.Field = info.Field((*lexemes)[i+2].Value.(string)); index.Field == nil {
+							return fmt.Errorf(eUnknownField, (*lexemes)[i+2].Value, info.Name)
+						}
+					}
+					buffer.push(newByteCode(cmdIndex, lexeme.Line, index))
 				}
 			}
 			if !call {
@@ -793,6 +1206,18 @@ main:
 					return fmt.Errorf(`unknown variable %s`, lexeme.Value.(string))
 				}
 				cmd = newByteCode(cmdVar, lexeme.Line, &VarInfo{Obj: objInfo, Owner: tobj})
+				if i < len(*lexemes)-1 && (*lexemes)[i+1].Type == isDot {
+					var err error
+					bytecode.push(cmd)
+					cmd = nil
+					if setIndex, indexInfo, err = compileFields(lexemes, &i, objInfo, tobj, &bytecode); err != nil {
+						return err
+					}
+					if setIndex {
+						noMap = false
+						continue
+					}
+				}
 			}
 		}
 		if lexeme.Type != lexNewLine {
@@ -825,6 +1250,43 @@ main:
 	return nil
 }
 
+// compileFields compiles the access to the fields of the struct variable var.Field.Field.
+// If the last field is followed by '=' then it returns true and the information for cmdSetIndex
+func compileFields(lexemes *Lexemes, ind *int, objInfo *ObjInfo, owner *CodeBlock,
+	bytecode *ByteCodes) (bool, *IndexInfo, error) {
+	i := *ind
+	info := owner.Structs[objInfo.GetVariable().Index]
+	if info == nil {
+		return false, nil, fmt.Errorf(`%s is not a struct`, objInfo.GetVariable().Name)
+	}
+	for ; i < len(*lexemes)-1 && (*lexemes)[i+1].Type == isDot; i += 2 {
+		if info == nil {
+			return false, nil, fmt.Errorf(`%s is not a struct`, (*lexemes)[i].Value)
+		}
+		if i+2 == len(*lexemes) || (*lexemes)[i+2].Type != lexIdent {
+			return false, nil, fError(nil, errMustName, (*lexemes)[i+1])
+		}
+		lexeme := (*lexemes)[i+2]
+		field := info.Field(lexeme.Value.(string))
+		if field == nil {
+			return false, nil, fmt.Errorf(eUnknownField, lexeme.Value, info.Name)
+		}
+		bytecode.push(newByteCode(cmdPush, lexeme.Line, field.Name))
+		if i+3 < len(*lexemes) && (*lexemes)[i+3].Type == isEq {
+			*ind = i + 3
+			return true, &IndexInfo{VarOffset: objInfo.GetVariable().Index, Owner: owner, Field: field}, nil
+		}
+		bytecode.push(newByteCode(cmdIndex, lexeme.Line, &IndexInfo{VarOffset: objInfo.GetVariable().Index,
+			Owner: owner}))
+		info = field.Struct
+	}
+	*ind = i
+	if i < len(*lexemes)-1 && (*lexemes)[i+1].Type == isLBrack {
+		return false, nil, errMultiIndex
+	}
+	return false, nil, nil
+}
+
 // ContractsList returns list of contracts names from source of code
 func ContractsList(value string) ([]string, error) {
 	names := make([]string, 0)
@@ -840,7 +1302,7 @@ func ContractsList(value string) ([]string, error) {
 			level++
 		case isRCurly:
 			level--
-		case lexKeyword | (keyContract << 8), lexKeyword | (keyFunc << 8):
+		case lexKeyword | (keyContract << 8), lexKeyword | (keyFunc << 8), lexKeyword | (keyLibrary << 8):
 			if level == 0 && i+1 < len(lexemes) && lexemes[i+1].Type == lexIdent {
 				names = append(names, lexemes[i+1].Value.(string))
 			}
@@ -5,6 +5,7 @@
 package script
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -808,3 +809,571 @@ func TestVM_CompileBlock(t *testing.T) {
 		})
 	}
 }
+
+func TestForIn(t *testing.T) {
+	test := []TestVM{
+		{`func for_array string {
+			var out string
+			var a array
+			a = [1, "two", 3]
+			for i, v in a {
+				out = out + Sprintf("%d:%v,", i, v)
+			}
+			return out
+		}`, `for_array`, `0:1,1:two,2:3,`},
+		{`func for_map string {
+			var out string
+			for k, v in {"b": 2, "a": 1, "c": "three"} {
+				out = out + Sprintf("%s=%v;", k, v)
+			}
+			return out
+		}`, `for_map`, `a=1;b=2;c=three;`},
+		{`func for_ext string {
+			var out string
+			for i, v in Split("a,b,c", ",") {
+				out = out + str(i) + v + "|"
+			}
+			for i, v in nil {
+				out = out + "nil"
+			}
+			return out
+		}`, `for_ext`, `0a|1b|2c|`},
+		{`func for_break string {
+			var out string
+			for i, v in [1, 2, 3, 4, 5] {
+				if v == 2 {
+					continue
+				}
+				if v == 4 {
+					break
+				}
+				out = out + Sprintf("%v", v)
+			}
+			for i, v in [[1, 2], [3, 4]] {
+				for j, w in v {
+					if j == 1 {
+						break
+					}
+					v = w
+					out = out + Sprintf("-%v", v)
+				}
+			}
+			return out
+		}`, `for_break`, `13-1-3`},
+		{`func index(a array, s string) int {
+			for i, v in a {
+				if v == s {
+					return i
+				}
+			}
+			return -1
+		}
+		func for_return string {
+			return Sprintf("%d %d", index(["a", "b", "c"], "b"), index(["a"], "d"))
+		}`, `for_return`, `1 -1`},
+		{`func for_one string {
+			for v in [1] {
+			}
+			return ""
+		}`, `for_one`, `for loop must have two variables`},
+		{`func for_three string {
+			for i, v, w in [1] {
+			}
+			return ""
+		}`, `for_three`, `for loop must have two variables`},
+		{`func for_dup string {
+			for v, v in [1] {
+			}
+			return ""
+		}`, `for_dup`, `'v' redeclared in this code block`},
+		{`func for_str string {
+			for i, v in "str" {
+			}
+			return ""
+		}`, `for_str`, `for ... in cannot iterate type string [:2]`},
+	}
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]any{"Sprintf": fmt.Sprintf, "Split": strings.Split,
+		"str": str}, nil, map[string]struct{}{"Sprintf": {}}})
+
+	for ikey, item := range test {
+		source := []rune(item.Input)
+		if err := vm.Compile(source, &OwnerInfo{StateID: uint32(ikey) + 22, Active: true, TableID: 1}); err != nil {
+			assert.Equal(t, item.Output, err.Error(), item.Func)
+			continue
+		}
+		out, err := vm.Call(item.Func, nil, map[string]any{`rt_state`: uint32(ikey) + 22})
+		if err != nil {
+			assert.Equal(t, item.Output, err.Error(), item.Func)
+			continue
+		}
+		assert.Equal(t, item.Output, out[0], item.Func)
+	}
+}
+
+func TestContextKeywords(t *testing.T) {
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]any{"Sprintf": fmt.Sprintf}, nil, map[string]struct{}{"Sprintf": {}}})
+
+	assert.NoError(t, vm.Compile([]rune(`contract OldNames {
+		data {
+			for int
+			import string "optional"
+		}
+		func names(struct int) string {
+			var for, in, try, catch, library, import, migrate int
+			for = struct
+			in = for + 1
+			try = in + 1
+			catch = try + 1
+			library = catch + 1
+			import = library + 1
+			migrate = import + 1
+			for i, v in [migrate] {
+				in = in + v
+			}
+			return Sprintf("%d %d", migrate, in)
+		}
+		action {
+			$result = names($for)
+		}
+	}`), &OwnerInfo{StateID: 1, Active: true, TableID: 1}))
+	out, err := vm.Call(`@1OldNames.names`, []any{int64(1)}, map[string]any{`rt_state`: uint32(1)})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `7 9`, out[0])
+	}
+}
+
+type testSavepoints struct {
+	log []string
+}
+
+func (sp *testSavepoints) Savepoint() (any, error) {
+	sp.log = append(sp.log, fmt.Sprintf("save%d", len(sp.log)))
+	return len(sp.log) - 1, nil
+}
+
+func (sp *testSavepoints) RollbackSavepoint(point any) error {
+	sp.log = append(sp.log, fmt.Sprintf("rollback%d", point))
+	return nil
+}
+
+func (sp *testSavepoints) ReleaseSavepoint(point any) error {
+	sp.log = append(sp.log, fmt.Sprintf("release%d", point))
+	return nil
+}
+
+func TestTryCatch(t *testing.T) {
+	test := []TestVM{
+		{`func try_error string {
+			try {
+				error "wrong value"
+			} catch err {
+				return err["type"] + ":" + err["message"]
+			}
+			return "OK"
+		}`, `try_error`, `error:wrong value`},
+		{`func check(i int) {
+			if i > 1 {
+				warning Sprintf("i is %d", i)
+			}
+		}
+		func try_warning string {
+			var out string
+			var i int
+			while i < 3 {
+				i = i + 1
+				try {
+					var s string
+					s = Sprintf("%d", i)
+					check(i)
+					out = out + s
+				} catch e {
+					out = out + "|" + e["type"] + ":" + e["message"] + "|"
+					continue
+				}
+				out = out + "."
+			}
+			return out
+		}`, `try_warning`, `1.|warning:i is 2||warning:i is 3|`},
+		{`func try_panic string {
+			var i int
+			try {
+				i = 10 / i
+			} catch err {
+				return err["type"] + ":" + err["message"]
+			}
+			return "OK"
+		}`, `try_panic`, `panic:divided by zero [:4]`},
+		{`func try_nested string {
+			try {
+				try {
+					info "inner"
+				} catch err {
+					error "outer " + err["message"]
+				}
+			} catch err {
+				return err["type"] + ":" + err["message"]
+			}
+			return "OK"
+		}`, `try_nested`, `error:outer inner`},
+		{`func try_return string {
+			try {
+				return "try"
+			} catch err {
+				return "catch"
+			}
+			return "OK"
+		}`, `try_return`, `try`},
+		{`func try_cost string {
+			try {
+				while true {
+				}
+			} catch err {
+				return "catch"
+			}
+			return "OK"
+		}`, `try_cost`, `runtime cost limit overflow [ :2]`},
+		{`func try_only string {
+			try {
+				error "wrong"
+			}
+			return "OK"
+		}`, `try_only`, `there is not catch after try [Ln:2]`},
+		{`func catch_only string {
+			catch err {
+			}
+			return "OK"
+		}`, `catch_only`, `there is not try before 6408 [Ln:2 Col:5]`},
+	}
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]any{"Sprintf": fmt.Sprintf}, nil, map[string]struct{}{"Sprintf": {}}})
+
+	for ikey, item := range test {
+		source := []rune(item.Input)
+		if err := vm.Compile(source, &OwnerInfo{StateID: uint32(ikey) + 22, Active: true, TableID: 1}); err != nil {
+			assert.Equal(t, item.Output, err.Error(), item.Func)
+			continue
+		}
+		out, err := vm.Call(item.Func, nil, map[string]any{`rt_state`: uint32(ikey) + 22, `txcost`: int64(10000)})
+		if err != nil {
+			assert.Equal(t, item.Output, err.Error(), item.Func)
+			continue
+		}
+		assert.Equal(t, item.Output, out[0], item.Func)
+	}
+
+	sp := &testSavepoints{}
+	assert.NoError(t, vm.Compile([]rune(`func try_savepoints string {
+			try {
+			} catch err {
+			}
+			try {
+				error "wrong"
+			} catch err {
+				try {
+				} catch err {
+				}
+			}
+			return "OK"
+		}`), &OwnerInfo{StateID: 1, Active: true, TableID: 1}))
+	out, err := vm.Call(`try_savepoints`, nil, map[string]any{`rt_state`: uint32(1), `sc`: sp})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{`OK`}, out)
+	assert.Equal(t, []string{`save0`, `release0`, `save2`, `rollback2`, `save4`, `release4`}, sp.log)
+}
+
+type testDebuggable struct {
+	debug *Debugger
+}
+
+func (d *testDebuggable) Debugger() *Debugger {
+	return d.debug
+}
+
+func TestDebugger(t *testing.T) {
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]any{"Sprintf": fmt.Sprintf}, nil, map[string]struct{}{"Sprintf": {}}})
+	assert.NoError(t, vm.Compile([]rune(`func debug_sum(n int) int {
+			var i, sum int
+			while i < n {
+				i = i + 1
+				sum = sum + i
+			}
+			return sum
+		}`), &OwnerInfo{StateID: 1, Active: true, TableID: 1}))
+
+	debug := NewDebugger()
+	debug.AddBreakpoint(`@1debug_sum`, 5)
+	extend := map[string]any{`rt_state`: uint32(1), `txcost`: int64(10000), `sc`: &testDebuggable{debug}}
+	out, err := vm.Call(`debug_sum`, []any{int64(3)}, extend)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{int64(6)}, out)
+
+	assert.Len(t, debug.Frames, 3)
+	for i, frame := range debug.Frames {
+		assert.Equal(t, uint16(5), frame.Line)
+		assert.Equal(t, `debug_sum`, frame.Contract)
+		assert.Equal(t, int64(i+1), frame.Vars[`i`])
+		assert.Equal(t, int64(i*(i+1)/2), frame.Vars[`sum`])
+		assert.Equal(t, int64(3), frame.Vars[`n`])
+	}
+	assert.NotEmpty(t, debug.Steps)
+	for _, step := range debug.Steps {
+		assert.GreaterOrEqual(t, step.Fuel, int64(1), step.Cmd)
+	}
+	assert.Equal(t, `while`, debug.Steps[len(debug.Steps)-3].Cmd)
+	assert.Greater(t, debug.Fuel(), int64(0))
+	assert.LessOrEqual(t, debug.Fuel(), 10000-extend[`txcost`].(int64))
+
+	debug = NewDebugger()
+	debug.Stepping = true
+	debug.OnBreak = func(frame *Frame) error {
+		if frame.Line == 4 {
+			return fmt.Errorf(`stopped`)
+		}
+		return nil
+	}
+	_, err = vm.Call(`debug_sum`, []any{int64(3)}, map[string]any{`rt_state`: uint32(1), `txcost`: int64(10000),
+		`sc`: &testDebuggable{debug}})
+	assert.EqualError(t, err, `stopped [ :3]`)
+	assert.Equal(t, uint16(4), debug.Frames[len(debug.Frames)-1].Line)
+	assert.Equal(t, len(debug.Steps), len(debug.Frames))
+
+	debug = NewDebugger()
+	debug.Stepping = true
+	debug.MaxFrames = 5
+	_, err = vm.Call(`debug_sum`, []any{int64(3)}, map[string]any{`rt_state`: uint32(1), `txcost`: int64(10000),
+		`sc`: &testDebuggable{debug}})
+	assert.NoError(t, err)
+	assert.Len(t, debug.Frames, 5)
+	assert.Greater(t, len(debug.Steps), 5)
+	assert.True(t, debug.Truncated)
+}
+
+func TestStruct(t *testing.T) {
+	test := []TestVM{
+		{`struct Point {
+			X, Y int
+			Name string
+		}
+		struct Line {
+			Start Point
+			End   Point
+			Price money
+		}
+		func point(x int, y int) Point {
+			return Point{X: x, Y: y, Name: "point"}
+		}
+		func width(l Line) int {
+			return l.End.X - l.Start.X
+		}
+		func struct_fields string {
+			var p Point
+			var l Line
+			p.X = 10
+			p["Y"] = 5
+			l.Start = p
+			l.End = point(30, 2)
+			l.End.Name = "end"
+			l.Price = 3
+			p.X = 20
+			return Sprintf("%d %d %s", width(l), l.Start.X+l.End.Y*2, JSONEncode(l))
+		}`, `struct_fields`, `20 14 {"Start":{"X":10,"Y":5,"Name":""},"End":{"X":30,"Y":2,"Name":"end"},"Price":"3"}`},
+		{`func struct_default string {
+			var p Point
+			p = {Y: 7}
+			return JSONEncode([Point{}, p, Line{End: {X: 1}}])
+		}`, `struct_default`, `[{"X":0,"Y":0,"Name":""},{"X":0,"Y":7,"Name":""},{"Start":{"X":0,"Y":0,"Name":""},"End":{"X":1,"Y":0,"Name":""},"Price":"0"}]`},
+		{`func struct_unknown_field int {
+			var p Point
+			return p.Z
+		}`, `struct_unknown_field`, `unknown field 'Z' of struct Point`},
+		{`func struct_unknown_index int {
+			var p Point
+			return p["Z"]
+		}`, `struct_unknown_index`, `unknown field 'Z' of struct Point`},
+		{`func struct_unknown_init string {
+			return JSONEncode(Point{X: 1, Z: 2})
+		}`, `struct_unknown_init`, `unknown field 'Z' of struct Point`},
+		{`func struct_not_struct int {
+			var p Point
+			return p.X.Y
+		}`, `struct_not_struct`, `X is not a struct`},
+		{`func struct_field_type string {
+			var p Point
+			p.X = "ten"
+			return "OK"
+		}`, `struct_field_type`, `field 'X' (type string) cannot be represented by the type int64 [:0]`},
+		{`func struct_assign string {
+			var p Point
+			p = {X: 1, Z: 2}
+			return "OK"
+		}`, `struct_assign`, `unknown field 'Z' of struct Point [:3]`},
+		{`func struct_param string {
+			return Sprintf("%d", width({Start: {X: "1"}}))
+		}`, `struct_param`, `field 'X' (type string) cannot be represented by the type int64 [ :2]`},
+		{`struct Node {
+			Next Node
+		}`, ``, `struct Node must be declared before struct Node [Ln:2 Col:10]`},
+		{`struct Empty {
+			Name
+		}`, ``, `expecting type of the struct field [Ln:2 Col:5]`},
+		{`contract StructData {
+			data {
+				P Point
+			}
+		}`, ``, `struct Point cannot be the type of the data field [Ln:3 Col:8]`},
+		{`func struct_inner {
+			struct Inner {
+				X int
+			}
+		}`, ``, `struct can be declared only at the top level [Ln:2 Col:5]`},
+	}
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]any{"Sprintf": fmt.Sprintf, "JSONEncode": func(v any) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	}}, nil, nil})
+
+	for _, item := range test {
+		if err := vm.Compile([]rune(item.Input), &OwnerInfo{StateID: 1, Active: true, TableID: 1}); err != nil {
+			assert.Equal(t, item.Output, err.Error(), item.Func)
+			continue
+		}
+		out, err := vm.Call(item.Func, nil, map[string]any{`rt_state`: uint32(1), `txcost`: int64(100000)})
+		if err != nil {
+			assert.Equal(t, item.Output, err.Error(), item.Func)
+			continue
+		}
+		assert.Equal(t, item.Output, out[0], item.Func)
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	vm := NewVM()
+	vm.Extend(&ExtendData{
+		Objects: map[string]any{
+			"Sprintf": fmt.Sprintf,
+			"KeyID":   func(owner *OwnerInfo) int64 { return owner.WalletID },
+			"Save":    func(v int64) int64 { return v },
+			"Len":     func(v []any) int64 { return int64(len(v)) },
+		},
+		AutoPars:   map[string]string{`*script.OwnerInfo`: `owner`},
+		WriteFuncs: map[string]struct{}{`Save`: {}},
+	})
+	compile := func(src string, state uint32) error {
+		return vm.Compile([]rune(src), &OwnerInfo{StateID: state, Active: true, TableID: 1})
+	}
+	call := func(name string) string {
+		out, err := vm.Call(name, nil, map[string]any{`rt_state`: uint32(1), `txcost`: int64(100000)})
+		if err != nil {
+			return err.Error()
+		}
+		return fmt.Sprint(out[0])
+	}
+	assert.NoError(t, compile(`library Math {
+		func Add(a, b int) int {
+			return a + b
+		}
+		func Twice(a int) int {
+			return Add(a, a)
+		}
+		func Sum(vals ...) int {
+			var i, sum int
+			while i < Len(vals) {
+				sum = sum + vals[i]
+				i = i + 1
+			}
+			return sum
+		}
+	}`, 1))
+	assert.NoError(t, compile(`library Text {
+		func Label(a int) string {
+			return Sprintf("#%d", a)
+		}
+	}`, 2))
+	assert.NoError(t, compile(`import Math
+		import @2Text
+		func lib_calls string {
+			return Text.Label(Math.Add(Math.Twice(3), Math.Sum(1, 2, 3)))
+		}
+		contract LibUser {
+			action {
+				$result = Math.Add(1, 2)
+			}
+		}`, 1))
+	assert.Equal(t, `#12`, call(`lib_calls`))
+	assert.Equal(t, []string{`Math`}, func() []string {
+		list, _ := ContractsList(`library Math {}`)
+		return list
+	}())
+
+	errs := []struct {
+		src string
+		err string
+	}{
+		{`import Geometry`, `unknown library Geometry [Ln:1 Col:1]`},
+		{`import Math
+		import Math`, `library Math is imported twice [Ln:2 Col:4]`},
+		{`import Math
+		func lib_unknown int {
+			return Math.Mul(1, 2)
+		}`, `unknown function Mul of library Math [Ln:3 Col:12]`},
+		{`import Math
+		func lib_params int {
+			return Math.Add(1)
+		}`, `function Add must have 2 parameters`},
+		{`func lib_inner {
+			import Math
+		}`, `import can be declared only at the top level [Ln:2 Col:5]`},
+		{`library Impure {
+			func Key int {
+				return $key_id
+			}
+		}`, `$key_id cannot be used in library @1Impure`},
+		{`library Impure {
+			func Key int {
+				return KeyID()
+			}
+		}`, `function KeyID cannot be called from library @1Impure`},
+		{`library Impure {
+			func Write int {
+				return Save(1)
+			}
+		}`, `function Save cannot be called from library @1Impure`},
+		{`library Impure {
+			func Call int {
+				return lib_calls()
+			}
+		}`, `function lib_calls cannot be called from library @1Impure`},
+		{`library Impure {
+			var a int
+		}`, `must be 'func' a08 10 [Ln:2 Col:5]`},
+		{`library Math {
+			func Add(a, b string) string {
+				return a + b
+			}
+		}`, `function Add of library @1Math cannot be removed or changed`},
+	}
+	for _, item := range errs {
+		err := compile(item.src, 1)
+		if assert.Error(t, err, item.src) {
+			assert.Equal(t, item.err, err.Error(), item.src)
+		}
+	}
+
+	// the imports are late-bound, the new version of the library is used by the compiled contracts
+	assert.NoError(t, compile(`library Math {
+		func Add(a, b int) int {
+			return a + b + 100
+		}
+		func Twice(a int) int {
+			return a * 2
+		}
+		func Sum(vals ...) int {
+			return 0
+		}
+	}`, 1))
+	assert.Equal(t, `#106`, call(`lib_calls`))
+}
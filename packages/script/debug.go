@@ -0,0 +1,309 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package script
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/types"
+)
+
+const (
+	// DefaultMaxTraceSteps is the default limit of the commands recorded in the trace
+	DefaultMaxTraceSteps = 100000
+	// DefaultMaxTraceFrames is the default limit of the snapshots recorded at the breakpoints
+	DefaultMaxTraceFrames = 1000
+)
+
+var cmdNames = map[uint16]string{
+	cmdPush:         `push`,
+	cmdVar:          `var`,
+	cmdExtend:       `extend`,
+	cmdCallExtend:   `callextend`,
+	cmdPushStr:      `pushstr`,
+	cmdCall:         `call`,
+	cmdCallVariadic: `callvariadic`,
+	cmdReturn:       `return`,
+	cmdIf:           `if`,
+	cmdElse:         `else`,
+	cmdAssignVar:    `assignvar`,
+	cmdAssign:       `assign`,
+	cmdLabel:        `label`,
+	cmdContinue:     `continue`,
+	cmdWhile:        `while`,
+	cmdBreak:        `break`,
+	cmdIndex:        `index`,
+	cmdSetIndex:     `setindex`,
+	cmdFuncName:     `funcname`,
+	cmdUnwrapArr:    `unwraparr`,
+	cmdMapInit:      `mapinit`,
+	cmdArrayInit:    `arrayinit`,
+	cmdError:        `error`,
+	cmdForIn:        `forin`,
+	cmdTry:          `try`,
+	cmdCatch:        `catch`,
+	cmdStructInit:   `structinit`,
+	cmdNot:          `not`,
+	cmdSign:         `sign`,
+	cmdAdd:          `add`,
+	cmdSub:          `sub`,
+	cmdMul:          `mul`,
+	cmdDiv:          `div`,
+	cmdAnd:          `and`,
+	cmdOr:           `or`,
+	cmdEqual:        `equal`,
+	cmdNotEq:        `noteq`,
+	cmdLess:         `less`,
+	cmdNotLess:      `notless`,
+	cmdGreat:        `great`,
+	cmdNotGreat:     `notgreat`,
+	cmdMod:          `mod`,
+	cmdPow:          `pow`,
+	cmdBitAnd:       `bitand`,
+	cmdBitOr:        `bitor`,
+	cmdBitXor:       `bitxor`,
+	cmdShiftL:       `shiftl`,
+	cmdShiftR:       `shiftr`,
+}
+
+// CmdName returns the name of the bytecode command
+func CmdName(cmd uint16) string {
+	if name, ok := cmdNames[cmd]; ok {
+		return name
+	}
+	return fmt.Sprintf(`cmd%d`, cmd)
+}
+
+// Debuggable is implemented by the smart contract which is executed under the debugger
+type Debuggable interface {
+	Debugger() *Debugger
+}
+
+// Breakpoint stops the execution at the line of the contract or the function
+type Breakpoint struct {
+	Contract string `json:"contract"`
+	Line     uint16 `json:"line"`
+}
+
+// TraceStep is the command of the bytecode executed by the runtime.
+// Fuel is the cost spent by the command including the called functions and contracts
+type TraceStep struct {
+	Contract string `json:"contract"`
+	Func     string `json:"func,omitempty"`
+	Line     uint16 `json:"line"`
+	Cmd      string `json:"cmd"`
+	Depth    int    `json:"depth"`
+	Stack    int    `json:"stack"`
+	Cost     int64  `json:"cost"`
+	Fuel     int64  `json:"fuel"`
+}
+
+// Frame is the state of the runtime at the breakpoint
+type Frame struct {
+	Step     int            `json:"step"`
+	Contract string         `json:"contract"`
+	Func     string         `json:"func,omitempty"`
+	Line     uint16         `json:"line"`
+	Cmd      string         `json:"cmd"`
+	Cost     int64          `json:"cost"`
+	Stack    []any          `json:"stack"`
+	Vars     map[string]any `json:"vars"`
+}
+
+// Debugger records the trace of the execution and stops at the breakpoints.
+// If Stepping is true then every command is a breakpoint. OnBreak is called at the
+// breakpoints, it can change Stepping or stop the execution by returning an error
+type Debugger struct {
+	Steps     []*TraceStep
+	Frames    []*Frame
+	MaxSteps  int
+	MaxFrames int
+	Truncated bool
+	Stepping  bool
+	OnBreak   func(*Frame) error
+
+	breakpoints map[Breakpoint]struct{}
+	locations   map[*CodeBlock]*TraceStep
+	count       int
+	depth       int
+}
+
+// NewDebugger returns a new debugger
+func NewDebugger() *Debugger {
+	return &Debugger{
+		MaxSteps:    DefaultMaxTraceSteps,
+		MaxFrames:   DefaultMaxTraceFrames,
+		breakpoints: make(map[Breakpoint]struct{}),
+		locations:   make(map[*CodeBlock]*TraceStep),
+	}
+}
+
+// AddBreakpoint sets the breakpoint at the line of the contract. The name of the contract
+// can be specified with or without the ecosystem prefix
+func (d *Debugger) AddBreakpoint(contract string, line uint16) {
+	d.breakpoints[Breakpoint{Contract: trimStateName(contract), Line: line}] = struct{}{}
+}
+
+// Breakpoints returns the list of the breakpoints
+func (d *Debugger) Breakpoints() []Breakpoint {
+	ret := make([]Breakpoint, 0, len(d.breakpoints))
+	for bp := range d.breakpoints {
+		ret = append(ret, bp)
+	}
+	return ret
+}
+
+// Fuel returns the total cost spent by the traced commands of the top level
+func (d *Debugger) Fuel() (fuel int64) {
+	for _, step := range d.Steps {
+		if step.Depth == 1 {
+			fuel += step.Fuel
+		}
+	}
+	return
+}
+
+func trimStateName(name string) string {
+	if strings.HasPrefix(name, `@`) {
+		if i := strings.IndexFunc(name[1:], func(r rune) bool { return r < '0' || r > '9' }); i > 0 {
+			return name[i+1:]
+		}
+	}
+	return name
+}
+
+// location returns the names of the contract and the function which the block belongs to
+func (d *Debugger) location(block *CodeBlock) *TraceStep {
+	if loc, ok := d.locations[block]; ok {
+		return loc
+	}
+	loc := &TraceStep{}
+	for b := block; b != nil; b = b.Parent {
+		switch info := b.GetInfo().(type) {
+		case *FuncInfo:
+			if len(loc.Func) == 0 {
+				loc.Func = info.Name
+			}
+		case *ContractInfo:
+			loc.Contract = info.Name
+		}
+		if len(loc.Contract) > 0 {
+			break
+		}
+	}
+	if len(loc.Contract) == 0 {
+		loc.Contract = loc.Func
+	}
+	d.locations[block] = loc
+	return loc
+}
+
+func (d *Debugger) enter() {
+	d.depth++
+}
+
+func (d *Debugger) leave(rt *RunTime, prev *TraceStep) {
+	d.finish(rt, prev)
+	d.depth--
+}
+
+func (d *Debugger) finish(rt *RunTime, prev *TraceStep) {
+	if prev != nil {
+		prev.Fuel = prev.Cost - rt.cost
+	}
+}
+
+// step is called before the command of the block is executed. prev is the previous
+// command of the same block
+func (d *Debugger) step(rt *RunTime, block *CodeBlock, cmd *ByteCode, prev *TraceStep) (*TraceStep, error) {
+	d.finish(rt, prev)
+	loc := d.location(block)
+	cur := &TraceStep{
+		Contract: loc.Contract,
+		Func:     loc.Func,
+		Line:     cmd.Line,
+		Cmd:      CmdName(cmd.Cmd),
+		Depth:    d.depth,
+		Stack:    rt.len(),
+		Cost:     rt.cost,
+	}
+	if len(d.Steps) < d.MaxSteps {
+		d.Steps = append(d.Steps, cur)
+	} else {
+		d.Truncated = true
+	}
+	d.count++
+	if !d.Stepping {
+		if prev != nil && prev.Line == cur.Line {
+			return cur, nil
+		}
+		if _, ok := d.breakpoints[Breakpoint{Contract: trimStateName(cur.Contract), Line: cur.Line}]; !ok {
+			return cur, nil
+		}
+	}
+	// the snapshots are not recorded over the limit, they are only passed to OnBreak
+	store := len(d.Frames) < d.MaxFrames
+	if !store {
+		d.Truncated = true
+		if d.OnBreak == nil {
+			return cur, nil
+		}
+	}
+	frame := &Frame{
+		Step:     d.count - 1,
+		Contract: cur.Contract,
+		Func:     cur.Func,
+		Line:     cur.Line,
+		Cmd:      cur.Cmd,
+		Cost:     cur.Cost,
+		Stack:    make([]any, rt.len()),
+		Vars:     make(map[string]any),
+	}
+	for i, v := range rt.stack[:rt.len()] {
+		frame.Stack[i] = debugValue(v)
+	}
+	for _, item := range rt.blocks {
+		for name, obj := range item.Block.Objects {
+			if obj.Type != ObjectType_Var {
+				continue
+			}
+			if idx := item.Offset + obj.GetVariable().Index; idx < len(rt.vars) {
+				frame.Vars[name] = debugValue(rt.vars[idx])
+			}
+		}
+	}
+	if store {
+		d.Frames = append(d.Frames, frame)
+	}
+	if d.OnBreak != nil {
+		if err := d.OnBreak(frame); err != nil {
+			return cur, err
+		}
+	}
+	return cur, nil
+}
+
+// debugValue copies arrays and maps so the snapshot is not changed by the next commands
+func debugValue(v any) any {
+	switch val := v.(type) {
+	case []any:
+		ret := make([]any, len(val))
+		for i, item := range val {
+			ret[i] = debugValue(item)
+		}
+		return ret
+	case *types.Map:
+		ret := types.NewMap()
+		for _, key := range val.Keys() {
+			item, _ := val.Get(key)
+			ret.Set(key, debugValue(item))
+		}
+		return ret
+	case *CodeBlock, *ObjInfo:
+		return fmt.Sprintf(`%T`, v)
+	}
+	return v
+}
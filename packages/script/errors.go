@@ -23,6 +23,19 @@ const (
 	eDataName             = `expecting name of the data field [Ln:%d Col:%d]`
 	eDataTag              = `unexpected tag [Ln:%d Col:%d]`
 	eConditionNotAllowed  = `condition %s is not allowed`
+	eUnknownField         = `unknown field '%s' of struct %s`
+	eFieldType            = `field '%s' (type %s) cannot be represented by the type %s`
+	eStructType           = `value of type %s cannot be used as struct %s`
+	eStructFieldType      = `expecting type of the struct field [Ln:%d Col:%d]`
+	eStructFieldName      = `expecting name of the struct field [Ln:%d Col:%d]`
+	eStructInit           = `struct %s must be initialized with {...} [Ln:%d Col:%d]`
+	eUnknownLibrary       = `unknown library %s [Ln:%d Col:%d]`
+	eUnknownLibFunc       = `unknown function %s of library %s [Ln:%d Col:%d]`
+	eLibraryImport        = `library %s is imported twice [Ln:%d Col:%d]`
+	eLibraryCall          = `function %s cannot be called from library %s`
+	eLibraryExtend        = `$%s cannot be used in library %s`
+	eLibraryChange        = `function %s of library %s cannot be removed or changed`
+	eLibraryName          = `%s is not a library`
 )
 
 var (
@@ -32,6 +45,7 @@ var (
 	errUnsupportedType    = errors.New(`unsupported combination of types in the operator`)
 	errMaxArrayIndex      = errors.New(`the index is out of range`)
 	errMaxMapCount        = errors.New(`the maxumim length of map`)
+	errMaxCallDepth       = errors.New(`max call depth`)
 	errRecursion          = errors.New(`the contract can't call itself recursively`)
 	errUnclosedArray      = errors.New(`unclosed array initialization`)
 	errUnclosedMap        = errors.New(`unclosed map initialization`)
@@ -45,4 +59,10 @@ var (
 	errEndExp             = errors.New(`unexpected end of the expression`)
 	errOper               = errors.New(`unexpected operator; expecting operand`)
 	errIncorrectParameter = errors.New(`incorrect parameter of the condition function`)
+	errCostLimit          = errors.New(`runtime cost limit overflow`)
+	errIntOverflow        = errors.New(`integer overflow`)
+	errNegativeExp        = errors.New(`negative exponent`)
+	errMaxExp             = errors.New(`the exponent is too big`)
+	errPowDigits          = errors.New(`the result of the exponentiation is too big`)
+	errNegativeShift      = errors.New(`negative shift count`)
 )
@@ -68,6 +68,22 @@ func ExecContract(rt *RunTime, name, txs string, params ...any) (any, error) {
 		prevExtend[key] = item
 		delete(rt.extend, key)
 	}
+	prevthis := rt.extend[Extend_this_contract]
+	prevparent := rt.extend[Extend_parent]
+	// the variables of the caller are restored even if the contract fails, so the error can be caught
+	defer func() {
+		rt.extend[Extend_parent] = prevparent
+		rt.extend[Extend_this_contract] = prevthis
+		for key := range rt.extend {
+			if isSysVar(key) {
+				continue
+			}
+			delete(rt.extend, key)
+		}
+		for key, item := range prevExtend {
+			rt.extend[key] = item
+		}
+	}()
 
 	var isSignature bool
 	if cblock.GetContractInfo().Tx != nil {
@@ -87,11 +103,9 @@ func ExecContract(rt *RunTime, name, txs string, params ...any) (any, error) {
 	for i, ipar := range pars {
 		rt.extend[ipar] = params[i]
 	}
-	prevthis := rt.extend[Extend_this_contract]
 	_, nameContract := converter.ParseName(name)
 	rt.extend[Extend_this_contract] = nameContract
 
-	prevparent := rt.extend[Extend_parent]
 	parent := ``
 	for i := len(rt.blocks) - 1; i >= 0; i-- {
 		if rt.blocks[i].Block.Type == ObjectType_Func && rt.blocks[i].Block.Parent != nil &&
@@ -122,6 +136,7 @@ func ExecContract(rt *RunTime, name, txs string, params ...any) (any, error) {
 		if err := stack.AppendStack(name); err != nil {
 			return nil, err
 		}
+		defer stack.PopStack(name)
 	}
 	if rt.extend[Extend_sc] != nil && isSignature {
 		obj := rt.vm.Objects[`check_signature`]
@@ -133,7 +148,7 @@ func ExecContract(rt *RunTime, name, txs string, params ...any) (any, error) {
 	}
 	for _, method := range []string{`conditions`, `action`} {
 		if block, ok := (*cblock).Objects[method]; ok && block.Type == ObjectType_Func {
-			rtemp := NewRunTime(rt.vm, rt.cost)
+			rtemp := rt.newChild()
 			rt.extend[Extend_parent] = parent
 			_, err = rtemp.Run(block.GetCodeBlock(), nil, rt.extend)
 			rt.cost = rtemp.cost
@@ -143,26 +158,10 @@ func ExecContract(rt *RunTime, name, txs string, params ...any) (any, error) {
 			}
 		}
 	}
-	if stack != nil {
-		stack.PopStack(name)
-	}
 	if err != nil {
 		return nil, err
 	}
-	rt.extend[Extend_parent] = prevparent
-	rt.extend[Extend_this_contract] = prevthis
-	result := rt.extend[Extend_result]
-	for key := range rt.extend {
-		if isSysVar(key) {
-			continue
-		}
-		delete(rt.extend, key)
-	}
-
-	for key, item := range prevExtend {
-		rt.extend[key] = item
-	}
-	return result, nil
+	return rt.extend[Extend_result], nil
 }
 
 // ExContract executes the name contract in the state with specified parameters
@@ -220,3 +219,64 @@ func GetSettings(rt *RunTime, cntname, name string) (any, error) {
 func MemoryUsage(rt *RunTime) int64 {
 	return rt.mem
 }
+
+// RunMigrate executes the migrate section of the name contract. It is called once when the source
+// of the contract is updated, the section is run with the fuel and the limits of the runtime
+// and doesn't see the variables of the caller. The contract is pushed to the call stack as in ExecContract
+// and the functions changing the contracts are denied
+func RunMigrate(rt *RunTime, name string) error {
+	contract, ok := rt.vm.Objects[name]
+	if !ok || contract.Type != ObjectType_Contract {
+		log.WithFields(log.Fields{"contract_name": name, "type": consts.ContractError}).Error("unknown contract")
+		return fmt.Errorf(eUnknownContract, name)
+	}
+	block, ok := contract.GetCodeBlock().Objects[`migrate`]
+	if !ok || block.Type != ObjectType_Func {
+		return nil
+	}
+	prevExtend := make(map[string]any)
+	for key, item := range rt.extend {
+		if isSysVar(key) {
+			continue
+		}
+		prevExtend[key] = item
+		delete(rt.extend, key)
+	}
+	prevthis := rt.extend[Extend_this_contract]
+	defer func() {
+		rt.extend[Extend_this_contract] = prevthis
+		for key := range rt.extend {
+			if isSysVar(key) {
+				continue
+			}
+			delete(rt.extend, key)
+		}
+		for key, item := range prevExtend {
+			rt.extend[key] = item
+		}
+	}()
+	_, nameContract := converter.ParseName(name)
+	rt.extend[Extend_this_contract] = nameContract
+
+	if err := rt.SubCost(CostContract); err != nil {
+		return err
+	}
+	// the contract is on the top of the stack, so the section has only the rights of the contract
+	if stack, ok := rt.extend[Extend_sc].(Stacker); ok {
+		if err := stack.AppendStack(name); err != nil {
+			return err
+		}
+		defer stack.PopStack(name)
+	}
+	if m, ok := rt.extend[Extend_sc].(Migrator); ok {
+		m.StartMigrate()
+		defer m.EndMigrate()
+	}
+	rtemp := rt.newChild()
+	_, err := rtemp.Run(block.GetCodeBlock(), nil, rt.extend)
+	rt.cost = rtemp.cost
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "contract_name": name, "type": consts.ContractError}).Error("executing migrate section")
+	}
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/types"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -41,6 +42,12 @@ const (
 	cfContinue
 	cfBreak
 	cfCmdError
+	cfFor
+	cfForVar
+	cfForIn
+	cfTry
+	cfCatch
+	cfCatchVar
 
 	//	cfEval
 )
@@ -78,6 +85,12 @@ var (
 		cfContinue:   fContinue,
 		cfBreak:      fBreak,
 		cfCmdError:   fCmdError,
+		cfFor:        fFor,
+		cfForVar:     fForVar,
+		cfForIn:      fForIn,
+		cfTry:        fTry,
+		cfCatch:      fCatch,
+		cfCatchVar:   fCatchVar,
 	}
 )
 
@@ -92,6 +105,8 @@ func fError(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
 		`must be type`,             // errVarType
 		`must be '='`,              // errAssign
 		`must be number or string`, // errStrNum
+		`must be 'in'`,             // errMustIn
+		`must be 'func'`,           // errMustFunc
 	}
 	logger := lexeme.GetLogger()
 	if lexeme.Type == lexNewLine {
@@ -113,12 +128,19 @@ func fNameBlock(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
 		name = StateName((*buf)[0].Owner.StateID, name)
 		fblock.Info = &ContractInfo{ID: uint32(len(prev.Children) - 1), Name: name,
 			Owner: (*buf)[0].Owner}
+	case stateLibBlock:
+		itype = ObjectType_Library
+		name = StateName((*buf)[0].Owner.StateID, name)
+		fblock.Info = &LibraryInfo{ID: uint32(len(prev.Children) - 1), Name: name,
+			Owner: (*buf)[0].Owner}
 	default:
 		itype = ObjectType_Func
 		fblock.Info = &FuncInfo{Name: name}
 	}
 	fblock.Type = itype
-	if _, ok := prev.Objects[name]; ok {
+	if _, ok := prev.Objects[name]; ok && prev.Type == ObjectType_Library {
+		return fmt.Errorf("%s '%s' redeclared in this library '%s'", itype, name, prev.GetLibraryInfo().Name)
+	} else if ok {
 		lexeme.GetLogger().WithFields(log.Fields{"type": consts.ParseError, "contract": prev.GetContractInfo().Name, "lex_value": name}).Errorf("%s redeclared in this contract", itype)
 		return fmt.Errorf("%s '%s' redeclared in this contract '%s'", itype, name, prev.GetContractInfo().Name)
 	}
@@ -208,6 +230,12 @@ func fFtype(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
 	for vkey, ivar := range block.Vars {
 		if ivar == reflect.TypeOf(nil) {
 			block.Vars[vkey] = lexeme.Value.(reflect.Type)
+			if lexeme.Struct != nil {
+				if block.Structs == nil {
+					block.Structs = make(map[int]*StructInfo)
+				}
+				block.Structs[vkey] = lexeme.Struct
+			}
 		}
 	}
 	return nil
@@ -295,6 +323,86 @@ func fBreak(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
 	return nil
 }
 
+func fFor(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
+	buf.peek().Info = &ForInfo{}
+	return nil
+}
+
+func fForVar(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
+	info := buf.peek().GetForInfo()
+	name := lexeme.Value.(string)
+	if !regexp.MustCompile(VarRegexp).MatchString(name) {
+		if len(name) > 20 {
+			name = name[:20] + "..."
+		}
+		return fmt.Errorf("identifier expected, got '%s'", name)
+	}
+	switch {
+	case len(info.Key) == 0:
+		info.Key = name
+	case len(info.Value) == 0:
+		if name == info.Key {
+			return fmt.Errorf("'%s' redeclared in this code block", name)
+		}
+		info.Value = name
+	default:
+		return fmt.Errorf("for loop must have two variables")
+	}
+	return nil
+}
+
+func fForIn(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
+	block := buf.peek()
+	info := block.GetForInfo()
+	if len(info.Value) == 0 {
+		return fmt.Errorf("for loop must have two variables")
+	}
+	// the expression of the range has been compiled into the loop block, it must be evaluated in the parent
+	parent := buf.get(len(*buf) - 2)
+	parent.Code = append(parent.Code, block.Code...)
+	block.Code = nil
+	if block.Objects == nil {
+		block.Objects = make(map[string]*ObjInfo)
+	}
+	for _, name := range []string{info.Key, info.Value} {
+		block.Objects[name] = &ObjInfo{Type: ObjectType_Var, Value: &ObjInfo_Variable{Name: name, Index: len(block.Vars)}}
+		block.Vars = append(block.Vars, reflect.TypeOf((*any)(nil)).Elem())
+	}
+	parent.Code.push(newByteCode(cmdForIn, lexeme.Line, block))
+	return nil
+}
+
+func fTry(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
+	buf.get(len(*buf) - 2).Code.push(newByteCode(cmdTry, lexeme.Line, buf.peek()))
+	return nil
+}
+
+func fCatch(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
+	prev := buf.get(len(*buf) - 2).Code.peek()
+	if prev == nil || prev.Cmd != cmdTry {
+		return fmt.Errorf(`there is not try before %v [Ln:%d Col:%d]`, lexeme.Type, lexeme.Line, lexeme.Column)
+	}
+	buf.get(len(*buf) - 2).Code.push(newByteCode(cmdCatch, lexeme.Line, buf.peek()))
+	return nil
+}
+
+func fCatchVar(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
+	block := buf.peek()
+	name := lexeme.Value.(string)
+	if !regexp.MustCompile(VarRegexp).MatchString(name) {
+		if len(name) > 20 {
+			name = name[:20] + "..."
+		}
+		return fmt.Errorf("identifier expected, got '%s'", name)
+	}
+	block.Info = &CatchInfo{Name: name}
+	block.Objects = map[string]*ObjInfo{
+		name: {Type: ObjectType_Var, Value: &ObjInfo_Variable{Name: name, Index: len(block.Vars)}},
+	}
+	block.Vars = append(block.Vars, reflect.TypeOf(&types.Map{}))
+	return nil
+}
+
 func fAssignVar(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
 	block := buf.peek()
 	var (
@@ -306,6 +414,9 @@ func fAssignVar(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
 			lexeme.GetLogger().WithFields(log.Fields{"type": consts.ParseError, "lex_value": lexeme.Value}).Error("modifying system variable")
 			return fmt.Errorf(eSysVar, lexeme.Value.(string))
 		}
+		if len(*buf) > 1 && (*buf)[1].Type == ObjectType_Library {
+			return fmt.Errorf(eLibraryExtend, lexeme.Value, (*buf)[1].GetLibraryInfo().Name)
+		}
 		ivar = VarInfo{Obj: &ObjInfo{Type: ObjectType_ExtVar, Value: &ObjInfo_ExtendVariable{Name: lexeme.Value.(string)}}, Owner: nil}
 	} else {
 		objInfo, tobj := findVar(lexeme.Value.(string), buf)
@@ -432,6 +543,10 @@ func fFieldType(buf *CodeBlocks, state stateTypes, lexeme *Lexeme) error {
 	if len(*tx) == 0 || (*tx)[len(*tx)-1].Type != nil {
 		return fmt.Errorf(eDataName, lexeme.Line, lexeme.Column)
 	}
+	if lexeme.Struct != nil {
+		return fmt.Errorf(`struct %s cannot be the type of the data field [Ln:%d Col:%d]`,
+			lexeme.Struct.Name, lexeme.Line, lexeme.Column)
+	}
 	for i, field := range *tx {
 		if field.Type == reflect.TypeOf(nil) {
 			(*tx)[i].Type = lexeme.Value.(reflect.Type)
@@ -10,6 +10,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/IBAX-io/go-ibax/packages/consts"
 	"github.com/IBAX-io/go-ibax/packages/types"
@@ -25,7 +26,7 @@ const (
 	// Here are all the created lexemes
 	lexUnknown = iota
 	lexSys     // a system lexeme is different bracket, =, comma and so on.
-	lexOper    // Operator is +, -, *, /
+	lexOper    // Operator is +, -, *, /, %, **, &, |, ^, <<, >>
 	lexNumber  // Number
 	lexIdent   // Identifier
 	lexNewLine // Line translation
@@ -69,6 +70,13 @@ const (
 	isEqEq     = 0x3d3d // ==
 	isGrEq     = 0x3e3d // >=
 	isOr       = 0x7c7c // ||
+	isPercent  = 0x0025 // %
+	isAmp      = 0x0026 // &
+	isCaret    = 0x005e // ^
+	isPipe     = 0x007c // |
+	isPower    = 0x2a2a // **
+	isShiftL   = 0x3c3c // <<
+	isShiftR   = 0x3e3e // >>
 
 )
 
@@ -97,12 +105,22 @@ const (
 	keyCond
 	keyTail
 	keyError
+	keyFor
+	keyIn
+	keyTry
+	keyCatch
+	keyStruct
+	keyLibrary
+	keyImport
+	keyMigrate
 )
 
 const (
 	msgWarning = `warning`
 	msgError   = `error`
 	msgInfo    = `info`
+	msgPanic   = `panic`
+	msgLimit   = `limit`
 )
 
 const (
@@ -116,6 +134,7 @@ const (
 	DtFloat
 	DtString
 	DtFile
+	DtStruct
 )
 
 type typeInfo struct {
@@ -136,11 +155,19 @@ var (
 		msgWarning:   keyWarning,
 		msgInfo:      keyInfo,
 		`while`:      keyWhile,
+		`for`:        keyFor,
+		`in`:         keyIn,
+		`try`:        keyTry,
+		`catch`:      keyCatch,
+		`struct`:     keyStruct,
+		`library`:    keyLibrary,
+		`import`:     keyImport,
 		`data`:       keyTX,
 		`settings`:   keySettings,
 		`nil`:        keyNil,
 		`action`:     keyAction,
 		`conditions`: keyCond,
+		`migrate`:    keyMigrate,
 		`true`:       keyTrue,
 		`false`:      keyFalse,
 		`break`:      keyBreak,
@@ -148,6 +175,19 @@ var (
 		`var`:        keyVar,
 		`...`:        keyTail}
 
+	// The keywords which were added after the contracts had been deployed. They are keywords
+	// only in the statement position and when they are followed by a name or '{', otherwise they
+	// are identifiers, so the contracts which use these words as names still compile
+	contextKeywords = map[uint32]rune{
+		keyFor:     'a',
+		keyTry:     '{',
+		keyCatch:   'a',
+		keyStruct:  'a',
+		keyLibrary: 'a',
+		keyImport:  'a',
+		keyMigrate: '{',
+	}
+
 	// list of available types
 	// The list of types which save the corresponding 'reflect' type
 	typesMap = map[string]typeInfo{
@@ -192,9 +232,10 @@ func GetFieldDefaultValue(fieldType uint32) any {
 type Lexeme struct {
 	Type   uint32 // Type of the lexeme
 	Ext    uint32
-	Value  any    // Value of lexeme
-	Line   uint16 // Line of the lexeme
-	Column uint32 // Position inside the line
+	Value  any         // Value of lexeme
+	Line   uint16      // Line of the lexeme
+	Column uint32      // Position inside the line
+	Struct *StructInfo // The struct if the lexeme is the name of the struct type
 }
 
 func NewLexeme(t uint32, ext uint32, value any, line uint16, column uint32) *Lexeme {
@@ -344,10 +385,14 @@ func lexParser(input []rune) (Lexemes, error) {
 				}
 			case lexIdent:
 				name := string(input[lexOff:right])
+				var next []rune
+				if int(right) < len(input) {
+					next = input[right:]
+				}
 				if name[0] == '$' {
 					lexID = lexExtend
 					value = name[1:]
-				} else if keyID, ok := keywords[name]; ok {
+				} else if keyID, ok := keywords[name]; ok && isKeyword(keyID, lexemes, next) {
 					switch keyID {
 					case keyIf:
 						ifbuf = append(ifbuf, ifBuf{})
@@ -362,7 +407,7 @@ func lexParser(input []rune) (Lexemes, error) {
 							value = uint32(keyIf)
 							ifbuf[len(ifbuf)-1].count++
 						}
-					case keyAction, keyCond:
+					case keyAction, keyCond, keyMigrate:
 						if len(lexemes) > 0 {
 							lexf := *lexemes[len(lexemes)-1]
 							if lexf.Type&0xff != lexKeyword || lexf.Value.(uint32) != keyFunc {
@@ -405,6 +450,48 @@ func lexParser(input []rune) (Lexemes, error) {
 	return lexemes, nil
 }
 
+// isKeyword returns false if the context keyword is used as an identifier
+func isKeyword(keyID uint32, lexemes Lexemes, next []rune) bool {
+	if keyID == keyIn {
+		// for name in, for key, name in
+		n := len(lexemes)
+		return n > 1 && lexemes[n-1].Type == lexIdent && (lexemes[n-2].Type == lexKeyword|(keyFor<<8) ||
+			n > 3 && lexemes[n-2].Type == isComma && lexemes[n-3].Type == lexIdent &&
+				lexemes[n-4].Type == lexKeyword|(keyFor<<8))
+	}
+	follow, ok := contextKeywords[keyID]
+	if !ok {
+		return true
+	}
+	if n := len(lexemes); n > 0 {
+		switch lexemes[n-1].Type {
+		case lexNewLine, isLCurly, isRCurly:
+		default:
+			return false
+		}
+	}
+	for i, ch := range next {
+		if ch == ' ' || ch == '\t' {
+			continue
+		}
+		if follow == '{' {
+			return ch == '{'
+		}
+		if ch != '_' && ch != '@' && !unicode.IsLetter(ch) {
+			return false
+		}
+		// a data field with the type, e.g. 'import string'
+		end := i
+		for end < len(next) && (next[end] == '_' || next[end] == '@' || unicode.IsLetter(next[end]) ||
+			unicode.IsDigit(next[end])) {
+			end++
+		}
+		_, isType := typesMap[string(next[i:end])]
+		return !isType
+	}
+	return false
+}
+
 func OriginalToString(original uint32) string {
 	for key, v := range typesMap {
 		if v.Original == original {
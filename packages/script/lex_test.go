@@ -35,7 +35,8 @@ func TestLexParser(t *testing.T) {
 		{`!ab < !b && 12>=56 && qwe!=asd`, `[2 33][4 ab][2 60][2 33][4 b][2 9766][3 12][2 15933][3 56][2 9766][4 qwe][2 8509][4 asd]`},
 		{`ab || 12 && 56`, `[4 ab][2 31868][3 12][2 9766][3 56]`},
 		{"12 /*rue \n weweswe*/ 42", `[3 12][3 42]`},
-		{`true | 42`, `unknown lexeme   [Ln:1 Col:7]`},
+		{`true | 42`, `[3 true][2 124][3 42]`},
+		{`a%3**2 & b|c ^ d<<1 >> 2<=3`, `[4 a][2 37][3 3][2 10794][3 2][2 38][4 b][2 124][4 c][2 94][4 d][2 15420][3 1][2 15934][3 2][2 15421][3 3]`},
 		{"(\r\n)\x03 -", "unknown lexeme  [Ln:2 Col:3]"},
 		{` +( - )	/ + // edeld lklm  3edwd`, `[2 43][10241 40][2 45][10497 41][2 47][2 43]`},
 		{`23+13424 * 1000.01 test`, `[3 23][2 43][3 13424][2 42][3 1000.01][4 test]`},
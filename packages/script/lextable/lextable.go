@@ -21,7 +21,7 @@ type States map[string]Action
 
 const (
 	// AlphaSize is the length of alphabet
-	AlphaSize = 34
+	AlphaSize = 36
 )
 
 /*
@@ -77,6 +77,8 @@ var (
 		'-',
 		'/',
 		'\\',
+		'%',
+		'^',
 		'0',
 		'1',
 		'a',
@@ -116,8 +118,11 @@ var (
 			"|": ["or", "", "push next"],
 			"=": ["eq", "", "push next"],
 			"/": ["solidus", "", "push next"],
-			"<>!": ["oneq", "", "push next"],
-			"*+-": ["main", "oper", "next"],
+			"<": ["less", "", "push next"],
+			">": ["great", "", "push next"],
+			"!": ["oneq", "", "push next"],
+			"*": ["asterisk", "", "push next"],
+			"+-%^": ["main", "oper", "next"],
 			"01": ["number", "", "push next"],
 			"a_r": ["ident", "", "push next"],
 			"@$": ["mustident", "", "push next"],
@@ -147,11 +152,23 @@ var (
 	},
 	"and": {
 			"&": ["main", "oper", "pop next"],
-			"d": ["error", "", ""]
+			"d": ["main", "oper", "pop"]
 		},
 	"or": {
 			"|": ["main", "oper", "pop next"],
-			"d": ["error", "", ""]
+			"d": ["main", "oper", "pop"]
+		},
+	"asterisk": {
+			"*": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
+		},
+	"less": {
+			"=<": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
+		},
+	"great": {
+			"=>": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
 		},
 	"eq": {
 			"=": ["main", "oper", "pop next"],
@@ -0,0 +1,147 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/pkg/errors"
+)
+
+// The codes of the exceeded limits of VM
+const (
+	LimitCallDepth  = `call_depth`
+	LimitMemory     = `memory`
+	LimitStringSize = `string_size`
+	LimitArraySize  = `array_size`
+)
+
+// VMVersionLimits is the version of VM from which the items of the maps and the memory and the call depth
+// of the nested contracts are counted and the sizes of the strings and the arrays are limited. The blocks
+// played before the version is set by vm_version platform parameter are played with the previous rules
+const VMVersionLimits = 1
+
+// Limits are the limits of the execution in VM. They are the same on every node, so the
+// transaction exceeding them fails deterministically
+type Limits struct {
+	Version    int64
+	CallDepth  int64
+	Memory     int64
+	StringSize int64
+	ArraySize  int64
+}
+
+// GetLimits returns the limits of VM from the platform parameters
+func GetLimits() Limits {
+	return Limits{
+		Version:    syspar.GetVMVersion(),
+		CallDepth:  syspar.GetMaxCallDepth(),
+		Memory:     syspar.GetMaxVMMemory(),
+		StringSize: syspar.GetMaxStringSize(),
+		ArraySize:  syspar.GetMaxArraySize(),
+	}
+}
+
+// counted returns true if the limits are counted by the rules of VMVersionLimits
+func (l Limits) counted() bool {
+	return l.Version >= VMVersionLimits
+}
+
+// Stats are the statistics of the execution of the transaction in VM
+type Stats struct {
+	PeakMemory   int64 `json:"peak_memory"`
+	PeakDepth    int64 `json:"peak_depth"`
+	Instructions int64 `json:"instructions"`
+}
+
+// StatsKeeper is implemented by the owner of the runtime which collects the statistics
+// of all the runs of the transaction
+type StatsKeeper interface {
+	VMStats() *Stats
+}
+
+// limitError returns the error of the exceeded limit. It has the type limit and the code of the limit
+func limitError(code string, limit int64) error {
+	out, _ := json.Marshal(&VMError{Type: msgLimit, Code: code,
+		Error: fmt.Sprintf(`%s limit %d exceeded`, strings.ReplaceAll(code, `_`, ` `), limit)})
+	return errors.New(string(out))
+}
+
+// IsLimitError returns true if the error is caused by the exceeded limit of VM
+func IsLimitError(err error) bool {
+	var vmErr VMError
+	return err != nil && strings.HasPrefix(err.Error(), `{`) &&
+		json.Unmarshal([]byte(err.Error()), &vmErr) == nil && vmErr.Type == msgLimit
+}
+
+// checkSize checks that the string or the array does not exceed the limits
+func (rt *RunTime) checkSize(v any) error {
+	if !rt.limits.counted() {
+		return nil
+	}
+	switch val := v.(type) {
+	case string:
+		if int64(len(val)) > rt.limits.StringSize {
+			return limitError(LimitStringSize, rt.limits.StringSize)
+		}
+	case []any:
+		if int64(len(val)) > rt.limits.ArraySize {
+			return limitError(LimitArraySize, rt.limits.ArraySize)
+		}
+	}
+	return nil
+}
+
+// checkMemory updates the peak memory and checks the memory limit of the transaction
+func (rt *RunTime) checkMemory() error {
+	mem := rt.memBase + rt.mem
+	if mem > rt.stats.PeakMemory {
+		rt.stats.PeakMemory = mem
+	}
+	if mem > rt.limits.Memory {
+		if !rt.limits.counted() {
+			return ErrMemoryLimit
+		}
+		return limitError(LimitMemory, rt.limits.Memory)
+	}
+	return nil
+}
+
+// checkCallDepth checks the limit of the call depth
+func (rt *RunTime) checkCallDepth() error {
+	if rt.callDepth >= rt.limits.CallDepth {
+		if !rt.limits.counted() {
+			return errMaxCallDepth
+		}
+		return limitError(LimitCallDepth, rt.limits.CallDepth)
+	}
+	return nil
+}
+
+// newChild creates the runtime of the nested contract. It continues the statistics of the current runtime
+// and, from VMVersionLimits, the call depth and the memory
+func (rt *RunTime) newChild() *RunTime {
+	child := NewRunTime(rt.vm, rt.cost)
+	child.limits = rt.limits
+	child.stats = rt.stats
+	if rt.limits.counted() {
+		child.callDepth = rt.callDepth
+		child.memBase = rt.memBase + rt.mem
+	}
+	return child
+}
+
+// calcMem returns the memory of the value, the items of *types.Map are counted from VMVersionLimits
+func (rt *RunTime) calcMem(v any) int64 {
+	return calcMem(v, rt.limits.counted())
+}
+
+// Stats returns the statistics of the execution
+func (rt *RunTime) Stats() Stats {
+	return *rt.stats
+}
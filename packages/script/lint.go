@@ -0,0 +1,535 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package script
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The static analysis of the contract source is implemented in this file. The lexemes are
+// grouped into the tree of the statements and blocks {...} and the rules are checked on
+// this tree. The compiler is used only to find the syntax errors.
+
+// The rules of the analyzer
+const (
+	LintSyntax         = `syntax`
+	LintUnusedVar      = `unused-var`
+	LintUnreachable    = `unreachable`
+	LintUnboundedWhile = `unbounded-while`
+	LintDBInLoop       = `db-in-loop`
+	LintMissingCond    = `missing-conditions`
+	LintUndefinedCont  = `undefined-contract`
+	LintUndefinedParam = `undefined-param`
+	LintShadowedExtend = `shadowed-extend`
+)
+
+// The severities of the issues
+const (
+	LintSeverityError   = `error`
+	LintSeverityWarning = `warning`
+
+	lintConditions = `conditions`
+)
+
+var (
+	reLintPos = regexp.MustCompile(`\[Ln:(\d+)(?: Col:(\d+))?\]`)
+
+	// lintDBFuncs are the functions which query the database
+	lintDBFuncs = map[string]struct{}{
+		"DBFind":                {},
+		"DBRow":                 {},
+		"DBSelect":              {},
+		"DBInsert":              {},
+		"DBUpdate":              {},
+		"DBUpdateExt":           {},
+		"DBUpdatePlatformParam": {},
+		"DBCount":               {},
+		"EcosysParam":           {},
+		"AppParam":              {},
+		"GetHistoryRow":         {},
+		"GetColumnType":         {},
+	}
+	// lintContractFuncs are the functions which get the name of the contract as the first parameter
+	lintContractFuncs = map[string]struct{}{
+		"CallContract":       {},
+		"ContractAccess":     {},
+		"ContractConditions": {},
+		"GetContractByName":  {},
+	}
+	// lintRuntimeVars are the extend variables of the runtime which are not protected by the compiler
+	lintRuntimeVars = map[string]struct{}{
+		Extend_rt_state: {},
+		Extend_rt:       {},
+	}
+)
+
+// LintIssue is the problem found by the static analysis of the contract source
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Line     uint16 `json:"line"`
+	Column   uint32 `json:"column"`
+}
+
+func (i *LintIssue) String() string {
+	return fmt.Sprintf(`%d:%d %s %s: %s`, i.Line, i.Column, i.Severity, i.Rule, i.Message)
+}
+
+// LintOptions are the optional sources of the analysis. If VM is defined then the source is
+// compiled to find the syntax errors and the contracts are searched in VM. ContractExists and
+// EcosysParamExists are used to check the names of contracts and ecosystem parameters
+type LintOptions struct {
+	VM                *VM
+	Owner             *OwnerInfo
+	ContractExists    func(name string) bool
+	EcosysParamExists func(name string) bool
+}
+
+type lintStmt struct {
+	lexemes Lexemes
+	block   *lintBlock
+}
+
+type lintBlock struct {
+	head  *lintStmt
+	stmts []*lintStmt
+}
+
+// keyword returns the keyword which starts the statement
+func (st *lintStmt) keyword() uint32 {
+	if len(st.lexemes) > 0 && st.lexemes[0].Type&0xff == lexKeyword {
+		return st.lexemes[0].Type >> 8
+	}
+	return 0
+}
+
+// name returns the name of contract or function
+func (st *lintStmt) name() *Lexeme {
+	if len(st.lexemes) > 1 && st.lexemes[1].Type == lexIdent {
+		return st.lexemes[1]
+	}
+	return nil
+}
+
+type linter struct {
+	lexemes   Lexemes
+	pos       int
+	opts      *LintOptions
+	issues    []*LintIssue
+	contracts map[string]bool
+	dbFuncs   map[string]struct{}
+}
+
+// Lint analyzes the source of contracts and functions and returns the found issues
+// sorted by the position
+func Lint(input string, opts *LintOptions) []*LintIssue {
+	if opts == nil {
+		opts = &LintOptions{}
+	}
+	l := &linter{opts: opts, contracts: make(map[string]bool), dbFuncs: make(map[string]struct{})}
+	lexemes, err := lexParser([]rune(input))
+	if err != nil {
+		l.syntaxError(err)
+		return l.issues
+	}
+	if opts.VM != nil {
+		owner := opts.Owner
+		if owner == nil {
+			owner = &OwnerInfo{StateID: 1}
+		}
+		if _, err = opts.VM.CompileBlock([]rune(input), owner); err != nil {
+			l.syntaxError(err)
+		}
+		for name := range opts.VM.FuncCallsDB {
+			l.dbFuncs[name] = struct{}{}
+		}
+	}
+	for name := range lintDBFuncs {
+		l.dbFuncs[name] = struct{}{}
+	}
+	l.lexemes = lexemes
+	root := &lintBlock{stmts: l.parseStmts()}
+	for _, st := range root.stmts {
+		if st.keyword() == keyContract && st.name() != nil {
+			l.contracts[st.name().Value.(string)] = true
+		}
+	}
+	l.checkBlock(root, nil)
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].Line != l.issues[j].Line {
+			return l.issues[i].Line < l.issues[j].Line
+		}
+		if l.issues[i].Column != l.issues[j].Column {
+			return l.issues[i].Column < l.issues[j].Column
+		}
+		return l.issues[i].Rule < l.issues[j].Rule
+	})
+	return l.issues
+}
+
+func (l *linter) syntaxError(err error) {
+	issue := &LintIssue{Rule: LintSyntax, Severity: LintSeverityError, Message: err.Error()}
+	if pos := reLintPos.FindStringSubmatch(err.Error()); pos != nil {
+		line, _ := strconv.ParseUint(pos[1], 10, 16)
+		col, _ := strconv.ParseUint(pos[2], 10, 32)
+		issue.Line, issue.Column = uint16(line), uint32(col)
+	}
+	l.issues = append(l.issues, issue)
+}
+
+func (l *linter) warning(rule string, lexeme *Lexeme, format string, args ...any) {
+	l.issues = append(l.issues, &LintIssue{
+		Rule:     rule,
+		Severity: LintSeverityWarning,
+		Message:  fmt.Sprintf(format, args...),
+		Line:     lexeme.Line,
+		Column:   lexeme.Column,
+	})
+}
+
+// isBlockHead returns true if the statement with the keyword has a block {...}
+func isBlockHead(key uint32) bool {
+	switch key {
+	case keyContract, keyLibrary, keyFunc, keyIf, keyElif, keyElse, keyWhile, keyFor, keyTry, keyCatch, keyTX, keySettings:
+		return true
+	}
+	return false
+}
+
+// opensBlock returns true if the curly bracket after prev starts the block of the statement.
+// Otherwise it is the initialization of the map
+func opensBlock(prev *Lexeme) bool {
+	switch prev.Type & 0xff {
+	case lexIdent, lexNumber, lexString, lexType, lexExtend:
+		return true
+	case lexKeyword:
+		return prev.Type>>8 != keyIn && prev.Type>>8 != keyReturn
+	case lexSys:
+		return prev.Type == isRPar || prev.Type == isRBrack || prev.Type == isRCurly
+	}
+	return false
+}
+
+// parseStmts groups the lexemes into the statements till the closing curly bracket
+func (l *linter) parseStmts() (stmts []*lintStmt) {
+	for l.pos < len(l.lexemes) {
+		lexeme := l.lexemes[l.pos]
+		if lexeme.Type == lexNewLine {
+			l.pos++
+			continue
+		}
+		if lexeme.Type == isRCurly {
+			l.pos++
+			return
+		}
+		stmts = append(stmts, l.parseStmt())
+	}
+	return
+}
+
+func (l *linter) parseStmt() *lintStmt {
+	st := &lintStmt{}
+	depth := 0
+	for l.pos < len(l.lexemes) {
+		lexeme := l.lexemes[l.pos]
+		switch lexeme.Type {
+		case lexNewLine:
+			if depth == 0 {
+				return st
+			}
+		case isLPar, isLBrack:
+			depth++
+		case isRPar, isRBrack:
+			depth--
+		case isLCurly:
+			if depth == 0 && isBlockHead(st.keyword()) && len(st.lexemes) > 0 &&
+				opensBlock(st.lexemes[len(st.lexemes)-1]) {
+				l.pos++
+				st.block = &lintBlock{head: st}
+				st.block.stmts = l.parseStmts()
+				return st
+			}
+			depth++
+		case isRCurly:
+			if depth == 0 {
+				return st
+			}
+			depth--
+		}
+		st.lexemes = append(st.lexemes, lexeme)
+		l.pos++
+	}
+	return st
+}
+
+// allLexemes returns the lexemes of the block including the nested blocks
+func (b *lintBlock) allLexemes() (ret Lexemes) {
+	for _, st := range b.stmts {
+		ret = append(ret, st.lexemes...)
+		if st.block != nil {
+			ret = append(ret, st.block.allLexemes()...)
+		}
+	}
+	return
+}
+
+func (l *linter) checkBlock(block *lintBlock, contract *lintStmt) {
+	terminated := false
+	for i, st := range block.stmts {
+		key := st.keyword()
+		if terminated {
+			l.warning(LintUnreachable, st.lexemes[0], `unreachable code`)
+			terminated = false
+		}
+		switch key {
+		case keyReturn, keyBreak, keyContinue, keyError, keyWarning, keyInfo:
+			terminated = st.block == nil
+		case keyContract:
+			l.checkContract(st)
+			continue
+		case keyVar:
+			l.checkVar(st, block.stmts[i+1:])
+		case keyWhile:
+			l.checkWhile(st)
+		}
+		if key == keyWhile || key == keyFor {
+			if st.block != nil {
+				l.checkDBInLoop(st.block.allLexemes())
+			}
+		}
+		l.checkCalls(st.lexemes)
+		if contract != nil {
+			l.checkExtend(st.lexemes, contract, block)
+		}
+		if st.block != nil {
+			l.checkBlock(st.block, contract)
+		}
+	}
+}
+
+func (l *linter) checkContract(st *lintStmt) {
+	name := st.name()
+	if st.block == nil || name == nil {
+		return
+	}
+	var cond bool
+	for _, item := range st.block.stmts {
+		if item.keyword() == keyFunc && item.name() != nil && item.name().Value.(string) == lintConditions {
+			cond = true
+		}
+	}
+	if !cond {
+		l.warning(LintMissingCond, name, `contract %s has no conditions section`, name.Value)
+	}
+	l.checkBlock(st.block, st)
+}
+
+// requiredFields returns the names of the required parameters of the data section of the contract
+func requiredFields(contract *lintStmt) map[string]bool {
+	ret := make(map[string]bool)
+	for _, st := range contract.block.stmts {
+		if st.keyword() != keyTX || st.block == nil {
+			continue
+		}
+		for _, field := range st.block.stmts {
+			if len(field.lexemes) == 0 || field.lexemes[0].Type != lexIdent {
+				continue
+			}
+			optional := false
+			for _, lexeme := range field.lexemes[1:] {
+				if lexeme.Type == lexString && strings.Contains(lexeme.Value.(string), TagOptional) {
+					optional = true
+				}
+			}
+			if !optional {
+				ret[field.lexemes[0].Value.(string)] = true
+			}
+		}
+	}
+	return ret
+}
+
+// checkVar checks that the declared variables are read by the next statements of the block
+func (l *linter) checkVar(st *lintStmt, next []*lintStmt) {
+	for i, lexeme := range st.lexemes[1:] {
+		// the identifier after the name of the variable is the name of the struct type
+		if lexeme.Type != lexIdent || st.lexemes[i].Type == lexIdent {
+			continue
+		}
+		if name := lexeme.Value.(string); !isRead(name, next) {
+			l.warning(LintUnusedVar, lexeme, `variable %s is declared but not used`, name)
+		}
+	}
+}
+
+// isRead returns true if the variable is used by the statements not as the target of the assignment
+func isRead(name string, stmts []*lintStmt) bool {
+	for _, st := range stmts {
+		dest := targets(st.lexemes)
+		for i, lexeme := range st.lexemes {
+			if lexeme.Type == lexIdent && lexeme.Value.(string) == name && !dest[lexeme] &&
+				(i == 0 || st.lexemes[i-1].Type != isDot) {
+				return true
+			}
+		}
+		if st.block != nil && isRead(name, st.block.stmts) {
+			return true
+		}
+	}
+	return false
+}
+
+// targets returns the variables which are assigned by the statement
+func targets(lexemes Lexemes) map[*Lexeme]bool {
+	ret := make(map[*Lexeme]bool)
+	depth := 0
+	for i, lexeme := range lexemes {
+		switch lexeme.Type {
+		case isEq:
+			if depth == 0 {
+				return ret
+			}
+		case isLBrack, isLPar, isLCurly:
+			depth++
+		case isRBrack, isRPar, isRCurly:
+			depth--
+		case lexIdent, lexExtend:
+			if depth == 0 && (i == 0 || lexemes[i-1].Type == isComma) {
+				ret[lexeme] = true
+			}
+		}
+	}
+	return nil
+}
+
+// assigned returns the names of the variables which are assigned in the block
+func assigned(stmts []*lintStmt, ret map[string]bool) map[string]bool {
+	for _, st := range stmts {
+		for lexeme := range targets(st.lexemes) {
+			ret[lexeme.Value.(string)] = true
+		}
+		if st.block != nil {
+			assigned(st.block.stmts, ret)
+		}
+	}
+	return ret
+}
+
+// checkWhile reports the while loop which cannot exit. The loop is bounded if its body
+// can exit or changes one of the variables of the condition, or the condition calls a function
+func (l *linter) checkWhile(st *lintStmt) {
+	if st.block == nil {
+		return
+	}
+	body := st.block.allLexemes()
+	for _, lexeme := range body {
+		switch lexeme.Type >> 8 {
+		case keyBreak, keyReturn, keyError, keyWarning, keyInfo:
+			if lexeme.Type&0xff == lexKeyword {
+				return
+			}
+		}
+	}
+	set := assigned(st.block.stmts, make(map[string]bool))
+	cond := st.lexemes[1:]
+	for i, lexeme := range cond {
+		if lexeme.Type != lexIdent && lexeme.Type != lexExtend {
+			continue
+		}
+		if i+1 < len(cond) && cond[i+1].Type == isLPar {
+			return
+		}
+		if set[lexeme.Value.(string)] {
+			return
+		}
+	}
+	l.warning(LintUnboundedWhile, st.lexemes[0], `while loop has no bound`)
+}
+
+func (l *linter) checkDBInLoop(body Lexemes) {
+	for i, lexeme := range body {
+		if lexeme.Type != lexIdent || i+1 >= len(body) || body[i+1].Type != isLPar ||
+			(i > 0 && body[i-1].Type == isDot) {
+			continue
+		}
+		if _, ok := l.dbFuncs[lexeme.Value.(string)]; ok {
+			l.warning(LintDBInLoop, lexeme, `%s queries the database inside the loop`, lexeme.Value)
+		}
+	}
+}
+
+// checkCalls checks the names of the contracts and ecosystem parameters in the calls
+func (l *linter) checkCalls(lexemes Lexemes) {
+	for i := 0; i+2 < len(lexemes); i++ {
+		if lexemes[i].Type != lexIdent || lexemes[i+1].Type != isLPar || lexemes[i+2].Type != lexString {
+			continue
+		}
+		fname, arg := lexemes[i].Value.(string), lexemes[i+2]
+		value := arg.Value.(string)
+		if _, ok := lintContractFuncs[fname]; ok && len(value) > 0 && !l.contractExists(value) {
+			l.warning(LintUndefinedCont, arg, `contract %s is not defined`, value)
+		}
+		if fname == `EcosysParam` && l.opts.EcosysParamExists != nil && !l.opts.EcosysParamExists(value) {
+			l.warning(LintUndefinedParam, arg, `ecosystem parameter %s is not defined`, value)
+		}
+	}
+}
+
+func (l *linter) contractExists(name string) bool {
+	if l.contracts[trimStateName(name)] {
+		return true
+	}
+	if l.opts.ContractExists != nil {
+		return l.opts.ContractExists(name)
+	}
+	if l.opts.VM != nil {
+		var state uint32 = 1
+		if l.opts.Owner != nil {
+			state = l.opts.Owner.StateID
+		}
+		return VMObjectExists(l.opts.VM, name, state)
+	}
+	return true
+}
+
+// checkExtend reports the assignment of the runtime variables and the assignment of
+// the required parameters of the data section outside of conditions
+func (l *linter) checkExtend(lexemes Lexemes, contract *lintStmt, block *lintBlock) {
+	for lexeme := range targets(lexemes) {
+		if lexeme.Type != lexExtend {
+			continue
+		}
+		name := lexeme.Value.(string)
+		if _, ok := lintRuntimeVars[name]; ok {
+			l.warning(LintShadowedExtend, lexeme, `$%s shadows the variable of the runtime`, name)
+		} else if requiredFields(contract)[name] && !isConditions(block, contract) {
+			l.warning(LintShadowedExtend, lexeme, `$%s overwrites the required parameter of the data section`, name)
+		}
+	}
+}
+
+// isConditions returns true if the block belongs to the conditions section of the contract
+func isConditions(block *lintBlock, contract *lintStmt) bool {
+	for _, st := range contract.block.stmts {
+		if st.keyword() == keyFunc && st.name() != nil && st.name().Value.(string) == lintConditions {
+			return st.block == block || st.block.contains(block)
+		}
+	}
+	return false
+}
+
+func (b *lintBlock) contains(block *lintBlock) bool {
+	for _, st := range b.stmts {
+		if st.block != nil && (st.block == block || st.block.contains(block)) {
+			return true
+		}
+	}
+	return false
+}
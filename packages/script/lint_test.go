@@ -0,0 +1,116 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package script
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lintRules(issues []*LintIssue) (ret []string) {
+	for _, issue := range issues {
+		ret = append(ret, issue.String())
+	}
+	return
+}
+
+func TestLint(t *testing.T) {
+	test := []struct {
+		Input  string
+		Output []string
+	}{
+		{`contract Clean {
+	data {
+		Name string
+	}
+	conditions {
+		if !$Name {
+			$Name = "default"
+		}
+	}
+	action {
+		var i, sum int
+		var list array
+		list = [1, 2, 3]
+		while i < 3 {
+			sum = sum + list[i]
+			i = i + 1
+		}
+		for k, v in {"a": 1} {
+			sum = sum + v
+		}
+		$result = sum
+	}
+}`, nil},
+		{`contract Bad {
+	data {
+		Name string
+	}
+	action {
+		var a, b int
+		var m map
+		m["x"] = 1
+		while b < 10 {
+			a = a + 1
+			DBFind("keys").Row()
+		}
+		$Name = "x"
+		$rt_state = 2
+		CallContract("Unknown", {})
+		return
+		a = 2
+	}
+}`, []string{
+			`1:10 warning missing-conditions: contract Bad has no conditions section`,
+			`7:8 warning unused-var: variable m is declared but not used`,
+			`9:4 warning unbounded-while: while loop has no bound`,
+			`11:5 warning db-in-loop: DBFind queries the database inside the loop`,
+			`13:4 warning shadowed-extend: $Name overwrites the required parameter of the data section`,
+			`14:4 warning shadowed-extend: $rt_state shadows the variable of the runtime`,
+			`15:17 warning undefined-contract: contract Unknown is not defined`,
+			`17:4 warning unreachable: unreachable code`,
+		}},
+		{`func f() {
+	while true {
+		if EcosysParam("missing") {
+			break
+		}
+	}
+	error "stop"
+	return
+}`, []string{
+			`3:7 warning db-in-loop: EcosysParam queries the database inside the loop`,
+			`3:19 warning undefined-param: ecosystem parameter missing is not defined`,
+			`8:3 warning unreachable: unreachable code`,
+		}},
+	}
+	for _, item := range test {
+		issues := Lint(item.Input, &LintOptions{
+			ContractExists: func(name string) bool {
+				return name == `Known`
+			},
+			EcosysParamExists: func(name string) bool {
+				return name != `missing`
+			},
+		})
+		assert.Equal(t, item.Output, lintRules(issues), item.Input)
+	}
+}
+
+func TestLintSyntax(t *testing.T) {
+	vm := NewVM()
+	assert.Equal(t, []string{`1:10 error syntax: must be '{' 2301 35 [Ln:1 Col:10]`},
+		lintRules(Lint(`func f() # {}`, &LintOptions{VM: vm})))
+	issues := Lint(`func f() {
+	var a int
+	a = a +
+}`, &LintOptions{VM: vm})
+	assert.Len(t, issues, 1)
+	assert.Equal(t, LintSyntax, issues[0].Rule)
+	assert.Empty(t, Lint(`func f() int {
+	return 1
+}`, &LintOptions{VM: vm}))
+}
@@ -7,7 +7,9 @@ package script
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -37,8 +39,9 @@ const (
 
 	maxArrayIndex = 1000000
 	maxMapCount   = 100000
-	maxCallDepth  = 1000
-	memoryLimit   = 128 << 20 // 128 MB
+	maxExponent   = 1000 // the maximum exponent of ** for money values
+	maxPowDigits  = 1000 // the maximum digits and decimal places of the result of ** for money values
+	forInVars     = 2    // the key and the value of for ... in loop
 	MaxErrLen     = 150
 )
 
@@ -81,6 +84,7 @@ var (
 // VMError represents error of VM
 type VMError struct {
 	Type  string `json:"type"`
+	Code  string `json:"code,omitempty"`
 	Error string `json:"error"`
 }
 
@@ -106,10 +110,14 @@ type RunTime struct {
 	err       error
 	unwrap    bool
 	timeLimit bool
-	callDepth uint16
+	callDepth int64
 	mem       int64
+	memBase   int64 // the memory of the parent runtimes
 	memVars   map[any]int64
+	limits    Limits
+	stats     *Stats
 	errInfo   ErrInfo
+	debug     *Debugger
 }
 
 // NewRunTime creates a new RunTime for the virtual machine
@@ -119,6 +127,8 @@ func NewRunTime(vm *VM, cost int64) *RunTime {
 		vm:      vm,
 		cost:    cost,
 		memVars: make(map[any]int64),
+		limits:  GetLimits(),
+		stats:   &Stats{},
 	}
 }
 
@@ -133,11 +143,14 @@ func (rt *RunTime) callFunc(cmd uint16, obj *ObjInfo) (err error) {
 	var (
 		count, in int
 	)
-	if rt.callDepth >= maxCallDepth {
-		return fmt.Errorf("max call depth")
+	if err = rt.checkCallDepth(); err != nil {
+		return
 	}
 
 	rt.callDepth++
+	if rt.callDepth > rt.stats.PeakDepth {
+		rt.stats.PeakDepth = rt.callDepth
+	}
 	defer func() {
 		rt.callDepth--
 	}()
@@ -291,6 +304,9 @@ func (rt *RunTime) callFunc(cmd uint16, obj *ObjInfo) (err error) {
 				return ret.Interface().(error)
 			}
 		} else {
+			if err = rt.checkSize(ret.Interface()); err != nil {
+				return
+			}
 			rt.push(ret.Interface())
 		}
 	}
@@ -328,7 +344,15 @@ func (rt *RunTime) extendFunc(name string) error {
 	return nil
 }
 
-func calcMem(v any) (mem int64) {
+func calcMem(v any, countMaps bool) (mem int64) {
+	if m, ok := v.(*types.Map); ok && countMaps {
+		mem = 4
+		for _, key := range m.Keys() {
+			item, _ := m.Get(key)
+			mem += int64(len(key)) + calcMem(item, countMaps)
+		}
+		return
+	}
 	rv := reflect.ValueOf(v)
 
 	switch rv.Kind() {
@@ -351,13 +375,13 @@ func calcMem(v any) (mem int64) {
 	case reflect.Slice, reflect.Array:
 		mem = 12
 		for i := 0; i < rv.Len(); i++ {
-			mem += calcMem(rv.Index(i).Interface())
+			mem += calcMem(rv.Index(i).Interface(), countMaps)
 		}
 	case reflect.Map:
 		mem = 4
 		for _, k := range rv.MapKeys() {
-			mem += calcMem(k.Interface())
-			mem += calcMem(rv.MapIndex(k).Interface())
+			mem += calcMem(k.Interface(), countMaps)
+			mem += calcMem(rv.MapIndex(k).Interface(), countMaps)
 		}
 	default:
 		mem = int64(unsafe.Sizeof(v))
@@ -372,14 +396,14 @@ func (rt *RunTime) setExtendVar(k string, v any) {
 }
 
 func (rt *RunTime) recalcMemExtendVar(k string) {
-	mem := calcMem(rt.extend[k])
+	mem := rt.calcMem(rt.extend[k])
 	rt.mem += mem - rt.memVars[k]
 	rt.memVars[k] = mem
 }
 
 func (rt *RunTime) addVar(v any) {
 	rt.vars = append(rt.vars, v)
-	mem := calcMem(v)
+	mem := rt.calcMem(v)
 	rt.memVars[len(rt.vars)-1] = mem
 	rt.mem += mem
 }
@@ -390,7 +414,7 @@ func (rt *RunTime) setVar(k int, v any) {
 }
 
 func (rt *RunTime) recalcMemVar(k int) {
-	mem := calcMem(rt.vars[k])
+	mem := rt.calcMem(rt.vars[k])
 	rt.mem += mem - rt.memVars[k]
 	rt.memVars[k] = mem
 }
@@ -469,6 +493,167 @@ func ValueToDecimal(v any) (ret decimal.Decimal, err error) {
 	return
 }
 
+// operCosts contains the costs of the operators which are charged in addition to the cost of the command
+var operCosts = map[uint16]int64{
+	cmdMod:    CostMod,
+	cmdPow:    CostPow,
+	cmdBitAnd: CostBitwise,
+	cmdBitOr:  CostBitwise,
+	cmdBitXor: CostBitwise,
+	cmdShiftL: CostShift,
+	cmdShiftR: CostShift,
+}
+
+// operDecimals returns the operands as decimals if one of them is decimal and another one is int or decimal
+func operDecimals(left, right any) (ret [2]decimal.Decimal, ok bool) {
+	for i, v := range []any{left, right} {
+		switch val := v.(type) {
+		case decimal.Decimal:
+			ret[i] = val
+			ok = true
+		case int64:
+			ret[i] = decimal.New(val, 0)
+		default:
+			return ret, false
+		}
+	}
+	return
+}
+
+func mulInt(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	ret := a * b
+	if ret/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, errIntOverflow
+	}
+	return ret, nil
+}
+
+// modOper calculates left % right for int and money values
+func modOper(left, right any) (any, error) {
+	l, lok := left.(int64)
+	r, rok := right.(int64)
+	if lok && rok {
+		if r == 0 {
+			return nil, errDivZero
+		}
+		return l % r, nil
+	}
+	if dec, ok := operDecimals(left, right); ok {
+		if dec[1].IsZero() {
+			return nil, errDivZero
+		}
+		return dec[0].Mod(dec[1]), nil
+	}
+	return nil, errUnsupportedType
+}
+
+// powOper calculates left ** right for int and money values, the exponent must be non-negative integer
+func powOper(left, right any) (any, error) {
+	l, lok := left.(int64)
+	r, rok := right.(int64)
+	if lok && rok {
+		if r < 0 {
+			return nil, errNegativeExp
+		}
+		var err error
+		ret := int64(1)
+		for ; r > 0; r >>= 1 {
+			if r&1 == 1 {
+				if ret, err = mulInt(ret, l); err != nil {
+					return nil, err
+				}
+			}
+			if r > 1 {
+				if l, err = mulInt(l, l); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return ret, nil
+	}
+	dec, exp, err := powDecimals(left, right)
+	if err != nil {
+		return nil, err
+	}
+	base, ret := dec[0], decimal.New(1, 0)
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			ret = ret.Mul(base)
+		}
+		if exp > 1 {
+			base = base.Mul(base)
+		}
+	}
+	return ret, nil
+}
+
+// powDecimals returns the money operands of ** and the exponent. It returns the error if the result
+// has more than maxPowDigits digits or decimal places
+func powDecimals(left, right any) (dec [2]decimal.Decimal, exp int64, err error) {
+	var ok bool
+	if dec, ok = operDecimals(left, right); !ok {
+		return dec, 0, errUnsupportedType
+	}
+	if !dec[1].IsInteger() || dec[1].IsNegative() {
+		return dec, 0, errNegativeExp
+	}
+	if dec[1].GreaterThan(decimal.New(maxExponent, 0)) {
+		return dec, 0, errMaxExp
+	}
+	exp = dec[1].IntPart()
+	if decimalDigits(dec[0])*exp > maxPowDigits || int64(math.Abs(float64(dec[0].Exponent())))*exp > maxPowDigits {
+		return dec, 0, errPowDigits
+	}
+	return dec, exp, nil
+}
+
+// decimalDigits returns the number of the digits of the coefficient of the decimal, it is less by one
+// at most than the real number
+func decimalDigits(d decimal.Decimal) int64 {
+	bits := d.Coefficient().BitLen()
+	if bits == 0 {
+		return 1
+	}
+	return int64(float64(bits-1)*math.Log10(2)) + 1
+}
+
+// powCost returns the cost of ** for money values which is proportional to the digits of the base
+// and the exponent. The result of ** for int values is limited by int64, so it has no additional cost
+func powCost(left, right any) int64 {
+	dec, exp, err := powDecimals(left, right)
+	if err != nil {
+		return 0
+	}
+	return CostPowDigits * decimalDigits(dec[0]) * exp / 10
+}
+
+// bitOper calculates the bitwise operators and shifts for int values
+func bitOper(cmd uint16, left, right any) (any, error) {
+	l, lok := left.(int64)
+	r, rok := right.(int64)
+	if !lok || !rok {
+		return nil, errUnsupportedType
+	}
+	switch cmd {
+	case cmdBitAnd:
+		return l & r, nil
+	case cmdBitOr:
+		return l | r, nil
+	case cmdBitXor:
+		return l ^ r, nil
+	}
+	if r < 0 {
+		return nil, errNegativeShift
+	}
+	if cmd == cmdShiftL {
+		return l << uint64(r), nil
+	}
+	return l >> uint64(r), nil
+}
+
 // SetCost sets the max cost of the execution.
 func (rt *RunTime) SetCost(cost int64) {
 	rt.cost = cost
@@ -479,7 +664,7 @@ func (rt *RunTime) SubCost(cost int64) error {
 		rt.cost -= cost
 	}
 	if rt.cost < 0 {
-		return fmt.Errorf("runtime cost limit overflow")
+		return errCostLimit
 	}
 	return nil
 }
@@ -530,10 +715,20 @@ func (rt *RunTime) getResultValue(item mapItem) (value any, err error) {
 		value, err = rt.getResultMap(item.Value.(*types.Map))
 	case mapArray:
 		value, err = rt.getResultArray(item.Value.([]mapItem))
+	case mapStruct:
+		value, err = rt.getResultStruct(item.Value.(*StructInit))
 	}
 	return
 }
 
+func (rt *RunTime) getResultStruct(cmd *StructInit) (*types.Map, error) {
+	initMap, err := rt.getResultMap(cmd.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Struct.Value(initMap)
+}
+
 func (rt *RunTime) getResultArray(cmd []mapItem) ([]any, error) {
 	initArr := make([]any, 0)
 	for _, val := range cmd {
@@ -586,13 +781,159 @@ func isSelfAssignment(dest, value any) bool {
 	return false
 }
 
+// stackVars returns the count of the variables of the block which are initialized from the stack
+func (block *CodeBlock) stackVars() int {
+	switch block.GetInfo().(type) {
+	case *ForInfo:
+		return forInVars
+	case *CatchInfo:
+		return 1
+	}
+	return 0
+}
+
+func (rt *RunTime) freeVars(off int) {
+	for i := off; i < len(rt.vars); i++ {
+		rt.mem -= rt.memVars[i]
+		delete(rt.memVars, i)
+	}
+	rt.vars = rt.vars[:off]
+}
+
+// runForIn executes the block of for ... in loop for each item of the array or the map.
+// The keys of the map are iterated in sorted order
+func (rt *RunTime) runForIn(block *CodeBlock, val any) (status int, err error) {
+	iterate := func(key, value any) (bool, error) {
+		if err = rt.SubCost(CostForIn); err != nil {
+			return false, err
+		}
+		off := len(rt.vars)
+		rt.push(key)
+		rt.push(value)
+		status, err = rt.RunCode(block)
+		rt.freeVars(off)
+		if err != nil || status == statusReturn {
+			return false, err
+		}
+		if status == statusBreak {
+			status = statusNormal
+			return false, nil
+		}
+		status = statusNormal
+		return true, nil
+	}
+	var next bool
+	switch v := val.(type) {
+	case nil:
+	case *types.Map:
+		keys := append([]string{}, v.Keys()...)
+		sort.Strings(keys)
+		for _, key := range keys {
+			item, ok := v.Get(key)
+			if !ok {
+				continue
+			}
+			if next, err = iterate(key, item); !next {
+				break
+			}
+		}
+	case []any:
+		for i := 0; i < len(v); i++ {
+			if next, err = iterate(int64(i), v[i]); !next {
+				break
+			}
+		}
+	default:
+		rv := reflect.ValueOf(val)
+		if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return statusNormal, fmt.Errorf(`for ... in cannot iterate type %s`, reflect.TypeOf(val))
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if next, err = iterate(int64(i), rv.Index(i).Interface()); !next {
+				break
+			}
+		}
+	}
+	return
+}
+
+// isCatchable returns true if the error of the try block can be handled by catch.
+// The exceeded limits of the execution cannot be caught
+func (rt *RunTime) isCatchable(err error) bool {
+	if rt.timeLimit || rt.cost < 0 || rt.memBase+rt.mem > rt.limits.Memory || IsLimitError(err) {
+		return false
+	}
+	for _, e := range []error{ErrVMTimeLimit, ErrMemoryLimit, errCostLimit} {
+		if strings.HasPrefix(err.Error(), e.Error()) {
+			return false
+		}
+	}
+	return true
+}
+
+// catchError returns the map with the type and the message of the caught error
+func catchError(err error) *types.Map {
+	var vmErr VMError
+	if json.Unmarshal([]byte(err.Error()), &vmErr) != nil || len(vmErr.Type) == 0 {
+		vmErr = VMError{Type: msgPanic, Error: err.Error()}
+	}
+	ret := types.NewMap()
+	ret.Set(`type`, vmErr.Type)
+	ret.Set(`message`, vmErr.Error)
+	return ret
+}
+
+// runTry executes the try block. If it fails, the changes of the block are rolled back and
+// the catch block gets the error
+func (rt *RunTime) runTry(try, catch *CodeBlock) (status int, err error) {
+	var point any
+	sp, _ := rt.extend[Extend_sc].(Savepointer)
+	if sp != nil {
+		if point, err = sp.Savepoint(); err != nil {
+			return
+		}
+	}
+	size, vars, blocks := rt.len(), len(rt.vars), len(rt.blocks)
+	status, err = rt.RunCode(try)
+	if err == nil {
+		if sp != nil {
+			err = sp.ReleaseSavepoint(point)
+		}
+		return
+	}
+	if !rt.isCatchable(err) {
+		return
+	}
+	if sp != nil {
+		if errRollback := sp.RollbackSavepoint(point); errRollback != nil {
+			return statusNormal, errRollback
+		}
+	}
+	rt.resetByIdx(size)
+	rt.freeVars(vars)
+	rt.blocks = rt.blocks[:blocks]
+	rt.unwrap = false
+	rt.errInfo = ErrInfo{}
+	rt.push(catchError(err))
+	return rt.RunCode(catch)
+}
+
 // RunCode executes CodeBlock
 func (rt *RunTime) RunCode(block *CodeBlock) (status int, err error) {
-	var cmd *ByteCode
+	var (
+		cmd     *ByteCode
+		dbgStep *TraceStep
+	)
+	if rt.debug != nil {
+		rt.debug.enter()
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.Errorf(`runtime run code crashed: %v`, r)
 		}
+		if rt.debug != nil {
+			rt.debug.leave(rt, dbgStep)
+		}
 		if err != nil && !strings.HasPrefix(err.Error(), `{`) {
 			var curContract, line string
 			if block.isParentContract() {
@@ -645,6 +986,15 @@ func (rt *RunTime) RunCode(block *CodeBlock) (status int, err error) {
 		var value any
 		if block.Type == ObjectType_Func && vkey < len(block.GetFuncInfo().Params) {
 			value = rt.stack[start-len(block.GetFuncInfo().Params)+vkey]
+			if info := block.Structs[vkey]; info != nil {
+				if value, err = info.Value(value); err != nil {
+					break
+				}
+			}
+		} else if count := block.stackVars(); vkey < count {
+			value = rt.stack[start-count+vkey]
+		} else if info := block.Structs[vkey]; info != nil {
+			value = info.New()
 		} else {
 			value = reflect.New(vpar).Elem().Interface()
 			if vpar == reflect.TypeOf(&types.Map{}) {
@@ -673,6 +1023,8 @@ func (rt *RunTime) RunCode(block *CodeBlock) (status int, err error) {
 	}
 	if block.Type == ObjectType_Func {
 		start -= len(block.GetFuncInfo().Params)
+	} else {
+		start -= block.stackVars()
 	}
 	var (
 		assign []*VarInfo
@@ -682,6 +1034,11 @@ func (rt *RunTime) RunCode(block *CodeBlock) (status int, err error) {
 	labels := make([]int, 0)
 main:
 	for ci := 0; ci < len(block.Code); ci++ {
+		if rt.debug != nil {
+			if dbgStep, err = rt.debug.step(rt, block, block.Code[ci], dbgStep); err != nil {
+				break
+			}
+		}
 		if err = rt.SubCost(1); err != nil {
 			break
 		}
@@ -690,9 +1047,9 @@ main:
 			break
 		}
 
-		if rt.mem > memoryLimit {
-			rt.vm.logger.WithFields(log.Fields{"type": consts.VMError}).Warn(ErrMemoryLimit)
-			err = ErrMemoryLimit
+		rt.stats.Instructions++
+		if err = rt.checkMemory(); err != nil {
+			rt.vm.logger.WithFields(log.Fields{"type": consts.VMError}).Warn(err)
 			break
 		}
 
@@ -707,6 +1064,11 @@ main:
 		for i := 1; i <= int(cmd.Cmd>>8); i++ {
 			top[i-1] = rt.stack[size-i]
 		}
+		if cost, ok := operCosts[cmd.Cmd]; ok {
+			if err = rt.SubCost(cost); err != nil {
+				break
+			}
+		}
 		switch cmd.Cmd {
 		case cmdPush:
 			rt.push(cmd.Value)
@@ -737,6 +1099,13 @@ main:
 					break
 				}
 			}
+		case cmdForIn:
+			val := rt.peek()
+			rt.resetByIdx(rt.len() - 1)
+			status, err = rt.runForIn(cmd.Value.(*CodeBlock), val)
+		case cmdTry:
+			status, err = rt.runTry(cmd.Value.(*CodeBlock), block.Code[ci+1].Value.(*CodeBlock))
+			ci++
 		case cmdLabel:
 			labels = append(labels, ci)
 		case cmdContinue:
@@ -767,6 +1136,11 @@ main:
 					for i := len(rt.blocks) - 1; i >= 0; i-- {
 						if item.Owner == rt.blocks[i].Block {
 							k := rt.blocks[i].Offset + item.Obj.GetVariable().Index
+							if info := rt.blocks[i].Block.Structs[item.Obj.GetVariable().Index]; info != nil {
+								if val, err = info.Value(val); err != nil {
+									break main
+								}
+							}
 							switch v := rt.blocks[i].Block.Vars[item.Obj.GetVariable().Index]; v.String() {
 							case Decimal:
 								var v decimal.Decimal
@@ -776,7 +1150,7 @@ main:
 								}
 								rt.setVar(k, v)
 							default:
-								if val != nil && v != reflect.TypeOf(val) {
+								if val != nil && v.Kind() != reflect.Interface && v != reflect.TypeOf(val) {
 									err = fmt.Errorf("variable '%v' (type %s) cannot be represented by the type %s", item.Obj.GetVariable().Name, reflect.TypeOf(val), v)
 									break
 								}
@@ -929,6 +1303,11 @@ main:
 					err = fmt.Errorf(eMapIndex, reflect.TypeOf(rt.stack[size-2]).String())
 					break
 				}
+				if indexInfo.Field != nil {
+					if rt.stack[size-1], err = indexInfo.Field.checkValue(rt.getStack(size - 1)); err != nil {
+						break
+					}
+				}
 				rt.stack[size-3].(*types.Map).Set(rt.stack[size-2].(string),
 					reflect.ValueOf(rt.getStack(size-1)).Interface())
 				rt.resetByIdx(size - 2)
@@ -945,6 +1324,10 @@ main:
 							err = errMaxArrayIndex
 							break
 						}
+						if rt.limits.counted() && ind >= rt.limits.ArraySize {
+							err = limitError(LimitArraySize, rt.limits.ArraySize)
+							break
+						}
 						slice = append(slice, make([]any, int(ind)-len(slice)+1)...)
 						indexInfo := cmd.Value.(*IndexInfo)
 						if indexInfo.Owner == nil { // Extend variable $varname
@@ -989,6 +1372,9 @@ main:
 				switch top[0].(type) {
 				case string:
 					bin = top[1].(string) + top[0].(string)
+					if err = rt.checkSize(bin); err != nil {
+						break main
+					}
 				case int64:
 					if tmpInt, err = converter.ValueToInt(top[1]); err == nil {
 						bin = tmpInt + top[0].(int64)
@@ -1182,6 +1568,21 @@ main:
 					break main
 				}
 			}
+		case cmdMod:
+			if bin, err = modOper(top[1], top[0]); err != nil {
+				break main
+			}
+		case cmdPow:
+			if err = rt.SubCost(powCost(top[1], top[0])); err != nil {
+				break main
+			}
+			if bin, err = powOper(top[1], top[0]); err != nil {
+				break main
+			}
+		case cmdBitAnd, cmdBitOr, cmdBitXor, cmdShiftL, cmdShiftR:
+			if bin, err = bitOper(cmd.Cmd, top[1], top[0]); err != nil {
+				break main
+			}
 		case cmdAnd:
 			bin = valueToBool(top[1]) && valueToBool(top[0])
 		case cmdOr:
@@ -1327,6 +1728,9 @@ main:
 			if err != nil {
 				break main
 			}
+			if err = rt.checkSize(initArray); err != nil {
+				break main
+			}
 			rt.push(initArray)
 		case cmdMapInit:
 			var initMap *types.Map
@@ -1335,6 +1739,13 @@ main:
 				break main
 			}
 			rt.push(initMap)
+		case cmdStructInit:
+			var initStruct *types.Map
+			initStruct, err = rt.getResultStruct(cmd.Value.(*StructInit))
+			if err != nil {
+				break main
+			}
+			rt.push(initStruct)
 		default:
 			rt.vm.logger.WithFields(log.Fields{"vm_cmd": cmd.Cmd}).Error("Unknown command")
 			err = fmt.Errorf(`unknown command %d`, cmd.Cmd)
@@ -1398,6 +1809,12 @@ func (rt *RunTime) Run(block *CodeBlock, params []any, extend map[string]any) (r
 	}()
 	info := block.GetFuncInfo()
 	rt.extend = extend
+	if d, ok := extend[Extend_sc].(Debuggable); ok && rt.debug == nil {
+		rt.debug = d.Debugger()
+	}
+	if k, ok := extend[Extend_sc].(StatsKeeper); ok {
+		rt.stats = k.VMStats()
+	}
 	var (
 		genBlock bool
 		timer    *time.Timer
@@ -1411,6 +1828,10 @@ func (rt *RunTime) Run(block *CodeBlock, params []any, extend map[string]any) (r
 	if genBlock {
 		timer = time.AfterFunc(time.Millisecond*time.Duration(extend[Extend_time_limit].(int64)), timeOver)
 	}
+	// the parameters of function are taken from the stack
+	for _, par := range params {
+		rt.push(par)
+	}
 	if _, err = rt.RunCode(block); err == nil {
 		if rt.len() < len(info.Results) {
 			var keyNames []string
@@ -5,12 +5,22 @@
 package script
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
+	"unsafe"
 
+	"github.com/IBAX-io/go-ibax/packages/types"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCalcMem(t *testing.T) {
+	m := types.NewMap()
+	m.Set("test", "test")
 	cases := []struct {
 		v   any
 		mem int64
@@ -23,9 +33,294 @@ func TestCalcMem(t *testing.T) {
 		{[]byte("test"), 16},
 		{[]string{"test", "test"}, 20},
 		{map[string]string{"test": "test"}, 12},
+		{m, 12},
 	}
 
 	for _, v := range cases {
-		assert.Equal(t, v.mem, calcMem(v.v))
+		assert.Equal(t, v.mem, calcMem(v.v, true))
 	}
+	// the items of the map are not counted before VMVersionLimits
+	assert.Equal(t, int64(unsafe.Sizeof(any(m))), calcMem(m, false))
+}
+
+func TestOperators(t *testing.T) {
+	cases := []struct {
+		expr string
+		out  any
+	}{
+		{`7 % 3`, int64(1)},
+		{`-7 % 3`, int64(-1)},
+		{`2 ** 10`, int64(1024)},
+		{`2 ** 3 ** 2`, int64(512)},
+		{`2 * 3 ** 2`, int64(18)},
+		{`(-2) ** 3`, int64(-8)},
+		{`7 ** 0`, int64(1)},
+		{`12 & 10`, int64(8)},
+		{`12 | 10`, int64(14)},
+		{`12 ^ 10`, int64(6)},
+		{`1 << 10`, int64(1024)},
+		{`-1024 >> 3`, int64(-128)},
+		{`1 | 2 & 3`, int64(3)},
+		{`1 + 2 << 3`, int64(17)},
+		{`5 & 4 == 4`, true},
+		{`Money(10) % Money(4)`, decimal.New(2, 0)},
+		{`Money(10) % 3`, decimal.New(1, 0)},
+		{`Money(10) ** 18`, decimal.New(1, 18)},
+		{`2 ** Money(3)`, decimal.New(8, 0)},
+		{`7 % 0`, `divided by zero`},
+		{`Money(7) % Money(0)`, `divided by zero`},
+		{`2 ** -1`, `negative exponent`},
+		{`2 ** 63`, `integer overflow`},
+		{`Money(2) ** 1001`, `the exponent is too big`},
+		{`Money(99) ** 1000`, `the result of the exponentiation is too big`},
+		{`(Money(99) ** 100) ** 100`, `the result of the exponentiation is too big`},
+		{`1 << -1`, `negative shift count`},
+		{`1.5 % 1`, `unsupported combination of types in the operator`},
+		{`Money(1) & 1`, `unsupported combination of types in the operator`},
+	}
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]any{"Money": func(v int64) decimal.Decimal {
+		return decimal.New(v, 0)
+	}}, nil, nil})
+	for i, item := range cases {
+		name := fmt.Sprintf(`oper%d`, i)
+		result := `int`
+		switch item.out.(type) {
+		case bool:
+			result = `bool`
+		case decimal.Decimal:
+			result = `money`
+		}
+		err := vm.Compile([]rune(fmt.Sprintf("func %s() %s {\n\t\treturn %s\n\t}", name, result, item.expr)),
+			&OwnerInfo{StateID: 1})
+		assert.NoError(t, err, item.expr)
+		out, err := vm.Call(name, nil, map[string]any{`rt_state`: uint32(1), `txcost`: int64(10000)})
+		if err != nil {
+			assert.Equal(t, item.out, strings.SplitN(err.Error(), ` [`, 2)[0], item.expr)
+			continue
+		}
+		if dec, ok := item.out.(decimal.Decimal); ok {
+			assert.True(t, dec.Equal(out[0].(decimal.Decimal)), item.expr)
+			continue
+		}
+		assert.Equal(t, item.out, out[0], item.expr)
+	}
+}
+
+func TestPowLimits(t *testing.T) {
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]any{"Money": func(v int64) decimal.Decimal {
+		return decimal.New(v, 0)
+	}}, nil, nil})
+	err := vm.Compile([]rune(`func nestedPow() money {
+		return (Money(99) ** 400) ** 1000
+	}
+	func bigPow() money {
+		return Money(99) ** 500
+	}
+	func smallPow() money {
+		return Money(99) ** 5
+	}`), &OwnerInfo{StateID: 1})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = vm.Call(`nestedPow`, nil, map[string]any{`rt_state`: uint32(1), `txcost`: int64(100000)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errPowDigits.Error())
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the cost depends on the digits of the base and the exponent
+	costs := make([]int64, 0, 2)
+	for _, name := range []string{`bigPow`, `smallPow`} {
+		extend := map[string]any{`rt_state`: uint32(1), `txcost`: int64(100000)}
+		_, err = vm.Call(name, nil, extend)
+		require.NoError(t, err)
+		costs = append(costs, 100000-extend[`txcost`].(int64))
+	}
+	assert.Equal(t, int64(CostPowDigits*2*500/10-CostPowDigits*2*5/10), costs[0]-costs[1])
+}
+
+func TestOperatorsCost(t *testing.T) {
+	vm := NewVM()
+	cost := func(oper string) int64 {
+		name := fmt.Sprintf(`cost%d`, len(vm.Children))
+		err := vm.Compile([]rune(fmt.Sprintf("func %s() int {\n\t\treturn 9 %s 2\n\t}", name, oper)),
+			&OwnerInfo{StateID: 1})
+		assert.NoError(t, err, oper)
+		extend := map[string]any{`rt_state`: uint32(1), `txcost`: int64(10000)}
+		_, err = vm.Call(name, nil, extend)
+		assert.NoError(t, err, oper)
+		return 10000 - extend[`txcost`].(int64)
+	}
+	base := cost(`+`)
+	for oper, want := range map[string]int64{`%`: CostMod, `**`: CostPow, `&`: CostBitwise, `|`: CostBitwise,
+		`^`: CostBitwise, `<<`: CostShift, `>>`: CostShift} {
+		assert.Equal(t, base+want, cost(oper), oper)
+	}
+}
+
+type testStatsKeeper struct {
+	stats Stats
+}
+
+func (k *testStatsKeeper) VMStats() *Stats {
+	return &k.stats
+}
+
+func TestLimits(t *testing.T) {
+	vm := NewVM()
+	err := vm.Compile([]rune(`func deep(n int) int {
+		if n > 0 {
+			return deep(n - 1)
+		}
+		return 0
+	}
+	func repeat(s string, n int) string {
+		var ret string
+		while n > 0 {
+			ret = ret + s
+			n = n - 1
+		}
+		return ret
+	}
+	func fill(n int) int {
+		var ret array
+		var i int
+		while i < n {
+			ret[i] = i
+			i = i + 1
+		}
+		return i
+	}
+	func catchDeep(n int) string {
+		try {
+			deep(n)
+		} catch err {
+			return err["type"]
+		}
+		return "ok"
+	}
+	func bigMap() int {
+		var m map
+		m = {"key": "0123456789012345678901234567890123456789012345678901234567890123456789"}
+		return 1
+	}`), &OwnerInfo{StateID: 1})
+	assert.NoError(t, err)
+
+	limits := Limits{Version: VMVersionLimits, CallDepth: 20, Memory: 1 << 20, StringSize: 100, ArraySize: 50}
+	run := func(name string, params ...any) ([]any, error, Stats) {
+		keeper := &testStatsKeeper{}
+		rt := NewRunTime(vm, 1000000)
+		rt.limits = limits
+		ret, err := rt.Run(vm.Objects[name].GetCodeBlock(), params,
+			map[string]any{`rt_state`: uint32(1), `sc`: keeper})
+		return ret, err, keeper.stats
+	}
+	limitCode := func(err error) string {
+		var vmErr VMError
+		if assert.Error(t, err) && assert.True(t, IsLimitError(err), err.Error()) {
+			assert.NoError(t, json.Unmarshal([]byte(err.Error()), &vmErr))
+		}
+		return vmErr.Code
+	}
+
+	ret, err, stats := run(`deep`, int64(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{int64(0)}, ret)
+	assert.Equal(t, int64(10), stats.PeakDepth)
+	assert.True(t, stats.Instructions > 0)
+	assert.True(t, stats.PeakMemory > 0)
+
+	_, err, stats = run(`deep`, int64(100))
+	assert.Equal(t, LimitCallDepth, limitCode(err))
+	assert.Equal(t, limits.CallDepth, stats.PeakDepth)
+
+	_, err, _ = run(`repeat`, `abcd`, int64(25))
+	assert.NoError(t, err)
+	_, err, _ = run(`repeat`, `abcd`, int64(26))
+	assert.Equal(t, LimitStringSize, limitCode(err))
+
+	_, err, _ = run(`fill`, int64(50))
+	assert.NoError(t, err)
+	_, err, _ = run(`fill`, int64(51))
+	assert.Equal(t, LimitArraySize, limitCode(err))
+
+	limits.Memory = 64
+	_, err, stats = run(`repeat`, `abcd`, int64(20))
+	assert.Equal(t, LimitMemory, limitCode(err))
+	assert.True(t, stats.PeakMemory > limits.Memory)
+
+	// the exceeded limits cannot be caught by try
+	limits.Memory = 1 << 20
+	ret, err, _ = run(`catchDeep`, int64(5))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{`ok`}, ret)
+	_, err, _ = run(`catchDeep`, int64(100))
+	assert.Equal(t, LimitCallDepth, limitCode(err))
+
+	limits.Memory = 64
+	_, err, _ = run(`bigMap`)
+	assert.Equal(t, LimitMemory, limitCode(err))
+
+	// the blocks played before VMVersionLimits keep the previous rules
+	limits = Limits{CallDepth: 20, Memory: 64, StringSize: 100, ArraySize: 50}
+	_, err, _ = run(`bigMap`)
+	assert.NoError(t, err)
+	limits.Memory = 1 << 20
+	_, err, _ = run(`repeat`, `abcd`, int64(26))
+	assert.NoError(t, err)
+	_, err, _ = run(`fill`, int64(51))
+	assert.NoError(t, err)
+	_, err, _ = run(`deep`, int64(100))
+	assert.ErrorContains(t, err, errMaxCallDepth.Error())
+	ret, err, _ = run(`catchDeep`, int64(100))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{`panic`}, ret)
+	limits.Memory = 64
+	_, err, _ = run(`repeat`, `abcd`, int64(20))
+	assert.ErrorContains(t, err, ErrMemoryLimit.Error())
+}
+
+func TestRunMigrate(t *testing.T) {
+	var records []string
+	vm := NewVM()
+	vm.Extend(&ExtendData{Objects: map[string]any{
+		"RunMigrate": RunMigrate,
+		"Record": func(s string) {
+			records = append(records, s)
+		},
+	}, AutoPars: map[string]string{`*script.RunTime`: `rt`}})
+	err := vm.Compile([]rune(`contract Upgrade {
+		migrate {
+			$value = "migrate"
+			Record($value + " " + $this_contract)
+		}
+		action {
+			Record("action")
+		}
+	}
+	contract Plain {
+		action {
+		}
+	}
+	func upgrade(name string) string {
+		$value = "caller"
+		RunMigrate(name)
+		return $value + " " + $this_contract
+	}`), &OwnerInfo{StateID: 1})
+	assert.NoError(t, err)
+
+	extend := map[string]any{`rt_state`: uint32(1), `txcost`: int64(100000), `this_contract`: `Caller`}
+	out, err := vm.Call(`upgrade`, []any{`@1Upgrade`}, extend)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{`caller Caller`}, out)
+	assert.Equal(t, []string{`migrate Upgrade`}, records)
+
+	_, err = vm.Call(`upgrade`, []any{`@1Plain`}, extend)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(records))
+
+	_, err = vm.Call(`upgrade`, []any{`@1Unknown`}, extend)
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), fmt.Sprintf(eUnknownContract, `@1Unknown`)))
 }
@@ -30,6 +30,12 @@ const (
 	stateConstsAssign
 	stateConstsValue
 	stateFields
+	stateFor
+	stateForComma
+	stateCatch
+	stateLibrary
+	stateLibBlock
+	stateLibBody
 	stateEval
 
 	// The list of state flags
@@ -53,6 +59,7 @@ var (
 			lexNewLine:                      newCompileState(stateRoot, cfNothing),
 			lexKeyword | (keyContract << 8): newCompileState(stateContract|statePush, cfNothing),
 			lexKeyword | (keyFunc << 8):     newCompileState(stateFunc|statePush, cfNothing),
+			lexKeyword | (keyLibrary << 8):  newCompileState(stateLibrary|statePush, cfNothing),
 			lexUnknown:                      newCompileState(errUnknownCmd, cfError),
 		},
 		stateBody: { // stateBody
@@ -63,7 +70,10 @@ var (
 			lexKeyword | (keyBreak << 8):    newCompileState(stateBody, cfBreak),
 			lexKeyword | (keyIf << 8):       newCompileState(stateEval|statePush|stateToBlock|stateMustEval, cfIf),
 			lexKeyword | (keyWhile << 8):    newCompileState(stateEval|statePush|stateToBlock|stateLabel|stateMustEval, cfWhile),
+			lexKeyword | (keyFor << 8):      newCompileState(stateFor|statePush, cfFor),
 			lexKeyword | (keyElse << 8):     newCompileState(stateBlock|statePush, cfElse),
+			lexKeyword | (keyTry << 8):      newCompileState(stateBlock|statePush, cfTry),
+			lexKeyword | (keyCatch << 8):    newCompileState(stateCatch|statePush, cfCatch),
 			lexKeyword | (keyVar << 8):      newCompileState(stateVar, cfNothing),
 			lexKeyword | (keyTX << 8):       newCompileState(stateTX, cfTX),
 			lexKeyword | (keySettings << 8): newCompileState(stateSettings, cfSettings),
@@ -144,6 +154,7 @@ var (
 		stateAssignEval: { // stateAssignEval
 			isLPar:     newCompileState(stateEval|stateToFork|stateToBody, cfNothing),
 			isLBrack:   newCompileState(stateEval|stateToFork|stateToBody, cfNothing),
+			isDot:      newCompileState(stateEval|stateToFork|stateToBody, cfNothing),
 			lexUnknown: newCompileState(stateAssign|stateToFork|stateStay, cfNothing),
 		},
 		stateAssign: { // stateAssign
@@ -190,5 +201,34 @@ var (
 			isRCurly:   newCompileState(stateToBody, cfFields),
 			lexUnknown: newCompileState(errMustRCurly, cfError),
 		},
+		stateFor: { // stateFor
+			lexIdent:   newCompileState(stateForComma, cfForVar),
+			lexUnknown: newCompileState(errVars, cfError),
+		},
+		stateForComma: { // stateForComma
+			isComma:                   newCompileState(stateFor, cfNothing),
+			lexKeyword | (keyIn << 8): newCompileState(stateEval|stateToBlock|stateMustEval, cfForIn),
+			lexUnknown:                newCompileState(errMustIn, cfError),
+		},
+		stateCatch: { // stateCatch
+			lexIdent:   newCompileState(stateBlock, cfCatchVar),
+			lexUnknown: newCompileState(errMustName, cfError),
+		},
+		stateLibrary: { // stateLibrary
+			lexNewLine: newCompileState(stateLibrary, cfNothing),
+			lexIdent:   newCompileState(stateLibBlock, cfNameBlock),
+			lexUnknown: newCompileState(errMustName, cfError),
+		},
+		stateLibBlock: { // stateLibBlock
+			lexNewLine: newCompileState(stateLibBlock, cfNothing),
+			isLCurly:   newCompileState(stateLibBody, cfNothing),
+			lexUnknown: newCompileState(errMustLCurly, cfError),
+		},
+		stateLibBody: { // stateLibBody
+			lexNewLine:                  newCompileState(stateLibBody, cfNothing),
+			lexKeyword | (keyFunc << 8): newCompileState(stateFunc|statePush, cfNothing),
+			isRCurly:                    newCompileState(statePop, cfNothing),
+			lexUnknown:                  newCompileState(errMustFunc, cfError),
+		},
 	}
 )
@@ -115,8 +115,8 @@ func getContractList(src string) (list []string) {
 }
 
 func VMGetContractByID(vm *VM, id int32) *ContractInfo {
-	var tableID int64
-	if id > consts.ShiftContractID {
+	tableID := int64(-1)
+	if id >= consts.ShiftContractID {
 		tableID = int64(id - consts.ShiftContractID)
 		id = int32(tableID + vm.ShiftContract)
 	}
@@ -127,7 +127,7 @@ func VMGetContractByID(vm *VM, id int32) *ContractInfo {
 	if vm.Children[idcont] == nil || vm.Children[idcont].Type != ObjectType_Contract {
 		return nil
 	}
-	if tableID > 0 && vm.Children[idcont].GetContractInfo().Owner.TableID != tableID {
+	if tableID >= 0 && vm.Children[idcont].GetContractInfo().Owner.TableID != tableID {
 		return nil
 	}
 	return vm.Children[idcont].GetContractInfo()
@@ -153,7 +153,7 @@ func RunContractByName(vm *VM, name string, methods []string, extend map[string]
 	contract := obj.GetCodeBlock()
 	extend[Extend_txcost] = extend[Extend_txcost].(int64) - CostContract - contract.contractBaseCost()
 	if extend[Extend_txcost].(int64) < 0 {
-		return fmt.Errorf("runtime cost limit overflow")
+		return errCostLimit
 	}
 	var err error
 	for i := 0; i < len(methods); i++ {
@@ -72,6 +72,10 @@ const (
 	ObjectType_Var ObjectType = 4
 	// ObjectType_ExtVar is an extended build in variable. $myvar
 	ObjectType_ExtVar ObjectType = 5
+	// ObjectType_Struct is a struct type. struct mystruct
+	ObjectType_Struct ObjectType = 6
+	// ObjectType_Library is a library of functions. library mylib
+	ObjectType_Library ObjectType = 7
 )
 
 var ObjectType_name = map[int32]string{
@@ -81,6 +85,8 @@ var ObjectType_name = map[int32]string{
 	3: "ExtFunc",
 	4: "Var",
 	5: "ExtVar",
+	6: "Struct",
+	7: "Library",
 }
 
 var ObjectType_value = map[string]int32{
@@ -90,6 +96,8 @@ var ObjectType_value = map[string]int32{
 	"ExtFunc":  3,
 	"Var":      4,
 	"ExtVar":   5,
+	"Struct":   6,
+	"Library":  7,
 }
 
 func (x ObjectType) String() string {
@@ -108,21 +116,21 @@ func init() {
 func init() { proto.RegisterFile("vm.proto", fileDescriptor_cab246c8c7c5372d) }
 
 var fileDescriptor_cab246c8c7c5372d = []byte{
-	// 241 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x28, 0xcb, 0xd5, 0x2b,
-	0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2b, 0x4e, 0x2e, 0xca, 0x2c, 0x28, 0xd1, 0xb2, 0xe0, 0x62,
-	0x0b, 0xf3, 0x0d, 0xa9, 0x2c, 0x48, 0x15, 0xe2, 0xe6, 0x62, 0xf7, 0xf4, 0x0b, 0x73, 0xf4, 0xf1,
-	0x74, 0x11, 0x60, 0x10, 0xe2, 0xe4, 0x62, 0x0d, 0xce, 0x4d, 0x2c, 0x2a, 0x11, 0x60, 0x14, 0x62,
-	0xe7, 0x62, 0x76, 0xf6, 0x71, 0x12, 0x60, 0x12, 0xe2, 0xe5, 0xe2, 0x74, 0xf6, 0x71, 0xf2, 0x4d,
-	0x2c, 0x2e, 0x49, 0x2d, 0x12, 0x60, 0xd6, 0x0a, 0xe6, 0xe2, 0xf2, 0x4f, 0xca, 0x4a, 0x4d, 0x2e,
-	0x81, 0xe9, 0x0e, 0xcd, 0xcb, 0xce, 0xcb, 0x2f, 0xcf, 0x13, 0x60, 0x10, 0xe2, 0xe1, 0xe2, 0x70,
-	0xce, 0xcf, 0x2b, 0x29, 0x4a, 0x4c, 0x06, 0x19, 0xc0, 0xc1, 0xc5, 0xe2, 0x56, 0x9a, 0x97, 0x2c,
-	0xc0, 0x04, 0x52, 0xe4, 0x5a, 0x51, 0x02, 0xe6, 0x30, 0x83, 0xcc, 0x0d, 0x4b, 0x2c, 0x12, 0x60,
-	0x11, 0xe2, 0xe2, 0x62, 0x73, 0xad, 0x28, 0x01, 0xb1, 0x59, 0x9d, 0x5c, 0x4e, 0x3c, 0x92, 0x63,
-	0xbc, 0xf0, 0x48, 0x8e, 0xf1, 0xc1, 0x23, 0x39, 0xc6, 0x09, 0x8f, 0xe5, 0x18, 0x2e, 0x3c, 0x96,
-	0x63, 0xb8, 0xf1, 0x58, 0x8e, 0x21, 0x4a, 0x2b, 0x3d, 0xb3, 0x24, 0xa3, 0x34, 0x49, 0x2f, 0x39,
-	0x3f, 0x57, 0xdf, 0xd3, 0xc9, 0x31, 0x42, 0x37, 0x33, 0x5f, 0x3f, 0x3d, 0x5f, 0x37, 0x33, 0x29,
-	0xb1, 0x42, 0xbf, 0x20, 0x31, 0x39, 0x3b, 0x31, 0x3d, 0xb5, 0x58, 0x1f, 0xe2, 0xa9, 0x24, 0x36,
-	0xb0, 0x1f, 0x8d, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0x01, 0x5c, 0x7e, 0x1d, 0xef, 0x00, 0x00,
-	0x00,
+	// 253 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x34, 0x8f, 0x4f, 0x4e, 0x83, 0x40,
+	0x14, 0x87, 0xa1, 0x7f, 0x80, 0x3e, 0x35, 0x99, 0xcc, 0x01, 0xe6, 0x00, 0x24, 0x2d, 0x0b, 0x37,
+	0x6e, 0x0b, 0xad, 0x09, 0x09, 0xd5, 0x45, 0x95, 0x18, 0x77, 0xc3, 0x84, 0xe0, 0x58, 0x99, 0x21,
+	0xc3, 0x43, 0xe9, 0x2d, 0x3c, 0x96, 0xcb, 0x2e, 0x5d, 0x1a, 0xb8, 0x88, 0x99, 0x1a, 0x77, 0xbf,
+	0x97, 0x7c, 0xef, 0x4b, 0x3e, 0x08, 0xde, 0xeb, 0x55, 0x63, 0x34, 0x6a, 0xea, 0xb5, 0xc2, 0xc8,
+	0x06, 0xc3, 0x1b, 0xf0, 0xf2, 0xdd, 0xc3, 0xb1, 0x29, 0xe9, 0x05, 0xf8, 0xe9, 0x5d, 0xbe, 0xce,
+	0xd2, 0x0d, 0x71, 0xe8, 0x02, 0xe6, 0xfb, 0x9a, 0x1b, 0x24, 0x2e, 0xf5, 0x61, 0x9a, 0x64, 0x31,
+	0x99, 0xd0, 0x2b, 0x58, 0x24, 0x59, 0xbc, 0xe3, 0x2d, 0x96, 0x86, 0x4c, 0xc3, 0x37, 0x80, 0xfb,
+	0xe2, 0xb5, 0x14, 0xf8, 0xff, 0xfd, 0xa8, 0x0e, 0x4a, 0x7f, 0x28, 0xe2, 0xd0, 0x4b, 0x08, 0x12,
+	0xad, 0xd0, 0x70, 0x61, 0x05, 0x01, 0xcc, 0x6e, 0x3b, 0x25, 0xc8, 0xc4, 0x42, 0xdb, 0x1e, 0xcf,
+	0xc7, 0xd4, 0x7a, 0x73, 0x6e, 0xc8, 0x8c, 0x02, 0x78, 0xdb, 0x1e, 0xed, 0x9e, 0xdb, 0xbd, 0x47,
+	0xd3, 0x09, 0x24, 0x9e, 0xa5, 0x33, 0x59, 0x18, 0x6e, 0x8e, 0xc4, 0x8f, 0x37, 0xcf, 0x61, 0x25,
+	0xf1, 0xa5, 0x2b, 0x56, 0x42, 0xd7, 0x51, 0x1a, 0xaf, 0x9f, 0x96, 0x52, 0x47, 0x95, 0x5e, 0xca,
+	0x82, 0xf7, 0x51, 0xc3, 0xc5, 0x81, 0x57, 0x65, 0x1b, 0xfd, 0x55, 0x7d, 0x0d, 0xcc, 0x3d, 0x0d,
+	0xcc, 0xfd, 0x19, 0x98, 0xfb, 0x39, 0x32, 0xe7, 0x34, 0x32, 0xe7, 0x7b, 0x64, 0x4e, 0xe1, 0x9d,
+	0xe3, 0xaf, 0x7f, 0x07, 0x00, 0x9a, 0xbf, 0x1b, 0xca, 0x08, 0x01, 0x00, 0x00,
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
 
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/types"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -22,6 +23,18 @@ const (
 	CostContract = 100
 	// CostExtend is the cost of the extend function calling
 	CostExtend = 10
+	// CostForIn is the cost of the iteration of for ... in loop
+	CostForIn = 1
+	// CostMod is the cost of the % operator
+	CostMod = 2
+	// CostPow is the cost of the ** operator
+	CostPow = 10
+	// CostPowDigits is the cost of every 10 digits of the result of the ** operator for money values
+	CostPowDigits = 1
+	// CostBitwise is the cost of the &, | and ^ operators
+	CostBitwise = 1
+	// CostShift is the cost of the << and >> operators
+	CostShift = 1
 
 	TagFile      = "file"
 	TagAddress   = "address"
@@ -46,6 +59,7 @@ type FieldInfo struct {
 	Type     reflect.Type
 	Original uint32
 	Tags     string
+	Struct   *StructInfo // The type of the field if it is the struct
 }
 
 // ContainsTag returns whether the tag is contained in this field
@@ -53,6 +67,87 @@ func (fi *FieldInfo) ContainsTag(tag string) bool {
 	return strings.Contains(fi.Tags, tag)
 }
 
+// checkValue checks the type of the value of the struct field and converts it if it is necessary
+func (fi *FieldInfo) checkValue(v any) (any, error) {
+	switch {
+	case fi.Struct != nil:
+		return fi.Struct.Value(v)
+	case fi.Original == DtMoney:
+		return ValueToDecimal(v)
+	case v != nil && fi.Type != reflect.TypeOf(v):
+		return nil, fmt.Errorf(eFieldType, fi.Name, reflect.TypeOf(v), fi.Type)
+	}
+	return v, nil
+}
+
+// StructInfo contains the fields of the struct type. The values of the struct are kept
+// as *types.Map with the fields in the order of the declaration
+type StructInfo struct {
+	Name   string
+	Fields []*FieldInfo
+}
+
+// Field returns the field of the struct by the name
+func (s *StructInfo) Field(name string) *FieldInfo {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// New returns the value of the struct with the default values of the fields
+func (s *StructInfo) New() *types.Map {
+	ret := types.NewMap()
+	for _, field := range s.Fields {
+		if field.Struct != nil {
+			ret.Set(field.Name, field.Struct.New())
+		} else {
+			ret.Set(field.Name, GetFieldDefaultValue(field.Original))
+		}
+	}
+	return ret
+}
+
+// Value checks the map and returns the copy of it as the value of the struct.
+// The missing fields get the default values
+func (s *StructInfo) Value(v any) (*types.Map, error) {
+	m, ok := v.(*types.Map)
+	if !ok {
+		return nil, fmt.Errorf(eStructType, reflect.TypeOf(v), s.Name)
+	}
+	for _, key := range m.Keys() {
+		if s.Field(key) == nil {
+			return nil, fmt.Errorf(eUnknownField, key, s.Name)
+		}
+	}
+	ret := types.NewMap()
+	for _, field := range s.Fields {
+		val, ok := m.Get(field.Name)
+		if !ok {
+			if field.Struct != nil {
+				ret.Set(field.Name, field.Struct.New())
+			} else {
+				ret.Set(field.Name, GetFieldDefaultValue(field.Original))
+			}
+			continue
+		}
+		val, err := field.checkValue(val)
+		if err != nil {
+			return nil, err
+		}
+		ret.Set(field.Name, val)
+	}
+	return ret, nil
+}
+
+// StructInit is the value of cmdStructInit
+type StructInit struct {
+	Struct *StructInfo
+	Fields *types.Map
+}
+
 // ContractInfo contains the contract information
 type ContractInfo struct {
 	ID       uint32
@@ -64,6 +159,14 @@ type ContractInfo struct {
 	CanWrite bool // If the function can update DB
 }
 
+// LibraryInfo contains the library information. The imports of the library are late-bound, the callers
+// always use the current functions of the library
+type LibraryInfo struct {
+	ID    uint32
+	Name  string
+	Owner *OwnerInfo
+}
+
 func (c *ContractInfo) TxMap() map[string]*FieldInfo {
 	if c == nil {
 		return nil
@@ -111,6 +214,7 @@ type IndexInfo struct {
 	VarOffset int
 	Owner     *CodeBlock
 	Extend    string
+	Field     *FieldInfo // The field of the struct which type is checked
 }
 
 // VM is the main type of the virtual machine
@@ -129,6 +233,20 @@ type Stacker interface {
 	PopStack(fn string)
 }
 
+// Migrator represents interface for marking the execution of the migrate section,
+// the functions changing the contracts are denied in it
+type Migrator interface {
+	StartMigrate()
+	EndMigrate()
+}
+
+// Savepointer represents interface for rolling back the changes of the failed try block
+type Savepointer interface {
+	Savepoint() (any, error)
+	RollbackSavepoint(point any) error
+	ReleaseSavepoint(point any) error
+}
+
 // NewVM creates a new virtual machine
 func NewVM() *VM {
 	vm := &VM{
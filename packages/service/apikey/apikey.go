@@ -0,0 +1,188 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package apikey
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/transaction"
+	"github.com/didip/tollbooth"
+	"github.com/didip/tollbooth/limiter"
+)
+
+const (
+	// HeaderPrefix is the prefix of the api key in the authorization header
+	HeaderPrefix = "ApiKey "
+	// TokenPrefix is the prefix of the generated api keys
+	TokenPrefix = "ibax_"
+
+	// ScopeRead allows only the read requests
+	ScopeRead = "read"
+	// ScopeSendTx allows to send the transactions of any contract
+	ScopeSendTx = "send_tx"
+	// ScopeContract allows to send the transactions of the specified contract, e.g. contract:@1TokensSend
+	ScopeContract = "contract:"
+
+	// DefaultRateLimit is the number of requests per second if the rate limit of the key is not set
+	DefaultRateLimit = 10
+)
+
+var (
+	ErrInvalidKey    = errors.New("api key is not valid")
+	ErrLimitReached  = errors.New("api key rate limit has been reached")
+	ErrReadOnly      = errors.New("api key does not allow to send transactions")
+	ErrEmptyScopes   = errors.New("api key scopes are empty")
+	errUnknownScope  = "unknown api key scope %s"
+	errContractScope = "api key does not allow to call contract %s"
+)
+
+// Key is the api key which has been found by the secret
+type Key struct {
+	*sqldb.APIKey
+	sendTx    bool
+	contracts map[string]bool
+}
+
+var limiters = struct {
+	sync.Mutex
+	list map[int64]*limiter.Limiter
+}{list: make(map[int64]*limiter.Limiter)}
+
+// Generate returns the new secret of the api key and the hash which should be stored
+func Generate() (string, []byte, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := TokenPrefix + hex.EncodeToString(buf)
+	return token, Hash(token), nil
+}
+
+// Hash returns the hash of the secret of the api key
+func Hash(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// FromHeader returns the secret of the api key from the authorization header
+func FromHeader(header string) (string, bool) {
+	if !strings.HasPrefix(header, HeaderPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(HeaderPrefix):]), true
+}
+
+// ParseScopes checks the list of scopes separated by commas and returns it in the normalized form
+func ParseScopes(scopes string, ecosystem int64) ([]string, error) {
+	var list []string
+	for _, scope := range strings.Split(scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		switch {
+		case len(scope) == 0:
+			continue
+		case scope == ScopeRead || scope == ScopeSendTx:
+		case strings.HasPrefix(scope, ScopeContract):
+			name := strings.TrimSpace(strings.TrimPrefix(scope, ScopeContract))
+			if len(name) == 0 {
+				return nil, fmt.Errorf(errUnknownScope, scope)
+			}
+			if !strings.HasPrefix(name, "@") {
+				name = fmt.Sprintf("@%d%s", ecosystem, name)
+			}
+			scope = ScopeContract + name
+		default:
+			return nil, fmt.Errorf(errUnknownScope, scope)
+		}
+		list = append(list, scope)
+	}
+	if len(list) == 0 {
+		return nil, ErrEmptyScopes
+	}
+	return list, nil
+}
+
+// Authenticate returns the active api key by its secret
+func Authenticate(token string) (*Key, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return nil, ErrInvalidKey
+	}
+	apiKey := &sqldb.APIKey{}
+	found, err := apiKey.GetByHash(Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	if !found || !apiKey.IsActive() {
+		return nil, ErrInvalidKey
+	}
+	scopes, err := ParseScopes(apiKey.Scopes, apiKey.Ecosystem)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{APIKey: apiKey, contracts: make(map[string]bool)}
+	for _, scope := range scopes {
+		switch {
+		case scope == ScopeSendTx:
+			key.sendTx = true
+		case strings.HasPrefix(scope, ScopeContract):
+			key.contracts[strings.TrimPrefix(scope, ScopeContract)] = true
+		}
+	}
+	return key, nil
+}
+
+// Allow returns false if the rate limit of the key has been reached
+func (k *Key) Allow() bool {
+	max := k.RateLimit
+	if max <= 0 {
+		max = DefaultRateLimit
+	}
+	limiters.Lock()
+	lmt, ok := limiters.list[k.ID]
+	if !ok || lmt.GetMax() != max {
+		lmt = tollbooth.NewLimiter(max, nil)
+		limiters.list[k.ID] = lmt
+	}
+	limiters.Unlock()
+	return !lmt.LimitReached(strconv.FormatInt(k.ID, 10))
+}
+
+// ReadOnly returns true if the key does not allow to send any transactions
+func (k *Key) ReadOnly() bool {
+	return !k.sendTx && len(k.contracts) == 0
+}
+
+// CheckTxs checks that the transactions are allowed by the scopes of the key
+func (k *Key) CheckTxs(txs [][]byte) error {
+	if k.ReadOnly() {
+		return ErrReadOnly
+	}
+	if k.sendTx {
+		return nil
+	}
+	for _, data := range txs {
+		tx := &transaction.Transaction{}
+		if err := tx.Unmarshall(bytes.NewBuffer(data), false); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("of transaction type %d", tx.Type())
+		if tx.IsSmartContract() && tx.SmartContract().TxContract != nil {
+			name = tx.SmartContract().TxContract.Name
+		}
+		if !k.contracts[name] {
+			return fmt.Errorf(errContractScope, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("read, contract:TokensSend,contract:@2Vote", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read", "contract:@1TokensSend", "contract:@2Vote"}, scopes)
+
+	_, err = ParseScopes(" , ", 1)
+	assert.Equal(t, ErrEmptyScopes, err)
+
+	_, err = ParseScopes("read,write", 1)
+	assert.Error(t, err)
+
+	_, err = ParseScopes("contract:", 1)
+	assert.Error(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	secret, hash, err := Generate()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, TokenPrefix))
+	assert.Equal(t, Hash(secret), hash)
+
+	token, ok := FromHeader(HeaderPrefix + secret)
+	assert.True(t, ok)
+	assert.Equal(t, secret, token)
+	_, ok = FromHeader("Bearer " + secret)
+	assert.False(t, ok)
+}
+
+func TestKeyScopes(t *testing.T) {
+	key := &Key{contracts: map[string]bool{}}
+	assert.True(t, key.ReadOnly())
+	assert.Equal(t, ErrReadOnly, key.CheckTxs([][]byte{{1}}))
+
+	key.sendTx = true
+	assert.False(t, key.ReadOnly())
+	assert.NoError(t, key.CheckTxs([][]byte{{1}}))
+}
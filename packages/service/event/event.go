@@ -0,0 +1,176 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package event
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	log "github.com/sirupsen/logrus"
+)
+
+// Topic is the kind of chain event a subscriber is listening to
+type Topic string
+
+const (
+	TopicNewHeads Topic = "newHeads"
+	TopicTxStatus Topic = "txStatus"
+	TopicLogs     Topic = "logs"
+)
+
+// subscriptionBuffer is the number of events that can wait for a slow subscriber
+const subscriptionBuffer = 128
+
+// BlockHeader is published on TopicNewHeads after a block has been inserted
+type BlockHeader struct {
+	BlockID       int64  `json:"block_id"`
+	Hash          string `json:"hash"`
+	RollbacksHash string `json:"rollbacks_hash"`
+	Time          int64  `json:"time"`
+	EcosystemID   int64  `json:"ecosystem_id"`
+	KeyID         int64  `json:"key_id"`
+	NodePosition  int64  `json:"node_position"`
+	ConsensusMode int32  `json:"consensus_mode"`
+	TxCount       int    `json:"tx_count"`
+}
+
+// TxStatus is published on TopicTxStatus when the status of a transaction changes
+type TxStatus struct {
+	Hash    string `json:"hash"`
+	BlockID int64  `json:"block_id"`
+	Code    int32  `json:"code"`
+	Result  string `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ContractLog is published on TopicLogs for every contract executed in a block
+type ContractLog struct {
+	BlockID      int64  `json:"block_id"`
+	TxHash       string `json:"tx_hash"`
+	EcosystemID  int64  `json:"ecosystem_id"`
+	ContractName string `json:"contract_name"`
+	Address      int64  `json:"address"`
+	Status       int64  `json:"status"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+// ContractEvent is published on TopicLogs for every event emitted by EmitEvent after the block is committed
+type ContractEvent struct {
+	BlockID      int64           `json:"block_id"`
+	TxHash       string          `json:"tx_hash"`
+	LogIndex     int64           `json:"log_index"`
+	EcosystemID  int64           `json:"ecosystem_id"`
+	ContractName string          `json:"contract_name"`
+	Name         string          `json:"name"`
+	Data         json.RawMessage `json:"data"`
+	Timestamp    int64           `json:"timestamp"`
+}
+
+// Filter reports whether the event must be delivered to the subscriber
+type Filter func(v any) bool
+
+// Subscription is a channel of events of one topic
+type Subscription struct {
+	topic  Topic
+	filter Filter
+	ch     chan any
+	feed   *Feed
+	once   sync.Once
+}
+
+// Chan returns the channel receiving events. It is closed after Unsubscribe
+func (s *Subscription) Chan() <-chan any {
+	return s.ch
+}
+
+// Topic returns the topic of subscription
+func (s *Subscription) Topic() Topic {
+	return s.topic
+}
+
+// Unsubscribe stops the delivery of events and closes the channel
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.feed.remove(s)
+		close(s.ch)
+	})
+}
+
+// Feed dispatches published events to subscribers
+type Feed struct {
+	mu   sync.RWMutex
+	subs map[Topic]map[*Subscription]struct{}
+}
+
+// NewFeed returns an empty feed
+func NewFeed() *Feed {
+	return &Feed{subs: make(map[Topic]map[*Subscription]struct{})}
+}
+
+// Subscribe registers a new subscriber of topic. Nil filter accepts all events
+func (f *Feed) Subscribe(topic Topic, filter Filter) *Subscription {
+	s := &Subscription{
+		topic:  topic,
+		filter: filter,
+		ch:     make(chan any, subscriptionBuffer),
+		feed:   f,
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs[topic] == nil {
+		f.subs[topic] = make(map[*Subscription]struct{})
+	}
+	f.subs[topic][s] = struct{}{}
+	return s
+}
+
+func (f *Feed) remove(s *Subscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subs[s.topic], s)
+}
+
+// Publish delivers the event to subscribers of topic. It never blocks,
+// events are dropped for subscribers whose buffer is full
+func (f *Feed) Publish(topic Topic, v any) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for s := range f.subs[topic] {
+		if s.filter != nil && !s.filter(v) {
+			continue
+		}
+		select {
+		case s.ch <- v:
+		default:
+			log.WithFields(log.Fields{"type": consts.ParameterExceeded, "topic": topic}).Warn("subscriber is too slow, event dropped")
+		}
+	}
+}
+
+// HasSubscribers reports whether anyone is listening to topic
+func (f *Feed) HasSubscribers(topic Topic) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.subs[topic]) > 0
+}
+
+var defaultFeed = NewFeed()
+
+// Subscribe registers a subscriber in the node feed
+func Subscribe(topic Topic, filter Filter) *Subscription {
+	return defaultFeed.Subscribe(topic, filter)
+}
+
+// Publish sends the event to the node feed
+func Publish(topic Topic, v any) {
+	defaultFeed.Publish(topic, v)
+}
+
+// HasSubscribers reports whether the node feed has subscribers of topic
+func HasSubscribers(topic Topic) bool {
+	return defaultFeed.HasSubscribers(topic)
+}
@@ -0,0 +1,49 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeed(t *testing.T) {
+	f := NewFeed()
+	all := f.Subscribe(TopicTxStatus, nil)
+	one := f.Subscribe(TopicTxStatus, func(v any) bool {
+		return v.(*TxStatus).Hash == "01"
+	})
+	assert.True(t, f.HasSubscribers(TopicTxStatus))
+	assert.False(t, f.HasSubscribers(TopicNewHeads))
+
+	f.Publish(TopicTxStatus, &TxStatus{Hash: "01"})
+	f.Publish(TopicTxStatus, &TxStatus{Hash: "02"})
+	f.Publish(TopicNewHeads, &BlockHeader{BlockID: 1})
+
+	assert.Equal(t, 2, len(all.Chan()))
+	assert.Equal(t, 1, len(one.Chan()))
+	assert.Equal(t, "01", (<-one.Chan()).(*TxStatus).Hash)
+
+	all.Unsubscribe()
+	all.Unsubscribe()
+	one.Unsubscribe()
+	assert.False(t, f.HasSubscribers(TopicTxStatus))
+	f.Publish(TopicTxStatus, &TxStatus{Hash: "01"})
+
+	_, ok := <-one.Chan()
+	assert.False(t, ok)
+}
+
+func TestFeedSlowSubscriber(t *testing.T) {
+	f := NewFeed()
+	s := f.Subscribe(TopicNewHeads, nil)
+	for i := 0; i < subscriptionBuffer+10; i++ {
+		f.Publish(TopicNewHeads, &BlockHeader{BlockID: int64(i)})
+	}
+	assert.Equal(t, subscriptionBuffer, len(s.Chan()))
+	s.Unsubscribe()
+}
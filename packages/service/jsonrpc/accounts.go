@@ -5,14 +5,19 @@
 package jsonrpc
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/IBAX-io/go-ibax/packages/consts"
 	"github.com/IBAX-io/go-ibax/packages/converter"
 	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
 	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type accountsApi struct {
@@ -144,3 +149,164 @@ func (b *accountsApi) GetBalance(ctx RequestContext, info *AccountOrKeyId, ecosy
 		TokenName:   eco.TokenName,
 	}, nil
 }
+
+type accountTxForm struct {
+	Account      *AccountOrKeyId `json:"account"`
+	Ecosystem    int64           `json:"ecosystem"`
+	Direction    string          `json:"direction"`
+	Model        string          `json:"model"`
+	ContractName string          `json:"contract"`
+	FromBlock    int64           `json:"from_block"`
+	ToBlock      int64           `json:"to_block"`
+	FromTime     int64           `json:"from_time"`
+	ToTime       int64           `json:"to_time"`
+	Cursor       string          `json:"cursor"`
+	Limit        int             `json:"limit"`
+
+	cursor *sqldb.AccountTxCursor
+}
+
+func (f *accountTxForm) Validate(r *http.Request) error {
+	if f == nil {
+		return errors.New(paramsEmpty)
+	}
+	if err := f.Account.Validate(r); err != nil {
+		return err
+	}
+	switch f.Direction {
+	case "", "in", "out":
+	default:
+		return fmt.Errorf(invalidParams, "direction")
+	}
+	switch f.Model {
+	case "", sqldb.AccountTxModelAccount, "utxo":
+	default:
+		return fmt.Errorf(invalidParams, "model")
+	}
+	if f.Ecosystem < 0 {
+		return fmt.Errorf(invalidParams, "ecosystem")
+	}
+	if f.FromBlock < 0 || f.ToBlock < 0 || (f.ToBlock > 0 && f.ToBlock < f.FromBlock) {
+		return fmt.Errorf(invalidParams, "block range")
+	}
+	if f.FromTime < 0 || f.ToTime < 0 || (f.ToTime > 0 && f.ToTime < f.FromTime) {
+		return fmt.Errorf(invalidParams, "time range")
+	}
+	if len(f.Cursor) > 0 {
+		c, err := decodeAccountTxCursor(f.Cursor)
+		if err != nil {
+			return fmt.Errorf(invalidParams, "cursor")
+		}
+		f.cursor = c
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultPaginatorLimit
+	}
+	if f.Limit > maxPaginatorLimit {
+		f.Limit = maxPaginatorLimit
+	}
+	return nil
+}
+
+// the cursor is opaque for clients, it keeps the sort key of the last record of the page
+func encodeAccountTxCursor(tx *sqldb.AccountTx) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%x:%s:%d", tx.BlockID, tx.TxHash, tx.Model, tx.Idx)))
+}
+
+func decodeAccountTxCursor(s string) (*sqldb.AccountTxCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(string(data), ":")
+	if len(parts) != 4 {
+		return nil, errors.New("invalid cursor")
+	}
+	c := &sqldb.AccountTxCursor{Model: parts[2]}
+	if c.BlockID, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return nil, err
+	}
+	if c.TxHash, err = hex.DecodeString(parts[1]); err != nil {
+		return nil, err
+	}
+	if c.Idx, err = strconv.ParseInt(parts[3], 10, 64); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type AccountTxResult struct {
+	Model        string `json:"model"`
+	BlockID      int64  `json:"block_id"`
+	Hash         string `json:"hash"`
+	Ecosystem    int64  `json:"ecosystem"`
+	Sender       string `json:"sender"`
+	Recipient    string `json:"recipient"`
+	Direction    string `json:"direction"`
+	Amount       string `json:"amount"`
+	Type         int64  `json:"type"`
+	Comment      string `json:"comment"`
+	ContractName string `json:"contract_name"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+type AccountTransactionsResult struct {
+	List       []AccountTxResult `json:"list"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// GetAccountTransactions returns the transfers of the account in all ecosystems from the latest ones.
+// The next page is requested with next_cursor of the previous result
+func (b *accountsApi) GetAccountTransactions(ctx RequestContext, form *accountTxForm) (*AccountTransactionsResult, *Error) {
+	r := ctx.HTTPRequest()
+	if err := parameterValidator(r, form); err != nil {
+		return nil, InvalidParamsError(err.Error())
+	}
+	logger := getLogger(r)
+
+	keyId := form.Account.KeyId
+	// one record more is requested to know whether there is the next page
+	txs, err := sqldb.GetAccountTransactions(nil, &sqldb.AccountTxFilter{
+		KeyID:        keyId,
+		Ecosystem:    form.Ecosystem,
+		Direction:    form.Direction,
+		Model:        form.Model,
+		ContractName: form.ContractName,
+		FromBlock:    form.FromBlock,
+		ToBlock:      form.ToBlock,
+		FromTime:     form.FromTime,
+		ToTime:       form.ToTime,
+		Cursor:       form.cursor,
+		Limit:        form.Limit + 1,
+	})
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting account transactions")
+		return nil, DefaultError(err.Error())
+	}
+	result := &AccountTransactionsResult{List: make([]AccountTxResult, 0, len(txs))}
+	if len(txs) > form.Limit {
+		txs = txs[:form.Limit]
+		result.NextCursor = encodeAccountTxCursor(&txs[len(txs)-1])
+	}
+	for _, tx := range txs {
+		direction := "out"
+		if tx.RecipientID == keyId && tx.Model != sqldb.AccountTxModelUTXOInput {
+			direction = "in"
+		}
+		result.List = append(result.List, AccountTxResult{
+			Model:        tx.Model,
+			BlockID:      tx.BlockID,
+			Hash:         hex.EncodeToString(tx.TxHash),
+			Ecosystem:    tx.Ecosystem,
+			Sender:       converter.AddressToString(tx.SenderID),
+			Recipient:    converter.AddressToString(tx.RecipientID),
+			Direction:    direction,
+			Amount:       tx.Amount.String(),
+			Type:         tx.Type,
+			Comment:      tx.Comment,
+			ContractName: tx.ContractName,
+			CreatedAt:    tx.CreatedAt,
+		})
+	}
+	return result, nil
+}
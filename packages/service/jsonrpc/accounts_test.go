@@ -0,0 +1,90 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package jsonrpc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountTxCursor(t *testing.T) {
+	for _, tx := range []sqldb.AccountTx{
+		{BlockID: 1, TxHash: []byte{0x01, 0xab}, Model: sqldb.AccountTxModelAccount, Idx: 10},
+		{BlockID: 1 << 40, TxHash: []byte{0xff}, Model: sqldb.AccountTxModelUTXOOutput},
+		{BlockID: 7, TxHash: []byte{0}, Model: sqldb.AccountTxModelUTXOInput, Idx: 3},
+	} {
+		c, err := decodeAccountTxCursor(encodeAccountTxCursor(&tx))
+		require.NoError(t, err)
+		assert.Equal(t, &sqldb.AccountTxCursor{BlockID: tx.BlockID, TxHash: tx.TxHash, Model: tx.Model, Idx: tx.Idx}, c)
+	}
+
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	for _, s := range []string{
+		"",
+		"not base64!",
+		encode("1:01:account"),
+		encode("1:01:account:2:3"),
+		encode("x:01:account:2"),
+		encode("1:zz:account:2"),
+		encode("1:01:account:y"),
+		base64.StdEncoding.EncodeToString([]byte("1:01:account:2")) + "=",
+	} {
+		_, err := decodeAccountTxCursor(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestAccountTxFormValidate(t *testing.T) {
+	account := &AccountOrKeyId{KeyId: 1}
+	cursor := encodeAccountTxCursor(&sqldb.AccountTx{BlockID: 2, TxHash: []byte{1}, Model: sqldb.AccountTxModelAccount, Idx: 1})
+
+	testTable := []struct {
+		Form  accountTxForm
+		Error string
+	}{
+		{Form: accountTxForm{Account: account}},
+		{Form: accountTxForm{Account: account, Direction: "in", Model: "utxo"}},
+		{Form: accountTxForm{Account: account, Direction: "out", Model: sqldb.AccountTxModelAccount}},
+		{Form: accountTxForm{Account: account, FromBlock: 5, ToBlock: 5, FromTime: 10, ToTime: 10}},
+		{Form: accountTxForm{Account: account, FromBlock: 5}},
+		{Form: accountTxForm{Account: account, ToTime: 5}},
+		{Form: accountTxForm{Account: account, Cursor: cursor}},
+		{Form: accountTxForm{}, Error: paramsEmpty},
+		{Form: accountTxForm{Account: &AccountOrKeyId{}}, Error: "invalid input"},
+		{Form: accountTxForm{Account: account, Direction: "both"}, Error: fmt.Sprintf(invalidParams, "direction")},
+		{Form: accountTxForm{Account: account, Model: sqldb.AccountTxModelUTXOOutput}, Error: fmt.Sprintf(invalidParams, "model")},
+		{Form: accountTxForm{Account: account, Ecosystem: -1}, Error: fmt.Sprintf(invalidParams, "ecosystem")},
+		{Form: accountTxForm{Account: account, FromBlock: -1}, Error: fmt.Sprintf(invalidParams, "block range")},
+		{Form: accountTxForm{Account: account, FromBlock: 6, ToBlock: 5}, Error: fmt.Sprintf(invalidParams, "block range")},
+		{Form: accountTxForm{Account: account, ToTime: -1}, Error: fmt.Sprintf(invalidParams, "time range")},
+		{Form: accountTxForm{Account: account, FromTime: 6, ToTime: 5}, Error: fmt.Sprintf(invalidParams, "time range")},
+		{Form: accountTxForm{Account: account, Cursor: "bad"}, Error: fmt.Sprintf(invalidParams, "cursor")},
+	}
+	for i, item := range testTable {
+		err := item.Form.Validate(nil)
+		if len(item.Error) > 0 {
+			assert.EqualError(t, err, item.Error, "on %d step", i)
+			continue
+		}
+		require.NoError(t, err, "on %d step", i)
+		assert.Equal(t, defaultPaginatorLimit, item.Form.Limit, "on %d step", i)
+		assert.Equal(t, len(item.Form.Cursor) > 0, item.Form.cursor != nil, "on %d step", i)
+	}
+
+	var form *accountTxForm
+	assert.EqualError(t, form.Validate(nil), paramsEmpty)
+
+	form = &accountTxForm{Account: account, Limit: maxPaginatorLimit + 1}
+	require.NoError(t, form.Validate(nil))
+	assert.Equal(t, maxPaginatorLimit, form.Limit)
+}
@@ -74,7 +74,9 @@ func (a *authApi) GetAuthStatus(ctx RequestContext) (*AuthStatusResponse, *Error
 	}
 
 	result.IsActive = true
-	result.ExpiresAt = claims.ExpiresAt.Unix()
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Unix()
+	}
 	return result, nil
 }
 
@@ -101,7 +103,9 @@ func (a *authApi) GetUid(ctx RequestContext) (*GetUIDResult, *Error) {
 	if token != nil {
 		if claims, ok := token.Claims.(*JWTClaims); ok && len(claims.KeyID) > 0 {
 			result.EcosystemID = claims.EcosystemID
-			result.Expire = claims.ExpiresAt.Sub(time.Now()).String()
+			if claims.ExpiresAt != nil {
+				result.Expire = claims.ExpiresAt.Sub(time.Now()).String()
+			}
 			result.KeyID = claims.KeyID
 			result.Address = converter.AddressToString(converter.StrToInt64(claims.KeyID))
 			return result, nil
@@ -1209,3 +1209,52 @@ func (c *commonApi) GetEcosystemCount(ctx RequestContext) (*int64, *Error) {
 
 	return &total, nil
 }
+
+type callForm struct {
+	Contract string         `json:"contract"`
+	Func     string         `json:"func"`
+	Params   map[string]any `json:"params"`
+	Args     []any          `json:"args"`
+}
+
+func (f *callForm) Validate(r *http.Request) error {
+	if f == nil {
+		return errors.New(paramsEmpty)
+	}
+	if f.Contract == "" {
+		return fmt.Errorf(invalidParams, "contract")
+	}
+	return nil
+}
+
+// Call runs the contract or the function of the contract without saving changes and returns its result.
+// The functions changing the state can't be called
+func (c *commonApi) Call(ctx RequestContext, auth Auth, form *callForm) (*smart.CallResult, *Error) {
+	r := ctx.HTTPRequest()
+	if err := form.Validate(r); err != nil {
+		return nil, InvalidParamsError(err.Error())
+	}
+	client := getClient(r)
+	logger := getLogger(r)
+
+	if getContract(r, form.Contract) == nil {
+		logger.WithFields(log.Fields{"type": consts.ContractError, "contract_name": form.Contract}).Debug("contract name")
+		return nil, DefaultError(fmt.Sprintf("There is not %s contract", form.Contract))
+	}
+	result, err := smart.CallReadOnly(&smart.ReadOnlyCall{
+		ContractCall: smart.ContractCall{
+			Contract:    form.Contract,
+			EcosystemID: client.EcosystemID,
+			KeyID:       client.KeyID,
+			Params:      form.Params,
+		},
+		Func: form.Func,
+		Args: form.Args,
+		Fuel: conf.Config.JsonRPC.CallFuel,
+	})
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.ContractError, "contract_name": form.Contract, "error": err}).Debug("calling contract")
+		return nil, DefaultError(err.Error())
+	}
+	return result, nil
+}
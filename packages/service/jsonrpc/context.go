@@ -8,6 +8,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/IBAX-io/go-ibax/packages/service/apikey"
 	"github.com/golang-jwt/jwt/v4"
 	log "github.com/sirupsen/logrus"
 )
@@ -18,6 +19,7 @@ const (
 	contextKeyLogger contextKey = iota
 	contextKeyToken
 	contextKeyClient
+	contextKeyAPIKey
 )
 
 func setContext(r *http.Request, key, value any) *http.Request {
@@ -60,3 +62,14 @@ func getClient(r *http.Request) *UserClient {
 	}
 	return nil
 }
+
+func setAPIKey(r *http.Request, key *apikey.Key) *http.Request {
+	return setContext(r, contextKeyAPIKey, key)
+}
+
+func getAPIKey(r *http.Request) *apikey.Key {
+	if v := getContext(r, contextKeyAPIKey); v != nil {
+		return v.(*apikey.Key)
+	}
+	return nil
+}
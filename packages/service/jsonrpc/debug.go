@@ -5,12 +5,22 @@
 package jsonrpc
 
 import (
+	"encoding/hex"
+	"errors"
+	"runtime"
+	"sync"
+
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/daemons"
+	"github.com/IBAX-io/go-ibax/packages/rollback"
+	"github.com/IBAX-io/go-ibax/packages/script"
 	"github.com/IBAX-io/go-ibax/packages/service/node"
-	"runtime"
+	log "github.com/sirupsen/logrus"
 )
 
 type debugApi struct {
+	replay sync.Mutex // the transactions are replayed one by one
 }
 
 func newDebugApi() *debugApi {
@@ -32,8 +42,12 @@ func (c *debugApi) GetMemStat() (*memMetric, *Error) {
 }
 
 type banMetric struct {
-	NodePosition int  `json:"node_position"`
-	Status       bool `json:"status"`
+	NodePosition int    `json:"node_position"`
+	Status       bool   `json:"status"`
+	Connected    bool   `json:"connected"`
+	Latency      int64  `json:"latency"`
+	Errors       int64  `json:"errors"`
+	LastError    string `json:"last_error,omitempty"`
 }
 
 func (c *debugApi) GetNodeBanStat() (*[]banMetric, *Error) {
@@ -42,11 +56,77 @@ func (c *debugApi) GetNodeBanStat() (*[]banMetric, *Error) {
 
 	b := node.GetNodesBanService()
 	for i, n := range nodes {
-		list = append(list, banMetric{
+		item := banMetric{
 			NodePosition: i,
 			Status:       b.IsBanned(n),
-		})
+		}
+		if stats, ok := b.GetPeerStats(n.TCPAddress); ok {
+			item.Connected = stats.Connected
+			item.Latency = stats.Latency.Milliseconds()
+			item.Errors = stats.Errors
+			item.LastError = stats.LastError
+		}
+		list = append(list, item)
 	}
 
 	return &list, nil
 }
+
+type traceOptions struct {
+	Breakpoints []script.Breakpoint `json:"breakpoints"`
+	Stepping    bool                `json:"stepping"`
+	MaxSteps    int                 `json:"max_steps"`
+	MaxFrames   int                 `json:"max_frames"`
+}
+
+type txTrace struct {
+	*rollback.ReplayResult
+	Fuel      int64               `json:"fuel"`
+	Truncated bool                `json:"truncated"`
+	Steps     []*script.TraceStep `json:"steps"`
+	Frames    []*script.Frame     `json:"frames"`
+}
+
+// TraceTransaction replays the transaction against the state before its block and returns
+// the execution trace of the contract and the snapshots at the breakpoints
+func (c *debugApi) TraceTransaction(ctx RequestContext, hash string, opt *traceOptions) (*txTrace, *Error) {
+	logger := getLogger(ctx.HTTPRequest())
+	txHash, err := hex.DecodeString(hash)
+	if err != nil || len(txHash) == 0 {
+		return nil, InvalidParamsError("hash is incorrect")
+	}
+	debug := script.NewDebugger()
+	if opt != nil {
+		for _, bp := range opt.Breakpoints {
+			debug.AddBreakpoint(bp.Contract, bp.Line)
+		}
+		debug.Stepping = opt.Stepping
+		if opt.MaxSteps > 0 && opt.MaxSteps < debug.MaxSteps {
+			debug.MaxSteps = opt.MaxSteps
+		}
+		if opt.MaxFrames > 0 && opt.MaxFrames < debug.MaxFrames {
+			debug.MaxFrames = opt.MaxFrames
+		}
+	}
+
+	c.replay.Lock()
+	defer c.replay.Unlock()
+	// the new blocks wait for the end of the replay instead of the rows locked by it
+	daemons.DBLock()
+	ret, err := rollback.ReplayTransaction(txHash, debug)
+	daemons.DBUnlock()
+	if err != nil {
+		if errors.Is(err, rollback.ErrReplayTxNotFound) {
+			return nil, NotFoundError()
+		}
+		logger.WithFields(log.Fields{"type": consts.BlockError, "error": err, "tx_hash": hash}).Error("replaying transaction")
+		return nil, DefaultError(err.Error())
+	}
+	return &txTrace{
+		ReplayResult: ret,
+		Fuel:         debug.Fuel(),
+		Truncated:    debug.Truncated,
+		Steps:        debug.Steps,
+		Frames:       debug.Frames,
+	}, nil
+}
@@ -0,0 +1,99 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package jsonrpc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/IBAX-io/go-ibax/packages/common/jsonschema"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+)
+
+const openRPCVersion = "1.2.6"
+
+// OpenRPCDocument is the description of JSON-RPC methods in OpenRPC format
+type OpenRPCDocument struct {
+	OpenRPC string          `json:"openrpc"`
+	Info    OpenRPCInfo     `json:"info"`
+	Methods []OpenRPCMethod `json:"methods"`
+}
+
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name         string              `json:"name"`
+	Params       []OpenRPCDescriptor `json:"params"`
+	Result       OpenRPCDescriptor   `json:"result"`
+	AuthRequired bool                `json:"x-auth-required,omitempty"`
+	Websocket    bool                `json:"x-websocket-only,omitempty"`
+}
+
+// OpenRPCDescriptor describes the parameter or the result of the method
+type OpenRPCDescriptor struct {
+	Name     string             `json:"name"`
+	Required bool               `json:"required,omitempty"`
+	Schema   *jsonschema.Schema `json:"schema"`
+}
+
+// Discover returns the OpenRPC document generated from the registered methods
+func (r *RpcServers) Discover() (*OpenRPCDocument, *Error) {
+	r.server.service.mu.Lock()
+	defer r.server.service.mu.Unlock()
+	doc := &OpenRPCDocument{
+		OpenRPC: openRPCVersion,
+		Info: OpenRPCInfo{
+			Title:   "IBAX JSON-RPC API",
+			Version: consts.Version(),
+		},
+		Methods: make([]OpenRPCMethod, 0),
+	}
+	for namespace, v := range r.server.service.services {
+		for name, cb := range v.callbacks {
+			doc.Methods = append(doc.Methods, cb.openRPCMethod(namespace+namespaceSeparator+name))
+		}
+	}
+	sort.Slice(doc.Methods, func(i, j int) bool {
+		return doc.Methods[i].Name < doc.Methods[j].Name
+	})
+	return doc, nil
+}
+
+func (c *callback) openRPCMethod(name string) OpenRPCMethod {
+	m := OpenRPCMethod{
+		Name:         name,
+		Params:       make([]OpenRPCDescriptor, len(c.argTypes)),
+		Result:       OpenRPCDescriptor{Name: "result", Schema: &jsonschema.Schema{}},
+		AuthRequired: c.hasAuth,
+		Websocket:    c.recv.Type() == reflect.TypeOf(&subscriptionApi{}),
+	}
+	for i, t := range c.argTypes {
+		// the names of arguments are not available, forms are named by their types
+		par := OpenRPCDescriptor{
+			Name:     fmt.Sprintf("param%d", i+1),
+			Required: t.Kind() != reflect.Pointer,
+			Schema:   jsonschema.Reflect(t, "json"),
+		}
+		if st := indirectType(t); st.Kind() == reflect.Struct && len(st.Name()) > 0 {
+			par.Name = formatName(st.Name())
+		}
+		m.Params[i] = par
+	}
+	if ftype := c.fn.Type(); ftype.NumOut() > 0 && c.errIndex != 0 {
+		m.Result.Schema = jsonschema.Reflect(ftype.Out(0), "json")
+	}
+	return m
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -8,9 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/service/apikey"
 	"github.com/IBAX-io/go-ibax/packages/types"
 	"github.com/golang-jwt/jwt/v4"
 	"strings"
+	"time"
 )
 
 var (
@@ -57,6 +59,20 @@ func parseJWTToken(header string) (*jwt.Token, error) {
 	})
 }
 
+// apiKeyToken returns the token with the claims of the api key, so the key is handled as the session of its account
+func apiKeyToken(key *apikey.Key) *jwt.Token {
+	claims := &JWTClaims{
+		EcosystemID: converter.Int64ToStr(key.Ecosystem),
+		KeyID:       converter.Int64ToStr(key.KeyID),
+		AccountID:   converter.AddressToString(key.KeyID),
+		RoleID:      "0",
+	}
+	if key.ExpiresAt > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Unix(key.ExpiresAt, 0))
+	}
+	return &jwt.Token{Claims: claims, Valid: true}
+}
+
 func getClientFromToken(token *jwt.Token, ecosysNameService types.EcosystemGetter) (*UserClient, error) {
 	claims, ok := token.Claims.(*JWTClaims)
 	if !ok {
@@ -0,0 +1,101 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package jsonrpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	log "github.com/sirupsen/logrus"
+)
+
+type getLogsForm struct {
+	FromBlock int64          `json:"from_block"`
+	ToBlock   int64          `json:"to_block"`
+	Contract  string         `json:"contract"`
+	Ecosystem int64          `json:"ecosystem"`
+	Name      string         `json:"name"`
+	Data      map[string]any `json:"data"`
+	paginatorForm
+}
+
+func (f *getLogsForm) Validate(r *http.Request) error {
+	if f == nil {
+		return errors.New(paramsEmpty)
+	}
+	if f.FromBlock < 0 {
+		return fmt.Errorf(invalidParams, "from_block")
+	}
+	if f.ToBlock < 0 || (f.ToBlock > 0 && f.ToBlock < f.FromBlock) {
+		return fmt.Errorf(invalidParams, "to_block")
+	}
+	if f.Ecosystem < 0 {
+		return fmt.Errorf(invalidParams, "ecosystem")
+	}
+	return f.paginatorForm.Validate(r)
+}
+
+// ContractEventResult is the event emitted by the contract with EmitEvent
+type ContractEventResult struct {
+	BlockID      int64           `json:"block_id"`
+	TxHash       string          `json:"tx_hash"`
+	LogIndex     int64           `json:"log_index"`
+	EcosystemID  int64           `json:"ecosystem"`
+	ContractName string          `json:"contract_name"`
+	Name         string          `json:"name"`
+	Data         json.RawMessage `json:"data"`
+	Timestamp    int64           `json:"timestamp"`
+}
+
+// GetLogs returns the events emitted by contracts filtered by block range, contract name, ecosystem, event name
+// and the fields of the event data
+func (b *blockChainApi) GetLogs(ctx RequestContext, form *getLogsForm) (*[]ContractEventResult, *Error) {
+	r := ctx.HTTPRequest()
+	if err := form.Validate(r); err != nil {
+		return nil, InvalidParamsError(err.Error())
+	}
+	logger := getLogger(r)
+
+	filter := &sqldb.ContractEventFilter{
+		FromBlock:    form.FromBlock,
+		ToBlock:      form.ToBlock,
+		EcosystemID:  form.Ecosystem,
+		ContractName: form.Contract,
+		Name:         form.Name,
+		Offset:       form.Offset,
+		Limit:        form.Limit,
+	}
+	if len(form.Data) > 0 {
+		data, err := json.Marshal(form.Data)
+		if err != nil {
+			return nil, InvalidParamsError(fmt.Sprintf(invalidParams, "data"))
+		}
+		filter.Data = string(data)
+	}
+	events, err := sqldb.GetContractEvents(filter)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting contract events")
+		return nil, DefaultError(err.Error())
+	}
+	result := make([]ContractEventResult, len(events))
+	for i, ev := range events {
+		result[i] = ContractEventResult{
+			BlockID:      ev.BlockID,
+			TxHash:       hex.EncodeToString(ev.TxHash),
+			LogIndex:     ev.LogIndex,
+			EcosystemID:  ev.EcosystemID,
+			ContractName: ev.ContractName,
+			Name:         ev.Name,
+			Data:         json.RawMessage(ev.Data),
+			Timestamp:    ev.Timestamp,
+		}
+	}
+	return &result, nil
+}
@@ -6,6 +6,7 @@ package jsonrpc
 
 import (
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/service/apikey"
 	"github.com/IBAX-io/go-ibax/packages/service/node"
 	"github.com/IBAX-io/go-ibax/packages/statsd"
 	"github.com/didip/tollbooth"
@@ -90,6 +91,23 @@ func tokenMiddleware(next http.Handler) http.Handler {
 	const authHeader = "AUTHORIZATION"
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret, ok := apikey.FromHeader(r.Header.Get(authHeader)); ok {
+			key, err := apikey.Authenticate(secret)
+			if err != nil {
+				logger := getLogger(r)
+				logger.WithFields(log.Fields{"type": consts.AccessDenied, "error": err}).Warning("checking api key")
+				WriteResponse(w, nil, nil, UnauthorizedError())
+				return
+			}
+			if !key.Allow() {
+				WriteResponse(w, nil, nil, LimitExceeded(apikey.ErrLimitReached.Error()))
+				return
+			}
+			r = setAPIKey(r, key)
+			r = setToken(r, apiKeyToken(key))
+			next.ServeHTTP(w, r)
+			return
+		}
 		//token, err := RefreshToken(r.Header.Get(authHeader))
 		token, err := parseJWTToken(r.Header.Get(authHeader))
 		if err != nil {
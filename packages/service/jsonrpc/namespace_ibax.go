@@ -11,6 +11,7 @@ type IbaxApi struct {
 	tx      *transactionApi
 	account *accountsApi
 	data    *dataApi
+	sub     *subscriptionApi
 }
 
 func (p *IbaxApi) GetApis() []any {
@@ -36,6 +37,9 @@ func (p *IbaxApi) GetApis() []any {
 	if p.data != nil {
 		apis = append(apis, p.data)
 	}
+	if p.sub != nil {
+		apis = append(apis, p.sub)
+	}
 	return apis
 }
 
@@ -47,5 +51,6 @@ func NewIbaxApi(m Mode) *IbaxApi {
 		tx:      newTransactionApi(),
 		account: newAccountsApi(m),
 		data:    newDataApi(),
+		sub:     newSubscriptionApi(),
 	}
 }
@@ -6,6 +6,8 @@ package jsonrpc
 
 import (
 	"fmt"
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/service/ratelimit"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"sync/atomic"
@@ -17,6 +19,14 @@ type Server struct {
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWebsocketRequest(r) {
+		if atomic.LoadInt32(&s.status) == 0 {
+			http.Error(w, "server is stopped", http.StatusServiceUnavailable)
+			return
+		}
+		s.serveWebsocket(w, r)
+		return
+	}
 	// Permit dumb empty requests for remote health-checks (AWS)
 	if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
 		w.WriteHeader(http.StatusOK)
@@ -39,6 +49,7 @@ func NewServer(m Mode) *Server {
 		status: 1,
 	}
 	server.service.mode = m
+	server.service.limiter = ratelimit.New("jsonrpc", conf.Config.RateLimit, conf.Config.RateLimit.Methods)
 
 	rpcService := &RpcServers{server}
 
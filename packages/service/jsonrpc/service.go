@@ -11,9 +11,13 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/service/metrics"
+	"github.com/IBAX-io/go-ibax/packages/service/ratelimit"
 )
 
 type service struct {
@@ -29,6 +33,7 @@ type serviceRegistry struct {
 	cancel   context.CancelFunc
 	runWg    sync.WaitGroup
 	t1       time.Time
+	limiter  *ratelimit.Limiter
 }
 
 func (r *serviceRegistry) registerName(namespace string, stct any) error {
@@ -115,6 +120,9 @@ func (s *serviceRegistry) run(ctx RequestContext, isBatch bool, reqs []*Request)
 	if cb == nil {
 		return nil, MethodNotFound(req.Method)
 	}
+	if err := s.limit(ctx, req.Method); err != nil {
+		return nil, err
+	}
 	if cb.hasAuth {
 		r := ctx.HTTPRequest()
 		if err := authRequire(r); err != nil {
@@ -129,9 +137,38 @@ func (s *serviceRegistry) run(ctx RequestContext, isBatch bool, reqs []*Request)
 		return nil, InvalidParamsError(err.Error())
 	}
 
+	startTime := time.Now()
+	defer func() {
+		metrics.ObserveAPIRequest("jsonrpc", ctx.HTTPRequest().Method, req.Method, time.Since(startTime))
+	}()
 	return runMethod(ctx, s.mode, args, cb)
 }
 
+// limit takes the weight of the method from the bucket of the client
+func (s *serviceRegistry) limit(ctx RequestContext, method string) *Error {
+	if s.limiter == nil {
+		return nil
+	}
+	r := ctx.HTTPRequest()
+	var apiKeyID, keyID int64
+	if key := getAPIKey(r); key != nil {
+		apiKeyID = key.ID
+	}
+	if client := getClient(r); client != nil {
+		keyID = client.KeyID
+	}
+	retry, ok := s.limiter.Take(method, s.limiter.Client(r, apiKeyID, keyID))
+	if ok {
+		return nil
+	}
+	seconds := int64(retry / time.Second)
+	if w, ok := ctx.Value(contextKeyHTTPResponseWriter).(http.ResponseWriter); ok {
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	return LimitExceeded(fmt.Sprintf("rate limit has been reached, retry after %d seconds", seconds),
+		map[string]any{"retry_after": seconds})
+}
+
 func runMethod(ctx RequestContext, m Mode, args []reflect.Value, cb *callback) (any, *Error) {
 	return cb.call(ctx, m, args)
 }
@@ -0,0 +1,151 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package jsonrpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/service/event"
+)
+
+const subscriptionMethod = "subscription"
+
+type subscriptionNotification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  subscriptionResult `json:"params"`
+}
+
+type subscriptionResult struct {
+	Subscription string `json:"subscription"`
+	Topic        string `json:"topic"`
+	Result       any    `json:"result"`
+}
+
+// SubscribeFilter narrows the events delivered to a subscription
+type SubscribeFilter struct {
+	Hashes    []string `json:"hashes,omitempty"`    // txStatus: transaction hashes
+	Contracts []string `json:"contracts,omitempty"` // logs: contract names
+	Ecosystem int64    `json:"ecosystem,omitempty"` // logs: ecosystem id
+	Names     []string `json:"names,omitempty"`     // logs: names of the events emitted by EmitEvent
+}
+
+func (f *SubscribeFilter) Validate(topic event.Topic) error {
+	switch topic {
+	case event.TopicNewHeads:
+	case event.TopicTxStatus:
+		if f == nil || len(f.Hashes) == 0 {
+			return fmt.Errorf(invalidParams, "hashes")
+		}
+		for _, h := range f.Hashes {
+			if _, err := hex.DecodeString(h); err != nil {
+				return fmt.Errorf(invalidParams, "hash "+h)
+			}
+		}
+	case event.TopicLogs:
+	default:
+		return fmt.Errorf("unknown subscription topic %s", topic)
+	}
+	return nil
+}
+
+func (f *SubscribeFilter) filter(topic event.Topic) event.Filter {
+	if f == nil {
+		return nil
+	}
+	switch topic {
+	case event.TopicTxStatus:
+		hashes := make(map[string]bool, len(f.Hashes))
+		for _, h := range f.Hashes {
+			hashes[strings.ToLower(h)] = true
+		}
+		return func(v any) bool {
+			s, ok := v.(*event.TxStatus)
+			return ok && hashes[s.Hash]
+		}
+	case event.TopicLogs:
+		contracts := make(map[string]bool, len(f.Contracts))
+		for _, c := range f.Contracts {
+			contracts[c] = true
+		}
+		names := make(map[string]bool, len(f.Names))
+		for _, n := range f.Names {
+			names[n] = true
+		}
+		ecosystem := f.Ecosystem
+		return func(v any) bool {
+			var (
+				ecosystemID int64
+				contract    string
+			)
+			switch l := v.(type) {
+			case *event.ContractLog:
+				if len(names) > 0 {
+					return false
+				}
+				ecosystemID, contract = l.EcosystemID, l.ContractName
+			case *event.ContractEvent:
+				if len(names) > 0 && !names[l.Name] {
+					return false
+				}
+				ecosystemID, contract = l.EcosystemID, l.ContractName
+			default:
+				return false
+			}
+			if ecosystem > 0 && ecosystemID != ecosystem {
+				return false
+			}
+			return len(contracts) == 0 || contracts[contract]
+		}
+	}
+	return nil
+}
+
+type subscriptionApi struct {
+}
+
+func newSubscriptionApi() *subscriptionApi {
+	return &subscriptionApi{}
+}
+
+func newSubscriptionID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// Subscribe creates a subscription to newHeads, txStatus or logs. It is available over websocket only
+func (s *subscriptionApi) Subscribe(ctx RequestContext, topic string, filter *SubscribeFilter) (*string, *Error) {
+	n, ok := notifierFromContext(ctx)
+	if !ok {
+		return nil, MethodNotSupported("subscribe over http, use websocket")
+	}
+	t := event.Topic(topic)
+	if err := filter.Validate(t); err != nil {
+		return nil, InvalidParamsError(err.Error())
+	}
+	id := newSubscriptionID()
+	if !n.subscribe(id, event.Subscribe(t, filter.filter(t))) {
+		return nil, LimitExceeded(fmt.Sprintf("max %d subscriptions per connection", conf.Config.JsonRPC.MaxSubscriptions))
+	}
+	return &id, nil
+}
+
+// Unsubscribe cancels the subscription created on the same connection
+func (s *subscriptionApi) Unsubscribe(ctx RequestContext, id string) (*bool, *Error) {
+	n, ok := notifierFromContext(ctx)
+	if !ok {
+		return nil, MethodNotSupported("unsubscribe over http, use websocket")
+	}
+	if !n.unsubscribe(id) {
+		return nil, ResourceNotFound(fmt.Sprintf("subscription %s not found", id))
+	}
+	result := true
+	return &result, nil
+}
@@ -12,11 +12,14 @@ import (
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
 	"github.com/IBAX-io/go-ibax/packages/consts"
 	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/smart"
 	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
 	"github.com/IBAX-io/go-ibax/packages/transaction"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 type transactionApi struct {
@@ -42,6 +45,12 @@ func txHandlerBatches(r *http.Request, m Mode, mtx map[string][]byte) ([]string,
 		transaction.BadTxForBan(client.KeyID)
 		return nil, fmt.Errorf("the size of tx is too big (%d)", len(txData))
 	}
+	if key := getAPIKey(r); key != nil {
+		if err := key.CheckTxs(txData); err != nil {
+			logger.WithFields(log.Fields{"type": consts.AccessDenied, "error": err, "api_key": key.ID}).Warning("checking api key scopes")
+			return nil, err
+		}
+	}
 
 	hash, err := m.ClientTxProcessor.ProcessClientTxBatches(txData, client.KeyID, logger)
 	if err != nil {
@@ -187,3 +196,53 @@ func (b *transactionApi) TxInfoMultiple(hashList []string, contractInfo *bool) (
 
 	return result, nil
 }
+
+type estimateFeeForm struct {
+	Contract string         `json:"contract"`
+	Params   map[string]any `json:"params"`
+	Expedite string         `json:"expedite"`
+	MaxSum   string         `json:"max_sum"`
+}
+
+func (f *estimateFeeForm) Validate(r *http.Request) error {
+	if f == nil {
+		return errors.New(paramsEmpty)
+	}
+	if f.Contract == "" {
+		return fmt.Errorf(invalidParams, "contract")
+	}
+	if f.Expedite != "" {
+		if _, err := decimal.NewFromString(f.Expedite); err != nil {
+			return fmt.Errorf(invalidParams, "expedite")
+		}
+	}
+	if f.MaxSum != "" && converter.StrToInt64(f.MaxSum) <= 0 {
+		return fmt.Errorf(invalidParams, "max_sum")
+	}
+	return nil
+}
+
+// EstimateFee runs the unsigned contract call of the current account without saving changes
+// and returns the fees and the max sum the signed transaction needs
+func (t *transactionApi) EstimateFee(ctx RequestContext, auth Auth, form *estimateFeeForm) (*smart.FeeEstimate, *Error) {
+	r := ctx.HTTPRequest()
+	if err := form.Validate(r); err != nil {
+		return nil, InvalidParamsError(err.Error())
+	}
+	client := getClient(r)
+	logger := getLogger(r)
+
+	result, err := smart.EstimateFee(&smart.ContractCall{
+		Contract:    form.Contract,
+		EcosystemID: client.EcosystemID,
+		KeyID:       client.KeyID,
+		Params:      form.Params,
+		Expedite:    form.Expedite,
+		MaxSum:      form.MaxSum,
+	})
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.ContractError, "contract": form.Contract, "error": err}).Error("estimating fee")
+		return nil, DefaultError(err.Error())
+	}
+	return result, nil
+}
@@ -66,6 +66,9 @@ func getTxInfo(txHash string, getInfo bool) (*TxInfoResult, error) {
 		}
 		status.Data.Status = ltx.Status
 		status.Data.Ecosystem = ltx.EcosystemID
+		status.Data.PeakMemory = ltx.PeakMemory
+		status.Data.PeakDepth = ltx.PeakDepth
+		status.Data.Instructions = ltx.Instructions
 	}
 	return &status, nil
 }
@@ -0,0 +1,237 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/service/event"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	wsReadBufferSize  = 1024
+	wsWriteBufferSize = 1024
+	wsWriteWait       = 10 * time.Second
+	wsPongWait        = 60 * time.Second
+	wsPingInterval    = wsPongWait * 9 / 10
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  wsReadBufferSize,
+	WriteBufferSize: wsWriteBufferSize,
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin allows the websocket connections of the clients without Origin header, the pages of the same
+// host and the configured origins. CORS is not applied to websocket upgrades, so the origin is checked here
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range conf.Config.JsonRPC.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+var contextKeyNotifier = ctxKey("Notifier")
+
+func isWebsocketRequest(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r)
+}
+
+// serveWebsocket upgrades the request and serves json-rpc messages until the connection is closed
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		getLogger(r).WithFields(log.Fields{"type": consts.NetworkError, "error": err}).Debug("upgrading websocket connection")
+		return
+	}
+	n := newNotifier(conn)
+	defer n.close()
+
+	go n.pingLoop()
+	n.readLoop(r, &s.service)
+}
+
+// notifier is a websocket connection able to push subscription notifications
+type notifier struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	subs   map[string]*event.Subscription
+	closed chan struct{}
+}
+
+func newNotifier(conn *websocket.Conn) *notifier {
+	return &notifier{
+		conn:   conn,
+		subs:   make(map[string]*event.Subscription),
+		closed: make(chan struct{}),
+	}
+}
+
+func notifierFromContext(ctx context.Context) (*notifier, bool) {
+	n, ok := ctx.Value(contextKeyNotifier).(*notifier)
+	return n, ok
+}
+
+func (n *notifier) readLoop(r *http.Request, registry *serviceRegistry) {
+	n.conn.SetReadLimit(maxRequestContentLength)
+	n.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	n.conn.SetPongHandler(func(string) error {
+		return n.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, msg, err := n.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		n.handleMessage(r, registry, msg)
+	}
+}
+
+func (n *notifier) handleMessage(r *http.Request, registry *serviceRegistry, msg []byte) {
+	ctx := context.WithValue(r.Context(), contextKeyHTTPRequest, r)
+	ctx = context.WithValue(ctx, contextKeyNotifier, n)
+	ctxer := func(raw json.RawMessage) {
+		ctx = context.WithValue(ctx, contextKeyRawJSON, raw)
+	}
+	reqs, batch, err := getBatch(io.NopCloser(bytes.NewReader(msg)), ctxer)
+	if err != nil {
+		n.write(generateResponse(nil, nil, InvalidParamsError(err.Error())))
+		return
+	}
+	if !batch {
+		n.write(n.call(RequestContext{ctx}, registry, false, reqs[0]))
+		return
+	}
+	if len(reqs) == 0 {
+		n.write(generateResponse(nil, nil, InvalidInput("empty batch request")))
+		return
+	}
+	resp := make([]any, 0, len(reqs))
+	for _, req := range reqs {
+		resp = append(resp, n.call(RequestContext{ctx}, registry, true, req))
+	}
+	n.write(resp)
+}
+
+func (n *notifier) call(ctx RequestContext, registry *serviceRegistry, isBatch bool, req *Request) any {
+	// methods writing raw http responses can't be served over websocket
+	if cb := registry.findCallback(req.Method); cb != nil && cb.notSingle {
+		return generateResponse(req, nil, MethodNotSupported(req.Method))
+	}
+	result, e := registry.run(ctx, isBatch, []*Request{req})
+	return generateResponse(req, result, e)
+}
+
+func (n *notifier) write(v any) {
+	n.writeMu.Lock()
+	defer n.writeMu.Unlock()
+	n.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := n.conn.WriteJSON(v); err != nil {
+		log.WithFields(log.Fields{"type": consts.NetworkError, "error": err}).Debug("writing websocket message")
+	}
+}
+
+func (n *notifier) pingLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n.writeMu.Lock()
+			err := n.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			n.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		case <-n.closed:
+			return
+		}
+	}
+}
+
+// subscribe registers the subscription and starts forwarding its events to the connection.
+// It returns false if the connection has the max number of subscriptions
+func (n *notifier) subscribe(id string, sub *event.Subscription) bool {
+	n.mu.Lock()
+	if max := conf.Config.JsonRPC.MaxSubscriptions; max > 0 && len(n.subs) >= max {
+		n.mu.Unlock()
+		sub.Unsubscribe()
+		return false
+	}
+	n.subs[id] = sub
+	n.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case v, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				n.write(&subscriptionNotification{
+					JSONRPC: JsonRPCVersion,
+					Method:  GetNamespace(NamespaceIBAX) + namespaceSeparator + subscriptionMethod,
+					Params: subscriptionResult{
+						Subscription: id,
+						Topic:        string(sub.Topic()),
+						Result:       v,
+					},
+				})
+			case <-n.closed:
+				return
+			}
+		}
+	}()
+	return true
+}
+
+func (n *notifier) unsubscribe(id string) bool {
+	n.mu.Lock()
+	sub, ok := n.subs[id]
+	delete(n.subs, id)
+	n.mu.Unlock()
+	if ok {
+		sub.Unsubscribe()
+	}
+	return ok
+}
+
+func (n *notifier) close() {
+	n.mu.Lock()
+	for id, sub := range n.subs {
+		sub.Unsubscribe()
+		delete(n.subs, id)
+	}
+	n.mu.Unlock()
+	close(n.closed)
+	n.conn.Close()
+}
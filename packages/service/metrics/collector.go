@@ -0,0 +1,108 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/service/node"
+	"github.com/IBAX-io/go-ibax/packages/service/ratelimit"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/transaction"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// nodeCollector collects the state of the node when the metrics are scraped
+type nodeCollector struct {
+	blockHeight *prometheus.Desc
+	queueTx     *prometheus.Desc
+	honorNodes  *prometheus.Desc
+	nodeBanned  *prometheus.Desc
+	bannedNodes *prometheus.Desc
+	bannedKeys  *prometheus.Desc
+	nodePaused  *prometheus.Desc
+	rateLimit   *prometheus.Desc
+}
+
+func newNodeCollector() *nodeCollector {
+	return &nodeCollector{
+		blockHeight: prometheus.NewDesc(namespace+"_block_height",
+			"ID of the last block of the node.", nil, nil),
+		queueTx: prometheus.NewDesc(namespace+"_queue_tx",
+			"Number of the transactions in queue_tx.", nil, nil),
+		honorNodes: prometheus.NewDesc(namespace+"_honor_nodes",
+			"Number of the honor nodes of the network.", nil, nil),
+		nodeBanned: prometheus.NewDesc(namespace+"_node_banned",
+			"State of the honor node in NodesBanService, 1 is banned.", []string{"position", "tcp_address"}, nil),
+		bannedNodes: prometheus.NewDesc(namespace+"_banned_nodes",
+			"Number of the banned honor nodes.", nil, nil),
+		bannedKeys: prometheus.NewDesc(namespace+"_banned_keys",
+			"Number of the keys banned for bad transactions.", nil, nil),
+		nodePaused: prometheus.NewDesc(namespace+"_node_paused",
+			"Pause type of the node, 0 is not paused.", nil, nil),
+		rateLimit: prometheus.NewDesc(namespace+"_ratelimit_requests_total",
+			"Number of the requests checked by the rate limit.", []string{"server", "name", "result"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *nodeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.blockHeight
+	ch <- c.queueTx
+	ch <- c.honorNodes
+	ch <- c.nodeBanned
+	ch <- c.bannedNodes
+	ch <- c.bannedKeys
+	ch <- c.nodePaused
+	ch <- c.rateLimit
+}
+
+// Collect implements prometheus.Collector
+func (c *nodeCollector) Collect(ch chan<- prometheus.Metric) {
+	if sqldb.DBConn != nil {
+		ib := &sqldb.InfoBlock{}
+		if found, err := ib.Get(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		} else if found {
+			ch <- prometheus.MustNewConstMetric(c.blockHeight, prometheus.GaugeValue, float64(ib.BlockID))
+		}
+		if count, err := sqldb.GetQueueTxCount(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting queue tx count")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.queueTx, prometheus.GaugeValue, float64(count))
+		}
+	}
+
+	nodes := syspar.GetNodes()
+	ch <- prometheus.MustNewConstMetric(c.honorNodes, prometheus.GaugeValue, float64(len(nodes)))
+	if nbs := node.GetNodesBanService(); nbs != nil {
+		var banned int
+		for i, n := range nodes {
+			var value float64
+			if nbs.IsBanned(n) {
+				value = 1
+				banned++
+			}
+			ch <- prometheus.MustNewConstMetric(c.nodeBanned, prometheus.GaugeValue, value,
+				strconv.Itoa(i), n.TCPAddress)
+		}
+		ch <- prometheus.MustNewConstMetric(c.bannedNodes, prometheus.GaugeValue, float64(banned))
+	}
+	ch <- prometheus.MustNewConstMetric(c.bannedKeys, prometheus.GaugeValue, float64(transaction.BannedKeysCount()))
+	ch <- prometheus.MustNewConstMetric(c.nodePaused, prometheus.GaugeValue, float64(node.NodePauseType()))
+
+	for server, lmt := range ratelimit.Limiters() {
+		for _, stat := range lmt.Stats() {
+			ch <- prometheus.MustNewConstMetric(c.rateLimit, prometheus.CounterValue, float64(stat.Allowed),
+				server, stat.Name, "allowed")
+			ch <- prometheus.MustNewConstMetric(c.rateLimit, prometheus.CounterValue, float64(stat.Limited),
+				server, stat.Name, "limited")
+		}
+	}
+}
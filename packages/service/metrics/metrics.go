@@ -0,0 +1,109 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ibax"
+
+// The modes of the processed blocks
+const (
+	BlockGenerated = "generate"
+	BlockPlayed    = "play"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	blockDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "block",
+		Name:      "duration_seconds",
+		Help:      "Time of generating or playing the block.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"mode"})
+	blockTxs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "block",
+		Name:      "transactions_total",
+		Help:      "Number of the transactions in the inserted blocks.",
+	}, []string{"mode"})
+	vmFuel = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "vm",
+		Name:      "fuel_used_total",
+		Help:      "Fuel used by the transactions of the inserted blocks.",
+	})
+	apiDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "api",
+		Name:      "request_duration_seconds",
+		Help:      "Time of handling the API request by the route or JSON-RPC method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"server", "method", "route"})
+	tcpConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "tcp",
+		Name:      "connections",
+		Help:      "Number of the open incoming TCP connections of the peers.",
+	})
+	tcpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tcp",
+		Name:      "requests_total",
+		Help:      "Number of the TCP requests of the peers by the request type.",
+	}, []string{"type"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		blockDuration,
+		blockTxs,
+		vmFuel,
+		apiDuration,
+		tcpConnections,
+		tcpRequests,
+		newNodeCollector(),
+	)
+}
+
+// Handler returns the handler of the metrics in Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveBlock records the inserted block
+func ObserveBlock(mode string, duration time.Duration, txs int, fuel int64) {
+	blockDuration.WithLabelValues(mode).Observe(duration.Seconds())
+	blockTxs.WithLabelValues(mode).Add(float64(txs))
+	vmFuel.Add(float64(fuel))
+}
+
+// ObserveAPIRequest records the time of the API request
+func ObserveAPIRequest(server, method, route string, duration time.Duration) {
+	apiDuration.WithLabelValues(server, method, route).Observe(duration.Seconds())
+}
+
+// TCPConnOpened records the incoming TCP connection and returns the function which should be called on close
+func TCPConnOpened() func() {
+	tcpConnections.Inc()
+	return tcpConnections.Dec
+}
+
+// TCPRequest records the TCP request of the type
+func TCPRequest(reqType uint16) {
+	tcpRequests.WithLabelValues(strconv.Itoa(int(reqType))).Inc()
+}
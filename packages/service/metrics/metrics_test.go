@@ -0,0 +1,43 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	ObserveBlock(BlockPlayed, time.Second, 3, 1500)
+	ObserveAPIRequest("api", "GET", "/api/v2/version", time.Millisecond)
+	closeConn := TCPConnOpened()
+	TCPRequest(1)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	closeConn()
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+
+	text := string(body)
+	for _, line := range []string{
+		`ibax_block_transactions_total{mode="play"} 3`,
+		`ibax_vm_fuel_used_total 1500`,
+		`ibax_block_duration_seconds_count{mode="play"} 1`,
+		`ibax_api_request_duration_seconds_count{method="GET",route="/api/v2/version",server="api"} 1`,
+		`ibax_tcp_connections 1`,
+		`ibax_tcp_requests_total{type="1"} 1`,
+		`ibax_honor_nodes 0`,
+		`ibax_banned_keys 0`,
+	} {
+		assert.Contains(t, text, line)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/IBAX-io/go-ibax/packages/common/crypto"
 	"github.com/IBAX-io/go-ibax/packages/conf"
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/network/tcpclient"
 	"github.com/IBAX-io/go-ibax/packages/script"
 	"github.com/IBAX-io/go-ibax/packages/smart"
 	"github.com/IBAX-io/go-ibax/packages/transaction"
@@ -28,6 +30,7 @@ type localBannedNode struct {
 type NodesBanService struct {
 	localBannedNodes map[int64]localBannedNode
 	honorNodes       []syspar.HonorNode
+	peerStats        map[string]tcpclient.PeerStats
 
 	m *sync.Mutex
 }
@@ -43,10 +46,12 @@ func GetNodesBanService() *NodesBanService {
 func InitNodesBanService() error {
 	nbs = &NodesBanService{
 		localBannedNodes: make(map[int64]localBannedNode),
+		peerStats:        make(map[string]tcpclient.PeerStats),
 		m:                &sync.Mutex{},
 	}
 
 	nbs.refreshNodes()
+	tcpclient.SetStatsReporter(nbs.ReportPeerStats)
 	return nil
 }
 
@@ -68,6 +73,25 @@ func (nbs *NodesBanService) RegisterBadBlock(node syspar.HonorNode, badBlockId,
 	return nil
 }
 
+// ReportPeerStats is saving the latency and the errors of the connection to the node
+func (nbs *NodesBanService) ReportPeerStats(stats tcpclient.PeerStats) {
+	nbs.m.Lock()
+	defer nbs.m.Unlock()
+	nbs.peerStats[stats.Host] = stats
+}
+
+// GetPeerStats returns the statistics of the connection to the node by its tcp address
+func (nbs *NodesBanService) GetPeerStats(host string) (tcpclient.PeerStats, bool) {
+	nbs.m.Lock()
+	defer nbs.m.Unlock()
+	host, err := tcpclient.NormalizeHostAddress(host, consts.DefaultTcpPort)
+	if err != nil {
+		return tcpclient.PeerStats{}, false
+	}
+	stats, ok := nbs.peerStats[host]
+	return stats, ok
+}
+
 // IsBanned is allows to check node ban (local or global)
 func (nbs *NodesBanService) IsBanned(node syspar.HonorNode) bool {
 	nbs.refreshNodes()
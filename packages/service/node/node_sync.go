@@ -0,0 +1,88 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package node
+
+import (
+	"sync"
+	"time"
+)
+
+// SyncStatus is the progress of the collecting of the blocks from the other nodes
+type SyncStatus struct {
+	Syncing      bool  `json:"syncing"`
+	StartBlock   int64 `json:"start_block"`
+	CurrentBlock int64 `json:"current_block"`
+	HeadersBlock int64 `json:"headers_block"`
+	TargetBlock  int64 `json:"target_block"`
+	Peers        int   `json:"peers"`
+	StartTime    int64 `json:"start_time"`
+}
+
+type syncProgress struct {
+	mutex sync.RWMutex
+
+	status SyncStatus
+}
+
+// sp contains the progress of the current synchronization
+var sp = &syncProgress{}
+
+// StartSync starts the progress of the synchronization from startBlock to targetBlock
+func StartSync(startBlock, targetBlock int64) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.status = SyncStatus{
+		Syncing:      true,
+		StartBlock:   startBlock,
+		CurrentBlock: startBlock,
+		HeadersBlock: startBlock,
+		TargetBlock:  targetBlock,
+		StartTime:    time.Now().Unix(),
+	}
+}
+
+// SetSyncPeers sets the number of the peers which the blocks are downloaded from
+func SetSyncPeers(peers int) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.status.Peers = peers
+}
+
+// SetSyncHeaders sets the last block of the checked headers
+func SetSyncHeaders(blockID int64) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.status.HeadersBlock = blockID
+}
+
+// SetSyncBlock sets the last played block
+func SetSyncBlock(blockID int64) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.status.CurrentBlock = blockID
+	if sp.status.HeadersBlock < blockID {
+		sp.status.HeadersBlock = blockID
+	}
+}
+
+// StopSync finishes the progress of the synchronization
+func StopSync() {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.status.Syncing = false
+}
+
+// GetSyncStatus returns the progress of the last synchronization
+func GetSyncStatus() SyncStatus {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	return sp.status
+}
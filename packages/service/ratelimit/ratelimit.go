@@ -0,0 +1,212 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/statsd"
+	"github.com/didip/tollbooth/libstring"
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRate is the number of requests per second of the client if the rate is not configured
+	DefaultRate = 10
+
+	bucketTTL      = time.Hour
+	defaultBucket  = ""
+	counterAllowed = "allowed"
+	counterLimited = "limited"
+)
+
+var defaultIPLookups = []string{"RemoteAddr"}
+
+// Limiter limits the requests of the clients by the rules of REST routes or JSON-RPC methods.
+// The rules without their own rate share the bucket of the default rule, so the expensive
+// calls with the bigger weight take more requests of the client
+type Limiter struct {
+	prefix    string
+	def       conf.RateLimitRule
+	rules     map[string]conf.RateLimitRule
+	ipLookups []string
+	buckets   map[string]*bucket
+
+	mu    sync.Mutex
+	stats map[string]*Stat
+}
+
+// Stat is the counters of the requests of the rule
+type Stat struct {
+	Name    string `json:"name"`
+	Allowed int64  `json:"allowed"`
+	Limited int64  `json:"limited"`
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Limiter)
+)
+
+// New returns the limiter of the rules. It returns nil if the rate limiting is disabled.
+// The prefix is used in the names of the metrics, e.g. api or jsonrpc
+func New(prefix string, cfg conf.RateLimitConfig, rules []conf.RateLimitRule) *Limiter {
+	if !cfg.Enabled {
+		return nil
+	}
+	l := &Limiter{
+		prefix:    prefix,
+		def:       cfg.Default,
+		rules:     make(map[string]conf.RateLimitRule),
+		ipLookups: cfg.IPLookups,
+		buckets:   make(map[string]*bucket),
+		stats:     make(map[string]*Stat),
+	}
+	if l.def.Rate <= 0 {
+		l.def.Rate = DefaultRate
+	}
+	if len(l.ipLookups) == 0 {
+		l.ipLookups = defaultIPLookups
+	}
+	l.buckets[defaultBucket] = newBucket(l.def, 1)
+	for _, rule := range rules {
+		l.rules[rule.Name] = rule
+		if rule.Rate > 0 {
+			l.buckets[rule.Name] = newBucket(rule, weight(rule))
+		} else if w := weight(rule); w > l.buckets[defaultBucket].burst {
+			l.buckets[defaultBucket].burst = w
+		}
+	}
+	registryMu.Lock()
+	registry[prefix] = l
+	registryMu.Unlock()
+	return l
+}
+
+func weight(rule conf.RateLimitRule) int {
+	if rule.Weight <= 0 {
+		return 1
+	}
+	return rule.Weight
+}
+
+// bucket is the token bucket of every client of the rule
+type bucket struct {
+	rate    rate.Limit
+	burst   int
+	mu      sync.Mutex
+	clients *cache.Cache
+}
+
+func newBucket(rule conf.RateLimitRule, minBurst int) *bucket {
+	burst := rule.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(rule.Rate))
+	}
+	if burst < minBurst {
+		burst = minBurst
+	}
+	return &bucket{
+		rate:    rate.Limit(rule.Rate),
+		burst:   burst,
+		clients: cache.New(bucketTTL, bucketTTL),
+	}
+}
+
+// take takes n tokens of the client. The tokens are taken only if the bucket has all of them
+func (b *bucket) take(client string, n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var lmt *rate.Limiter
+	if v, ok := b.clients.Get(client); ok {
+		lmt = v.(*rate.Limiter)
+	} else {
+		lmt = rate.NewLimiter(b.rate, b.burst)
+	}
+	b.clients.Set(client, lmt, cache.DefaultExpiration)
+	return lmt.AllowN(time.Now(), n)
+}
+
+// Client returns the key of the client of the request. The requests with API key or
+// authorized by the key ID are limited for the key, otherwise they are limited by IP
+func (l *Limiter) Client(r *http.Request, apiKeyID, keyID int64) string {
+	switch {
+	case apiKeyID != 0:
+		return fmt.Sprintf("apikey:%d", apiKeyID)
+	case keyID != 0:
+		return fmt.Sprintf("key:%d", keyID)
+	}
+	return "ip:" + libstring.RemoteIP(l.ipLookups, 0, r)
+}
+
+// Take takes the weight of the call of the route or method from the bucket of the client.
+// If the limit has been reached, nothing is taken and it returns false and the duration after which
+// the call can be retried
+func (l *Limiter) Take(name, client string) (time.Duration, bool) {
+	rule, ok := l.rules[name]
+	bucket := defaultBucket
+	if !ok || rule.Rate <= 0 {
+		rule.Rate = l.def.Rate
+	} else {
+		bucket = name
+	}
+	w := weight(rule)
+	if !l.buckets[bucket].take(client, w) {
+		l.count(name, counterLimited)
+		return time.Duration(math.Ceil(float64(w)/rule.Rate)) * time.Second, false
+	}
+	l.count(name, counterAllowed)
+	return 0, true
+}
+
+func (l *Limiter) count(name, counter string) {
+	l.mu.Lock()
+	stat, ok := l.stats[name]
+	if !ok {
+		stat = &Stat{Name: name}
+		l.stats[name] = stat
+	}
+	if counter == counterAllowed {
+		stat.Allowed++
+	} else {
+		stat.Limited++
+	}
+	l.mu.Unlock()
+
+	if statsd.Client != nil {
+		statsd.Client.Inc(fmt.Sprintf("ratelimit.%s.%s", l.prefix, counter), 1, 1.0)
+	}
+}
+
+// Stats returns the counters of the rules ordered by name
+func (l *Limiter) Stats() []Stat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := make([]Stat, 0, len(l.stats))
+	for _, stat := range l.stats {
+		list = append(list, *stat)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Limiters returns all limiters of the node
+func Limiters() map[string]*Limiter {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	list := make(map[string]*Limiter, len(registry))
+	for prefix, l := range registry {
+		list[prefix] = l
+	}
+	return list
+}
@@ -0,0 +1,76 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	assert.Nil(t, New("test", conf.RateLimitConfig{}, nil))
+
+	cfg := conf.RateLimitConfig{Enabled: true, Default: conf.RateLimitRule{Rate: 1, Burst: 4}}
+	l := New("test", cfg, []conf.RateLimitRule{
+		{Name: "ibax.getList", Weight: 3},
+		{Name: "ibax.sendTx", Rate: 1, Burst: 1},
+	})
+	require.NotNil(t, l)
+
+	_, ok := l.Take("ibax.getList", "ip:1")
+	assert.True(t, ok)
+	_, ok = l.Take("ibax.maxBlockId", "ip:1")
+	assert.True(t, ok)
+	// the weighted method has taken the requests of the default bucket
+	retry, ok := l.Take("ibax.getList", "ip:1")
+	assert.False(t, ok)
+	assert.Equal(t, 3*time.Second, retry)
+	_, ok = l.Take("ibax.getList", "ip:2")
+	assert.True(t, ok)
+
+	// the method with its own rate has the separate bucket
+	_, ok = l.Take("ibax.sendTx", "ip:1")
+	assert.True(t, ok)
+	_, ok = l.Take("ibax.sendTx", "ip:1")
+	assert.False(t, ok)
+
+	stats := l.Stats()
+	require.Equal(t, 3, len(stats))
+	assert.Equal(t, Stat{Name: "ibax.getList", Allowed: 2, Limited: 1}, stats[0])
+	assert.Equal(t, l, Limiters()["test"])
+}
+
+func TestClient(t *testing.T) {
+	l := New("test", conf.RateLimitConfig{Enabled: true}, nil)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "10.0.0.2")
+
+	assert.Equal(t, "apikey:2", l.Client(r, 2, 5))
+	assert.Equal(t, "key:5", l.Client(r, 0, 5))
+	assert.Equal(t, "ip:10.0.0.1", l.Client(r, 0, 0))
+}
+
+func TestLimiterRejectedWeight(t *testing.T) {
+	cfg := conf.RateLimitConfig{Enabled: true, Default: conf.RateLimitRule{Rate: 0.001, Burst: 4}}
+	l := New("test", cfg, []conf.RateLimitRule{{Name: "ibax.getList", Weight: 3}})
+	require.NotNil(t, l)
+
+	_, ok := l.Take("ibax.getList", "ip:1")
+	assert.True(t, ok)
+	// the rejected call does not take the rest of the bucket
+	_, ok = l.Take("ibax.getList", "ip:1")
+	assert.False(t, ok)
+	_, ok = l.Take("ibax.maxBlockId", "ip:1")
+	assert.True(t, ok)
+	_, ok = l.Take("ibax.maxBlockId", "ip:1")
+	assert.False(t, ok)
+}
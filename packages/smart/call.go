@@ -0,0 +1,142 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package smart
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/types"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReadOnlyCall is a call of the contract or of the function of the contract which doesn't change the state
+type ReadOnlyCall struct {
+	ContractCall
+	Func string // the function of the contract, the contract is run if it is empty
+	Args []any  // the parameters of the function
+	Fuel int64  // the maximum fuel of the call, it can't be greater than max_fuel of the platform
+}
+
+// CallResult is the result of the read-only call
+type CallResult struct {
+	Contract string `json:"contract"`
+	Func     string `json:"func,omitempty"`
+	Fuel     int64  `json:"fuel"`
+	Result   any    `json:"result"`
+}
+
+// CallReadOnly runs the contract call in the transaction which is always rolled back.
+// The functions changing the state are not allowed in the call
+func CallReadOnly(call *ReadOnlyCall) (*CallResult, error) {
+	dbTx, err := sqldb.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+
+	if call.Fuel <= 0 || call.Fuel > syspar.GetMaxCost() {
+		call.Fuel = syspar.GetMaxCost()
+	}
+	call.MaxSum = converter.Int64ToStr(call.Fuel)
+	sc, err := call.newSmartContract(dbTx)
+	if err != nil {
+		return nil, err
+	}
+	defer sc.flushVM()
+	sc.ReadOnly = true
+	if _, err = sc.Key.SetTablePrefix(sc.TxSmart.EcosystemID).Get(dbTx, sc.TxSmart.KeyID); err != nil {
+		sc.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting wallet")
+		return nil, err
+	}
+	return sc.callReadOnly(call)
+}
+
+func (sc *SmartContract) callReadOnly(call *ReadOnlyCall) (*CallResult, error) {
+	ret := &CallResult{Contract: sc.TxContract.Name, Func: call.Func}
+	sc.TxContract.Extend = sc.getExtend()
+	if err := sc.AppendStack(sc.TxContract.Name); err != nil {
+		return nil, err
+	}
+	extend := sc.TxContract.Extend
+	before := extend[script.Extend_txcost].(int64)
+	_, nameContract := converter.ParseName(sc.TxContract.Name)
+	extend[script.Extend_original_contract] = nameContract
+	extend[script.Extend_this_contract] = nameContract
+
+	var err error
+	if len(call.Func) == 0 {
+		err = script.RunContractByName(sc.VM, sc.TxContract.Name, []string{`conditions`, `action`}, extend, sc.Hash)
+		ret.Result = extend[script.Extend_result]
+	} else {
+		var (
+			fn   = sc.TxContract.GetFunc(call.Func)
+			args []any
+		)
+		if fn == nil {
+			return nil, fmt.Errorf(eUnknownFunc, call.Func, sc.TxContract.Name)
+		}
+		if args, err = funcArgs(fn.GetFuncInfo(), call.Args); err != nil {
+			return nil, err
+		}
+		ret.Result, err = script.VMRun(sc.VM, fn, args, extend, sc.Hash)
+	}
+	ret.Fuel = before - extend[script.Extend_txcost].(int64)
+	if err != nil {
+		return nil, vmError(err)
+	}
+	return ret, nil
+}
+
+// funcArgs converts JSON values of args to the types of the function parameters
+func funcArgs(info *script.FuncInfo, args []any) ([]any, error) {
+	if info.Names != nil || info.Variadic {
+		return nil, fmt.Errorf("function %s with tail or variadic parameters cannot be called", info.Name)
+	}
+	if len(args) != len(info.Params) {
+		return nil, fmt.Errorf("function %s requires %d parameters", info.Name, len(info.Params))
+	}
+	ret := make([]any, len(args))
+	for i, par := range info.Params {
+		var err error
+		v := args[i]
+		switch par {
+		case reflect.TypeOf(int64(0)):
+			switch val := v.(type) {
+			case float64:
+				v = int64(val)
+			case string:
+				v, err = converter.ValueToInt(val)
+			}
+		case reflect.TypeOf(decimal.Zero):
+			switch val := v.(type) {
+			case float64:
+				v = decimal.NewFromFloat(val)
+			case string:
+				v, err = decimal.NewFromString(val)
+			}
+		case reflect.TypeOf(&types.Map{}):
+			if val, ok := v.(map[string]any); ok {
+				v = types.LoadMap(val)
+			}
+		case reflect.TypeOf([]any{}):
+			if v == nil {
+				v = make([]any, 0)
+			}
+		}
+		if err != nil || (par.Kind() != reflect.Interface && reflect.TypeOf(v) != par) {
+			return nil, fmt.Errorf("invalid parameter %d of function %s", i+1, info.Name)
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
@@ -77,9 +77,21 @@ func VMGetContract(vm *script.VM, name string, state uint32) *Contract {
 	return nil
 }
 
+// VMGetLibrary returns the information about the library of the ecosystem
+func VMGetLibrary(vm *script.VM, name string, state uint32) *script.LibraryInfo {
+	if len(name) == 0 {
+		return nil
+	}
+	obj, ok := vm.Objects[script.StateName(state, name)]
+	if ok && obj.Type == script.ObjectType_Library {
+		return obj.GetCodeBlock().GetLibraryInfo()
+	}
+	return nil
+}
+
 func VMGetContractByID(vm *script.VM, id int32) *Contract {
-	var tableID int64
-	if id > consts.ShiftContractID {
+	tableID := int64(-1)
+	if id >= consts.ShiftContractID {
 		tableID = int64(id - consts.ShiftContractID)
 		id = int32(tableID + vm.ShiftContract)
 	}
@@ -90,7 +102,7 @@ func VMGetContractByID(vm *script.VM, id int32) *Contract {
 	if vm.Children[idcont] == nil || vm.Children[idcont].Type != script.ObjectType_Contract {
 		return nil
 	}
-	if tableID > 0 && vm.Children[idcont].GetContractInfo().Owner.TableID != tableID {
+	if tableID >= 0 && vm.Children[idcont].GetContractInfo().Owner.TableID != tableID {
 		return nil
 	}
 	return &Contract{Name: vm.Children[idcont].GetContractInfo().Name,
@@ -123,24 +135,39 @@ func (contract *Contract) GetFunc(name string) *script.CodeBlock {
 func loadContractList(list []sqldb.Contract) error {
 	if script.GetVM().ShiftContract == 0 {
 		script.LoadSysFuncs(script.GetVM(), 1)
+		// the contract with the reserved id 0 is compiled before the offset is fixed,
+		// so it gets the offset itself and the other contracts keep their ids
+		if len(list) > 0 && list[0].ID == 0 {
+			if err := loadContractItem(list[0]); err != nil {
+				return err
+			}
+			list = list[1:]
+		}
 		script.GetVM().ShiftContract = int64(len(script.GetVM().Children) - 1)
 	}
 
 	for _, item := range list {
-		clist, err := script.ContractsList(item.Value)
-		if err != nil {
+		if err := loadContractItem(item); err != nil {
 			return err
 		}
-		owner := script.OwnerInfo{
-			StateID:  uint32(item.EcosystemID),
-			Active:   false,
-			TableID:  item.ID,
-			WalletID: item.WalletID,
-			TokenID:  item.TokenID,
-		}
-		if err = script.GetVM().Compile([]rune(item.Value), &owner); err != nil {
-			logErrorValue(err, consts.EvalError, "Load Contract", strings.Join(clist, `,`))
-		}
+	}
+	return nil
+}
+
+func loadContractItem(item sqldb.Contract) error {
+	clist, err := script.ContractsList(item.Value)
+	if err != nil {
+		return err
+	}
+	owner := script.OwnerInfo{
+		StateID:  uint32(item.EcosystemID),
+		Active:   false,
+		TableID:  item.ID,
+		WalletID: item.WalletID,
+		TokenID:  item.TokenID,
+	}
+	if err = script.GetVM().Compile([]rune(item.Value), &owner); err != nil {
+		logErrorValue(err, consts.EvalError, "Load Contract", strings.Join(clist, `,`))
 	}
 	return nil
 }
@@ -42,6 +42,11 @@ const (
 	eEcoFuelRate           = `fuel rate must be greater than 0 or empty in ecosystem %d`
 	eEcoCurrentBalance     = `account %s current balance is not enough in ecosystem %d`
 	eEcoCurrentBalanceDiff = eEcoCurrentBalance + `, at least [%s] difference`
+	eReadOnlyCall          = `%s cannot be called in read-only mode`
+	eEstimateCall          = `%s cannot be called in fee estimation`
+	eMigrateCall           = `%s cannot be called in migrate section`
+	eUnknownFunc           = `unknown function %s in %s contract`
+	eUnknownVersion        = `version %d of contract %d has not been found`
 )
 
 var (
@@ -59,6 +64,7 @@ var (
 	errEmpty             = errors.New(`empty value and condition`)
 	errEmptyCond         = errors.New(`the condition is empty`)
 	errEmptyContract     = errors.New(`empty contract name in ContractConditions`)
+	errEmptyEventName    = errors.New(`the event name cannot be empty`)
 	errEmptyPublicKey    = errors.New(`empty public key`)
 	errFounderAccount    = errors.New(`unknown founder account`)
 	errKeyIDAccount      = errors.New(`unknown address account`)
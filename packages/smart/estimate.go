@@ -0,0 +1,269 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package smart
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/common/crypto"
+	"github.com/IBAX-io/go-ibax/packages/common/random"
+	"github.com/IBAX-io/go-ibax/packages/conf"
+	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/notificator"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/types"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+// ContractCall is an unsigned call of the contract. Params are decoded from JSON
+type ContractCall struct {
+	Contract    string
+	EcosystemID int64
+	KeyID       int64
+	Params      map[string]any
+	Expedite    string
+	MaxSum      string
+}
+
+// PaymentEstimate is the fee which will be paid in one token ecosystem
+type PaymentEstimate struct {
+	TokenEcosystem   int64             `json:"token_ecosystem"`
+	TokenSymbol      string            `json:"token_symbol"`
+	From             string            `json:"from"`
+	PaymentType      string            `json:"payment_type"`
+	Fees             map[string]string `json:"fees"`
+	Combustion       string            `json:"combustion"`
+	Taxes            string            `json:"taxes"`
+	Total            string            `json:"total"`
+	Balance          string            `json:"balance"`
+	Detail           any               `json:"detail"`
+	DetailCombustion any               `json:"detail_combustion"`
+}
+
+// FeeEstimate is the result of the dry run of the contract
+type FeeEstimate struct {
+	Contract string             `json:"contract"`
+	Fuel     int64              `json:"fuel"`
+	MaxSum   string             `json:"max_sum"`
+	Payments []*PaymentEstimate `json:"payments"`
+	Result   string             `json:"result,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// EstimateFee runs the contract call against the current state in the transaction
+// which is always rolled back and returns the fees the call would be charged
+func EstimateFee(call *ContractCall) (*FeeEstimate, error) {
+	dbTx, err := sqldb.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+
+	sc, err := call.newSmartContract(dbTx)
+	if err != nil {
+		return nil, err
+	}
+	defer sc.flushVM()
+	return sc.estimateFee()
+}
+
+// newSmartContract prepares the contract of the call to run in dbTx as the tx of the next block
+func (call *ContractCall) newSmartContract(dbTx *sqldb.DbTransaction) (*SmartContract, error) {
+	contract := GetContract(call.Contract, uint32(call.EcosystemID))
+	if contract == nil {
+		return nil, fmt.Errorf(eUnknownContract, call.Contract)
+	}
+	smartTx := &types.SmartTransaction{
+		Header: &types.Header{
+			ID:          int(contract.Info().ID),
+			EcosystemID: call.EcosystemID,
+			KeyID:       call.KeyID,
+			Time:        time.Now().Unix(),
+			NetworkID:   conf.Config.LocalConf.NetworkID,
+		},
+		MaxSum:   call.MaxSum,
+		Expedite: call.Expedite,
+		Params:   call.Params,
+	}
+	if txInfo := contract.Info().Tx; txInfo != nil {
+		smartTx.Params = jsonTxParams(*txInfo, call.Params)
+	}
+	// the payload is decoded back to get the same params as the node gets from the signed tx
+	payload, err := smartTx.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	smartTx = &types.SmartTransaction{}
+	if err = smartTx.Unmarshal(payload); err != nil {
+		return nil, err
+	}
+	txData := make(map[string]any)
+	if txInfo := contract.Info().Tx; txInfo != nil {
+		for k := range smartTx.Params {
+			if _, ok := contract.Info().TxMap()[k]; !ok {
+				return nil, fmt.Errorf("'%s' parameter is not required", k)
+			}
+		}
+		if txData, err = FillTxData(*txInfo, smartTx.Params); err != nil {
+			return nil, fmt.Errorf("contract '%s': %w", contract.Name, err)
+		}
+	}
+
+	ib := &sqldb.InfoBlock{}
+	if _, err = ib.Get(); err != nil {
+		return nil, err
+	}
+	hash := crypto.DoubleHash(payload)
+	now := time.Now()
+	return &SmartContract{
+		VM:         script.GetVM(),
+		TxSmart:    smartTx,
+		TxData:     txData,
+		TxContract: contract,
+		BlockHeader: &types.BlockHeader{
+			BlockId:      ib.BlockID + 1,
+			Timestamp:    now.Unix(),
+			EcosystemId:  ib.EcosystemID,
+			KeyId:        ib.KeyID,
+			NodePosition: converter.StrToInt64(ib.NodePosition),
+		},
+		PreBlockHeader: &types.BlockHeader{
+			BlockId:   ib.BlockID,
+			BlockHash: ib.Hash,
+		},
+		Hash:          hash,
+		Payload:       payload,
+		Timestamp:     now.UnixMilli(),
+		TxSize:        int64(len(payload)),
+		DbTransaction: dbTx,
+		Rand:          random.NewRand(now.Unix()).BytesSeed(hash),
+		Notifications: notificator.NewQueue(),
+		GenBlock:      true,
+		TimeLimit:     syspar.GetMaxBlockGenerationTime(),
+		Rollback:      true,
+		Estimate:      true,
+		Key:           &sqldb.Key{},
+		RollBackTx:    make([]*types.RollbackTx, 0),
+		OutputsMap:    make(map[sqldb.KeyUTXO][]sqldb.SpentInfo),
+		TxInputsMap:   make(map[sqldb.KeyUTXO][]sqldb.SpentInfo),
+		TxOutputsMap:  make(map[sqldb.KeyUTXO][]sqldb.SpentInfo),
+	}, nil
+}
+
+// flushVM reverts the changes of VM made by the contract which is not going to be committed
+func (sc *SmartContract) flushVM() {
+	for i := len(sc.FlushRollback) - 1; i >= 0; i-- {
+		sc.FlushRollback[i].FlushVM()
+	}
+}
+
+func (sc *SmartContract) estimateFee() (*FeeEstimate, error) {
+	if err := sc.loadKey(sc.TxSmart.KeyID); err != nil {
+		return nil, err
+	}
+	est := &FeeEstimate{Contract: sc.TxContract.Name, Payments: make([]*PaymentEstimate, 0)}
+	needPayment := sc.needPayment()
+	if needPayment {
+		if err := sc.prepareMultiPay(); err != nil {
+			est.Error = err.Error()
+			return est, nil
+		}
+	}
+
+	sc.TxContract.Extend = sc.getExtend()
+	if err := sc.AppendStack(sc.TxContract.Name); err != nil {
+		return nil, err
+	}
+	result, err := sc.runContract()
+	if err != nil {
+		est.Error = vmError(err).Error()
+		sc.GetLogger().WithFields(log.Fields{"type": consts.ContractError, "error": err}).Debug("estimating fee")
+	}
+	est.Result = result
+	est.Fuel = sc.TxFuel
+	est.MaxSum = converter.Int64ToStr(sc.TxFuel)
+	if !needPayment {
+		return est, nil
+	}
+
+	sc.Penalty = err != nil
+	for _, pay := range sc.multiPays {
+		pay.Penalty = sc.Penalty
+		sc.setVMCost(pay)
+		est.Payments = append(est.Payments, pay.estimate())
+	}
+	return est, nil
+}
+
+func (pay *PaymentInfo) estimate() *PaymentEstimate {
+	money := pay.GetPayMoney()
+	p := &PaymentEstimate{
+		TokenEcosystem: pay.TokenEco,
+		TokenSymbol:    pay.Ecosystem.TokenSymbol,
+		From:           converter.AddressToString(pay.FromID),
+		PaymentType:    pay.PaymentType.String(),
+		Fees:           make(map[string]string, len(pay.FuelCategories)),
+		Combustion:     decimal.Zero.String(),
+		Taxes:          decimal.Zero.String(),
+		Total:          money.String(),
+		Balance:        pay.PayWallet.CapableAmount().String(),
+		Detail:         pay.Detail(),
+	}
+	for _, f := range pay.FuelCategories {
+		p.Fees[f.FuelType.String()] = f.Fees().String()
+	}
+	if pay.Indirect {
+		return p
+	}
+	if pay.Combustion.Flag == 2 && pay.TokenEco != consts.DefaultTokenEcosystem {
+		combustion := pay.Combustion.Fees(money)
+		p.Combustion = combustion.String()
+		p.DetailCombustion = pay.DetailCombustion()
+		money = money.Sub(combustion)
+	}
+	p.Taxes = money.Mul(decimal.NewFromInt(pay.TaxesSize)).Div(decimal.New(100, 0)).Floor().String()
+	return p
+}
+
+// jsonTxParams converts JSON values of params to the types the signed tx has for the contract fields
+func jsonTxParams(fieldInfos []*script.FieldInfo, params map[string]any) map[string]any {
+	ret := make(map[string]any, len(params))
+	for k, v := range params {
+		ret[k] = v
+	}
+	for _, fitem := range fieldInfos {
+		v, ok := ret[fitem.Name]
+		if !ok {
+			continue
+		}
+		switch fitem.Original {
+		case script.DtInt:
+			switch val := v.(type) {
+			case float64:
+				ret[fitem.Name] = int64(val)
+			case string:
+				ret[fitem.Name] = converter.StrToInt64(val)
+			}
+		case script.DtAddress:
+			switch val := v.(type) {
+			case float64:
+				ret[fitem.Name] = int64(val)
+			case string:
+				ret[fitem.Name] = converter.AddressToID(val)
+			}
+		case script.DtMoney:
+			if val, ok := v.(float64); ok {
+				ret[fitem.Name] = decimal.NewFromFloat(val).String()
+			}
+		}
+	}
+	return ret
+}
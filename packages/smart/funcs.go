@@ -83,6 +83,9 @@ type TxInfo struct {
 	CreatedAt    int64          `json:"created_at"`
 	Size         string         `json:"size"`
 	Status       int64          `json:"status"` //0:success 1:penalty
+	PeakMemory   int64          `json:"peak_memory"`
+	PeakDepth    int64          `json:"peak_depth"`
+	Instructions int64          `json:"instructions"`
 }
 
 type TableInfo struct {
@@ -91,10 +94,11 @@ type TableInfo struct {
 }
 
 type FlushInfo struct {
-	ID   uint32            // id
-	Prev *script.CodeBlock // previous item, nil if the new item has been appended
-	Info *script.ObjInfo
-	Name string // the name
+	ID    uint32            // id
+	Prev  *script.CodeBlock // previous item, nil if the new item has been appended
+	Info  *script.ObjInfo
+	Name  string                                // the name
+	Funcs map[*script.ObjInfo]*script.CodeBlock // previous code of the functions of the library
 }
 
 func (finfo *FlushInfo) FlushVM() {
@@ -108,6 +112,9 @@ func (finfo *FlushInfo) FlushVM() {
 	} else {
 		script.GetVM().Children[finfo.ID] = finfo.Prev
 		script.GetVM().Objects[finfo.Name] = finfo.Info
+		for obj, block := range finfo.Funcs {
+			obj.Value = block
+		}
 	}
 }
 
@@ -125,6 +132,7 @@ var (
 		"DBUpdatePlatformParam": {},
 		"DBUpdateExt":           {},
 		"DBSelect":              {},
+		"LintContract":          {},
 	}
 	writeFuncs = map[string]struct{}{
 		"CreateColumn":          {},
@@ -136,6 +144,7 @@ var (
 		"CreateEcosystem":       {},
 		"CreateContract":        {},
 		"UpdateContract":        {},
+		"RollbackContract":      {},
 		"CreateLanguage":        {},
 		"EditLanguage":          {},
 		"BindWallet":            {},
@@ -147,6 +156,58 @@ var (
 		"DeleteCLB":             {},
 		"DelColumn":             {},
 		"DelTable":              {},
+		"EmitEvent":             {},
+	}
+	// readOnlyDeniedFuncs are the functions changing the state which can't be used in read-only calls
+	readOnlyDeniedFuncs = map[string]struct{}{
+		"CreateColumn":             {},
+		"CreateTable":              {},
+		"CreateView":               {},
+		"DBInsert":                 {},
+		"DBUpdate":                 {},
+		"DBUpdatePlatformParam":    {},
+		"DBUpdateExt":              {},
+		"CreateEcosystem":          {},
+		"CreateContract":           {},
+		"UpdateContract":           {},
+		"RollbackContract":         {},
+		"CreateLanguage":           {},
+		"EditLanguage":             {},
+		"BndWallet":                {},
+		"UnbndWallet":              {},
+		"EditEcosysName":           {},
+		"UpdateNodesBan":           {},
+		"UpdateNotifications":      {},
+		"UpdateRolesNotifications": {},
+		"UpdateCron":               {},
+		"CreateCLB":                {},
+		"DeleteCLB":                {},
+		"StartCLB":                 {},
+		"StopCLBProcess":           {},
+		"DelColumn":                {},
+		"DelTable":                 {},
+		"PermColumn":               {},
+		"PermTable":                {},
+		"TableConditions":          {},
+		"EmitEvent":                {},
+		"HTTPRequest":              {},
+		"HTTPPostJSON":             {},
+	}
+	// estimateDeniedFuncs are the functions changing VM which can't be used in the fee estimation,
+	// the changes of the shared VM would be visible to the other transactions until the estimation ends
+	estimateDeniedFuncs = map[string]struct{}{
+		"CreateContract":   {},
+		"UpdateContract":   {},
+		"RollbackContract": {},
+		"BndWallet":        {},
+		"UnbndWallet":      {},
+	}
+	// migrateDeniedFuncs are the functions changing the contracts which can't be used in the migrate section,
+	// the section must not change the other contracts bypassing their conditions
+	migrateDeniedFuncs = map[string]struct{}{
+		"CreateContract":   {},
+		"UpdateContract":   {},
+		"RollbackContract": {},
 	}
 	// map for table name to parameter with conditions
 	tableParamConditions = map[string]string{
@@ -208,6 +269,7 @@ func EmbedFuncs(vt script.VMType) map[string]any {
 		"IdToAddress":                  converter.IDToAddress,
 		"Int":                          Int,
 		"Len":                          Len,
+		"LintContract":                 LintContract,
 		"Money":                        Money,
 		"FormatMoney":                  FormatMoney,
 		"PermColumn":                   PermColumn,
@@ -233,6 +295,7 @@ func EmbedFuncs(vt script.VMType) map[string]any {
 		"CreateEcosystem":              CreateEcosystem,
 		"CreateContract":               CreateContract,
 		"UpdateContract":               UpdateContract,
+		"RollbackContract":             RollbackContract,
 		"TableConditions":              TableConditions,
 		"CreateLanguage":               CreateLanguage,
 		"EditLanguage":                 EditLanguage,
@@ -275,6 +338,7 @@ func EmbedFuncs(vt script.VMType) map[string]any {
 		"UpdateRolesNotifications":     UpdateRolesNotifications,
 		"DelTable":                     DelTable,
 		"DelColumn":                    DelColumn,
+		"EmitEvent":                    EmitEvent,
 		"HexToPub":                     crypto.HexToPub,
 		"PubToHex":                     PubToHex,
 		"UpdateNodesBan":               UpdateNodesBan,
@@ -476,6 +540,13 @@ func UpdateContract(sc *SmartContract, id int64, value, conditions string, recip
 	if err := validateAccess(sc, "UpdateContract"); err != nil {
 		return err
 	}
+	return updateContract(sc, id, value, conditions, recipient, tokenID, 0)
+}
+
+// updateContract changes the source and the conditions of the contract and saves its new version.
+// restored is the version whose source is restored by RollbackContract, the migrate section
+// of the contract is run only when the contract is upgraded
+func updateContract(sc *SmartContract, id int64, value, conditions string, recipient int64, tokenID string, restored int64) error {
 	pars := make(map[string]any)
 	ecosystemID := sc.TxSmart.EcosystemID
 	var root any
@@ -491,11 +562,16 @@ func UpdateContract(sc *SmartContract, id int64, value, conditions string, recip
 		pars["conditions"] = conditions
 	}
 
+	var version int64
 	if len(pars) > 0 {
 		if !sc.CLB {
 			if err := SysRollback(sc, SysRollData{Type: "EditContract", ID: id}); err != nil {
 				return err
 			}
+			var err error
+			if version, err = lastContractVersion(sc, id); err != nil {
+				return err
+			}
 		}
 		if _, err := DBUpdate(sc, "@1contracts", id, types.LoadMap(pars)); err != nil {
 			return err
@@ -506,6 +582,14 @@ func UpdateContract(sc *SmartContract, id int64, value, conditions string, recip
 			return err
 		}
 	}
+	if len(pars) > 0 && !sc.CLB {
+		if err := addContractVersion(sc, id, version+1, restored); err != nil {
+			return err
+		}
+	}
+	if len(value) > 0 && restored == 0 {
+		return runMigrate(sc, root)
+	}
 	return nil
 }
 
@@ -545,6 +629,9 @@ func CreateContract(sc *SmartContract, name, value, conditions string, tokenEcos
 		if err != nil {
 			return 0, err
 		}
+		if err = addContractVersion(sc, id, 1, 0); err != nil {
+			return 0, err
+		}
 	}
 	return id, nil
 }
@@ -1138,29 +1225,45 @@ func FlushContract(sc *SmartContract, iroot any, id int64) error {
 	}
 	root := iroot.(*script.CodeBlock)
 	if id != 0 {
-		if len(root.Children) != 1 || root.Children[0].Type != script.ObjectType_Contract {
+		if len(root.Children) != 1 || (root.Children[0].Type != script.ObjectType_Contract &&
+			root.Children[0].Type != script.ObjectType_Library) {
 			return errOneContract
 		}
 	}
 	for i, item := range root.Children {
-		if item.Type == script.ObjectType_Contract {
+		switch item.Type {
+		case script.ObjectType_Contract:
 			root.Children[i].GetContractInfo().Owner.TableID = id
+		case script.ObjectType_Library:
+			root.Children[i].GetLibraryInfo().Owner.TableID = id
 		}
 	}
 	for key, item := range root.Objects {
 		if cur, ok := sc.VM.Objects[key]; ok {
-			var id uint32
+			var (
+				id    uint32
+				funcs map[*script.ObjInfo]*script.CodeBlock
+			)
 			switch item.Type {
 			case script.ObjectType_Contract:
 				id = cur.GetCodeBlock().GetContractInfo().ID
 			case script.ObjectType_Func:
 				id = cur.GetCodeBlock().GetFuncInfo().ID
+			case script.ObjectType_Library:
+				id = cur.GetCodeBlock().GetLibraryInfo().ID
+				funcs = make(map[*script.ObjInfo]*script.CodeBlock)
+				for _, obj := range cur.GetCodeBlock().Objects {
+					if obj.Type == script.ObjectType_Func {
+						funcs[obj] = obj.GetCodeBlock()
+					}
+				}
 			}
 			sc.FlushRollback = append(sc.FlushRollback, &FlushInfo{
-				ID:   id,
-				Prev: sc.VM.Children[id],
-				Info: cur,
-				Name: key,
+				ID:    id,
+				Prev:  sc.VM.Children[id],
+				Info:  cur,
+				Name:  key,
+				Funcs: funcs,
 			})
 		} else {
 			sc.FlushRollback = append(sc.FlushRollback, &FlushInfo{
@@ -2394,6 +2497,48 @@ func DelTable(sc *SmartContract, tableName string) (err error) {
 	return sc.DbTransaction.DropTable(tblname)
 }
 
+// EmitEvent records the event of the contract in the current transaction
+func EmitEvent(sc *SmartContract, name string, data *types.Map) error {
+	if sc.CLB {
+		return ErrNotImplementedOnCLB
+	}
+	if len(name) == 0 {
+		return errEmptyEventName
+	}
+	if len(name) > 255 || !converter.IsLatin(name) {
+		return fmt.Errorf(eLatin, name)
+	}
+	out := `{}`
+	if data != nil {
+		var err error
+		if out, err = JSONEncode(data); err != nil {
+			return err
+		}
+	}
+	// the event belongs to the contract on the top of the stack which can be called by the tx contract
+	contract := sc.TxContract.Name
+	if stack := sc.TxContract.StackCont; len(stack) > 0 {
+		if c := VMGetContract(sc.VM, stack[len(stack)-1].(string), uint32(sc.TxSmart.EcosystemID)); c != nil {
+			contract = c.Name
+		}
+	}
+	ev := &sqldb.ContractEvent{
+		TxHash:       sc.Hash,
+		LogIndex:     sc.EventIndex,
+		BlockID:      sc.BlockHeader.BlockId,
+		EcosystemID:  sc.TxSmart.EcosystemID,
+		ContractName: contract,
+		Name:         name,
+		Data:         out,
+		Timestamp:    sc.BlockHeader.Timestamp,
+	}
+	if err := ev.Create(sc.DbTransaction); err != nil {
+		return logErrorDB(err, "inserting contract event")
+	}
+	sc.EventIndex++
+	return SysRollback(sc, SysRollData{Type: "EmitEvent", ID: ev.LogIndex, TableName: ev.TableName()})
+}
+
 func FormatMoney(sc *SmartContract, exp string, digit int64) (string, error) {
 	var cents int64
 	if digit != 0 {
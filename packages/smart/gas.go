@@ -281,7 +281,7 @@ func (sc *SmartContract) payContract(errNeedPay bool) error {
 	for i := 0; i < len(sc.multiPays); i++ {
 		pay := sc.multiPays[i]
 		pay.Penalty = sc.Penalty
-		pay.SetDecimalByType(FuelType_vmCost_fee, sc.TxUsedCost.Mul(pay.FuelRate).Mul(decimal.New(1, int32(pay.Ecosystem.Digits-sc.multiPays[0].Ecosystem.Digits))))
+		sc.setVMCost(pay)
 		money := pay.GetPayMoney()
 		wltAmount := pay.PayWallet.CapableAmount()
 		if wltAmount.Cmp(money) < 0 {
@@ -314,6 +314,11 @@ func (sc *SmartContract) payContract(errNeedPay bool) error {
 	return nil
 }
 
+// setVMCost converts the used fuel to the vm cost fee in the token of pay
+func (sc *SmartContract) setVMCost(pay *PaymentInfo) {
+	pay.SetDecimalByType(FuelType_vmCost_fee, sc.TxUsedCost.Mul(pay.FuelRate).Mul(decimal.New(1, int32(pay.Ecosystem.Digits-sc.multiPays[0].Ecosystem.Digits))))
+}
+
 func (sc *SmartContract) accountBalanceSingle(eco, id int64) (decimal.Decimal, error) {
 	key := &sqldb.Key{}
 	_, err := key.SetTablePrefix(eco).Get(sc.DbTransaction, id)
@@ -0,0 +1,59 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package smart
+
+import (
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/types"
+)
+
+// LintOptions returns the options of the static analysis which resolve the contracts in VM
+// and the ecosystem parameters in the database
+func LintOptions(vm *script.VM, ecosystem int64, dbTx *sqldb.DbTransaction) *script.LintOptions {
+	return &script.LintOptions{
+		VM:    vm,
+		Owner: &script.OwnerInfo{StateID: uint32(ecosystem)},
+		ContractExists: func(name string) bool {
+			return VMGetContract(vm, name, uint32(ecosystem)) != nil
+		},
+		EcosysParamExists: func(name string) bool {
+			sp := &sqldb.StateParameter{}
+			sp.SetTablePrefix(converter.Int64ToStr(ecosystem))
+			found, err := sp.Get(dbTx, name)
+			if err != nil {
+				logErrorDB(err, "getting ecosystem param")
+			}
+			return found
+		},
+	}
+}
+
+// lintCostSize is the size of the source which is charged with price_exec_lint_contract
+const lintCostSize = 1024
+
+// LintContract returns the issues found by the static analysis of the contract source.
+// Every issue is the map with rule, severity, message, line and column keys. The price of the
+// function is charged for the call and for every lintCostSize bytes of the source
+func LintContract(sc *SmartContract, value string) (int64, []any) {
+	var cost int64
+	if price := getCost("LintContract"); price > 0 {
+		cost = price * int64(len(value)/lintCostSize)
+	}
+	issues := script.Lint(value, LintOptions(sc.VM, sc.TxSmart.EcosystemID, sc.DbTransaction))
+	ret := make([]any, 0, len(issues))
+	for _, issue := range issues {
+		ret = append(ret, types.LoadMap(map[string]any{
+			"rule":     issue.Rule,
+			"severity": issue.Severity,
+			"message":  issue.Message,
+			"line":     int64(issue.Line),
+			"column":   int64(issue.Column),
+		}))
+	}
+	return cost, ret
+}
@@ -0,0 +1,109 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+package smart
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeMethods are the methods of SmartContract writing the tables
+var writeMethods = []string{"sc.insert", "sc.update", "sc.updateWhere", "sc.selectiveLoggingAndUpd"}
+
+// packageCalls returns the functions and the methods of SmartContract of the package with the functions they call.
+// The methods are named with sc. prefix
+func packageCalls(t *testing.T) (map[string]map[string]bool, *ast.CompositeLit) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+	var (
+		calls  = make(map[string]map[string]bool)
+		embeds *ast.CompositeLit
+	)
+	for _, f := range pkgs["smart"].Files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			name := fn.Name.Name
+			if fn.Recv != nil {
+				name = "sc." + name
+			}
+			called := make(map[string]bool)
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.CallExpr:
+					switch fun := n.Fun.(type) {
+					case *ast.Ident:
+						called[fun.Name] = true
+					case *ast.SelectorExpr:
+						if x, ok := fun.X.(*ast.Ident); ok && x.Name == "sc" {
+							called["sc."+fun.Sel.Name] = true
+						}
+					}
+				case *ast.CompositeLit:
+					if fn.Name.Name == "EmbedFuncs" && embeds == nil {
+						embeds = n
+					}
+				}
+				return true
+			})
+			calls[name] = called
+		}
+	}
+	require.NotNil(t, embeds)
+	return calls, embeds
+}
+
+func TestReadOnlyDeniedFuncs(t *testing.T) {
+	calls, embeds := packageCalls(t)
+
+	writers := make(map[string]bool)
+	for _, m := range writeMethods {
+		writers[m] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, called := range calls {
+			if writers[name] {
+				continue
+			}
+			for c := range called {
+				if writers[c] {
+					writers[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var checked int
+	for _, elt := range embeds.Elts {
+		kv := elt.(*ast.KeyValueExpr)
+		key, err := strconv.Unquote(kv.Key.(*ast.BasicLit).Value)
+		require.NoError(t, err)
+		ident, ok := kv.Value.(*ast.Ident)
+		if !ok || !writers[ident.Name] {
+			continue
+		}
+		checked++
+		t.Run(key, func(t *testing.T) {
+			_, ok := readOnlyDeniedFuncs[key]
+			require.True(t, ok, "%s writes the tables and must be denied in read-only calls", key)
+		})
+	}
+	require.NotZero(t, checked)
+}
@@ -55,6 +55,8 @@ type SmartContract struct {
 	CLB             bool
 	Rollback        bool
 	FullAccess      bool
+	ReadOnly        bool // the contract is run by a call which must not change the state
+	Estimate        bool // the contract is run by the fee estimation against the shared VM
 	SysUpdate       bool
 	VM              *script.VM
 	TxSmart         *types.SmartTransaction
@@ -91,10 +93,40 @@ type SmartContract struct {
 	TxOutputsMap    map[sqldb.KeyUTXO][]sqldb.SpentInfo
 	PrevSysPar      map[string]string
 	EcoParams       []sqldb.EcoParam
+	EventIndex      int64 // the index of the next event emitted by the transaction
+	savepoints      int64 // the count of the savepoints of try blocks
+	migrate         int   // the depth of the running migrate sections
+	Debug           *script.Debugger
+	Stats           script.Stats // the statistics of the execution in VM
+}
+
+// Debugger returns the debugger of the contract execution, it is nil if the contract is not debugged
+func (sc *SmartContract) Debugger() *script.Debugger {
+	return sc.Debug
+}
+
+// VMStats returns the statistics of the execution in VM which are collected by all runs of the transaction
+func (sc *SmartContract) VMStats() *script.Stats {
+	return &sc.Stats
 }
 
 // AppendStack adds an element to the stack of contract call or removes the top element when name is empty
 func (sc *SmartContract) AppendStack(fn string) error {
+	if sc.ReadOnly {
+		if _, ok := readOnlyDeniedFuncs[fn]; ok {
+			return fmt.Errorf(eReadOnlyCall, fn)
+		}
+	}
+	if sc.Estimate {
+		if _, ok := estimateDeniedFuncs[fn]; ok {
+			return fmt.Errorf(eEstimateCall, fn)
+		}
+	}
+	if sc.migrate > 0 {
+		if _, ok := migrateDeniedFuncs[fn]; ok {
+			return fmt.Errorf(eMigrateCall, fn)
+		}
+	}
 	if sc.isAllowStack(fn) {
 		cont := sc.TxContract
 		for _, item := range cont.StackCont {
@@ -118,12 +150,110 @@ func (sc *SmartContract) PopStack(fn string) {
 	}
 }
 
+// StartMigrate marks the start of the migrate section
+func (sc *SmartContract) StartMigrate() {
+	sc.migrate++
+}
+
+// EndMigrate marks the end of the migrate section
+func (sc *SmartContract) EndMigrate() {
+	sc.migrate--
+}
+
 func (sc *SmartContract) isAllowStack(fn string) bool {
 	// Stack contains only contracts
 	c := VMGetContract(sc.VM, fn, uint32(sc.TxSmart.EcosystemID))
 	return c != nil
 }
 
+// trySavepoint is the state of the transaction before the try block
+type trySavepoint struct {
+	mark          string
+	rollbackTx    int
+	binLogSql     int
+	flushRollback int
+	eventIndex    int64
+	notifications any
+	txInputsMap   map[sqldb.KeyUTXO][]sqldb.SpentInfo
+	txOutputsMap  map[sqldb.KeyUTXO][]sqldb.SpentInfo
+}
+
+// copyOutputsMap returns the copy of the outputs which is not changed by the appending to outputsMap
+func copyOutputsMap(outputsMap map[sqldb.KeyUTXO][]sqldb.SpentInfo) map[sqldb.KeyUTXO][]sqldb.SpentInfo {
+	if outputsMap == nil {
+		return nil
+	}
+	out := make(map[sqldb.KeyUTXO][]sqldb.SpentInfo, len(outputsMap))
+	for k, v := range outputsMap {
+		out[k] = v[:len(v):len(v)]
+	}
+	return out
+}
+
+// Savepoint creates the savepoint before the try block of the contract
+func (sc *SmartContract) Savepoint() (any, error) {
+	sc.savepoints++
+	point := &trySavepoint{
+		mark:          consts.SetSavePointMarkTry(sc.savepoints),
+		rollbackTx:    len(sc.RollBackTx),
+		flushRollback: len(sc.FlushRollback),
+		eventIndex:    sc.EventIndex,
+		txInputsMap:   copyOutputsMap(sc.TxInputsMap),
+		txOutputsMap:  copyOutputsMap(sc.TxOutputsMap),
+	}
+	if sc.Notifications != nil {
+		point.notifications = sc.Notifications.Mark()
+	}
+	if sc.DbTransaction != nil {
+		point.binLogSql = len(sc.DbTransaction.BinLogSql)
+		if err := sc.DbTransaction.Savepoint(point.mark); err != nil {
+			sc.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating try savepoint")
+			return nil, err
+		}
+	}
+	return point, nil
+}
+
+// RollbackSavepoint rolls back the changes of the failed try block
+func (sc *SmartContract) RollbackSavepoint(point any) error {
+	sp := point.(*trySavepoint)
+	if sc.DbTransaction != nil {
+		if err := sc.DbTransaction.RollbackSavepoint(sp.mark); err != nil {
+			sc.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("rollback try savepoint")
+			return err
+		}
+		if err := sc.DbTransaction.ReleaseSavepoint(sp.mark); err != nil {
+			sc.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("releasing try savepoint")
+			return err
+		}
+		sc.DbTransaction.BinLogSql = sc.DbTransaction.BinLogSql[:sp.binLogSql]
+	}
+	for i := len(sc.FlushRollback) - 1; i >= sp.flushRollback; i-- {
+		sc.FlushRollback[i].FlushVM()
+	}
+	sc.FlushRollback = sc.FlushRollback[:sp.flushRollback]
+	sc.RollBackTx = sc.RollBackTx[:sp.rollbackTx]
+	sc.EventIndex = sp.eventIndex
+	sc.TxInputsMap = sp.txInputsMap
+	sc.TxOutputsMap = sp.txOutputsMap
+	if sc.Notifications != nil {
+		sc.Notifications.Truncate(sp.notifications)
+	}
+	return nil
+}
+
+// ReleaseSavepoint releases the savepoint of the successful try block
+func (sc *SmartContract) ReleaseSavepoint(point any) error {
+	if sc.DbTransaction == nil {
+		return nil
+	}
+	if err := sc.DbTransaction.ReleaseSavepoint(point.(*trySavepoint).mark); err != nil {
+		sc.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("releasing try savepoint")
+		return err
+	}
+	return nil
+}
+
 func InitVM() {
 	script.GetVM().SetExtendCost(getCost)
 	script.GetVM().SetFuncCallsDB(funcCallsDBP)
@@ -554,14 +684,6 @@ func (sc *SmartContract) CallContract(point string) (string, error) {
 	)
 	logger := sc.GetLogger()
 
-	retError := func(err error) (string, error) {
-		eText := err.Error()
-		if !strings.HasPrefix(eText, `{`) && err != script.ErrVMTimeLimit {
-			err = script.SetVMError(`panic`, eText)
-		}
-		return ``, err
-	}
-
 	if err = sc.checkTxSign(); err != nil {
 		return ``, err
 	}
@@ -578,8 +700,50 @@ func (sc *SmartContract) CallContract(point string) (string, error) {
 	sc.TxContract.Extend = sc.getExtend()
 	if err = sc.AppendStack(sc.TxContract.Name); err != nil {
 		logger.WithFields(log.Fields{"type": consts.ContractError, "error": err}).Error("loop in contract")
-		return retError(err)
+		return ``, vmError(err)
+	}
+	result, err = sc.runContract()
+lp:
+	if err != nil {
+		sc.RollBackTx = nil
+		sc.DbTransaction.BinLogSql = nil
+		if errReset := sc.DbTransaction.ResetSavepoint(point); errReset != nil {
+			return ``, vmError(errors.Wrap(err, errReset.Error()))
+		}
+		if needPayment {
+			if errPay := sc.payContract(true); errPay != nil {
+				sc.RollBackTx = nil
+				sc.DbTransaction.BinLogSql = nil
+				if errRollsp := sc.DbTransaction.RollbackSavepoint(point); errRollsp != nil {
+					return ``, vmError(errors.Wrap(err, errRollsp.Error()))
+				}
+				return errors.Wrap(err, errPay.Error()).Error(), nil
+			}
+			return err.Error(), nil
+		}
+		return ``, vmError(err)
+	}
+
+	if needPayment {
+		if errPay := sc.payContract(false); errPay != nil {
+			err = errPay
+			goto lp
+		}
+	}
+	return result, nil
+}
+
+// vmError wraps err as a VM panic unless it is already a VM error
+func vmError(err error) error {
+	eText := err.Error()
+	if !strings.HasPrefix(eText, `{`) && err != script.ErrVMTimeLimit {
+		err = script.SetVMError(`panic`, eText)
 	}
+	return err
+}
+
+// runContract executes conditions and action of the tx contract and sets the used fuel
+func (sc *SmartContract) runContract() (result string, err error) {
 	sc.VM = script.GetVM()
 
 	ctrctExtend := sc.TxContract.Extend
@@ -604,41 +768,14 @@ func (sc *SmartContract) CallContract(point string) (string, error) {
 		if ctrctExtend[script.Extend_result] != nil {
 			result = fmt.Sprint(ctrctExtend[script.Extend_result])
 			if !utf8.ValidString(result) {
-				result, err = retError(errNotValidUTF)
+				result, err = ``, vmError(errNotValidUTF)
 			}
 			if len(result) > 255 {
 				result = result[:255] + `...`
 			}
 		}
 	}
-lp:
-	if err != nil {
-		sc.RollBackTx = nil
-		sc.DbTransaction.BinLogSql = nil
-		if errReset := sc.DbTransaction.ResetSavepoint(point); errReset != nil {
-			return retError(errors.Wrap(err, errReset.Error()))
-		}
-		if needPayment {
-			if errPay := sc.payContract(true); errPay != nil {
-				sc.RollBackTx = nil
-				sc.DbTransaction.BinLogSql = nil
-				if errRollsp := sc.DbTransaction.RollbackSavepoint(point); errRollsp != nil {
-					return retError(errors.Wrap(err, errRollsp.Error()))
-				}
-				return errors.Wrap(err, errPay.Error()).Error(), nil
-			}
-			return err.Error(), nil
-		}
-		return retError(err)
-	}
-
-	if needPayment {
-		if errPay := sc.payContract(false); errPay != nil {
-			err = errPay
-			goto lp
-		}
-	}
-	return result, nil
+	return
 }
 
 func (sc *SmartContract) checkTxSign() error {
@@ -651,20 +788,7 @@ func (sc *SmartContract) checkTxSign() error {
 		return err
 	}
 
-	isFound, err := sc.Key.SetTablePrefix(sc.TxSmart.EcosystemID).Get(sc.DbTransaction, signedBy)
-	if err != nil {
-		sc.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting wallet")
-		return err
-	}
-
-	if !isFound {
-		err = fmt.Errorf(eEcoKeyNotFound, converter.AddressToString(signedBy), sc.TxSmart.EcosystemID)
-		sc.GetLogger().WithFields(log.Fields{"type": consts.ContractError, "error": err}).Error("looking for keyid")
-		return err
-	}
-	if sc.Key.Disable() {
-		err = fmt.Errorf(eEcoKeyDisable, converter.AddressToString(signedBy), sc.TxSmart.EcosystemID)
-		sc.GetLogger().WithFields(log.Fields{"type": consts.ContractError, "error": err}).Error("disable keyid")
+	if err = sc.loadKey(signedBy); err != nil {
 		return err
 	}
 	if len(sc.Key.PublicKey) > 0 {
@@ -689,3 +813,24 @@ func (sc *SmartContract) checkTxSign() error {
 	}
 	return nil
 }
+
+// loadKey reads the wallet of keyID in the tx ecosystem into sc.Key
+func (sc *SmartContract) loadKey(keyID int64) error {
+	isFound, err := sc.Key.SetTablePrefix(sc.TxSmart.EcosystemID).Get(sc.DbTransaction, keyID)
+	if err != nil {
+		sc.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting wallet")
+		return err
+	}
+
+	if !isFound {
+		err = fmt.Errorf(eEcoKeyNotFound, converter.AddressToString(keyID), sc.TxSmart.EcosystemID)
+		sc.GetLogger().WithFields(log.Fields{"type": consts.ContractError, "error": err}).Error("looking for keyid")
+		return err
+	}
+	if sc.Key.Disable() {
+		err = fmt.Errorf(eEcoKeyDisable, converter.AddressToString(keyID), sc.TxSmart.EcosystemID)
+		sc.GetLogger().WithFields(log.Fields{"type": consts.ContractError, "error": err}).Error("disable keyid")
+		return err
+	}
+	return nil
+}
@@ -128,8 +128,15 @@ func UpdatePlatformParam(sc *SmartContract, name, value, conditions string) (int
 			syspar.MaxBlockUserTx,
 			syspar.MaxTxFuel,
 			syspar.MaxBlockFuel,
-			syspar.MaxForsignSize:
+			syspar.MaxForsignSize,
+			syspar.MaxVMMemory,
+			syspar.MaxStringSize,
+			syspar.MaxArraySize:
 			ok = ival > 0
+		case syspar.MaxCallDepth:
+			ok = ival > 0 && ival <= 10000
+		case syspar.VMVersion:
+			ok = ival >= syspar.GetVMVersion()
 		case syspar.FuelRate,
 			syspar.TaxesWallet:
 			if err := unmarshalJSON([]byte(value), &list, `system param`); err != nil {
@@ -5,9 +5,14 @@
 package smart
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/notificator"
 	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+	"github.com/IBAX-io/go-ibax/packages/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -84,3 +89,209 @@ func TestCheckAppend(t *testing.T) {
 	_, err := script.VMRun(script.GetVM(), cfunc, nil, map[string]any{}, nil)
 	require.NoError(t, err)
 }
+
+func TestJSONTxParams(t *testing.T) {
+	fields := []*script.FieldInfo{
+		{Name: "Amount", Original: script.DtMoney},
+		{Name: "Count", Original: script.DtInt},
+		{Name: "Recipient", Original: script.DtAddress},
+		{Name: "Flag", Original: script.DtBool},
+		{Name: "Rate", Original: script.DtFloat, Tags: script.TagOptional},
+	}
+	params := jsonTxParams(fields, map[string]any{
+		"Amount":    float64(1000),
+		"Count":     float64(3),
+		"Recipient": "0000-0000-0000-0000-5555",
+		"Flag":      true,
+	})
+	require.Equal(t, "1000", params["Amount"])
+	require.Equal(t, int64(3), params["Count"])
+	require.Equal(t, int64(5555), params["Recipient"])
+
+	txData, err := FillTxData(fields, params)
+	require.NoError(t, err)
+	require.Equal(t, true, txData["Flag"])
+	require.Equal(t, float64(0), txData["Rate"])
+}
+
+func TestCallFunc(t *testing.T) {
+	code := `contract CallFuncTest {
+		func Sum(a int, b money, m map) money {
+			return Money(a) + b + Money(m["c"])
+		}
+		action {
+			DBInsert("keys", {"id": 1})
+		}
+	}`
+	owner := script.OwnerInfo{StateID: 1, TableID: 2}
+	require.NoError(t, script.GetVM().Compile([]rune(code), &owner))
+
+	fn := GetContract("CallFuncTest", 1).GetFunc("Sum")
+	args, err := funcArgs(fn.GetFuncInfo(), []any{float64(1), "2", map[string]any{"c": "3"}})
+	require.NoError(t, err)
+	ret, err := script.VMRun(script.GetVM(), fn, args, map[string]any{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "6", fmt.Sprint(ret[0]))
+
+	_, err = funcArgs(fn.GetFuncInfo(), []any{"a", "2", nil})
+	require.Error(t, err)
+
+	sc := &SmartContract{ReadOnly: true}
+	require.EqualError(t, sc.AppendStack("DBInsert"), "DBInsert cannot be called in read-only mode")
+}
+
+func TestEmitEvent(t *testing.T) {
+	code := `contract EmitEventTest {
+		action {
+			EmitEvent("Transfer", {"amount": 1})
+		}
+	}`
+	owner := script.OwnerInfo{StateID: 1, TableID: 3}
+	require.NoError(t, script.GetVM().Compile([]rune(code), &owner))
+
+	sc := &SmartContract{}
+	require.Equal(t, errEmptyEventName, EmitEvent(sc, "", nil))
+	require.EqualError(t, EmitEvent(sc, "bad name", nil), fmt.Sprintf(eLatin, "bad name"))
+
+	sc.ReadOnly = true
+	require.EqualError(t, sc.AppendStack("EmitEvent"), "EmitEvent cannot be called in read-only mode")
+}
+
+func TestEstimateDeniedFuncs(t *testing.T) {
+	sc := &SmartContract{Estimate: true}
+	for name := range estimateDeniedFuncs {
+		require.EqualError(t, sc.AppendStack(name), name+" cannot be called in fee estimation")
+	}
+}
+
+func TestTrySavepointUTXO(t *testing.T) {
+	key := sqldb.KeyUTXO{Ecosystem: 1, KeyId: 10}
+	// utxoTransfer changes the maps and the notifications as the UTXO builtins do and fails after it
+	utxoTransfer := func(sc *SmartContract, value string) error {
+		sqldb.PutAllOutputsMap([]sqldb.SpentInfo{{OutputKeyId: key.KeyId, OutputValue: value, Ecosystem: key.Ecosystem}}, sc.TxInputsMap)
+		sqldb.PutAllOutputsMap([]sqldb.SpentInfo{{OutputKeyId: 20, OutputValue: value, Ecosystem: key.Ecosystem}}, sc.TxOutputsMap)
+		sc.Notifications.AddAccounts(key.Ecosystem, "10")
+		if value == "0" {
+			return fmt.Errorf("wrong value %s", value)
+		}
+		return nil
+	}
+	vm := script.NewVM()
+	vm.Extend(&script.ExtendData{
+		Objects:  map[string]any{"UTXOTransfer": utxoTransfer},
+		AutoPars: map[string]string{`*smart.SmartContract`: `sc`},
+	})
+	require.NoError(t, vm.Compile([]rune(`func try_utxo string {
+			UTXOTransfer("5")
+			try {
+				UTXOTransfer("0")
+			} catch err {
+				return err["message"]
+			}
+			return "OK"
+		}`), &script.OwnerInfo{StateID: 1, Active: true, TableID: 1}))
+
+	sc := &SmartContract{
+		VM:            vm,
+		TxSmart:       &types.SmartTransaction{Header: &types.Header{EcosystemID: 1}},
+		Notifications: notificator.NewQueue(),
+		TxInputsMap:   make(map[sqldb.KeyUTXO][]sqldb.SpentInfo),
+		TxOutputsMap:  make(map[sqldb.KeyUTXO][]sqldb.SpentInfo),
+	}
+	out, err := vm.Call(`try_utxo`, nil, map[string]any{`rt_state`: uint32(1), `sc`: sc})
+	require.NoError(t, err)
+	require.Equal(t, []any{`wrong value 0 [UTXOTransfer :4]`}, out)
+
+	require.Len(t, sc.TxInputsMap[key], 1)
+	require.Equal(t, "5", sc.TxInputsMap[key][0].OutputValue)
+	outKey := sqldb.KeyUTXO{Ecosystem: 1, KeyId: 20}
+	require.Len(t, sc.TxOutputsMap[outKey], 1)
+	require.Equal(t, "5", sc.TxOutputsMap[outKey][0].OutputValue)
+	require.Equal(t, 1, sc.Notifications.Size())
+}
+
+func TestMigrateDeniedFuncs(t *testing.T) {
+	var (
+		updated []string
+		stacks  []string
+	)
+	updateContract := func(sc *SmartContract, name string) error {
+		updated = append(updated, name)
+		return nil
+	}
+	topStack := func(sc *SmartContract) {
+		stack := sc.TxContract.StackCont
+		stacks = append(stacks, stack[len(stack)-1].(string))
+	}
+	vm := script.NewVM()
+	vm.Extend(&script.ExtendData{
+		Objects: map[string]any{
+			"RunMigrate":     script.RunMigrate,
+			"UpdateContract": updateContract,
+			"TopStack":       topStack,
+		},
+		AutoPars: map[string]string{`*smart.SmartContract`: `sc`, `*script.RunTime`: `rt`},
+	})
+	require.NoError(t, vm.Compile([]rune(`contract EditContract {
+			action {
+			}
+		}
+		contract Foreign {
+			conditions {
+				error "foreign conditions"
+			}
+			action {
+			}
+		}
+		contract Upgrade {
+			migrate {
+				TopStack()
+				UpdateContract("@1Foreign")
+			}
+			action {
+			}
+		}
+		func edit(name string) {
+			RunMigrate(name)
+			UpdateContract("@1Foreign")
+		}`), &script.OwnerInfo{StateID: 1, Active: true, TableID: 1}))
+
+	sc := &SmartContract{
+		VM:         vm,
+		TxSmart:    &types.SmartTransaction{Header: &types.Header{EcosystemID: 1}},
+		TxContract: &Contract{Name: "@1EditContract", StackCont: []any{"@1EditContract"}, Extend: make(map[string]any)},
+	}
+	extend := map[string]any{`rt_state`: uint32(1), `sc`: sc, `txcost`: int64(100000), `this_contract`: `EditContract`}
+	_, err := vm.Call(`edit`, []any{`@1Upgrade`}, extend)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "UpdateContract cannot be called in migrate section")
+	// the migrated contract is on the top of the stack in the migrate section
+	require.Equal(t, []string{"@1Upgrade"}, stacks)
+	require.Empty(t, updated)
+	require.Equal(t, []any{"@1EditContract"}, sc.TxContract.StackCont)
+	require.Zero(t, sc.migrate)
+
+	// the caller of the migrate section is not restricted
+	_, err = vm.Call(`edit`, []any{`@1Foreign`}, extend)
+	require.NoError(t, err)
+	require.Equal(t, []string{"@1Foreign"}, updated)
+}
+
+func TestReservedContractID(t *testing.T) {
+	vm := script.GetVM()
+	shift := vm.ShiftContract
+	vm.ShiftContract = 0
+	defer func() { vm.ShiftContract = shift }()
+
+	require.NoError(t, loadContractList([]sqldb.Contract{
+		{ID: 0, Value: `contract ReservedIDTest { action { } }`, EcosystemID: 1},
+		{ID: 1, Value: `contract FirstIDTest { action { } }`, EcosystemID: 1},
+		{ID: 2, Value: `contract SecondIDTest { action { } }`, EcosystemID: 1},
+	}))
+	for i, name := range []string{"@1ReservedIDTest", "@1FirstIDTest", "@1SecondIDTest"} {
+		contract := GetContractByID(int32(consts.ShiftContractID + i))
+		require.NotNil(t, contract)
+		require.Equal(t, name, contract.Name)
+		require.Equal(t, int64(i), contract.Info().Owner.TableID)
+	}
+}
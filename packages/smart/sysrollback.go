@@ -84,6 +84,15 @@ func SysRollbackContract(name string, EcosystemID int64) error {
 		}
 		vm.Children = vm.Children[:id]
 		delete(vm.Objects, c.Name)
+	} else if lib := VMGetLibrary(vm, name, uint32(EcosystemID)); lib != nil {
+		id := lib.ID
+		if int(id) != len(vm.Children)-1 {
+			err := fmt.Errorf(eRollbackContract, id, len(vm.Children)-1)
+			log.WithFields(log.Fields{"type": consts.VMError, "error": err}).Error("rollback library")
+			return err
+		}
+		vm.Children = vm.Children[:id]
+		delete(vm.Objects, lib.Name)
 	}
 
 	return nil
@@ -106,14 +115,18 @@ func SysRollbackNewContract(sysData SysRollData, EcosystemID string) error {
 func SysFlushContract(iroot any, id int64, active bool) error {
 	root := iroot.(*script.CodeBlock)
 	if id != 0 {
-		if len(root.Children) != 1 || root.Children[0].Type != script.ObjectType_Contract {
+		if len(root.Children) != 1 || (root.Children[0].Type != script.ObjectType_Contract &&
+			root.Children[0].Type != script.ObjectType_Library) {
 			return fmt.Errorf(`only one contract must be in the record`)
 		}
 	}
 	for i, item := range root.Children {
-		if item.Type == script.ObjectType_Contract {
+		switch item.Type {
+		case script.ObjectType_Contract:
 			root.Children[i].GetContractInfo().Owner.TableID = id
 			root.Children[i].GetContractInfo().Owner.Active = active
+		case script.ObjectType_Library:
+			root.Children[i].GetLibraryInfo().Owner.TableID = id
 		}
 	}
 	script.GetVM().FlushBlock(root)
@@ -273,3 +286,19 @@ func SysRollbackDeleteTable(dbTx *sqldb.DbTransaction, sysData SysRollData) erro
 	}
 	return nil
 }
+
+// SysRollbackEvent is rolling back the event emitted by the transaction
+func SysRollbackEvent(dbTx *sqldb.DbTransaction, txHash []byte, sysData SysRollData) error {
+	if err := sqldb.DeleteContractEvent(dbTx, txHash, sysData.ID); err != nil {
+		return logErrorDB(err, "deleting contract event")
+	}
+	return nil
+}
+
+// SysRollbackContractVersion removes the version of the contract from the history
+func SysRollbackContractVersion(dbTx *sqldb.DbTransaction, sysData SysRollData) error {
+	if err := sqldb.DeleteContractVersion(dbTx, sysData.ID, converter.StrToInt64(sysData.Data)); err != nil {
+		return logErrorDB(err, "deleting contract version")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package smart
+
+import (
+	"fmt"
+
+	"github.com/IBAX-io/go-ibax/packages/converter"
+	"github.com/IBAX-io/go-ibax/packages/script"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+)
+
+// RollbackContract restores the source of the specified version of the contract. The restored
+// source is saved as the new version, the conditions of the contract are not changed
+func RollbackContract(sc *SmartContract, id, version, recipient int64, tokenID string) error {
+	if err := validateAccess(sc, "RollbackContract"); err != nil {
+		return err
+	}
+	if sc.CLB {
+		return ErrNotImplementedOnCLB
+	}
+	ver := &sqldb.ContractVersion{}
+	found, err := ver.Get(sc.DbTransaction, id, version)
+	if err != nil {
+		return logErrorDB(err, "getting contract version")
+	}
+	if !found {
+		return fmt.Errorf(eUnknownVersion, version, id)
+	}
+	cur := &sqldb.Contract{}
+	if found, err = cur.GetByTx(sc.DbTransaction, id); err != nil {
+		return logErrorDB(err, "getting contract")
+	}
+	if !found {
+		return errContractNotFound
+	}
+	if err = ValidateEditContractNewValue(sc, ver.Value, cur.Value); err != nil {
+		return err
+	}
+	return updateContract(sc, id, ver.Value, "", recipient, tokenID, version)
+}
+
+// newContractVersion returns the version of the contract with the current source and conditions
+func newContractVersion(sc *SmartContract, id, version int64) (*sqldb.ContractVersion, error) {
+	c := &sqldb.Contract{}
+	found, err := c.GetByTx(sc.DbTransaction, id)
+	if err != nil {
+		return nil, logErrorDB(err, "getting contract")
+	}
+	if !found {
+		return nil, errContractNotFound
+	}
+	return &sqldb.ContractVersion{
+		ContractID:  id,
+		Version:     version,
+		EcosystemID: c.EcosystemID,
+		Name:        c.Name,
+		Value:       c.Value,
+		Conditions:  c.Conditions,
+		WalletID:    c.WalletID,
+		TokenID:     c.TokenID,
+	}, nil
+}
+
+// createContractVersion saves the version of the contract in the history
+func createContractVersion(sc *SmartContract, ver *sqldb.ContractVersion) error {
+	if err := ver.Create(sc.DbTransaction); err != nil {
+		return logErrorDB(err, "inserting contract version")
+	}
+	return SysRollback(sc, SysRollData{Type: "ContractVersion", ID: ver.ContractID,
+		Data: converter.Int64ToStr(ver.Version)})
+}
+
+// lastContractVersion returns the number of the last version of the contract. The contracts created
+// before the history have no versions, so their current source is saved as the first version
+func lastContractVersion(sc *SmartContract, id int64) (int64, error) {
+	last := &sqldb.ContractVersion{}
+	found, err := last.GetLast(sc.DbTransaction, id)
+	if err != nil {
+		return 0, logErrorDB(err, "getting last contract version")
+	}
+	if found {
+		return last.Version, nil
+	}
+	first, err := newContractVersion(sc, id, 1)
+	if err != nil {
+		return 0, err
+	}
+	return 1, createContractVersion(sc, first)
+}
+
+// addContractVersion saves the current source of the contract as the version which is active
+// from the current block
+func addContractVersion(sc *SmartContract, id, version, restored int64) error {
+	ver, err := newContractVersion(sc, id, version)
+	if err != nil {
+		return err
+	}
+	ver.BlockID = sc.BlockHeader.BlockId
+	ver.TxHash = sc.Hash
+	ver.Timestamp = sc.BlockHeader.Timestamp
+	ver.Restored = restored
+	return createContractVersion(sc, ver)
+}
+
+// runMigrate executes the migrate section of the updated contract
+func runMigrate(sc *SmartContract, root any) error {
+	block := root.(*script.CodeBlock)
+	if len(block.Children) != 1 || block.Children[0].Type != script.ObjectType_Contract || sc.TxContract == nil {
+		return nil
+	}
+	rt, ok := sc.TxContract.Extend[script.Extend_rt].(*script.RunTime)
+	if !ok {
+		return nil
+	}
+	return script.RunMigrate(rt, block.Children[0].GetContractInfo().Name)
+}
@@ -0,0 +1,130 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var errEmptyChain = errors.New("blockchain is empty")
+
+// Create writes the snapshot of the state at the last block to the directory. The state is read in one
+// transaction, so the snapshot is consistent even if the node plays the blocks at the same time
+func Create(ctx context.Context, dir string) (*Manifest, error) {
+	if err := os.MkdirAll(dir, 0775); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "dir": dir}).Error("creating snapshot directory")
+		return nil, err
+	}
+	f, err := os.CreateTemp(dir, `snapshot-*.tmp`)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "dir": dir}).Error("creating snapshot file")
+		return nil, err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	m := &Manifest{Version: Version, Time: time.Now().Unix()}
+	err = withTx(ctx, `BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY`, func(conn *sql.Conn, db *gorm.DB) error {
+		ib := &sqldb.InfoBlock{}
+		if err := db.Last(ib).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+			return err
+		}
+		if ib.BlockID == 0 {
+			return errEmptyChain
+		}
+		bc := &sqldb.BlockChain{}
+		if err := db.Where("id = ?", ib.BlockID).First(bc).Error; err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": ib.BlockID}).Error("getting block")
+			return err
+		}
+		m.BlockID = bc.ID
+		m.BlockHash = hex.EncodeToString(bc.Hash)
+		m.RollbacksHash = hex.EncodeToString(bc.RollbacksHash)
+
+		s, err := dumpSchema(ctx, conn)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("reading db schema")
+			return err
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling db schema")
+			return err
+		}
+		w := newWriter(f)
+		if err = w.record(recSchema, data); err != nil {
+			return err
+		}
+		for _, t := range s.Tables {
+			if localTables[t.Name] {
+				continue
+			}
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			var where string
+			if filter, ok := tableFilters[t.Name]; ok {
+				where = ` WHERE ` + fmt.Sprintf(filter, m.BlockID)
+			}
+			tw, err := w.table(t.Name)
+			if err != nil {
+				return err
+			}
+			rows, err := copyTo(ctx, conn, tw, fmt.Sprintf(`COPY (SELECT * FROM %s%s ORDER BY %s) TO STDOUT`,
+				quote(t.Name), where, strings.Join(t.info.orderBy, `, `)))
+			if err != nil {
+				log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": t.Name}).Error("copying table")
+				return err
+			}
+			hash, err := tw.Close()
+			if err != nil {
+				return err
+			}
+			m.Tables = append(m.Tables, Table{Name: t.Name, Rows: rows, Hash: hash})
+		}
+		return w.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	m.StateHash = hex.EncodeToString(StateHash(m.Tables))
+	hash, size, err := FileHash(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	m.FileHash, m.Size = hex.EncodeToString(hash), size
+
+	path := Path(dir, m.BlockID)
+	if err = os.Rename(f.Name(), path); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": path}).Error("renaming snapshot file")
+		return nil, err
+	}
+	if err = writeManifest(path, m); err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"block_id": m.BlockID, "size": m.Size, "tables": len(m.Tables)}).Info("snapshot created")
+	return m, nil
+}
@@ -0,0 +1,633 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/smart"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var errDriver = errors.New("snapshot requires pgx connection")
+
+// sessionSettings make the text format of COPY the same on all nodes
+var sessionSettings = []string{
+	`SET LOCAL TIME ZONE 'UTC'`,
+	`SET LOCAL DateStyle = 'ISO, MDY'`,
+	`SET LOCAL IntervalStyle = 'postgres'`,
+	`SET LOCAL extra_float_digits = 1`,
+	`SET LOCAL bytea_output = 'hex'`,
+}
+
+// withTx runs f in the transaction on the dedicated connection. db is the gorm session of the connection.
+// The transaction is committed if f returns nil
+func withTx(ctx context.Context, begin string, f func(conn *sql.Conn, db *gorm.DB) error) (err error) {
+	if sqldb.DBConn == nil {
+		return sqldb.ErrDBConn
+	}
+	sqlDB, err := sqldb.DBConn.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting db connection")
+		return err
+	}
+	defer conn.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = conn.ExecContext(ctx, begin); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("beginning snapshot transaction")
+		return err
+	}
+	defer func() {
+		end := `COMMIT`
+		if err != nil {
+			end = `ROLLBACK`
+		}
+		if _, errEnd := conn.ExecContext(context.Background(), end); errEnd != nil && err == nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": errEnd}).Error("finishing snapshot transaction")
+			err = errEnd
+		}
+	}()
+	for _, stmt := range sessionSettings {
+		if _, err = conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return f(conn, db)
+}
+
+// copyTo writes the result of COPY ... TO STDOUT
+func copyTo(ctx context.Context, conn *sql.Conn, w io.Writer, query string) (rows int64, err error) {
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errDriver
+		}
+		tag, err := c.Conn().PgConn().CopyTo(ctx, w, query)
+		rows = tag.RowsAffected()
+		return err
+	})
+	return
+}
+
+// copyFrom reads the data of COPY ... FROM STDIN
+func copyFrom(ctx context.Context, conn *sql.Conn, r io.Reader, query string) (rows int64, err error) {
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errDriver
+		}
+		tag, err := c.Conn().PgConn().CopyFrom(ctx, r, query)
+		rows = tag.RowsAffected()
+		return err
+	})
+	return
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// tableInfo is the table of the current schema
+type tableInfo struct {
+	oid     uint32
+	orderBy []string
+}
+
+// column is the column of the table in the schema of the snapshot
+type column struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default,omitempty"`
+	NotNull bool   `json:"not_null,omitempty"`
+}
+
+// constraint is the primary key, the unique or the non-negative check constraint of the table
+type constraint struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Columns []string `json:"columns"`
+}
+
+// indexColumn is the column of the index with the operator class if it is not the default one
+type indexColumn struct {
+	Name    string `json:"name"`
+	OpClass string `json:"op_class,omitempty"`
+}
+
+// index is the index of the table which is not created by the constraint
+type index struct {
+	Name    string        `json:"name"`
+	Unique  bool          `json:"unique,omitempty"`
+	Method  string        `json:"method"`
+	Columns []indexColumn `json:"columns"`
+}
+
+// tableSchema is the table in the schema of the snapshot
+type tableSchema struct {
+	Name        string       `json:"name"`
+	Columns     []column     `json:"columns"`
+	Constraints []constraint `json:"constraints,omitempty"`
+	Indexes     []index      `json:"indexes,omitempty"`
+	info        tableInfo
+}
+
+// sequence is the sequence in the schema of the snapshot
+type sequence struct {
+	Name        string `json:"name"`
+	Start       int64  `json:"start"`
+	Increment   int64  `json:"increment"`
+	Last        *int64 `json:"last,omitempty"`
+	OwnerTable  string `json:"owner_table,omitempty"`
+	OwnerColumn string `json:"owner_column,omitempty"`
+}
+
+// schema is the schema of the database. It is saved as the data, so the restoring node checks it and
+// builds the statements itself. The functions are not saved, they are created by the local migrations.
+// The views are created by their descriptions in 1_views table
+type schema struct {
+	Sequences []sequence    `json:"sequences"`
+	Tables    []tableSchema `json:"tables"`
+}
+
+const (
+	constraintPrimary     = `primary`
+	constraintUnique      = `unique`
+	constraintNonNegative = `nonnegative`
+)
+
+const (
+	typePattern = `(?:bigint|integer|smallint|numeric|real|double precision|boolean|text|bytea|jsonb|json|uuid|date|` +
+		`character varying|character|timestamp(?:\(\d\))? (?:with|without) time zone)(?:\(\d+(?:,\d+)?\))?(?:\[\])?`
+	literalPattern = `'[^'\\]*'`
+	numberPattern  = `-?\d+(?:\.\d+)?`
+)
+
+var (
+	identRe   = regexp.MustCompile(`^[A-Za-z0-9_]{1,63}$`)
+	typeRe    = regexp.MustCompile(`^` + typePattern + `$`)
+	defaultRe = regexp.MustCompile(`^(?:` + literalPattern + `(?:::` + typePattern + `)?|` +
+		`\(` + literalPattern + `::` + typePattern + `\)::` + typePattern + `|` +
+		numberPattern + `|\(` + numberPattern + `\)::` + typePattern + `|` +
+		`nextval\('"?[A-Za-z0-9_]{1,63}"?'::regclass\)|true|false|now\(\)|CURRENT_TIMESTAMP|` +
+		`NULL(?:::` + typePattern + `)?)$`)
+	nonNegativeRe = regexp.MustCompile(`^CHECK \(\("?[A-Za-z0-9_]{1,63}"? >= \(?0\)?(?:::numeric)?\)\)$`)
+
+	indexMethods = map[string]bool{`btree`: true, `hash`: true, `gin`: true}
+	opClasses    = map[string]bool{``: true, `jsonb_path_ops`: true}
+	viewCompares = map[string]bool{`=`: true, `<>`: true, `!=`: true, `<`: true, `>`: true, `<=`: true, `>=`: true}
+)
+
+func queryStrings(ctx context.Context, conn *sql.Conn, query string, args ...any) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []string
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		ret = append(ret, s)
+	}
+	return ret, rows.Err()
+}
+
+// splitNames splits the comma separated list of the names
+func splitNames(list string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return strings.Split(list, `,`)
+}
+
+// dumpSchema returns the sequences, the tables, the constraints and the indexes of the current schema
+func dumpSchema(ctx context.Context, conn *sql.Conn) (*schema, error) {
+	s := &schema{}
+	owners := make(map[string][2]string)
+	rows, err := conn.QueryContext(ctx, `SELECT s.relname, t.relname, a.attname FROM pg_depend d
+		JOIN pg_class s ON s.oid = d.objid AND s.relkind = 'S'
+		JOIN pg_class t ON t.oid = d.refobjid
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = d.refobjsubid
+		WHERE d.deptype = 'a' AND s.relnamespace = current_schema()::regnamespace`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var seq, table, col string
+		if err = rows.Scan(&seq, &table, &col); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		owners[seq] = [2]string{table, col}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = conn.QueryContext(ctx, `SELECT sequencename, start_value, increment_by, last_value
+		FROM pg_sequences WHERE schemaname = current_schema() ORDER BY sequencename`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			seq  sequence
+			last sql.NullInt64
+		)
+		if err = rows.Scan(&seq.Name, &seq.Start, &seq.Increment, &last); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if last.Valid {
+			seq.Last = &last.Int64
+		}
+		if owner, ok := owners[seq.Name]; ok {
+			seq.OwnerTable, seq.OwnerColumn = owner[0], owner[1]
+		}
+		s.Sequences = append(s.Sequences, seq)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = conn.QueryContext(ctx, `SELECT oid, relname FROM pg_class
+		WHERE relnamespace = current_schema()::regnamespace AND relkind = 'r' ORDER BY relname`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var t tableSchema
+		if err = rows.Scan(&t.info.oid, &t.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.Tables = append(s.Tables, t)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range s.Tables {
+		if err = dumpTable(ctx, conn, &s.Tables[i]); err != nil {
+			return nil, err
+		}
+	}
+	return s, s.check()
+}
+
+// dumpTable reads the columns, the constraints and the indexes of the table
+func dumpTable(ctx context.Context, conn *sql.Conn, t *tableSchema) error {
+	rows, err := conn.QueryContext(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod),
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attnotnull
+		FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped ORDER BY a.attnum`, t.info.oid)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var c column
+		if err = rows.Scan(&c.Name, &c.Type, &c.Default, &c.NotNull); err != nil {
+			rows.Close()
+			return err
+		}
+		t.Columns = append(t.Columns, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if t.info.orderBy, err = queryStrings(ctx, conn, `SELECT quote_ident(a.attname) FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1 AND i.indisprimary ORDER BY a.attnum`, t.info.oid); err != nil {
+		return err
+	}
+	if len(t.info.orderBy) == 0 {
+		t.info.orderBy = []string{quote(t.Name)}
+	}
+
+	rows, err = conn.QueryContext(ctx, `SELECT c.conname, c.contype, pg_get_constraintdef(c.oid),
+			array_to_string(ARRAY(SELECT a.attname FROM unnest(c.conkey) WITH ORDINALITY AS k(num, pos)
+				JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.num ORDER BY k.pos), ',')
+		FROM pg_constraint c WHERE c.conrelid = $1 AND c.contype <> 'n' ORDER BY c.conname`, t.info.oid)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			c        constraint
+			typ, def string
+			names    string
+		)
+		if err = rows.Scan(&c.Name, &typ, &def, &names); err != nil {
+			rows.Close()
+			return err
+		}
+		switch {
+		case typ == `p`:
+			c.Type = constraintPrimary
+		case typ == `u`:
+			c.Type = constraintUnique
+		case typ == `c` && nonNegativeRe.MatchString(def):
+			c.Type = constraintNonNegative
+		default:
+			rows.Close()
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "table": t.Name, "constraint": def}).Error("unsupported constraint")
+			return ErrSchema
+		}
+		c.Columns = splitNames(names)
+		t.Constraints = append(t.Constraints, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = conn.QueryContext(ctx, `SELECT c.relname, i.indisunique, am.amname,
+			array_to_string(ARRAY(SELECT a.attname || CASE WHEN o.opcdefault THEN '' ELSE ' ' || o.opcname END
+				FROM unnest(i.indkey::int2[], i.indclass::oid[]) WITH ORDINALITY AS k(num, opc, pos)
+				JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.num
+				JOIN pg_opclass o ON o.oid = k.opc ORDER BY k.pos), ','),
+			i.indexprs IS NOT NULL OR i.indpred IS NOT NULL
+		FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid JOIN pg_am am ON am.oid = c.relam
+		WHERE i.indrelid = $1 AND NOT EXISTS (SELECT 1 FROM pg_constraint s WHERE s.conindid = i.indexrelid)
+		ORDER BY c.relname`, t.info.oid)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			idx   index
+			names string
+			expr  bool
+		)
+		if err = rows.Scan(&idx.Name, &idx.Unique, &idx.Method, &names, &expr); err != nil {
+			return err
+		}
+		if expr {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "table": t.Name, "index": idx.Name}).Error("unsupported expression index")
+			return ErrSchema
+		}
+		for _, name := range splitNames(names) {
+			col := indexColumn{Name: name}
+			if off := strings.IndexByte(name, ' '); off > 0 {
+				col.Name, col.OpClass = name[:off], name[off+1:]
+			}
+			idx.Columns = append(idx.Columns, col)
+		}
+		t.Indexes = append(t.Indexes, idx)
+	}
+	return rows.Err()
+}
+
+// check checks that the schema has only the supported objects with the correct names, types and
+// default values, so the statements built by the schema do only what they are intended for
+func (s *schema) check() error {
+	fail := func(kind, value string) error {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, kind: value}).Error("checking snapshot schema")
+		return ErrSchema
+	}
+	tables := make(map[string]map[string]bool, len(s.Tables))
+	for _, t := range s.Tables {
+		if !identRe.MatchString(t.Name) || tables[t.Name] != nil || len(t.Columns) == 0 {
+			return fail(`table`, t.Name)
+		}
+		cols := make(map[string]bool, len(t.Columns))
+		for _, c := range t.Columns {
+			if !identRe.MatchString(c.Name) || cols[c.Name] {
+				return fail(`column`, c.Name)
+			}
+			if !typeRe.MatchString(c.Type) {
+				return fail(`column_type`, c.Type)
+			}
+			if len(c.Default) > 0 && !defaultRe.MatchString(c.Default) {
+				return fail(`default`, c.Default)
+			}
+			cols[c.Name] = true
+		}
+		for _, c := range t.Constraints {
+			if !identRe.MatchString(c.Name) || len(c.Columns) == 0 ||
+				(c.Type == constraintNonNegative && len(c.Columns) != 1) {
+				return fail(`constraint`, c.Name)
+			}
+			switch c.Type {
+			case constraintPrimary, constraintUnique, constraintNonNegative:
+			default:
+				return fail(`constraint_type`, c.Type)
+			}
+			for _, name := range c.Columns {
+				if !cols[name] {
+					return fail(`column`, name)
+				}
+			}
+		}
+		for _, idx := range t.Indexes {
+			if !identRe.MatchString(idx.Name) || !indexMethods[idx.Method] || len(idx.Columns) == 0 {
+				return fail(`index`, idx.Name)
+			}
+			for _, c := range idx.Columns {
+				if !cols[c.Name] || !opClasses[c.OpClass] {
+					return fail(`column`, c.Name)
+				}
+			}
+		}
+		tables[t.Name] = cols
+	}
+	for _, seq := range s.Sequences {
+		if !identRe.MatchString(seq.Name) || seq.Increment == 0 {
+			return fail(`sequence`, seq.Name)
+		}
+		if (len(seq.OwnerTable) > 0 || len(seq.OwnerColumn) > 0) && !tables[seq.OwnerTable][seq.OwnerColumn] {
+			return fail(`sequence`, seq.Name)
+		}
+	}
+	return nil
+}
+
+// parseSchema unmarshals and checks the schema
+func parseSchema(data []byte) (*schema, error) {
+	s := &schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling snapshot schema")
+		return nil, err
+	}
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// quoteNames returns the list of the quoted names
+func quoteNames(names []string) string {
+	list := make([]string, len(names))
+	for i, name := range names {
+		list[i] = quote(name)
+	}
+	return strings.Join(list, `, `)
+}
+
+// pre returns the statements creating the sequences and the tables, they are executed before loading
+// of the data. The schema must be checked
+func (s *schema) pre() []string {
+	var ret []string
+	for _, seq := range s.Sequences {
+		ret = append(ret, `DROP SEQUENCE IF EXISTS `+quote(seq.Name)+` CASCADE`,
+			fmt.Sprintf(`CREATE SEQUENCE %s START WITH %d INCREMENT BY %d`, quote(seq.Name), seq.Start, seq.Increment))
+	}
+	for _, t := range s.Tables {
+		cols := make([]string, len(t.Columns))
+		for i, c := range t.Columns {
+			cols[i] = quote(c.Name) + ` ` + c.Type
+			if len(c.Default) > 0 {
+				cols[i] += ` DEFAULT ` + c.Default
+			}
+			if c.NotNull {
+				cols[i] += ` NOT NULL`
+			}
+		}
+		ret = append(ret, fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", quote(t.Name), strings.Join(cols, ",\n\t")))
+	}
+	return ret
+}
+
+// post returns the statements creating the constraints and the indexes and setting the sequences,
+// they are executed after loading of the data. The schema must be checked
+func (s *schema) post() []string {
+	var ret []string
+	for _, t := range s.Tables {
+		for _, c := range t.Constraints {
+			var def string
+			switch c.Type {
+			case constraintPrimary:
+				def = `PRIMARY KEY (` + quoteNames(c.Columns) + `)`
+			case constraintUnique:
+				def = `UNIQUE (` + quoteNames(c.Columns) + `)`
+			case constraintNonNegative:
+				def = `CHECK (` + quote(c.Columns[0]) + ` >= 0)`
+			}
+			ret = append(ret, fmt.Sprintf(`ALTER TABLE %s ADD CONSTRAINT %s %s`, quote(t.Name), quote(c.Name), def))
+		}
+		for _, idx := range t.Indexes {
+			cols := make([]string, len(idx.Columns))
+			for i, c := range idx.Columns {
+				cols[i] = strings.TrimSpace(quote(c.Name) + ` ` + c.OpClass)
+			}
+			var unique string
+			if idx.Unique {
+				unique = `UNIQUE `
+			}
+			ret = append(ret, fmt.Sprintf(`CREATE %sINDEX %s ON %s USING %s (%s)`, unique, quote(idx.Name),
+				quote(t.Name), idx.Method, strings.Join(cols, `, `)))
+		}
+	}
+	for _, seq := range s.Sequences {
+		if seq.Last != nil {
+			ret = append(ret, fmt.Sprintf(`SELECT setval('%s', %d, true)`, quote(seq.Name), *seq.Last))
+		}
+		if len(seq.OwnerTable) > 0 {
+			ret = append(ret, fmt.Sprintf(`ALTER SEQUENCE %s OWNED BY %s.%s`, quote(seq.Name),
+				quote(seq.OwnerTable), quote(seq.OwnerColumn)))
+		}
+	}
+	return ret
+}
+
+// createViews creates the views by their descriptions in 1_views table, the names and the operators
+// of the descriptions are checked as the schema
+func createViews(db *gorm.DB) error {
+	var views []struct {
+		Name      string
+		Ecosystem int64
+		Columns   string
+		Wheres    string
+	}
+	if err := db.Table(`1_views`).Select(`name, ecosystem, COALESCE(columns::text, '') AS columns,
+		COALESCE(wheres::text, '') AS wheres`).Order(`id`).Scan(&views).Error; err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting views")
+		return err
+	}
+	dbTx := sqldb.NewDbTransaction(db)
+	for _, v := range views {
+		var (
+			cols   []smart.ViewColSch
+			wheres []smart.ViewWheSch
+		)
+		if json.Unmarshal([]byte(v.Columns), &cols) != nil || json.Unmarshal([]byte(v.Wheres), &wheres) != nil ||
+			!identRe.MatchString(v.Name) || len(cols) == 0 || len(wheres) == 0 {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "view": v.Name}).Error("checking view")
+			return ErrSchema
+		}
+		colList := make([]string, len(cols))
+		for i, c := range cols {
+			if !identRe.MatchString(c.Table) || !identRe.MatchString(c.Col) ||
+				(len(c.Alias) > 0 && !identRe.MatchString(c.Alias)) {
+				log.WithFields(log.Fields{"type": consts.InvalidObject, "view": v.Name}).Error("checking view columns")
+				return ErrSchema
+			}
+			colList[i] = quote(c.Table) + `.` + c.Col
+			if len(c.Alias) > 0 {
+				colList[i] += ` AS ` + c.Alias
+			}
+		}
+		var (
+			whereList = make([]string, len(wheres))
+			tables    []string
+			has       = make(map[string]bool)
+		)
+		for i, w := range wheres {
+			if !identRe.MatchString(w.TableOne) || !identRe.MatchString(w.TableTwo) || !identRe.MatchString(w.ColOne) ||
+				!identRe.MatchString(w.ColTwo) || !viewCompares[w.Compare] {
+				log.WithFields(log.Fields{"type": consts.InvalidObject, "view": v.Name}).Error("checking view wheres")
+				return ErrSchema
+			}
+			whereList[i] = quote(w.TableOne) + `.` + w.ColOne + ` ` + w.Compare + ` ` + quote(w.TableTwo) + `.` + w.ColTwo
+			for _, t := range []string{w.TableOne, w.TableTwo} {
+				if !has[t] {
+					has[t] = true
+					tables = append(tables, t)
+				}
+			}
+		}
+		sort.Strings(tables)
+		err := sqldb.CreateView(dbTx, fmt.Sprintf(`%d_%s`, v.Ecosystem, v.Name), quoteNames(tables),
+			strings.Join(whereList, ` AND `), strings.Join(colList, ",\n"))
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "view": v.Name}).Error("creating view")
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// The data file is the gzip stream of the records. The schema in json is the first record, the data of
+// the table is placed between recTable and recEnd records
+const (
+	recSchema byte = iota + 1 // the schema in json
+	recTable                  // the name of the table, its data follows
+	recData                   // the rows of the table in the text format of COPY
+	recEnd                    // the end of the data of the table
+)
+
+const (
+	// dataChunkSize is the maximum size of recData record
+	dataChunkSize = 1 << 20
+	// maxRecordSize is the maximum size of any record
+	maxRecordSize = 16 << 20
+)
+
+var errRecordSize = errors.New("snapshot record is too large")
+
+// writer writes the records to the data file
+type writer struct {
+	gz  *gzip.Writer
+	buf *bufio.Writer
+}
+
+func newWriter(w io.Writer) *writer {
+	gz := gzip.NewWriter(w)
+	return &writer{gz: gz, buf: bufio.NewWriterSize(gz, dataChunkSize)}
+}
+
+func (w *writer) record(typ byte, data []byte) error {
+	var head [1 + binary.MaxVarintLen64]byte
+	head[0] = typ
+	n := binary.PutUvarint(head[1:], uint64(len(data)))
+	if _, err := w.buf.Write(head[:1+n]); err != nil {
+		return err
+	}
+	_, err := w.buf.Write(data)
+	return err
+}
+
+// table returns the writer of the data of the table
+func (w *writer) table(name string) (*tableWriter, error) {
+	if err := w.record(recTable, []byte(name)); err != nil {
+		return nil, err
+	}
+	return &tableWriter{w: w, hash: sha256.New()}, nil
+}
+
+func (w *writer) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// tableWriter splits the data of the table to the records and calculates its hash
+type tableWriter struct {
+	w    *writer
+	hash hash.Hash
+}
+
+func (tw *tableWriter) Write(p []byte) (int, error) {
+	tw.hash.Write(p)
+	for i := 0; i < len(p); i += dataChunkSize {
+		end := i + dataChunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+		if err := tw.w.record(recData, p[i:end]); err != nil {
+			return i, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close writes the end of the table and returns the hash of its data
+func (tw *tableWriter) Close() (string, error) {
+	return hex.EncodeToString(tw.hash.Sum(nil)), tw.w.record(recEnd, nil)
+}
+
+// reader reads the records of the data file
+type reader struct {
+	gz  *gzip.Reader
+	buf *bufio.Reader
+}
+
+func newReader(r io.Reader) (*reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{gz: gz, buf: bufio.NewReaderSize(gz, dataChunkSize)}, nil
+}
+
+// next returns the next record, io.EOF is returned at the end of the file
+func (r *reader) next() (byte, []byte, error) {
+	typ, err := r.buf.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := binary.ReadUvarint(r.buf)
+	if err != nil {
+		return 0, nil, unexpected(err)
+	}
+	if size > maxRecordSize {
+		return 0, nil, errRecordSize
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(r.buf, data); err != nil {
+		return 0, nil, unexpected(err)
+	}
+	return typ, data, nil
+}
+
+// table returns the reader of the data of the table which follows recTable record
+func (r *reader) table() *tableReader {
+	return &tableReader{r: r, hash: sha256.New()}
+}
+
+func (r *reader) Close() error {
+	return r.gz.Close()
+}
+
+// tableReader reads the data of the table until recEnd record and calculates its hash
+type tableReader struct {
+	r    *reader
+	hash hash.Hash
+	data []byte
+	done bool
+}
+
+func (tr *tableReader) Read(p []byte) (int, error) {
+	for len(tr.data) == 0 {
+		if tr.done {
+			return 0, io.EOF
+		}
+		typ, data, err := tr.r.next()
+		if err != nil {
+			return 0, unexpected(err)
+		}
+		switch typ {
+		case recData:
+			tr.hash.Write(data)
+			tr.data = data
+		case recEnd:
+			tr.done = true
+		default:
+			return 0, fmt.Errorf("unexpected snapshot record %d in table data", typ)
+		}
+	}
+	n := copy(p, tr.data)
+	tr.data = tr.data[n:]
+	return n, nil
+}
+
+// Hash reads the rest of the data of the table and returns its hash
+func (tr *tableReader) Hash() (string, error) {
+	if _, err := io.Copy(io.Discard, tr); err != nil {
+		return ``, err
+	}
+	return hex.EncodeToString(tr.hash.Sum(nil)), nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/IBAX-io/go-ibax/packages/block"
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Restore replaces all tables of the database with the snapshot in one transaction. The data file is checked
+// by the manifest, the schema is checked and its statements are built locally, the tables are checked by their hashes and the rollbacks of the block of the snapshot are
+// checked by the rollbacks hash of the block. blockData is the block of the snapshot confirmed by the peers,
+// the restored block must be the same if it is not empty. The restored first block must be the same as
+// the first block of the database if it has been loaded
+func Restore(ctx context.Context, m *Manifest, path string, blockData []byte) error {
+	err := m.CheckFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := newReader(f)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tables := make(map[string]Table, len(m.Tables))
+	for _, t := range m.Tables {
+		tables[t.Name] = t
+	}
+	err = withTx(ctx, `BEGIN`, func(conn *sql.Conn, db *gorm.DB) error {
+		first, err := firstBlockHash(db)
+		if err != nil {
+			return err
+		}
+		if err := sqldb.NewDbTransaction(db).DropTables(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("dropping all tables")
+			return err
+		}
+		var s *schema
+		restored := make(map[string]bool)
+		for {
+			typ, data, err := r.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			switch {
+			case typ == recSchema && s == nil:
+				if s, err = parseSchema(data); err != nil {
+					return err
+				}
+				if err = execAll(ctx, conn, s.pre()); err != nil {
+					return err
+				}
+			case typ == recTable && s != nil:
+				name := string(data)
+				t, ok := tables[name]
+				if !ok || restored[name] {
+					return fmt.Errorf("unexpected table %s in snapshot", name)
+				}
+				tr := r.table()
+				rows, err := copyFrom(ctx, conn, tr, fmt.Sprintf(`COPY %s FROM STDIN`, quote(name)))
+				if err != nil {
+					log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": name}).Error("copying table")
+					return err
+				}
+				hash, err := tr.Hash()
+				if err != nil {
+					return err
+				}
+				if rows != t.Rows || hash != t.Hash {
+					log.WithFields(log.Fields{"type": consts.InvalidObject, "table": name, "rows": rows, "hash": hash}).Error("checking snapshot table")
+					return ErrTableHash
+				}
+				restored[name] = true
+			default:
+				return fmt.Errorf("unexpected snapshot record %d", typ)
+			}
+		}
+		if s == nil || len(restored) != len(tables) {
+			return fmt.Errorf("snapshot has %d tables of %d", len(restored), len(tables))
+		}
+		if err := execAll(ctx, conn, s.post()); err != nil {
+			return err
+		}
+		if err := createViews(db); err != nil {
+			return err
+		}
+		return m.checkState(db, blockData, first)
+	})
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"block_id": m.BlockID, "tables": len(m.Tables)}).Info("snapshot restored")
+	return nil
+}
+
+// execAll executes the statements of the schema
+func execAll(ctx context.Context, conn *sql.Conn, stmts []string) error {
+	for _, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": stmt}).Error("executing snapshot schema")
+			return err
+		}
+	}
+	return nil
+}
+
+// firstBlockHash returns the hash of the first block if it has been loaded
+func firstBlockHash(db *gorm.DB) ([]byte, error) {
+	bc := &sqldb.BlockChain{}
+	if err := db.Where("id = 1").First(bc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting first block")
+		return nil, err
+	}
+	return bc.Hash, nil
+}
+
+// checkState checks the restored first block, the block of the snapshot and its rollbacks
+func (m *Manifest) checkState(db *gorm.DB, blockData, first []byte) error {
+	if first != nil {
+		restored, err := firstBlockHash(db)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(first, restored) {
+			return ErrFirstBlock
+		}
+	}
+	bc := &sqldb.BlockChain{}
+	if err := db.Where("id = ?", m.BlockID).First(bc).Error; err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": m.BlockID}).Error("getting block")
+		return err
+	}
+	if hex.EncodeToString(bc.Hash) != m.BlockHash || (len(blockData) > 0 && !bytes.Equal(blockData, bc.Data)) {
+		return ErrBlockHash
+	}
+	if _, err := m.CheckBlock(bc.Data); err != nil {
+		return err
+	}
+	rHash, err := block.GetRollbacksHashWithDiffArr(sqldb.NewDbTransaction(db), m.BlockID)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(rHash, bc.RollbacksHash) || hex.EncodeToString(rHash) != m.RollbacksHash {
+		return ErrRollbacksHash
+	}
+	ib := &sqldb.InfoBlock{}
+	if err = db.Last(ib).Error; err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		return err
+	}
+	if ib.BlockID != m.BlockID || !bytes.Equal(ib.Hash, bc.Hash) {
+		return fmt.Errorf("info block %d does not match snapshot block %d", ib.BlockID, m.BlockID)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package snapshot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Version is the version of the format of the snapshot
+	Version = 2
+
+	dataExt     = ".snapshot"
+	manifestExt = ".json"
+)
+
+var (
+	ErrNotFound      = errors.New("snapshot not found")
+	ErrVersion       = errors.New("unsupported snapshot version")
+	ErrFileHash      = errors.New("snapshot file hash mismatch")
+	ErrTableHash     = errors.New("snapshot table hash mismatch")
+	ErrStateHash     = errors.New("snapshot state hash mismatch")
+	ErrBlockHash     = errors.New("snapshot block hash mismatch")
+	ErrRollbacksHash = errors.New("snapshot rollbacks hash mismatch")
+	ErrFirstBlock    = errors.New("snapshot first block mismatch")
+	ErrSchema        = errors.New("unsupported snapshot schema")
+)
+
+// localTables are the tables of the node which are restored without data
+var localTables = map[string]bool{
+	"api_keys":              true,
+	"confirmations":         true,
+	"external_blockchain":   true,
+	"queue_blocks":          true,
+	"queue_tx":              true,
+	"stop_daemons":          true,
+	"transactions":          true,
+	"transactions_attempts": true,
+	"transactions_status":   true,
+}
+
+// unhashedTables are the tables which may differ between the nodes, they are not included in the state hash
+var unhashedTables = map[string]bool{
+	"info_block":        true,
+	"install":           true,
+	"migration_history": true,
+	"rollback_tx":       true,
+}
+
+// tableFilters are the conditions of the rows of the tables which are saved partially. %[1]d is the block of the snapshot
+var tableFilters = map[string]string{
+	"block_chain": `id = 1 OR id = %[1]d`,
+	"rollback_tx": `block_id = %[1]d`,
+}
+
+// Table is the data of the table in the snapshot
+type Table struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+	Hash string `json:"hash"`
+}
+
+// Manifest describes the snapshot of the state at the block
+type Manifest struct {
+	Version       int     `json:"version"`
+	BlockID       int64   `json:"block_id"`
+	BlockHash     string  `json:"block_hash"`
+	RollbacksHash string  `json:"rollbacks_hash"`
+	StateHash     string  `json:"state_hash"`
+	Time          int64   `json:"time"`
+	Size          int64   `json:"size"`
+	FileHash      string  `json:"file_hash"`
+	Tables        []Table `json:"tables"`
+}
+
+// ParseManifest unmarshals and checks the manifest
+func ParseManifest(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling snapshot manifest")
+		return nil, err
+	}
+	if m.Version != Version {
+		return nil, ErrVersion
+	}
+	if m.BlockID < 1 || m.Size <= 0 {
+		return nil, fmt.Errorf("wrong snapshot manifest of block %d", m.BlockID)
+	}
+	if m.StateHash != hex.EncodeToString(StateHash(m.Tables)) {
+		return nil, ErrStateHash
+	}
+	return m, nil
+}
+
+// StateHash returns the hash of the tables which are the same on all nodes
+func StateHash(tables []Table) []byte {
+	list := make([]Table, 0, len(tables))
+	for _, t := range tables {
+		if !unhashedTables[t.Name] {
+			list = append(list, t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	h := sha256.New()
+	for _, t := range list {
+		fmt.Fprintf(h, "%s:%d:%s\n", t.Name, t.Rows, t.Hash)
+	}
+	return h.Sum(nil)
+}
+
+// Path returns the path of the data file of the snapshot
+func Path(dir string, blockID int64) string {
+	return filepath.Join(dir, strconv.FormatInt(blockID, 10)+dataExt)
+}
+
+// ManifestPath returns the path of the manifest of the data file
+func ManifestPath(path string) string {
+	return strings.TrimSuffix(path, dataExt) + manifestExt
+}
+
+// ReadManifest reads the manifest of the data file
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(ManifestPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": path}).Error("reading snapshot manifest")
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+func writeManifest(path string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, ``, `  `)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling snapshot manifest")
+		return err
+	}
+	tmp := ManifestPath(path) + `.tmp`
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": tmp}).Error("writing snapshot manifest")
+		return err
+	}
+	return os.Rename(tmp, ManifestPath(path))
+}
+
+// List returns the block ids of the snapshots in the directory in ascending order
+func List(dir string) ([]int64, error) {
+	files, err := filepath.Glob(filepath.Join(dir, `*`+manifestExt))
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, f := range files {
+		id, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(f), manifestExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err = os.Stat(Path(dir, id)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// Latest returns the manifest of the newest snapshot in the directory
+func Latest(dir string) (*Manifest, error) {
+	ids, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		m, err := ReadManifest(Path(dir, ids[i]))
+		if err == nil && m.BlockID == ids[i] {
+			return m, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Prune removes the old snapshots except keep newest ones
+func Prune(dir string, keep int) error {
+	ids, err := List(dir)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(ids)-keep; i++ {
+		path := Path(dir, ids[i])
+		if err = os.Remove(ManifestPath(path)); err == nil {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": path}).Error("removing snapshot")
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadChunk reads the part of the data file of the snapshot
+func ReadChunk(dir string, blockID, offset int64, size int) ([]byte, error) {
+	f, err := os.Open(Path(dir, blockID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// FileHash returns the hash of the data file
+func FileHash(path string) ([]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return h.Sum(nil), size, nil
+}
+
+// CheckFile checks the size and the hash of the data file
+func (m *Manifest) CheckFile(path string) error {
+	hash, size, err := FileHash(path)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": path}).Error("reading snapshot")
+		return err
+	}
+	if size != m.Size || hex.EncodeToString(hash) != m.FileHash {
+		return ErrFileHash
+	}
+	return nil
+}
+
+// CheckBlock checks that the binary block is the block of the snapshot and its hash is correct
+func (m *Manifest) CheckBlock(data []byte) (*types.BlockHeader, error) {
+	bd := &types.BlockData{}
+	if err := bd.UnmarshallBlock(data); err != nil {
+		return nil, err
+	}
+	if bd.Header == nil || bd.PrevHeader == nil || bd.Header.BlockId != m.BlockID {
+		return nil, fmt.Errorf("wrong block of snapshot %d", m.BlockID)
+	}
+	if !bytes.Equal(bd.Header.BlockHash, bd.Header.GenHash(bd.PrevHeader, bd.MerkleRoot)) ||
+		hex.EncodeToString(bd.Header.BlockHash) != m.BlockHash {
+		return nil, ErrBlockHash
+	}
+	return bd.Header, nil
+}
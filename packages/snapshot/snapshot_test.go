@@ -0,0 +1,205 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package snapshot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		large = []byte(strings.Repeat("1\tvalue\n", dataChunkSize/4))
+	)
+	w := newWriter(&buf)
+	require.NoError(t, w.record(recSchema, []byte(`{"tables":[]}`)))
+	tw, err := w.table("t")
+	require.NoError(t, err)
+	_, err = tw.Write(large)
+	require.NoError(t, err)
+	hash, err := tw.Close()
+	require.NoError(t, err)
+	sum := sha256.Sum256(large)
+	require.Equal(t, hex.EncodeToString(sum[:]), hash)
+	require.NoError(t, w.Close())
+
+	r, err := newReader(&buf)
+	require.NoError(t, err)
+	typ, data, err := r.next()
+	require.NoError(t, err)
+	require.Equal(t, recSchema, typ)
+	require.Equal(t, `{"tables":[]}`, string(data))
+
+	typ, data, err = r.next()
+	require.NoError(t, err)
+	require.Equal(t, recTable, typ)
+	require.Equal(t, "t", string(data))
+	tr := r.table()
+	data, err = io.ReadAll(tr)
+	require.NoError(t, err)
+	require.Equal(t, large, data)
+	readHash, err := tr.Hash()
+	require.NoError(t, err)
+	require.Equal(t, hash, readHash)
+
+	_, _, err = r.next()
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, r.Close())
+}
+
+func TestManifest(t *testing.T) {
+	tables := []Table{
+		{Name: "1_keys", Rows: 2, Hash: "aa"},
+		{Name: "info_block", Rows: 1, Hash: "bb"},
+		{Name: "1_contracts", Rows: 10, Hash: "cc"},
+	}
+	m := Manifest{Version: Version, BlockID: 5, Size: 10, Tables: tables,
+		StateHash: hex.EncodeToString(StateHash(tables))}
+
+	// the state hash does not depend on the order and the local tables
+	other := []Table{tables[2], tables[0], {Name: "info_block", Rows: 1, Hash: "dd"}}
+	require.Equal(t, StateHash(tables), StateHash(other))
+
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	parsed, err := ParseManifest(data)
+	require.NoError(t, err)
+	require.Equal(t, m, *parsed)
+
+	m.Tables[0].Rows = 3
+	data, err = json.Marshal(m)
+	require.NoError(t, err)
+	_, err = ParseManifest(data)
+	require.Equal(t, ErrStateHash, err)
+
+	m.Version = Version + 1
+	data, err = json.Marshal(m)
+	require.NoError(t, err)
+	_, err = ParseManifest(data)
+	require.Equal(t, ErrVersion, err)
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Latest(dir)
+	require.Equal(t, ErrNotFound, err)
+
+	for _, id := range []int64{30, 10, 20} {
+		path := Path(dir, id)
+		content := []byte(strings.Repeat("x", int(id)))
+		require.NoError(t, os.WriteFile(path, content, 0644))
+		hash := sha256.Sum256(content)
+		m := &Manifest{Version: Version, BlockID: id, Size: id, FileHash: hex.EncodeToString(hash[:]),
+			StateHash: hex.EncodeToString(StateHash(nil))}
+		require.NoError(t, writeManifest(path, m))
+		require.NoError(t, m.CheckFile(path))
+	}
+	// the data file without the manifest is ignored
+	require.NoError(t, os.WriteFile(Path(dir, 40), []byte("x"), 0644))
+
+	ids, err := List(dir)
+	require.NoError(t, err)
+	require.Equal(t, []int64{10, 20, 30}, ids)
+
+	m, err := Latest(dir)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), m.BlockID)
+
+	chunk, err := ReadChunk(dir, 30, 25, 10)
+	require.NoError(t, err)
+	require.Equal(t, []byte("xxxxx"), chunk)
+	chunk, err = ReadChunk(dir, 30, 30, 10)
+	require.NoError(t, err)
+	require.Empty(t, chunk)
+	_, err = ReadChunk(dir, 50, 0, 10)
+	require.Equal(t, ErrNotFound, err)
+
+	require.NoError(t, Prune(dir, 2))
+	ids, err = List(dir)
+	require.NoError(t, err)
+	require.Equal(t, []int64{20, 30}, ids)
+
+	m.Size++
+	require.Equal(t, ErrFileHash, m.CheckFile(Path(dir, 30)))
+}
+
+func TestSchema(t *testing.T) {
+	last := int64(7)
+	valid := func() *schema {
+		return &schema{
+			Sequences: []sequence{{Name: "1_keys_id_seq", Start: 1, Increment: 1, Last: &last,
+				OwnerTable: "1_keys", OwnerColumn: "id"}},
+			Tables: []tableSchema{{
+				Name: "1_keys",
+				Columns: []column{
+					{Name: "id", Type: "bigint", Default: `nextval('"1_keys_id_seq"'::regclass)`, NotNull: true},
+					{Name: "amount", Type: "numeric(30,0)", Default: "'0'::numeric", NotNull: true},
+					{Name: "account", Type: "character varying(255)", Default: "''::character varying"},
+					{Name: "data", Type: "jsonb", Default: "'{}'::jsonb"},
+				},
+				Constraints: []constraint{
+					{Name: "1_keys_pkey", Type: constraintPrimary, Columns: []string{"id"}},
+					{Name: "1_keys_amount_check", Type: constraintNonNegative, Columns: []string{"amount"}},
+				},
+				Indexes: []index{
+					{Name: "1_keys_account", Unique: true, Method: "btree", Columns: []indexColumn{{Name: "account"}}},
+					{Name: "1_keys_data", Method: "gin", Columns: []indexColumn{{Name: "data", OpClass: "jsonb_path_ops"}}},
+				},
+			}},
+		}
+	}
+	data, err := json.Marshal(valid())
+	require.NoError(t, err)
+	s, err := parseSchema(data)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`DROP SEQUENCE IF EXISTS "1_keys_id_seq" CASCADE`,
+		`CREATE SEQUENCE "1_keys_id_seq" START WITH 1 INCREMENT BY 1`,
+		"CREATE TABLE \"1_keys\" (\n\t\"id\" bigint DEFAULT nextval('\"1_keys_id_seq\"'::regclass) NOT NULL,\n\t" +
+			"\"amount\" numeric(30,0) DEFAULT '0'::numeric NOT NULL,\n\t" +
+			"\"account\" character varying(255) DEFAULT ''::character varying,\n\t" +
+			"\"data\" jsonb DEFAULT '{}'::jsonb\n)",
+	}, s.pre())
+	require.Equal(t, []string{
+		`ALTER TABLE "1_keys" ADD CONSTRAINT "1_keys_pkey" PRIMARY KEY ("id")`,
+		`ALTER TABLE "1_keys" ADD CONSTRAINT "1_keys_amount_check" CHECK ("amount" >= 0)`,
+		`CREATE UNIQUE INDEX "1_keys_account" ON "1_keys" USING btree ("account")`,
+		`CREATE INDEX "1_keys_data" ON "1_keys" USING gin ("data" jsonb_path_ops)`,
+		`SELECT setval('"1_keys_id_seq"', 7, true)`,
+		`ALTER SEQUENCE "1_keys_id_seq" OWNED BY "1_keys"."id"`,
+	}, s.post())
+
+	for _, change := range []func(s *schema){
+		func(s *schema) { s.Tables[0].Name = `1_keys"; DROP TABLE "1_keys` },
+		func(s *schema) { s.Tables[0].Columns[1].Name = `amount" text, "x` },
+		func(s *schema) { s.Tables[0].Columns[1].Type = `bigint); DROP TABLE "1_keys"; --` },
+		func(s *schema) { s.Tables[0].Columns[1].Default = `(SELECT pg_sleep(10))` },
+		func(s *schema) { s.Tables[0].Columns[1].Default = `'0'; DROP TABLE "1_keys"; --'` },
+		func(s *schema) { s.Tables[0].Columns[1].Default = `f()` },
+		func(s *schema) { s.Tables[0].Constraints[0].Type = `check` },
+		func(s *schema) { s.Tables[0].Constraints[1].Columns = []string{"unknown"} },
+		func(s *schema) { s.Tables[0].Indexes[0].Method = `btree (id); DROP TABLE "1_keys"; --` },
+		func(s *schema) { s.Tables[0].Indexes[1].Columns[0].OpClass = `DESC) WHERE (f()` },
+		func(s *schema) { s.Sequences[0].OwnerColumn = "unknown" },
+		func(s *schema) { s.Tables = append(s.Tables, s.Tables[0]) },
+	} {
+		s := valid()
+		change(s)
+		data, err := json.Marshal(s)
+		require.NoError(t, err)
+		_, err = parseSchema(data)
+		require.Equal(t, ErrSchema, err)
+	}
+}
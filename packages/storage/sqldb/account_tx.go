@@ -0,0 +1,145 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package sqldb
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// The models of transfers of the account
+const (
+	AccountTxModelAccount    = "account"     // the record of 1_history
+	AccountTxModelUTXOOutput = "utxo_output" // the output of spent_info received by the account
+	AccountTxModelUTXOInput  = "utxo_input"  // the output of spent_info spent by the account
+)
+
+// AccountTx is the transfer of tokens of the account in the account or UTXO model
+type AccountTx struct {
+	Model        string
+	Idx          int64 // id of 1_history or the index of the output or input in the transaction
+	BlockID      int64
+	TxHash       []byte
+	Ecosystem    int64
+	SenderID     int64
+	RecipientID  int64
+	Amount       decimal.Decimal
+	Type         int64
+	Comment      string
+	ContractName string
+	CreatedAt    int64
+}
+
+// AccountTxCursor is the position of the last record on the previous page
+type AccountTxCursor struct {
+	BlockID int64
+	TxHash  []byte
+	Model   string
+	Idx     int64
+}
+
+// AccountTxFilter is the condition of the transfers query. Zero values are not used
+type AccountTxFilter struct {
+	KeyID        int64
+	Ecosystem    int64
+	Direction    string // in, out
+	Model        string // account, utxo
+	ContractName string
+	FromBlock    int64
+	ToBlock      int64
+	FromTime     int64
+	ToTime       int64
+	Cursor       *AccountTxCursor
+	Limit        int
+}
+
+const accountTxQuery = `SELECT * FROM (
+	SELECT 'account' AS model, h.id AS idx, h.block_id, h.txhash AS tx_hash, h.ecosystem,
+		h.sender_id, h.recipient_id, h.amount, h.type, h.comment,
+		coalesce(lt.contract_name, '') AS contract_name, h.created_at
+	FROM "1_history" AS h LEFT JOIN log_transactions AS lt ON lt.hash = h.txhash
+	WHERE h.sender_id = @key OR h.recipient_id = @key
+	UNION ALL
+	SELECT 'utxo_output', si.output_index, si.block_id, si.output_tx_hash, si.ecosystem,
+		coalesce(lt.address, 0), si.output_key_id, si.output_value, si.type, '',
+		coalesce(lt.contract_name, ''), coalesce(lt.timestamp, 0)
+	FROM spent_info AS si LEFT JOIN log_transactions AS lt ON lt.hash = si.output_tx_hash
+	WHERE si.output_key_id = @key
+	UNION ALL
+	SELECT 'utxo_input', si.input_index, lt.block, si.input_tx_hash, si.ecosystem,
+		si.output_key_id, 0, si.output_value, si.type, '',
+		lt.contract_name, lt.timestamp
+	FROM spent_info AS si JOIN log_transactions AS lt ON lt.hash = si.input_tx_hash
+	WHERE si.output_key_id = @key AND si.input_tx_hash IS NOT NULL
+) AS t`
+
+// GetAccountTransactions returns the transfers of the account from 1_history and spent_info
+// ordered from the latest ones. The order is stable so the cursor can be used for the next page
+func GetAccountTransactions(dbTx *DbTransaction, f *AccountTxFilter) ([]AccountTx, error) {
+	query, args := accountTxFilterQuery(f)
+	var result []AccountTx
+	err := GetDB(dbTx).Raw(query, args).Scan(&result).Error
+	return result, err
+}
+
+// accountTxFilterQuery returns the query of the transfers with the conditions of the filter and its named args
+func accountTxFilterQuery(f *AccountTxFilter) (string, map[string]any) {
+	var (
+		where []string
+		args  = map[string]any{"key": f.KeyID}
+	)
+	if f.Ecosystem > 0 {
+		where = append(where, "ecosystem = @ecosystem")
+		args["ecosystem"] = f.Ecosystem
+	}
+	switch f.Direction {
+	case "in":
+		where = append(where, "recipient_id = @key AND model <> 'utxo_input'")
+	case "out":
+		where = append(where, "sender_id = @key AND model <> 'utxo_output'")
+	}
+	switch f.Model {
+	case AccountTxModelAccount:
+		where = append(where, "model = 'account'")
+	case "utxo":
+		where = append(where, "model <> 'account'")
+	}
+	if len(f.ContractName) > 0 {
+		where = append(where, "contract_name = @contract")
+		args["contract"] = f.ContractName
+	}
+	if f.FromBlock > 0 {
+		where = append(where, "block_id >= @from_block")
+		args["from_block"] = f.FromBlock
+	}
+	if f.ToBlock > 0 {
+		where = append(where, "block_id <= @to_block")
+		args["to_block"] = f.ToBlock
+	}
+	if f.FromTime > 0 {
+		where = append(where, "created_at >= @from_time")
+		args["from_time"] = f.FromTime
+	}
+	if f.ToTime > 0 {
+		where = append(where, "created_at <= @to_time")
+		args["to_time"] = f.ToTime
+	}
+	if c := f.Cursor; c != nil {
+		where = append(where, "(block_id, tx_hash, model, idx) < (@c_block, @c_hash, @c_model, @c_idx)")
+		args["c_block"], args["c_hash"], args["c_model"], args["c_idx"] = c.BlockID, c.TxHash, c.Model, c.Idx
+	}
+	query := accountTxQuery
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY block_id DESC, tx_hash DESC, model DESC, idx DESC"
+	if f.Limit > 0 {
+		query += " LIMIT @limit"
+		args["limit"] = f.Limit
+	}
+	return query, args
+}
@@ -0,0 +1,64 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package sqldb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountTxFilterQuery(t *testing.T) {
+	testTable := []struct {
+		Filter AccountTxFilter
+		Where  []string
+		Args   []string
+	}{
+		{
+			Filter: AccountTxFilter{KeyID: 1},
+		},
+		{
+			Filter: AccountTxFilter{KeyID: 1, Direction: "in", Model: AccountTxModelAccount},
+			Where:  []string{"recipient_id = @key AND model <> 'utxo_input'", "model = 'account'"},
+		},
+		{
+			Filter: AccountTxFilter{KeyID: 1, Direction: "out", Model: "utxo"},
+			Where:  []string{"sender_id = @key AND model <> 'utxo_output'", "model <> 'account'"},
+		},
+		{
+			Filter: AccountTxFilter{KeyID: 1, Ecosystem: 2, ContractName: "@1TokensSend", Limit: 10},
+			Where:  []string{"ecosystem = @ecosystem", "contract_name = @contract"},
+			Args:   []string{"ecosystem", "contract", "limit"},
+		},
+		{
+			Filter: AccountTxFilter{KeyID: 1, FromBlock: 5, ToBlock: 10, FromTime: 100, ToTime: 200},
+			Where: []string{"block_id >= @from_block", "block_id <= @to_block",
+				"created_at >= @from_time", "created_at <= @to_time"},
+			Args: []string{"from_block", "to_block", "from_time", "to_time"},
+		},
+		{
+			Filter: AccountTxFilter{KeyID: 1, Cursor: &AccountTxCursor{BlockID: 3, TxHash: []byte{1}, Model: AccountTxModelUTXOInput, Idx: 2}},
+			Where:  []string{"(block_id, tx_hash, model, idx) < (@c_block, @c_hash, @c_model, @c_idx)"},
+			Args:   []string{"c_block", "c_hash", "c_model", "c_idx"},
+		},
+	}
+
+	for i, item := range testTable {
+		query, args := accountTxFilterQuery(&item.Filter)
+		assert.True(t, strings.HasPrefix(query, accountTxQuery), "on %d step wrong query", i)
+		assert.Equal(t, len(item.Where) > 0, strings.Contains(query, " WHERE "), "on %d step wrong where %s", i, query)
+		for _, w := range item.Where {
+			assert.Contains(t, query, w, "on %d step", i)
+		}
+		assert.Equal(t, item.Filter.Limit > 0, strings.Contains(query, " LIMIT @limit"), "on %d step wrong limit", i)
+		assert.Equal(t, item.Filter.KeyID, args["key"], "on %d step wrong key", i)
+		assert.Equal(t, len(item.Args)+1, len(args), "on %d step wrong args %v", i, args)
+		for _, a := range item.Args {
+			assert.Contains(t, args, a, "on %d step", i)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package sqldb
+
+import (
+	"time"
+)
+
+// APIKey is model of the long-lived key for the server-to-server access to the api.
+// The table is local for the node and is not a part of the blockchain state
+type APIKey struct {
+	ID        int64   `gorm:"primary_key;not null"`
+	Name      string  `gorm:"not null;size:255"`
+	KeyID     int64   `gorm:"not null"`
+	Ecosystem int64   `gorm:"not null"`
+	Scopes    string  `gorm:"not null"`
+	Hash      []byte  `gorm:"not null"`
+	RateLimit float64 `gorm:"not null"`
+	CreatedAt int64   `gorm:"not null"`
+	ExpiresAt int64   `gorm:"not null"`
+	RevokedAt int64   `gorm:"not null"`
+}
+
+// TableName returns name of table
+func (*APIKey) TableName() string {
+	return "api_keys"
+}
+
+// Create is creating record of model
+func (k *APIKey) Create() error {
+	return DBConn.Create(k).Error
+}
+
+// GetByHash is retrieving the key by the hash of its secret
+func (k *APIKey) GetByHash(hash []byte) (bool, error) {
+	return isFound(DBConn.Where("hash = ?", hash).First(k))
+}
+
+// IsActive returns true if the key is not revoked and is not expired
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == 0 && (k.ExpiresAt == 0 || k.ExpiresAt > time.Now().Unix())
+}
+
+// GetAPIKeys returns all keys of the node
+func GetAPIKeys() ([]APIKey, error) {
+	var keys []APIKey
+	err := DBConn.Order("id asc").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey is revoking the key. It returns false if there is no active key with such id
+func RevokeAPIKey(id int64) (bool, error) {
+	res := DBConn.Model(&APIKey{}).Where("id = ? AND revoked_at = 0", id).
+		Update("revoked_at", time.Now().Unix())
+	return res.RowsAffected > 0, res.Error
+}
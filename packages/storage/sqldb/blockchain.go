@@ -74,13 +74,13 @@ func GetBlockchain(startBlockID int64, endblockID int64, order ordering) ([]Bloc
 }
 
 // GetBlocks is retrieving limited chain of blocks from database
-func (b *BlockChain) GetBlocks(startFromID int64, limit int) ([]BlockChain, error) {
+func (b *BlockChain) GetBlocks(dbTx *DbTransaction, startFromID int64, limit int) ([]BlockChain, error) {
 	var err error
 	blockchain := new([]BlockChain)
 	if startFromID > 0 {
-		err = DBConn.Order("id desc").Limit(limit).Where("id > ?", startFromID).Find(&blockchain).Error
+		err = GetDB(dbTx).Order("id desc").Limit(limit).Where("id > ?", startFromID).Find(&blockchain).Error
 	} else {
-		err = DBConn.Order("id desc").Limit(limit).Find(&blockchain).Error
+		err = GetDB(dbTx).Order("id desc").Limit(limit).Find(&blockchain).Error
 	}
 	return *blockchain, err
 }
@@ -30,6 +30,11 @@ func (c *Contract) Get(Id int64) (bool, error) {
 	return isFound(DBConn.Where("id = ?", Id).First(c))
 }
 
+// GetByTx is retrieving the contract in the database transaction
+func (c *Contract) GetByTx(dbTx *DbTransaction, id int64) (bool, error) {
+	return isFound(GetDB(dbTx).Where("id = ?", id).First(c))
+}
+
 // GetList is retrieving records from database
 func (c *Contract) GetList(offset, limit int) ([]Contract, error) {
 	result := new([]Contract)
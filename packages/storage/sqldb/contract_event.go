@@ -0,0 +1,84 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package sqldb
+
+// ContractEvent is model of the event emitted by the contract
+type ContractEvent struct {
+	TxHash       []byte `gorm:"primary_key;not null" json:"-"`
+	LogIndex     int64  `gorm:"primary_key;not null" json:"log_index"`
+	BlockID      int64  `gorm:"not null" json:"block_id"`
+	EcosystemID  int64  `gorm:"not null;column:ecosystem" json:"ecosystem"`
+	ContractName string `gorm:"not null;size:255" json:"contract_name"`
+	Name         string `gorm:"not null;size:255" json:"name"`
+	Data         string `gorm:"not null;type:jsonb" json:"data"`
+	Timestamp    int64  `gorm:"not null" json:"timestamp"`
+}
+
+// ContractEventFilter is the condition of the events query. Zero values are not used
+type ContractEventFilter struct {
+	FromBlock    int64
+	ToBlock      int64
+	EcosystemID  int64
+	ContractName string
+	Name         string
+	Data         string // JSON object which must be contained in the data of the event
+	Offset       int
+	Limit        int
+}
+
+// TableName returns name of table
+func (*ContractEvent) TableName() string {
+	return "contract_events"
+}
+
+// Create is creating record of model
+func (ce *ContractEvent) Create(dbTx *DbTransaction) error {
+	return GetDB(dbTx).Create(ce).Error
+}
+
+// DeleteContractEvent is deleting the event of the transaction
+func DeleteContractEvent(dbTx *DbTransaction, txHash []byte, logIndex int64) error {
+	return GetDB(dbTx).Exec("DELETE FROM contract_events WHERE tx_hash = ? AND log_index = ?", txHash, logIndex).Error
+}
+
+// GetContractEventsByHash returns the events of the transaction
+func GetContractEventsByHash(dbTx *DbTransaction, txHash []byte) ([]ContractEvent, error) {
+	var events []ContractEvent
+	err := GetDB(dbTx).Where("tx_hash = ?", txHash).Order("log_index asc").Find(&events).Error
+	return events, err
+}
+
+// GetContractEvents returns the events matching the filter ordered by block
+func GetContractEvents(f *ContractEventFilter) ([]ContractEvent, error) {
+	var events []ContractEvent
+	q := DBConn.Model(&ContractEvent{})
+	if f.FromBlock > 0 {
+		q = q.Where("block_id >= ?", f.FromBlock)
+	}
+	if f.ToBlock > 0 {
+		q = q.Where("block_id <= ?", f.ToBlock)
+	}
+	if f.EcosystemID > 0 {
+		q = q.Where("ecosystem = ?", f.EcosystemID)
+	}
+	if len(f.ContractName) > 0 {
+		q = q.Where("contract_name = ?", f.ContractName)
+	}
+	if len(f.Name) > 0 {
+		q = q.Where("name = ?", f.Name)
+	}
+	if len(f.Data) > 0 {
+		q = q.Where("data @> ?::jsonb", f.Data)
+	}
+	if f.Offset > 0 {
+		q = q.Offset(f.Offset)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	err := q.Order("block_id asc, tx_hash asc, log_index asc").Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,56 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) IBAX. All rights reserved.
+ *  See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package sqldb
+
+// ContractVersion is model of the version of the contract source
+type ContractVersion struct {
+	ContractID  int64  `gorm:"primary_key;not null" json:"contract_id"`
+	Version     int64  `gorm:"primary_key;not null" json:"version"`
+	EcosystemID int64  `gorm:"not null;column:ecosystem" json:"ecosystem"`
+	Name        string `gorm:"not null;size:255" json:"name"`
+	Value       string `gorm:"not null" json:"value,omitempty"`
+	Conditions  string `gorm:"not null" json:"conditions"`
+	WalletID    int64  `gorm:"not null" json:"wallet_id"`
+	TokenID     int64  `gorm:"not null" json:"token_id"`
+	BlockID     int64  `gorm:"not null" json:"block_id"`
+	TxHash      []byte `gorm:"not null" json:"-"`
+	Timestamp   int64  `gorm:"not null" json:"timestamp"`
+	Restored    int64  `gorm:"not null" json:"restored"` // the restored version if the version is created by the rollback
+}
+
+// TableName returns name of table
+func (*ContractVersion) TableName() string {
+	return "contract_versions"
+}
+
+// Create is creating record of model
+func (cv *ContractVersion) Create(dbTx *DbTransaction) error {
+	return GetDB(dbTx).Create(cv).Error
+}
+
+// Get is retrieving the version of the contract
+func (cv *ContractVersion) Get(dbTx *DbTransaction, contractID, version int64) (bool, error) {
+	return isFound(GetDB(dbTx).Where("contract_id = ? AND version = ?", contractID, version).First(cv))
+}
+
+// GetLast is retrieving the last version of the contract
+func (cv *ContractVersion) GetLast(dbTx *DbTransaction, contractID int64) (bool, error) {
+	return isFound(GetDB(dbTx).Where("contract_id = ?", contractID).Order("version desc").First(cv))
+}
+
+// DeleteContractVersion is deleting the version of the contract
+func DeleteContractVersion(dbTx *DbTransaction, contractID, version int64) error {
+	return GetDB(dbTx).Exec("DELETE FROM contract_versions WHERE contract_id = ? AND version = ?",
+		contractID, version).Error
+}
+
+// GetContractVersions returns the versions of the contract without the sources ordered by version
+func GetContractVersions(contractID int64) ([]ContractVersion, error) {
+	var versions []ContractVersion
+	err := DBConn.Select("contract_id, version, ecosystem, name, conditions, wallet_id, token_id, block_id, tx_hash, timestamp, restored").
+		Where("contract_id = ?", contractID).Order("version asc").Find(&versions).Error
+	return versions, err
+}
@@ -256,6 +256,11 @@ func (tr *DbTransaction) RollbackSavepoint(mark string) error {
 	return tr.Connection().RollbackTo(mark).Error
 }
 
+// ReleaseSavepoint releases PostgreSQL Savepoint
+func (tr *DbTransaction) ReleaseSavepoint(mark string) error {
+	return tr.Connection().Exec(`RELEASE SAVEPOINT ` + mark).Error
+}
+
 func (tr *DbTransaction) ResetSavepoint(mark string) error {
 	if err := tr.RollbackSavepoint(mark); err != nil {
 		return err
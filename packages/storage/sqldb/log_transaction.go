@@ -19,6 +19,9 @@ type LogTransaction struct {
 	EcosystemID  int64  `gorm:"not null"`
 	Status       int64  `gorm:"not null"`
 	ContractName string `gorm:"not null"`
+	PeakMemory   int64  `gorm:"not null"` // the peak memory of VM
+	PeakDepth    int64  `gorm:"not null"` // the peak depth of the calls in VM
+	Instructions int64  `gorm:"not null"` // the count of the executed instructions of VM
 }
 
 // GetByHash returns LogTransactions existence by hash
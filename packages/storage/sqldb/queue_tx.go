@@ -56,6 +56,13 @@ func GetQueuedTransactionsCount(hash []byte) (int64, error) {
 	return rowsCount, err
 }
 
+// GetQueueTxCount counting all transactions in the queue
+func GetQueueTxCount() (int64, error) {
+	var rowsCount int64
+	err := DBConn.Table("queue_tx").Count(&rowsCount).Error
+	return rowsCount, err
+}
+
 // GetAllUnverifiedAndUnusedTransactions is returns all unverified and unused transaction
 func GetAllUnverifiedAndUnusedTransactions(dbTx *DbTransaction, limit int) ([]*QueueTx, error) {
 	query := `SELECT *
@@ -74,6 +74,13 @@ func GetTxRecord(tx *DbTransaction, hashStr string) (resultList []any, err error
 		}
 
 	}
-
+	// the events are not in the rollback records of tables, they are added separately
+	events, err := GetContractEventsByHash(tx, converter.HexToBin(hashStr))
+	if err != nil {
+		return
+	}
+	for _, ev := range events {
+		resultList = append(resultList, ev)
+	}
 	return
 }
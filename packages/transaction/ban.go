@@ -56,6 +56,20 @@ func BannedTill(keyID int64) string {
 	return ``
 }
 
+// BannedKeysCount returns the number of the keys which are banned now
+func BannedKeysCount() int {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	now := time.Now()
+	var count int
+	for _, ban := range banList {
+		if now.Before(ban.Time) {
+			count++
+		}
+	}
+	return count
+}
+
 // BadTxForBan adds info about bad tx of the key
 func BadTxForBan(keyID int64) {
 	var (
@@ -5,6 +5,7 @@
 package transaction
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/IBAX-io/go-ibax/packages/conf/syspar"
 	"github.com/IBAX-io/go-ibax/packages/consts"
+	"github.com/IBAX-io/go-ibax/packages/pbgo"
+	"github.com/IBAX-io/go-ibax/packages/service/event"
 	"github.com/IBAX-io/go-ibax/packages/storage/sqldb"
 	"github.com/IBAX-io/go-ibax/packages/utils"
 
@@ -84,7 +87,7 @@ func MarkTransactionBad(hash []byte, errText string) error {
 	}
 	log.WithFields(log.Fields{"type": consts.BadTxError, "tx_hash": hash, "error": errText}).Debug("tx marked as bad")
 
-	return sqldb.NewDbTransaction(sqldb.DBConn).Connection().Transaction(func(tx *gorm.DB) error {
+	err := sqldb.NewDbTransaction(sqldb.DBConn).Connection().Transaction(func(tx *gorm.DB) error {
 		// looks like there is no hash in queue_tx at this moment
 		qtx := &sqldb.QueueTx{}
 		_, err := qtx.GetByHash(sqldb.NewDbTransaction(tx), hash)
@@ -108,6 +111,15 @@ func MarkTransactionBad(hash []byte, errText string) error {
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	event.Publish(event.TopicTxStatus, &event.TxStatus{
+		Hash:  hex.EncodeToString(hash),
+		Code:  int32(pbgo.TxInvokeStatusCode_FAILED),
+		Error: errText,
+	})
+	return nil
 }
 
 // ProcessQueueTransaction writes transactions into the queue
@@ -178,6 +178,16 @@ func (b *BlockData) UnmarshallBlock(data []byte) error {
 	return nil
 }
 
+// MarshallHeader returns the binary block without transactions. It contains the headers and the merkle root,
+// so the hash and the sign of the block can be checked
+func MarshallHeader(data []byte) ([]byte, error) {
+	b := &BlockData{}
+	if err := proto.Unmarshal(data, b); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling block")
+	}
+	return proto.Marshal(&BlockData{Header: b.Header, PrevHeader: b.PrevHeader, MerkleRoot: b.MerkleRoot})
+}
+
 // MerkleTreeRoot return Merkle value
 func MerkleTreeRoot(dataArray [][]byte) []byte {
 	result := make(map[int32][][]byte)
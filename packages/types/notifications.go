@@ -9,4 +9,8 @@ type Notifications interface {
 	AddRoles(ecosystem int64, roles ...int64)
 	Size() int
 	Send()
+	// Mark returns the position of the queue which the queue can be truncated to
+	Mark() any
+	// Truncate removes the notifications added after the mark
+	Truncate(mark any)
 }